@@ -3,9 +3,11 @@
 package agent
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
+	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/schema"
 )
 
@@ -31,3 +33,42 @@ func toolDescriptions(tools []schema.Tool) string {
 
 	return strings.Join(toolDescriptions, "\n")
 }
+
+// callAgentChain calls the llm chain of an agent as a child of the agent run and
+// returns the text output of the chain.
+func callAgentChain(ctx context.Context, chain schema.Chain, inputs schema.ChainValues, cm schema.CallbackManagerForChainRun) (string, error) {
+	resp, err := golc.Call(ctx, chain, inputs, golc.ChildCallOptions(cm))
+	if err != nil {
+		return "", err
+	}
+
+	output, ok := resp[chain.OutputKeys()[0]].(string)
+	if !ok {
+		return "", ErrInvalidChainReturnType
+	}
+
+	return output, nil
+}
+
+// generateFinalAnswer asks the model of a text based agent for a final answer by appending
+// finalAnswerThoughts to the scratchpad of the intermediate steps. If the model doesn't follow
+// the format, the whole output is the final answer.
+func generateFinalAnswer(scratchPad string, inputs schema.ChainValues, outputKey string, call func(inputs schema.ChainValues) (string, error), parseOutput func(output string) ([]*schema.AgentAction, *schema.AgentFinish, error)) (*schema.AgentFinish, error) {
+	inputs["agentScratchpad"] = scratchPad + finalAnswerThoughts
+
+	output, err := call(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, finish, err := parseOutput(output); err == nil && finish != nil {
+		return finish, nil
+	}
+
+	return &schema.AgentFinish{
+		ReturnValues: map[string]any{
+			outputKey: output,
+		},
+		Log: output,
+	}, nil
+}
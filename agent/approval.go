@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/schema"
+)
+
+// ApprovalDecision is the decision of an approval handler.
+type ApprovalDecision string
+
+const (
+	// ApprovalDecisionApprove executes the action unchanged.
+	ApprovalDecisionApprove ApprovalDecision = "approve"
+	// ApprovalDecisionEdit executes the action with the edited tool input.
+	ApprovalDecisionEdit ApprovalDecision = "edit"
+	// ApprovalDecisionReject skips the action and feeds the message back to the agent as observation.
+	ApprovalDecisionReject ApprovalDecision = "reject"
+)
+
+// DefaultRejectionMessage is the observation of a rejected action without message.
+const DefaultRejectionMessage = "The action was rejected by a human. Try another approach."
+
+// ApprovalRequest is a request for the approval of an action.
+type ApprovalRequest struct {
+	// ID is the unique id of the request.
+	ID string
+	// RunID is the id of the executor run.
+	RunID string
+	// Action is the action to be approved.
+	Action *schema.AgentAction
+}
+
+// ApprovalResponse is the response of an approval handler.
+type ApprovalResponse struct {
+	// Decision is the decision of the handler.
+	Decision ApprovalDecision
+	// ToolInput replaces the tool input of the action, if the decision is ApprovalDecisionEdit.
+	ToolInput *schema.ToolInput
+	// Message is fed back to the agent as observation, if the decision is ApprovalDecisionReject.
+	Message string
+}
+
+// ApprovalHandler decides whether an action may be executed.
+type ApprovalHandler interface {
+	// Approve blocks until the action is approved, edited or rejected.
+	Approve(ctx context.Context, request *ApprovalRequest) (*ApprovalResponse, error)
+}
+
+// Compile time check to ensure ApprovalHandlerFunc satisfies the ApprovalHandler interface.
+var _ ApprovalHandler = ApprovalHandlerFunc(nil)
+
+// ApprovalHandlerFunc is a function, which synchronously decides whether an action may be executed.
+type ApprovalHandlerFunc func(ctx context.Context, request *ApprovalRequest) (*ApprovalResponse, error)
+
+// Approve calls the function.
+func (f ApprovalHandlerFunc) Approve(ctx context.Context, request *ApprovalRequest) (*ApprovalResponse, error) {
+	return f(ctx, request)
+}
+
+// RequireApprovalForTools returns a function for ExecutorOptions.RequireApproval, which selects
+// the actions of the given tools.
+func RequireApprovalForTools(toolNames ...string) func(action *schema.AgentAction) bool {
+	return func(action *schema.AgentAction) bool {
+		return util.Contains(toolNames, action.Tool)
+	}
+}
+
+// Compile time check to ensure QueueApprovalHandler satisfies the ApprovalHandler interface.
+var _ ApprovalHandler = (*QueueApprovalHandler)(nil)
+
+// QueueApprovalHandler is an asynchronous approval handler. The approval requests are queued
+// and answered with Respond, e.g. by a web application or a chat bot. The executor waits for
+// the response until the context is done.
+type QueueApprovalHandler struct {
+	requests chan *ApprovalRequest
+	pending  map[string]chan *ApprovalResponse
+	mu       sync.Mutex
+}
+
+// NewQueueApprovalHandler creates a new instance of QueueApprovalHandler with the given queue size.
+func NewQueueApprovalHandler(size int) *QueueApprovalHandler {
+	return &QueueApprovalHandler{
+		requests: make(chan *ApprovalRequest, size),
+		pending:  make(map[string]chan *ApprovalResponse),
+	}
+}
+
+// Requests returns the queue of approval requests.
+func (h *QueueApprovalHandler) Requests() <-chan *ApprovalRequest {
+	return h.requests
+}
+
+// Respond answers the approval request with the given id.
+func (h *QueueApprovalHandler) Respond(id string, response *ApprovalResponse) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch, ok := h.pending[id]
+	if !ok {
+		return fmt.Errorf("unknown approval request: %s", id)
+	}
+
+	delete(h.pending, id)
+
+	ch <- response
+
+	return nil
+}
+
+// Approve queues the request and blocks until it's answered or the context is done.
+func (h *QueueApprovalHandler) Approve(ctx context.Context, request *ApprovalRequest) (*ApprovalResponse, error) {
+	ch := make(chan *ApprovalResponse, 1)
+
+	h.mu.Lock()
+	h.pending[request.ID] = ch
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.pending, request.ID)
+		h.mu.Unlock()
+	}()
+
+	select {
+	case h.requests <- request:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case response := <-ch:
+		return response, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// approve asks the approval handler for the approval of the action. It returns the action to be
+// executed or, if the action was rejected, the observation fed back to the agent.
+func (e Executor) approve(ctx context.Context, runID string, action *schema.AgentAction) (*schema.AgentAction, string, error) {
+	if e.opts.ApprovalHandler == nil || (e.opts.RequireApproval != nil && !e.opts.RequireApproval(action)) {
+		return action, "", nil
+	}
+
+	response, err := e.opts.ApprovalHandler.Approve(ctx, &ApprovalRequest{
+		ID:     uuid.New().String(),
+		RunID:  runID,
+		Action: action,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	if response == nil {
+		return nil, "", fmt.Errorf("no approval response for action %s", action.Tool)
+	}
+
+	switch response.Decision {
+	case ApprovalDecisionApprove:
+		return action, "", nil
+	case ApprovalDecisionEdit:
+		if response.ToolInput == nil {
+			return nil, "", fmt.Errorf("edited action %s without tool input", action.Tool)
+		}
+
+		edited := *action
+		edited.ToolInput = response.ToolInput
+
+		return &edited, "", nil
+	case ApprovalDecisionReject:
+		if response.Message == "" {
+			return nil, DefaultRejectionMessage, nil
+		}
+
+		return nil, response.Message, nil
+	default:
+		return nil, "", fmt.Errorf("unknown approval decision: %s", response.Decision)
+	}
+}
@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestExecutorApproval(t *testing.T) {
+	newExecutor := func(t *testing.T, runs *[]string, optFns ...func(o *ExecutorOptions)) *Executor {
+		var mu sync.Mutex
+
+		sqlTool := &mockTool{
+			ToolName: "SQL",
+			ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				*runs = append(*runs, input.(string))
+
+				return "OK", nil
+			},
+		}
+
+		searchTool := &mockTool{
+			ToolName: "Search",
+			ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+				return "found", nil
+			},
+		}
+
+		agent := &mockAgent{
+			PlanFunc: func(ctx context.Context, steps []schema.AgentStep, inputs schema.ChainValues) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+				if len(steps) == 0 {
+					return []*schema.AgentAction{
+						{Tool: "Search", ToolInput: schema.NewToolInputFromString("users")},
+						{Tool: "SQL", ToolInput: schema.NewToolInputFromString("DROP TABLE users")},
+					}, nil, nil
+				}
+
+				return nil, &schema.AgentFinish{ReturnValues: schema.ChainValues{
+					"output": fmt.Sprintf("%s|%s|%s", steps[0].Observation, steps[1].Action.ToolInput, steps[1].Observation),
+				}}, nil
+			},
+		}
+
+		executor, err := NewExecutor(agent, []schema.Tool{sqlTool, searchTool}, optFns...)
+		require.NoError(t, err)
+
+		return executor
+	}
+
+	t.Run("Approve", func(t *testing.T) {
+		runs := []string{}
+		requests := []string{}
+
+		executor := newExecutor(t, &runs, func(o *ExecutorOptions) {
+			o.RequireApproval = RequireApprovalForTools("SQL")
+			o.ApprovalHandler = ApprovalHandlerFunc(func(ctx context.Context, request *ApprovalRequest) (*ApprovalResponse, error) {
+				requests = append(requests, request.Action.Tool)
+				return &ApprovalResponse{Decision: ApprovalDecisionApprove}, nil
+			})
+		})
+
+		outputs, err := executor.Call(context.Background(), schema.ChainValues{})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"output": "found|DROP TABLE users|OK"}, outputs)
+		assert.Equal(t, []string{"SQL"}, requests)
+		assert.Equal(t, []string{"DROP TABLE users"}, runs)
+	})
+
+	t.Run("Edit", func(t *testing.T) {
+		runs := []string{}
+
+		executor := newExecutor(t, &runs, func(o *ExecutorOptions) {
+			o.RequireApproval = RequireApprovalForTools("SQL")
+			o.ApprovalHandler = ApprovalHandlerFunc(func(ctx context.Context, request *ApprovalRequest) (*ApprovalResponse, error) {
+				return &ApprovalResponse{
+					Decision:  ApprovalDecisionEdit,
+					ToolInput: schema.NewToolInputFromString("SELECT * FROM users"),
+				}, nil
+			})
+		})
+
+		outputs, err := executor.Call(context.Background(), schema.ChainValues{})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"output": "found|SELECT * FROM users|OK"}, outputs)
+		assert.Equal(t, []string{"SELECT * FROM users"}, runs)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		runs := []string{}
+
+		executor := newExecutor(t, &runs, func(o *ExecutorOptions) {
+			o.RequireApproval = RequireApprovalForTools("SQL")
+			o.ApprovalHandler = ApprovalHandlerFunc(func(ctx context.Context, request *ApprovalRequest) (*ApprovalResponse, error) {
+				return &ApprovalResponse{
+					Decision: ApprovalDecisionReject,
+					Message:  "Dropping tables is not allowed",
+				}, nil
+			})
+		})
+
+		outputs, err := executor.Call(context.Background(), schema.ChainValues{})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"output": "found|DROP TABLE users|Dropping tables is not allowed"}, outputs)
+		assert.Empty(t, runs)
+	})
+
+	t.Run("NilResponse", func(t *testing.T) {
+		runs := []string{}
+
+		executor := newExecutor(t, &runs, func(o *ExecutorOptions) {
+			o.RequireApproval = RequireApprovalForTools("SQL")
+			o.ApprovalHandler = ApprovalHandlerFunc(func(ctx context.Context, request *ApprovalRequest) (*ApprovalResponse, error) {
+				return nil, nil
+			})
+		})
+
+		_, err := executor.Call(context.Background(), schema.ChainValues{})
+		require.EqualError(t, err, "no approval response for action SQL")
+		assert.Empty(t, runs)
+	})
+
+	t.Run("Queue", func(t *testing.T) {
+		runs := []string{}
+		handler := NewQueueApprovalHandler(1)
+
+		executor := newExecutor(t, &runs, func(o *ExecutorOptions) {
+			o.ApprovalHandler = handler
+		})
+
+		go func() {
+			for request := range handler.Requests() {
+				response := &ApprovalResponse{Decision: ApprovalDecisionApprove}
+				if request.Action.Tool == "SQL" {
+					response = &ApprovalResponse{Decision: ApprovalDecisionReject}
+				}
+
+				assert.NoError(t, handler.Respond(request.ID, response))
+			}
+		}()
+
+		outputs, err := executor.Call(context.Background(), schema.ChainValues{})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"output": fmt.Sprintf("found|DROP TABLE users|%s", DefaultRejectionMessage)}, outputs)
+		assert.Empty(t, runs)
+	})
+
+	t.Run("QueueTimeout", func(t *testing.T) {
+		runs := []string{}
+		handler := NewQueueApprovalHandler(2)
+
+		executor := newExecutor(t, &runs, func(o *ExecutorOptions) {
+			o.ApprovalHandler = handler
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := executor.Call(ctx, schema.ChainValues{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Error(t, handler.Respond("unknown", &ApprovalResponse{}))
+	})
+}
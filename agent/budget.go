@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure tokenCounter satisfies the Callback interface.
+var _ schema.Callback = (*tokenCounter)(nil)
+
+// tokenCounter is a callback, which sums up the tokens used by the models of an agent.
+type tokenCounter struct {
+	callback.NoopHandler
+	totalTokens int
+	mu          sync.Mutex
+}
+
+// AlwaysVerbose returns true, so that the tokens are counted independent of the verbosity.
+func (c *tokenCounter) AlwaysVerbose() bool {
+	return true
+}
+
+// OnModelEnd adds the total tokens of the model run, if the model reports its token usage.
+func (c *tokenCounter) OnModelEnd(ctx context.Context, input *schema.ModelEndInput) error {
+	if input.Result.LLMOutput == nil {
+		return nil
+	}
+
+	tokenUsage, ok := input.Result.LLMOutput["TokenUsage"].(map[string]int)
+	if !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.totalTokens += tokenUsage["TotalTokens"]
+
+	return nil
+}
+
+// total returns the tokens counted so far.
+func (c *tokenCounter) total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.totalTokens
+}
+
+// tokenCountingCallbackManager adds the token counter to the inheritable callbacks of a chain run.
+type tokenCountingCallbackManager struct {
+	schema.CallbackManagerForChainRun
+	counter *tokenCounter
+}
+
+// GetInheritableCallbacks returns the inheritable callbacks of the chain run and the token counter.
+func (m *tokenCountingCallbackManager) GetInheritableCallbacks() []schema.Callback {
+	callbacks := m.CallbackManagerForChainRun.GetInheritableCallbacks()
+
+	return append(callbacks[:len(callbacks):len(callbacks)], m.counter)
+}
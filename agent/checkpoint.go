@@ -0,0 +1,563 @@
+package agent
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hupe1980/golc/integration/sqldb"
+	"github.com/hupe1980/golc/schema"
+)
+
+// CheckpointStatus is the status of an executor run.
+type CheckpointStatus string
+
+const (
+	// CheckpointStatusRunning marks a run, which is in progress or was interrupted.
+	CheckpointStatusRunning CheckpointStatus = "running"
+	// CheckpointStatusFinished marks a run, which returned a final answer.
+	CheckpointStatusFinished CheckpointStatus = "finished"
+)
+
+// Checkpoint is the persisted state of an executor run.
+type Checkpoint struct {
+	// RunID is the id of the run.
+	RunID string
+	// Inputs are the inputs of the run. They must be JSON-serializable for stores, which persist the checkpoint as JSON.
+	Inputs schema.ChainValues
+	// Steps are the intermediate steps of the run.
+	Steps []schema.AgentStep
+	// Actions are the actions of the iteration in progress. They are executed before the agent plans again,
+	// when the run is resumed, so that every planned action has a step.
+	Actions []*schema.AgentAction
+	// ActionSteps are the steps of the completed actions of the iteration in progress by action index.
+	ActionSteps map[int]schema.AgentStep
+	// Iterations is the number of completed iterations.
+	Iterations int
+	// Status is the status of the run.
+	Status CheckpointStatus
+	// Outputs are the outputs of a finished run.
+	Outputs schema.ChainValues
+	// UpdatedAt is the time of the last update.
+	UpdatedAt time.Time
+}
+
+// clone returns a copy of the checkpoint, which doesn't share the steps with the original.
+func (cp *Checkpoint) clone() *Checkpoint {
+	c := *cp
+	c.Inputs = cp.Inputs.Clone()
+	c.Steps = append([]schema.AgentStep(nil), cp.Steps...)
+	c.Actions = append([]*schema.AgentAction(nil), cp.Actions...)
+
+	if cp.ActionSteps != nil {
+		c.ActionSteps = make(map[int]schema.AgentStep, len(cp.ActionSteps))
+		for i, step := range cp.ActionSteps {
+			c.ActionSteps[i] = step
+		}
+	}
+
+	if cp.Outputs != nil {
+		c.Outputs = cp.Outputs.Clone()
+	}
+
+	return &c
+}
+
+type checkpointJSON struct {
+	RunID       string                 `json:"runID"`
+	Inputs      schema.ChainValues     `json:"inputs"`
+	Steps       []checkpointStep       `json:"steps"`
+	Actions     []checkpointStep       `json:"actions,omitempty"`
+	ActionSteps map[int]checkpointStep `json:"actionSteps,omitempty"`
+	Iterations  int                    `json:"iterations"`
+	Status      CheckpointStatus       `json:"status"`
+	Outputs     schema.ChainValues     `json:"outputs,omitempty"`
+	UpdatedAt   time.Time              `json:"updatedAt"`
+}
+
+type checkpointStep struct {
+	Tool        string              `json:"tool"`
+	ToolInput   string              `json:"toolInput"`
+	Structured  bool                `json:"structured,omitempty"`
+	Log         string              `json:"log,omitempty"`
+	MessageLog  []map[string]string `json:"messageLog,omitempty"`
+	ToolCallID  string              `json:"toolCallID,omitempty"`
+	Observation string              `json:"observation"`
+}
+
+// MarshalJSON marshals the checkpoint to JSON.
+func (cp *Checkpoint) MarshalJSON() ([]byte, error) {
+	steps := make([]checkpointStep, len(cp.Steps))
+	for i, step := range cp.Steps {
+		steps[i] = newCheckpointStep(step)
+	}
+
+	var actions []checkpointStep
+	for _, action := range cp.Actions {
+		actions = append(actions, newCheckpointStep(schema.AgentStep{Action: action}))
+	}
+
+	var actionSteps map[int]checkpointStep
+	if cp.ActionSteps != nil {
+		actionSteps = make(map[int]checkpointStep, len(cp.ActionSteps))
+		for i, step := range cp.ActionSteps {
+			actionSteps[i] = newCheckpointStep(step)
+		}
+	}
+
+	return json.Marshal(checkpointJSON{
+		RunID:       cp.RunID,
+		Inputs:      cp.Inputs,
+		Steps:       steps,
+		Actions:     actions,
+		ActionSteps: actionSteps,
+		Iterations:  cp.Iterations,
+		Status:      cp.Status,
+		Outputs:     cp.Outputs,
+		UpdatedAt:   cp.UpdatedAt,
+	})
+}
+
+// UnmarshalJSON unmarshals the checkpoint from JSON.
+func (cp *Checkpoint) UnmarshalJSON(data []byte) error {
+	var v checkpointJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	steps := make([]schema.AgentStep, len(v.Steps))
+
+	for i, s := range v.Steps {
+		step, err := s.agentStep()
+		if err != nil {
+			return err
+		}
+
+		steps[i] = step
+	}
+
+	var actions []*schema.AgentAction
+
+	for _, s := range v.Actions {
+		step, err := s.agentStep()
+		if err != nil {
+			return err
+		}
+
+		actions = append(actions, step.Action)
+	}
+
+	var actionSteps map[int]schema.AgentStep
+
+	if v.ActionSteps != nil {
+		actionSteps = make(map[int]schema.AgentStep, len(v.ActionSteps))
+
+		for i, s := range v.ActionSteps {
+			step, err := s.agentStep()
+			if err != nil {
+				return err
+			}
+
+			actionSteps[i] = step
+		}
+	}
+
+	*cp = Checkpoint{
+		RunID:       v.RunID,
+		Inputs:      v.Inputs,
+		Steps:       steps,
+		Actions:     actions,
+		ActionSteps: actionSteps,
+		Iterations:  v.Iterations,
+		Status:      v.Status,
+		Outputs:     v.Outputs,
+		UpdatedAt:   v.UpdatedAt,
+	}
+
+	return nil
+}
+
+// newCheckpointStep converts the step to its JSON representation.
+func newCheckpointStep(step schema.AgentStep) checkpointStep {
+	s := checkpointStep{
+		Observation: step.Observation,
+	}
+
+	if a := step.Action; a != nil {
+		s.Tool = a.Tool
+		s.Log = a.Log
+		s.ToolCallID = a.ToolCallID
+
+		if a.ToolInput != nil {
+			s.ToolInput = a.ToolInput.String()
+			s.Structured = a.ToolInput.Structured()
+		}
+
+		for _, m := range a.MessageLog {
+			s.MessageLog = append(s.MessageLog, schema.ChatMessageToMap(m))
+		}
+	}
+
+	return s
+}
+
+// agentStep converts the JSON representation back to a step.
+func (s checkpointStep) agentStep() (schema.AgentStep, error) {
+	toolInput := schema.NewToolInputFromString(s.ToolInput)
+	if s.Structured {
+		toolInput = schema.NewToolInputFromArguments(s.ToolInput)
+	}
+
+	var messageLog schema.ChatMessages
+
+	for _, m := range s.MessageLog {
+		message, err := schema.MapToChatMessage(m)
+		if err != nil {
+			return schema.AgentStep{}, err
+		}
+
+		messageLog = append(messageLog, message)
+	}
+
+	return schema.AgentStep{
+		Action: &schema.AgentAction{
+			Tool:       s.Tool,
+			ToolInput:  toolInput,
+			Log:        s.Log,
+			MessageLog: messageLog,
+			ToolCallID: s.ToolCallID,
+		},
+		Observation: s.Observation,
+	}, nil
+}
+
+// CheckpointStore is a store for the checkpoints of executor runs.
+type CheckpointStore interface {
+	// Save creates or replaces the checkpoint of a run.
+	Save(ctx context.Context, checkpoint *Checkpoint) error
+	// Load returns the checkpoint of the run or ErrCheckpointNotFound.
+	Load(ctx context.Context, runID string) (*Checkpoint, error)
+	// Delete removes the checkpoint of the run.
+	Delete(ctx context.Context, runID string) error
+	// List returns the ids of all runs with a checkpoint.
+	List(ctx context.Context) ([]string, error)
+}
+
+// Compile time check to ensure InMemoryCheckpointStore satisfies the CheckpointStore interface.
+var _ CheckpointStore = (*InMemoryCheckpointStore)(nil)
+
+// InMemoryCheckpointStore is a checkpoint store, which keeps the checkpoints in memory.
+// It allows to resume interrupted runs, but doesn't survive process restarts.
+type InMemoryCheckpointStore struct {
+	checkpoints map[string]*Checkpoint
+	mu          sync.RWMutex
+}
+
+// NewInMemoryCheckpointStore creates a new instance of InMemoryCheckpointStore.
+func NewInMemoryCheckpointStore() *InMemoryCheckpointStore {
+	return &InMemoryCheckpointStore{
+		checkpoints: make(map[string]*Checkpoint),
+	}
+}
+
+// Save creates or replaces the checkpoint of a run.
+func (s *InMemoryCheckpointStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoints[checkpoint.RunID] = checkpoint.clone()
+
+	return nil
+}
+
+// Load returns the checkpoint of the run or ErrCheckpointNotFound.
+func (s *InMemoryCheckpointStore) Load(ctx context.Context, runID string) (*Checkpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	checkpoint, ok := s.checkpoints[runID]
+	if !ok {
+		return nil, ErrCheckpointNotFound
+	}
+
+	return checkpoint.clone(), nil
+}
+
+// Delete removes the checkpoint of the run.
+func (s *InMemoryCheckpointStore) Delete(ctx context.Context, runID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.checkpoints, runID)
+
+	return nil
+}
+
+// List returns the ids of all runs with a checkpoint.
+func (s *InMemoryCheckpointStore) List(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	runIDs := make([]string, 0, len(s.checkpoints))
+	for runID := range s.checkpoints {
+		runIDs = append(runIDs, runID)
+	}
+
+	sort.Strings(runIDs)
+
+	return runIDs, nil
+}
+
+// Compile time check to ensure FileCheckpointStore satisfies the CheckpointStore interface.
+var _ CheckpointStore = (*FileCheckpointStore)(nil)
+
+// FileCheckpointStore is a checkpoint store, which writes every checkpoint as JSON file to a directory.
+type FileCheckpointStore struct {
+	dir string
+}
+
+// NewFileCheckpointStore creates a new instance of FileCheckpointStore. The directory is created, if it doesn't exist.
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &FileCheckpointStore{
+		dir: dir,
+	}, nil
+}
+
+// Save creates or replaces the checkpoint of a run. The file is replaced atomically.
+func (s *FileCheckpointStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	path, err := s.path(checkpoint.RunID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name()) // nolint errcheck
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load returns the checkpoint of the run or ErrCheckpointNotFound.
+func (s *FileCheckpointStore) Load(ctx context.Context, runID string) (*Checkpoint, error) {
+	path, err := s.path(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrCheckpointNotFound
+		}
+
+		return nil, err
+	}
+
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+// Delete removes the checkpoint of the run.
+func (s *FileCheckpointStore) Delete(ctx context.Context, runID string) error {
+	path, err := s.path(runID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// List returns the ids of all runs with a checkpoint.
+func (s *FileCheckpointStore) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	runIDs := []string{}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+
+		runIDs = append(runIDs, strings.TrimSuffix(name, ".json"))
+	}
+
+	return runIDs, nil
+}
+
+func (s *FileCheckpointStore) path(runID string) (string, error) {
+	if runID == "" || strings.HasPrefix(runID, ".") || strings.ContainsAny(runID, `/\`) {
+		return "", fmt.Errorf("invalid run id: %q", runID)
+	}
+
+	return filepath.Join(s.dir, runID+".json"), nil
+}
+
+// Compile time check to ensure SQLCheckpointStore satisfies the CheckpointStore interface.
+var _ CheckpointStore = (*SQLCheckpointStore)(nil)
+
+// SQLCheckpointStoreOptions contains options for configuring the SQLCheckpointStore.
+type SQLCheckpointStoreOptions struct {
+	// TableName is the name of the checkpoint table. Default is "agent_checkpoints".
+	TableName string
+}
+
+// SQLCheckpointStore is a checkpoint store, which keeps the checkpoints as JSON in a sql table.
+// It supports the SQLite3, Postgres, CockroachDB, MySQL and MariaDB engines of the sqldb package.
+type SQLCheckpointStore struct {
+	engine sqldb.Engine
+	opts   SQLCheckpointStoreOptions
+}
+
+// NewSQLCheckpointStore creates a new instance of SQLCheckpointStore. Use CreateTable to create the checkpoint table.
+func NewSQLCheckpointStore(engine sqldb.Engine, optFns ...func(o *SQLCheckpointStoreOptions)) *SQLCheckpointStore {
+	opts := SQLCheckpointStoreOptions{
+		TableName: "agent_checkpoints",
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return &SQLCheckpointStore{
+		engine: engine,
+		opts:   opts,
+	}
+}
+
+// CreateTable creates the checkpoint table, if it doesn't exist.
+func (s *SQLCheckpointStore) CreateTable(ctx context.Context) error {
+	_, err := s.engine.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	run_id VARCHAR(255) NOT NULL PRIMARY KEY,
+	checkpoint TEXT NOT NULL,
+	updated_at BIGINT NOT NULL
+)`, s.opts.TableName))
+
+	return err
+}
+
+// Save creates or replaces the checkpoint of a run.
+func (s *SQLCheckpointStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (run_id, checkpoint, updated_at) VALUES (%s, %s, %s) ", s.opts.TableName, s.placeholder(1), s.placeholder(2), s.placeholder(3))
+
+	if s.isMySQL() {
+		query += "ON DUPLICATE KEY UPDATE checkpoint = VALUES(checkpoint), updated_at = VALUES(updated_at)"
+	} else {
+		query += "ON CONFLICT (run_id) DO UPDATE SET checkpoint = excluded.checkpoint, updated_at = excluded.updated_at"
+	}
+
+	_, err = s.engine.Exec(ctx, query, checkpoint.RunID, string(data), checkpoint.UpdatedAt.UnixNano())
+
+	return err
+}
+
+// Load returns the checkpoint of the run or ErrCheckpointNotFound.
+func (s *SQLCheckpointStore) Load(ctx context.Context, runID string) (*Checkpoint, error) {
+	var data string
+
+	row := s.engine.QueryRow(ctx, fmt.Sprintf("SELECT checkpoint FROM %s WHERE run_id = %s", s.opts.TableName, s.placeholder(1)), runID)
+	if err := row.Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCheckpointNotFound
+		}
+
+		return nil, err
+	}
+
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal([]byte(data), checkpoint); err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+// Delete removes the checkpoint of the run.
+func (s *SQLCheckpointStore) Delete(ctx context.Context, runID string) error {
+	_, err := s.engine.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE run_id = %s", s.opts.TableName, s.placeholder(1)), runID)
+	return err
+}
+
+// List returns the ids of all runs with a checkpoint.
+func (s *SQLCheckpointStore) List(ctx context.Context) ([]string, error) {
+	rows, err := s.engine.Query(ctx, fmt.Sprintf("SELECT run_id FROM %s ORDER BY updated_at", s.opts.TableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runIDs := []string{}
+
+	for rows.Next() {
+		var runID string
+		if err := rows.Scan(&runID); err != nil {
+			return nil, err
+		}
+
+		runIDs = append(runIDs, runID)
+	}
+
+	return runIDs, rows.Err()
+}
+
+func (s *SQLCheckpointStore) isMySQL() bool {
+	switch s.engine.Dialect() {
+	case "MySQL", "MariaDB":
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *SQLCheckpointStore) placeholder(n int) string {
+	switch s.engine.Dialect() {
+	case "Postgres", "CockroachDB":
+		return fmt.Sprintf("$%d", n)
+	default:
+		return "?"
+	}
+}
@@ -0,0 +1,269 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/integration/sqldb"
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestCheckpointStore(t *testing.T) {
+	checkpoint := &Checkpoint{
+		RunID:  "run1",
+		Inputs: schema.ChainValues{"input": "foo"},
+		Steps: []schema.AgentStep{{
+			Action: &schema.AgentAction{
+				Tool:       "Search",
+				ToolInput:  schema.NewToolInputFromArguments(`{"query": "foo"}`),
+				Log:        "log",
+				MessageLog: schema.ChatMessages{schema.NewAIChatMessage("Searching")},
+				ToolCallID: "call1",
+			},
+			Observation: "bar",
+		}},
+		Actions: []*schema.AgentAction{
+			{Tool: "Search", ToolInput: schema.NewToolInputFromString("baz"), ToolCallID: "call2"},
+			{Tool: "Search", ToolInput: schema.NewToolInputFromString("qux"), ToolCallID: "call3"},
+		},
+		ActionSteps: map[int]schema.AgentStep{
+			1: {Action: &schema.AgentAction{Tool: "Search", ToolInput: schema.NewToolInputFromString("qux"), ToolCallID: "call3"}, Observation: "quux"},
+		},
+		Iterations: 1,
+		Status:     CheckpointStatusRunning,
+		UpdatedAt:  time.Unix(1700000000, 0).UTC(),
+	}
+
+	testStore := func(t *testing.T, store CheckpointStore) {
+		ctx := context.Background()
+
+		_, err := store.Load(ctx, "run1")
+		assert.ErrorIs(t, err, ErrCheckpointNotFound)
+
+		require.NoError(t, store.Save(ctx, checkpoint))
+
+		loaded, err := store.Load(ctx, "run1")
+		require.NoError(t, err)
+		assert.Equal(t, checkpoint, loaded)
+
+		finished := checkpoint.clone()
+		finished.Status = CheckpointStatusFinished
+		finished.Outputs = schema.ChainValues{"output": "baz"}
+
+		require.NoError(t, store.Save(ctx, finished))
+
+		loaded, err = store.Load(ctx, "run1")
+		require.NoError(t, err)
+		assert.Equal(t, finished, loaded)
+
+		runIDs, err := store.List(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"run1"}, runIDs)
+
+		require.NoError(t, store.Delete(ctx, "run1"))
+
+		_, err = store.Load(ctx, "run1")
+		assert.ErrorIs(t, err, ErrCheckpointNotFound)
+	}
+
+	t.Run("InMemory", func(t *testing.T) {
+		testStore(t, NewInMemoryCheckpointStore())
+	})
+
+	t.Run("File", func(t *testing.T) {
+		store, err := NewFileCheckpointStore(t.TempDir())
+		require.NoError(t, err)
+
+		testStore(t, store)
+
+		assert.Error(t, store.Save(context.Background(), &Checkpoint{RunID: "../run"}))
+	})
+
+	t.Run("SQL", func(t *testing.T) {
+		engine, err := sqldb.NewSQLite3(filepath.Join(t.TempDir(), "checkpoints.db"))
+		require.NoError(t, err)
+
+		defer engine.Close()
+
+		store := NewSQLCheckpointStore(engine)
+		require.NoError(t, store.CreateTable(context.Background()))
+
+		testStore(t, store)
+	})
+}
+
+func TestExecutorCheckpoint(t *testing.T) {
+	var slowCalls, flakyCalls atomic.Int32
+
+	slowTool := &mockTool{
+		ToolName: "Slow",
+		ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+			slowCalls.Add(1)
+			return "slow result", nil
+		},
+	}
+
+	flakyTool := &mockTool{
+		ToolName: "Flaky",
+		ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+			if flakyCalls.Add(1) == 1 {
+				return "", errors.New("connection lost")
+			}
+
+			return "flaky result", nil
+		},
+	}
+
+	agent := &mockAgent{
+		PlanFunc: func(ctx context.Context, steps []schema.AgentStep, inputs schema.ChainValues) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+			switch len(steps) {
+			case 0:
+				return []*schema.AgentAction{{Tool: "Slow", ToolInput: schema.NewToolInputFromString("foo")}}, nil, nil
+			case 1:
+				return []*schema.AgentAction{{Tool: "Flaky", ToolInput: schema.NewToolInputFromString("bar")}}, nil, nil
+			default:
+				return nil, &schema.AgentFinish{ReturnValues: schema.ChainValues{"output": steps[0].Observation + ", " + steps[1].Observation}}, nil
+			}
+		},
+	}
+
+	store, err := NewFileCheckpointStore(t.TempDir())
+	require.NoError(t, err)
+
+	executor, err := NewExecutor(agent, []schema.Tool{slowTool, flakyTool}, func(o *ExecutorOptions) {
+		o.CheckpointStore = store
+	})
+	require.NoError(t, err)
+
+	_, err = executor.Call(context.Background(), schema.ChainValues{"input": "foo"})
+	require.EqualError(t, err, "connection lost")
+
+	runIDs, err := store.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, runIDs, 1)
+
+	checkpoint, err := store.Load(context.Background(), runIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, CheckpointStatusRunning, checkpoint.Status)
+	assert.Equal(t, 1, checkpoint.Iterations)
+	assert.Len(t, checkpoint.Steps, 1)
+	assert.Len(t, checkpoint.Actions, 1)
+	assert.Empty(t, checkpoint.ActionSteps)
+
+	tracer := callback.NewTracer()
+
+	outputs, err := executor.Resume(context.Background(), runIDs[0], func(co *golc.CallOptions) {
+		co.Callbacks = []schema.Callback{tracer}
+		co.Tags = []string{"resumed"}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, schema.ChainValues{"output": "slow result, flaky result"}, outputs)
+	assert.Equal(t, int32(1), slowCalls.Load())
+
+	runs := tracer.Runs()
+	require.Len(t, runs, 1)
+	assert.Equal(t, []string{"resumed"}, runs[0].Tags)
+	assert.Equal(t, map[string]any{"input": "foo"}, runs[0].Inputs)
+	assert.Equal(t, map[string]any{"output": "slow result, flaky result"}, runs[0].Outputs)
+
+	checkpoint, err = store.Load(context.Background(), runIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, CheckpointStatusFinished, checkpoint.Status)
+
+	outputs, err = executor.Resume(context.Background(), runIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, schema.ChainValues{"output": "slow result, flaky result"}, outputs)
+
+	_, err = executor.Resume(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+}
+
+func TestExecutorCheckpointParallelActions(t *testing.T) {
+	var slowCalls, flakyCalls atomic.Int32
+
+	slowDone := make(chan struct{})
+
+	slowTool := &mockTool{
+		ToolName: "Slow",
+		ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+			if slowCalls.Add(1) == 1 {
+				close(slowDone)
+			}
+
+			return "slow result", nil
+		},
+	}
+
+	flakyTool := &mockTool{
+		ToolName: "Flaky",
+		ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+			if flakyCalls.Add(1) == 1 {
+				// The second action completes before the first action fails.
+				<-slowDone
+				return "", errors.New("connection lost")
+			}
+
+			return "flaky result", nil
+		},
+	}
+
+	var plans [][]schema.AgentStep
+
+	agent := &mockAgent{
+		PlanFunc: func(ctx context.Context, steps []schema.AgentStep, inputs schema.ChainValues) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+			plans = append(plans, steps)
+
+			if len(steps) == 0 {
+				return []*schema.AgentAction{
+					{Tool: "Flaky", ToolInput: schema.NewToolInputFromString("foo"), ToolCallID: "call1"},
+					{Tool: "Slow", ToolInput: schema.NewToolInputFromString("bar"), ToolCallID: "call2"},
+				}, nil, nil
+			}
+
+			return nil, &schema.AgentFinish{ReturnValues: schema.ChainValues{"output": steps[0].Observation + ", " + steps[1].Observation}}, nil
+		},
+	}
+
+	store := NewInMemoryCheckpointStore()
+
+	executor, err := NewExecutor(agent, []schema.Tool{slowTool, flakyTool}, func(o *ExecutorOptions) {
+		o.CheckpointStore = store
+	})
+	require.NoError(t, err)
+
+	_, err = executor.Call(context.Background(), schema.ChainValues{"input": "foo"})
+	require.EqualError(t, err, "connection lost")
+
+	runIDs, err := store.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, runIDs, 1)
+
+	// The checkpoint holds the planned actions and the step of the completed action by action index.
+	checkpoint, err := store.Load(context.Background(), runIDs[0])
+	require.NoError(t, err)
+	assert.Empty(t, checkpoint.Steps)
+	assert.Len(t, checkpoint.Actions, 2)
+	require.Len(t, checkpoint.ActionSteps, 1)
+	assert.Equal(t, "slow result", checkpoint.ActionSteps[1].Observation)
+
+	outputs, err := executor.Resume(context.Background(), runIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, schema.ChainValues{"output": "flaky result, slow result"}, outputs)
+	assert.Equal(t, int32(1), slowCalls.Load())
+	assert.Equal(t, int32(2), flakyCalls.Load())
+
+	// The agent plans again only after all actions of the interrupted iteration are completed.
+	require.Len(t, plans, 2)
+	assert.Equal(t, "call1", plans[1][0].Action.ToolCallID)
+	assert.Equal(t, "call2", plans[1][1].Action.ToolCallID)
+}
@@ -6,7 +6,7 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/chain"
 	"github.com/hupe1980/golc/memory"
 	"github.com/hupe1980/golc/prompt"
@@ -16,6 +16,9 @@ import (
 // Compile time check to ensure ConversationalReactDescription satisfies the agent interface.
 var _ schema.Agent = (*ConversationalReactDescription)(nil)
 
+// Compile time check to ensure ConversationalReactDescription satisfies the EarlyStopper interface.
+var _ EarlyStopper = (*ConversationalReactDescription)(nil)
+
 const (
 	defaultConversationalPrefix = `Assistant is a large language model trained by OpenAI.
 
@@ -58,6 +61,8 @@ type ConversationalReactDescriptionOptions struct {
 	AIPrefix      string
 	OutputKey     string
 	MaxIterations int
+	// ConfigureExecutor configures the options of the executor, e.g. error handling or early stopping.
+	ConfigureExecutor func(o *ExecutorOptions)
 }
 
 type ConversationalReactDescription struct {
@@ -96,23 +101,46 @@ func NewConversationalReactDescription(llm schema.Model, tools []schema.Tool, op
 
 	return NewExecutor(agent, tools, func(o *ExecutorOptions) {
 		o.MaxIterations = opts.MaxIterations
+
+		if opts.ConfigureExecutor != nil {
+			opts.ConfigureExecutor(o)
+		}
 	})
 }
 
-func (a *ConversationalReactDescription) Plan(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+func (a *ConversationalReactDescription) Plan(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues, optFns ...func(o *schema.AgentPlanOptions)) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+	opts := schema.AgentPlanOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
 	inputs["agentScratchpad"] = a.constructScratchPad(intermediateSteps)
 
-	resp, err := golc.Call(ctx, a.chain, inputs)
+	output, err := callAgentChain(ctx, a.chain, inputs, opts.CallbackManger)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	output, ok := resp[a.chain.OutputKeys()[0]].(string)
-	if !ok {
-		return nil, nil, ErrInvalidChainReturnType
+	return a.parseOutput(output)
+}
+
+// GenerateFinalAnswer asks the model for a final answer based on the intermediate steps.
+// It's used by the executor with EarlyStoppingMethodGenerate.
+func (a *ConversationalReactDescription) GenerateFinalAnswer(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues, optFns ...func(o *schema.AgentPlanOptions)) (*schema.AgentFinish, error) {
+	opts := schema.AgentPlanOptions{
+		CallbackManger: &callback.NoopManager{},
 	}
 
-	return a.parseOutput(output)
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return generateFinalAnswer(a.constructScratchPad(intermediateSteps), inputs, a.opts.OutputKey, func(inputs schema.ChainValues) (string, error) {
+		return callAgentChain(ctx, a.chain, inputs, opts.CallbackManger)
+	}, a.parseOutput)
 }
 
 func (a *ConversationalReactDescription) InputKeys() []string {
@@ -163,7 +191,7 @@ func (a *ConversationalReactDescription) parseOutput(output string) ([]*schema.A
 	matches := r.FindStringSubmatch(output)
 
 	if len(matches) == 0 {
-		return nil, nil, fmt.Errorf("%w: %s", ErrUnableToParseOutput, output)
+		return nil, nil, &OutputParserError{Output: output}
 	}
 
 	toolInput := schema.NewToolInputFromString(strings.TrimSpace(matches[2]))
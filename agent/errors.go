@@ -1,10 +1,32 @@
 package agent
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrAgentNoReturn          = errors.New("no actions or finish was returned by the agent")
 	ErrNotFinished            = errors.New("agent not finished before max iterations")
 	ErrInvalidChainReturnType = errors.New("agent chain did not return a string")
 	ErrUnableToParseOutput    = errors.New("unable to parse agent output")
+	ErrCheckpointNotFound     = errors.New("checkpoint not found")
+	ErrNoCheckpointStore      = errors.New("no checkpoint store")
 )
+
+// OutputParserError is returned by agents, which are unable to parse the output of the model.
+// It wraps ErrUnableToParseOutput.
+type OutputParserError struct {
+	// Output is the unparsable output of the model.
+	Output string
+}
+
+// Error returns the error message including the output of the model.
+func (e *OutputParserError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnableToParseOutput, e.Output)
+}
+
+// Unwrap returns ErrUnableToParseOutput.
+func (e *OutputParserError) Unwrap() error {
+	return ErrUnableToParseOutput
+}
@@ -2,25 +2,95 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tool"
+	"golang.org/x/sync/errgroup"
 )
 
 // Compile time check to ensure Executor satisfies the chain interface.
 var _ schema.Chain = (*Executor)(nil)
 
-const DefaultMaxIterations = 5
+const (
+	DefaultMaxIterations  = 5
+	DefaultMaxConcurrency = 5
+)
+
+const (
+	// IntermediateStepsKey is the output key of the intermediate steps, if ReturnIntermediateSteps is set.
+	IntermediateStepsKey = "intermediateSteps"
+
+	// ExceptionTool is the tool name of the steps, which feed parsing errors back to the agent.
+	ExceptionTool = "_Exception"
+
+	// StoppedResponse is the final answer of a run, which was stopped with EarlyStoppingMethodForce.
+	StoppedResponse = "Agent stopped due to iteration limit or time limit."
+)
+
+// EarlyStoppingMethod defines how the executor ends a run, which exhausted its iteration, time or token budget.
+type EarlyStoppingMethod string
+
+const (
+	// EarlyStoppingMethodError returns an error wrapping ErrNotFinished. It's the default.
+	EarlyStoppingMethodError EarlyStoppingMethod = "error"
+	// EarlyStoppingMethodForce returns the StoppedResponse as final answer.
+	EarlyStoppingMethodForce EarlyStoppingMethod = "force"
+	// EarlyStoppingMethodGenerate asks the model for a final answer based on the intermediate steps.
+	// The agent must implement the EarlyStopper interface.
+	EarlyStoppingMethodGenerate EarlyStoppingMethod = "generate"
+)
+
+// EarlyStopper is implemented by agents, which can generate a final answer from the intermediate steps.
+type EarlyStopper interface {
+	// GenerateFinalAnswer asks the model for a final answer based on the intermediate steps.
+	GenerateFinalAnswer(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues, optFns ...func(o *schema.AgentPlanOptions)) (*schema.AgentFinish, error)
+}
+
+// ErrorHandler converts an error into an observation, which is fed back to the agent.
+type ErrorHandler func(err error) string
+
+// DefaultErrorHandler returns the error message as observation.
+func DefaultErrorHandler(err error) string {
+	return fmt.Sprintf("Error: %s", err)
+}
 
 // ExecutorOptions holds configuration options for the Executor.
 type ExecutorOptions struct {
 	*schema.CallbackOptions
-	MaxIterations  int
+	MaxIterations int
+	// MaxConcurrency is the maximum number of actions of a single plan that are executed concurrently.
+	MaxConcurrency int
 	Memory         schema.Memory
 	AgentChainType string
+	// CheckpointStore persists the inputs and intermediate steps of every run after each tool call,
+	// so that an interrupted run can be continued with Resume. If nil, no checkpoints are created.
+	CheckpointStore CheckpointStore
+	// ApprovalHandler is asked for approval before the actions selected by RequireApproval are executed.
+	ApprovalHandler ApprovalHandler
+	// RequireApproval selects the actions, which need approval. If nil, all actions need approval
+	// as long as an approval handler is set.
+	RequireApproval func(action *schema.AgentAction) bool
+	// HandleToolErrors converts tool errors into observations. If nil, a tool error aborts the run.
+	HandleToolErrors ErrorHandler
+	// HandleParsingErrors converts errors parsing the model output into observations. If nil, a parsing error aborts the run.
+	HandleParsingErrors ErrorHandler
+	// EarlyStoppingMethod defines how a run, which exhausted its budget, ends. Default is EarlyStoppingMethodError.
+	EarlyStoppingMethod EarlyStoppingMethod
+	// MaxExecutionTime is the wall-clock budget of a single call. Zero means no limit.
+	MaxExecutionTime time.Duration
+	// MaxTokens is the budget of tokens used by the models of the agent in a single call. It requires models,
+	// which report their token usage. Zero means no limit.
+	MaxTokens int
+	// ReturnIntermediateSteps adds the intermediate steps to the outputs.
+	ReturnIntermediateSteps bool
 }
 
 // Executor represents an agent executor that executes a chain of actions based on inputs and a defined agent model.
@@ -36,8 +106,10 @@ func NewExecutor(agent schema.Agent, tools []schema.Tool, optFns ...func(o *Exec
 		CallbackOptions: &schema.CallbackOptions{
 			Verbose: golc.Verbose,
 		},
-		MaxIterations:  DefaultMaxIterations,
-		AgentChainType: "Executor",
+		MaxIterations:       DefaultMaxIterations,
+		MaxConcurrency:      DefaultMaxConcurrency,
+		AgentChainType:      "Executor",
+		EarlyStoppingMethod: EarlyStoppingMethodError,
 	}
 
 	for _, fn := range optFns {
@@ -59,6 +131,7 @@ func NewExecutor(agent schema.Agent, tools []schema.Tool, optFns ...func(o *Exec
 
 // Call executes the AgentExecutor chain with the given context and inputs.
 // It returns the outputs of the chain or an error, if any.
+// If a checkpoint store is configured, the run is checkpointed under the run id of the chain run.
 func (e Executor) Call(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
 	opts := schema.CallOptions{
 		CallbackManger: &callback.NoopManager{},
@@ -68,16 +141,117 @@ func (e Executor) Call(ctx context.Context, inputs schema.ChainValues, optFns ..
 		fn(&opts)
 	}
 
-	steps := []schema.AgentStep{}
+	runID := opts.CallbackManger.RunID()
+	if runID == "" {
+		runID = uuid.New().String()
+	}
+
+	return e.run(ctx, &Checkpoint{
+		RunID:  runID,
+		Inputs: inputs.Clone(),
+		Steps:  []schema.AgentStep{},
+		Status: CheckpointStatusRunning,
+	}, opts)
+}
+
+// Resume resumes the run with the given id from its last checkpoint, e.g. after a crash, timeout or restart.
+// The intermediate steps of the checkpoint are not executed again. If the run is already finished, its outputs
+// are returned.
+func (e Executor) Resume(ctx context.Context, runID string, optFns ...func(o *golc.CallOptions)) (schema.ChainValues, error) {
+	if e.opts.CheckpointStore == nil {
+		return nil, ErrNoCheckpointStore
+	}
+
+	checkpoint, err := e.opts.CheckpointStore.Load(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	if checkpoint.Status == CheckpointStatusFinished {
+		return e.outputs(checkpoint), nil
+	}
+
+	// The resumed run is called like any other chain, so that it gets the same callbacks and memory handling.
+	return golc.Call(ctx, resumedRun{Executor: e, checkpoint: checkpoint}, checkpoint.Inputs.Clone(), optFns...)
+}
+
+// resumedRun is the chain of a resumed run. It continues the run from its checkpoint instead of
+// starting a new one.
+type resumedRun struct {
+	Executor
+	checkpoint *Checkpoint
+}
+
+// Call continues the agent loop from the checkpoint of the run. The inputs of the checkpoint are used.
+func (r resumedRun) Call(ctx context.Context, _ schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+	opts := schema.CallOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return r.run(ctx, r.checkpoint, opts)
+}
+
+// run executes the agent loop starting from the given checkpoint.
+func (e Executor) run(ctx context.Context, checkpoint *Checkpoint, opts schema.CallOptions) (schema.ChainValues, error) {
+	if err := e.saveCheckpoint(ctx, checkpoint); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	// The token counter is passed to the models of the agent as inheritable callback.
+	counter := &tokenCounter{}
+	planCallbackManager := &tokenCountingCallbackManager{
+		CallbackManagerForChainRun: opts.CallbackManger,
+		counter:                    counter,
+	}
+
+	// A resumed run completes the actions of the interrupted iteration before the agent plans again,
+	// so that every planned action, e.g. every tool call of a model, has a step.
+	if len(checkpoint.Actions) > 0 {
+		if err := e.completeIteration(ctx, checkpoint, opts.CallbackManger); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if err := e.checkBudget(checkpoint, start, counter); err != nil {
+			return e.stopEarly(ctx, checkpoint, err, planCallbackManager, opts)
+		}
 
-	for i := 0; i <= e.opts.MaxIterations; i++ {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
-			actions, finish, err := e.agent.Plan(ctx, steps, inputs.Clone())
+			actions, finish, err := e.agent.Plan(ctx, checkpoint.Steps, checkpoint.Inputs.Clone(), func(o *schema.AgentPlanOptions) {
+				o.CallbackManger = planCallbackManager
+			})
 			if err != nil {
-				return nil, err
+				var parseErr *OutputParserError
+				if e.opts.HandleParsingErrors == nil || !errors.As(err, &parseErr) {
+					return nil, err
+				}
+
+				// Feed the parsing error back to the agent, so that it can correct its output.
+				checkpoint.Steps = append(checkpoint.Steps, schema.AgentStep{
+					Action: &schema.AgentAction{
+						Tool:      ExceptionTool,
+						ToolInput: schema.NewToolInputFromString(parseErr.Output),
+						Log:       parseErr.Output,
+					},
+					Observation: e.opts.HandleParsingErrors(err),
+				})
+				checkpoint.Iterations++
+
+				if err := e.saveCheckpoint(ctx, checkpoint); err != nil {
+					return nil, err
+				}
+
+				continue
 			}
 
 			if len(actions) == 0 && finish == nil {
@@ -85,13 +259,7 @@ func (e Executor) Call(ctx context.Context, inputs schema.ChainValues, optFns ..
 			}
 
 			if finish != nil {
-				if cbErr := opts.CallbackManger.OnAgentFinish(ctx, &schema.AgentFinishManagerInput{
-					Finish: finish,
-				}); cbErr != nil {
-					return nil, cbErr
-				}
-
-				return finish.ReturnValues, nil
+				return e.finish(ctx, checkpoint, finish, opts)
 			}
 
 			for _, action := range actions {
@@ -100,31 +268,219 @@ func (e Executor) Call(ctx context.Context, inputs schema.ChainValues, optFns ..
 				}); cbErr != nil {
 					return nil, cbErr
 				}
+			}
 
-				t, ok := e.toolsMap[action.Tool]
-				if !ok {
-					steps = append(steps, schema.AgentStep{
-						Action:      action,
-						Observation: fmt.Sprintf("%s is not a valid tool, try another one", action.Tool),
-					})
+			checkpoint.Actions = actions
+			checkpoint.ActionSteps = map[int]schema.AgentStep{}
 
-					continue
-				}
+			if err := e.saveCheckpoint(ctx, checkpoint); err != nil {
+				return nil, err
+			}
 
-				observation, err := tool.Run(ctx, t, action.ToolInput)
-				if err != nil {
-					return nil, err
-				}
+			if err := e.completeIteration(ctx, checkpoint, opts.CallbackManger); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
 
-				steps = append(steps, schema.AgentStep{
-					Action:      action,
-					Observation: observation,
-				})
+// completeIteration executes the actions of the iteration in progress, which are not completed yet, and
+// appends the steps of all actions in action order. Every completed action is checkpointed, so that slow
+// tools don't have to run again after an interruption.
+func (e Executor) completeIteration(ctx context.Context, checkpoint *Checkpoint, cm schema.CallbackManagerForChainRun) error {
+	if checkpoint.ActionSteps == nil {
+		checkpoint.ActionSteps = map[int]schema.AgentStep{}
+	}
+
+	// The steps are passed as copy, because onStep adds the steps of the completed actions.
+	steps, err := e.takeActions(ctx, checkpoint.RunID, checkpoint.Actions, maps.Clone(checkpoint.ActionSteps), cm, func(i int, step schema.AgentStep) error {
+		checkpoint.ActionSteps[i] = step
+		return e.saveCheckpoint(ctx, checkpoint)
+	})
+	if err != nil {
+		return err
+	}
+
+	checkpoint.Steps = append(checkpoint.Steps, steps...)
+	checkpoint.Actions = nil
+	checkpoint.ActionSteps = nil
+	checkpoint.Iterations++
+
+	return e.saveCheckpoint(ctx, checkpoint)
+}
+
+// checkBudget returns an error wrapping ErrNotFinished, if the iteration, time or token budget is exhausted.
+func (e Executor) checkBudget(checkpoint *Checkpoint, start time.Time, counter *tokenCounter) error {
+	if checkpoint.Iterations > e.opts.MaxIterations {
+		return ErrNotFinished
+	}
+
+	if e.opts.MaxExecutionTime > 0 && time.Since(start) >= e.opts.MaxExecutionTime {
+		return fmt.Errorf("%w: max execution time of %s exceeded", ErrNotFinished, e.opts.MaxExecutionTime)
+	}
+
+	if e.opts.MaxTokens > 0 && counter.total() >= e.opts.MaxTokens {
+		return fmt.Errorf("%w: max tokens of %d exceeded", ErrNotFinished, e.opts.MaxTokens)
+	}
+
+	return nil
+}
+
+// stopEarly stops the run according to the early stopping method, after the budget is exhausted.
+func (e Executor) stopEarly(ctx context.Context, checkpoint *Checkpoint, reason error, cm schema.CallbackManagerForChainRun, opts schema.CallOptions) (schema.ChainValues, error) {
+	switch e.opts.EarlyStoppingMethod {
+	case EarlyStoppingMethodForce:
+		returnValues := make(map[string]any, len(e.agent.OutputKeys()))
+		for _, k := range e.agent.OutputKeys() {
+			returnValues[k] = StoppedResponse
+		}
+
+		return e.finish(ctx, checkpoint, &schema.AgentFinish{
+			ReturnValues: returnValues,
+			Log:          StoppedResponse,
+		}, opts)
+	case EarlyStoppingMethodGenerate:
+		stopper, ok := e.agent.(EarlyStopper)
+		if !ok {
+			return nil, fmt.Errorf("%w: agent does not support early stopping method %s", reason, e.opts.EarlyStoppingMethod)
+		}
+
+		finish, err := stopper.GenerateFinalAnswer(ctx, checkpoint.Steps, checkpoint.Inputs.Clone(), func(o *schema.AgentPlanOptions) {
+			o.CallbackManger = cm
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return e.finish(ctx, checkpoint, finish, opts)
+	default:
+		return nil, reason
+	}
+}
+
+// finish completes the run with the final answer of the agent.
+func (e Executor) finish(ctx context.Context, checkpoint *Checkpoint, finish *schema.AgentFinish, opts schema.CallOptions) (schema.ChainValues, error) {
+	if cbErr := opts.CallbackManger.OnAgentFinish(ctx, &schema.AgentFinishManagerInput{
+		Finish: finish,
+	}); cbErr != nil {
+		return nil, cbErr
+	}
+
+	checkpoint.Status = CheckpointStatusFinished
+	checkpoint.Outputs = finish.ReturnValues
+
+	if err := e.saveCheckpoint(ctx, checkpoint); err != nil {
+		return nil, err
+	}
+
+	return e.outputs(checkpoint), nil
+}
+
+// outputs returns the outputs of a finished run including the intermediate steps, if requested.
+func (e Executor) outputs(checkpoint *Checkpoint) schema.ChainValues {
+	if !e.opts.ReturnIntermediateSteps {
+		return checkpoint.Outputs
+	}
+
+	outputs := checkpoint.Outputs.Clone()
+	outputs[IntermediateStepsKey] = checkpoint.Steps
+
+	return outputs
+}
+
+// saveCheckpoint saves the checkpoint, if a checkpoint store is configured.
+func (e Executor) saveCheckpoint(ctx context.Context, checkpoint *Checkpoint) error {
+	if e.opts.CheckpointStore == nil {
+		return nil
+	}
+
+	checkpoint.UpdatedAt = time.Now()
+
+	return e.opts.CheckpointStore.Save(ctx, checkpoint)
+}
+
+// takeActions executes the actions concurrently and returns the resulting steps
+// in the same order as the actions. Actions with a step in done are not executed again. Actions,
+// which need approval, are executed only after the approval handler approved them. The tools inherit
+// the callbacks of the chain run. After every tool call, onStep is called with the index of the action
+// and its step. The calls of onStep are serialized.
+func (e Executor) takeActions(ctx context.Context, runID string, actions []*schema.AgentAction, done map[int]schema.AgentStep, cm schema.CallbackManagerForChainRun, onStep func(i int, step schema.AgentStep) error) ([]schema.AgentStep, error) {
+	errs, errctx := errgroup.WithContext(ctx)
+
+	if e.opts.MaxConcurrency > 0 {
+		errs.SetLimit(e.opts.MaxConcurrency)
+	}
+
+	steps := make([]schema.AgentStep, len(actions))
+
+	var mu sync.Mutex
+
+	for i, action := range actions {
+		i, action := i, action
+
+		if step, ok := done[i]; ok {
+			steps[i] = step
+			continue
+		}
+
+		errs.Go(func() error {
+			step, err := e.takeAction(errctx, runID, action, cm)
+			if err != nil {
+				return err
 			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			steps[i] = step
+
+			return onStep(i, step)
+		})
+	}
+
+	if err := errs.Wait(); err != nil {
+		return nil, err
+	}
+
+	return steps, nil
+}
+
+// takeAction executes a single action, if it's approved, and returns the resulting step.
+func (e Executor) takeAction(ctx context.Context, runID string, action *schema.AgentAction, cm schema.CallbackManagerForChainRun) (schema.AgentStep, error) {
+	t, ok := e.toolsMap[action.Tool]
+	if !ok {
+		return schema.AgentStep{
+			Action:      action,
+			Observation: fmt.Sprintf("%s is not a valid tool, try another one", action.Tool),
+		}, nil
+	}
+
+	approved, rejection, err := e.approve(ctx, runID, action)
+	if err != nil {
+		return schema.AgentStep{}, err
+	}
+
+	if approved == nil {
+		return schema.AgentStep{
+			Action:      action,
+			Observation: rejection,
+		}, nil
+	}
+
+	observation, err := tool.Run(ctx, t, approved.ToolInput, tool.ChildOptions(cm))
+	if err != nil {
+		// Errors caused by the context are never fed back, because the run cannot continue anyway.
+		if e.opts.HandleToolErrors == nil || ctx.Err() != nil {
+			return schema.AgentStep{}, err
 		}
+
+		observation = e.opts.HandleToolErrors(err)
 	}
 
-	return nil, ErrNotFinished
+	return schema.AgentStep{
+		Action:      approved,
+		Observation: observation,
+	}, nil
 }
 
 // Memory returns the memory associated with the chain.
@@ -154,5 +510,11 @@ func (e Executor) InputKeys() []string {
 
 // OutputKeys returns the output keys the chain will return.
 func (e Executor) OutputKeys() []string {
+	if e.opts.ReturnIntermediateSteps {
+		outputKeys := e.agent.OutputKeys()
+
+		return append(outputKeys[:len(outputKeys):len(outputKeys)], IntermediateStepsKey)
+	}
+
 	return e.agent.OutputKeys()
 }
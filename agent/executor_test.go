@@ -3,10 +3,15 @@ package agent
 import (
 	"context"
 	"errors"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/hupe1980/golc/model/llm"
 	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExecutor(t *testing.T) {
@@ -65,6 +70,63 @@ func TestExecutor(t *testing.T) {
 		assert.ErrorContains(t, err, "executor error")
 	})
 
+	t.Run("Call_ParallelActions", func(t *testing.T) {
+		t.Parallel()
+
+		var wg sync.WaitGroup
+
+		wg.Add(2)
+
+		parallelTool := &mockTool{
+			ToolName: "Parallel",
+			ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+				wg.Done()
+
+				// Both actions must run at the same time to pass the barrier.
+				done := make(chan struct{})
+				go func() {
+					wg.Wait()
+					close(done)
+				}()
+
+				select {
+				case <-done:
+					return "Observation " + input.(string), nil
+				case <-time.After(5 * time.Second):
+					return "", errors.New("actions not executed concurrently")
+				}
+			},
+		}
+
+		agent := &mockAgent{
+			PlanFunc: func(ctx context.Context, steps []schema.AgentStep, inputs schema.ChainValues) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+				if len(steps) == 0 {
+					return []*schema.AgentAction{
+						{Tool: "Parallel", ToolInput: schema.NewToolInputFromString("a"), ToolCallID: "call_a"},
+						{Tool: "Parallel", ToolInput: schema.NewToolInputFromString("b"), ToolCallID: "call_b"},
+					}, nil, nil
+				}
+
+				assert.Len(t, steps, 2)
+				assert.Equal(t, "call_a", steps[0].Action.ToolCallID)
+				assert.Equal(t, "Observation a", steps[0].Observation)
+				assert.Equal(t, "call_b", steps[1].Action.ToolCallID)
+				assert.Equal(t, "Observation b", steps[1].Observation)
+
+				return nil, &schema.AgentFinish{
+					ReturnValues: schema.ChainValues{"output": "finish"},
+				}, nil
+			},
+		}
+
+		executor, err := NewExecutor(agent, []schema.Tool{parallelTool})
+		assert.NoError(t, err)
+
+		outputs, err := executor.Call(context.Background(), schema.ChainValues{})
+		assert.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"output": "finish"}, outputs)
+	})
+
 	t.Run("InputKeys", func(t *testing.T) {
 		agent := &mockAgent{
 			IKeys: []string{"foo", "bar"},
@@ -125,7 +187,7 @@ type mockAgent struct {
 }
 
 // Plan is a method required by the schema.Agent interface.
-func (m *mockAgent) Plan(ctx context.Context, steps []schema.AgentStep, inputs schema.ChainValues) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+func (m *mockAgent) Plan(ctx context.Context, steps []schema.AgentStep, inputs schema.ChainValues, optFns ...func(o *schema.AgentPlanOptions)) ([]*schema.AgentAction, *schema.AgentFinish, error) {
 	if m.PlanFunc != nil {
 		return m.PlanFunc(ctx, steps, inputs)
 	}
@@ -152,3 +214,239 @@ func (m *mockAgent) InputKeys() []string {
 func (m *mockAgent) OutputKeys() []string {
 	return m.OKeys
 }
+
+func TestExecutorErrorHandling(t *testing.T) {
+	t.Parallel()
+
+	t.Run("HandleToolErrors", func(t *testing.T) {
+		t.Parallel()
+
+		tool := &mockTool{
+			ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+				return "", errors.New("tool error")
+			},
+		}
+
+		agent := &mockAgent{
+			PlanFunc: func(ctx context.Context, steps []schema.AgentStep, inputs schema.ChainValues) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+				if len(steps) == 0 {
+					return []*schema.AgentAction{{Tool: "Mock", ToolInput: schema.NewToolInputFromString("input")}}, nil, nil
+				}
+
+				return nil, &schema.AgentFinish{ReturnValues: schema.ChainValues{"output": steps[0].Observation}}, nil
+			},
+		}
+
+		executor, err := NewExecutor(agent, []schema.Tool{tool})
+		require.NoError(t, err)
+
+		_, err = executor.Call(context.Background(), schema.ChainValues{})
+		assert.ErrorContains(t, err, "tool error")
+
+		executor, err = NewExecutor(agent, []schema.Tool{tool}, func(o *ExecutorOptions) {
+			o.HandleToolErrors = DefaultErrorHandler
+		})
+		require.NoError(t, err)
+
+		outputs, err := executor.Call(context.Background(), schema.ChainValues{})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"output": "Error: tool error"}, outputs)
+	})
+
+	t.Run("HandleParsingErrors", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+
+		fake := llm.NewFake(func(ctx context.Context, prompt string) (*schema.ModelResult, error) {
+			calls++
+
+			text := "I don't know the format"
+			if calls > 1 {
+				assert.Contains(t, prompt, "Observation: Invalid format")
+				text = "Final Answer: 42"
+			}
+
+			return &schema.ModelResult{
+				Generations: []schema.Generation{{Text: text}},
+				LLMOutput:   map[string]any{},
+			}, nil
+		})
+
+		executor, err := NewReactDescription(fake, []schema.Tool{&mockTool{}})
+		require.NoError(t, err)
+
+		_, err = executor.Call(context.Background(), schema.ChainValues{"input": "question"})
+		assert.ErrorIs(t, err, ErrUnableToParseOutput)
+
+		var parseErr *OutputParserError
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, "I don't know the format", parseErr.Output)
+
+		calls = 0
+
+		executor, err = NewReactDescription(fake, []schema.Tool{&mockTool{}}, func(o *ReactDescriptionOptions) {
+			o.ConfigureExecutor = func(o *ExecutorOptions) {
+				o.HandleParsingErrors = func(err error) string {
+					return "Invalid format"
+				}
+				o.ReturnIntermediateSteps = true
+			}
+		})
+		require.NoError(t, err)
+
+		outputs, err := executor.Call(context.Background(), schema.ChainValues{"input": "question"})
+		require.NoError(t, err)
+		assert.Equal(t, " 42", outputs["output"])
+
+		steps, ok := outputs[IntermediateStepsKey].([]schema.AgentStep)
+		require.True(t, ok)
+		require.Len(t, steps, 1)
+		assert.Equal(t, ExceptionTool, steps[0].Action.Tool)
+		assert.Equal(t, "Invalid format", steps[0].Observation)
+	})
+}
+
+func TestExecutorEarlyStopping(t *testing.T) {
+	t.Parallel()
+
+	loopingAgent := &mockAgent{
+		OKeys: []string{"output"},
+		PlanFunc: func(ctx context.Context, steps []schema.AgentStep, inputs schema.ChainValues) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+			return []*schema.AgentAction{{Tool: "Mock", ToolInput: schema.NewToolInputFromString("input")}}, nil, nil
+		},
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		executor, err := NewExecutor(loopingAgent, []schema.Tool{&mockTool{}}, func(o *ExecutorOptions) {
+			o.MaxIterations = 2
+		})
+		require.NoError(t, err)
+
+		_, err = executor.Call(context.Background(), schema.ChainValues{})
+		assert.ErrorIs(t, err, ErrNotFinished)
+	})
+
+	t.Run("Force", func(t *testing.T) {
+		t.Parallel()
+
+		executor, err := NewExecutor(loopingAgent, []schema.Tool{&mockTool{}}, func(o *ExecutorOptions) {
+			o.MaxIterations = 2
+			o.EarlyStoppingMethod = EarlyStoppingMethodForce
+			o.ReturnIntermediateSteps = true
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"output", IntermediateStepsKey}, executor.OutputKeys())
+
+		outputs, err := executor.Call(context.Background(), schema.ChainValues{})
+		require.NoError(t, err)
+		assert.Equal(t, StoppedResponse, outputs["output"])
+		assert.Len(t, outputs[IntermediateStepsKey], 3)
+	})
+
+	t.Run("Generate", func(t *testing.T) {
+		t.Parallel()
+
+		fake := llm.NewFake(func(ctx context.Context, prompt string) (*schema.ModelResult, error) {
+			text := "Thought: I need more data\nAction: Mock\nAction Input: data"
+			if strings.HasSuffix(prompt, "I now need to return a final answer based on the previous steps:") {
+				text = "Final Answer: best guess"
+			}
+
+			return &schema.ModelResult{
+				Generations: []schema.Generation{{Text: text}},
+				LLMOutput:   map[string]any{},
+			}, nil
+		})
+
+		executor, err := NewReactDescription(fake, []schema.Tool{&mockTool{}}, func(o *ReactDescriptionOptions) {
+			o.MaxIterations = 1
+			o.ConfigureExecutor = func(o *ExecutorOptions) {
+				o.EarlyStoppingMethod = EarlyStoppingMethodGenerate
+			}
+		})
+		require.NoError(t, err)
+
+		outputs, err := executor.Call(context.Background(), schema.ChainValues{"input": "question"})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"output": " best guess"}, outputs)
+	})
+
+	t.Run("GenerateUnsupported", func(t *testing.T) {
+		t.Parallel()
+
+		executor, err := NewExecutor(loopingAgent, []schema.Tool{&mockTool{}}, func(o *ExecutorOptions) {
+			o.MaxIterations = 1
+			o.EarlyStoppingMethod = EarlyStoppingMethodGenerate
+		})
+		require.NoError(t, err)
+
+		_, err = executor.Call(context.Background(), schema.ChainValues{})
+		assert.ErrorIs(t, err, ErrNotFinished)
+		assert.ErrorContains(t, err, "does not support early stopping method generate")
+	})
+}
+
+func TestExecutorBudget(t *testing.T) {
+	t.Parallel()
+
+	t.Run("MaxExecutionTime", func(t *testing.T) {
+		t.Parallel()
+
+		tool := &mockTool{
+			ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+				time.Sleep(20 * time.Millisecond)
+				return "Observation", nil
+			},
+		}
+
+		agent := &mockAgent{
+			PlanFunc: func(ctx context.Context, steps []schema.AgentStep, inputs schema.ChainValues) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+				return []*schema.AgentAction{{Tool: "Mock", ToolInput: schema.NewToolInputFromString("input")}}, nil, nil
+			},
+		}
+
+		executor, err := NewExecutor(agent, []schema.Tool{tool}, func(o *ExecutorOptions) {
+			o.MaxIterations = 100
+			o.MaxExecutionTime = 10 * time.Millisecond
+		})
+		require.NoError(t, err)
+
+		_, err = executor.Call(context.Background(), schema.ChainValues{})
+		assert.ErrorIs(t, err, ErrNotFinished)
+		assert.ErrorContains(t, err, "max execution time of 10ms exceeded")
+	})
+
+	t.Run("MaxTokens", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+
+		fake := llm.NewFake(func(ctx context.Context, prompt string) (*schema.ModelResult, error) {
+			calls++
+
+			return &schema.ModelResult{
+				Generations: []schema.Generation{{Text: "Action: Mock\nAction Input: data"}},
+				LLMOutput: map[string]any{
+					"TokenUsage": map[string]int{"TotalTokens": 60},
+				},
+			}, nil
+		})
+
+		executor, err := NewReactDescription(fake, []schema.Tool{&mockTool{}}, func(o *ReactDescriptionOptions) {
+			o.MaxIterations = 100
+			o.ConfigureExecutor = func(o *ExecutorOptions) {
+				o.MaxTokens = 100
+			}
+		})
+		require.NoError(t, err)
+
+		_, err = executor.Call(context.Background(), schema.ChainValues{"input": "question"})
+		assert.ErrorIs(t, err, ErrNotFinished)
+		assert.ErrorContains(t, err, "max tokens of 100 exceeded")
+		assert.Equal(t, 2, calls)
+	})
+}
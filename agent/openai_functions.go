@@ -2,10 +2,11 @@ package agent
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/prompt"
 	"github.com/hupe1980/golc/schema"
@@ -15,6 +16,9 @@ import (
 // Compile time check to ensure OpenAIFunctions satisfies the agent interface.
 var _ schema.Agent = (*OpenAIFunctions)(nil)
 
+// Compile time check to ensure OpenAIFunctions satisfies the EarlyStopper interface.
+var _ EarlyStopper = (*OpenAIFunctions)(nil)
+
 // OpenAIFunctionsOptions represents the configuration options for the OpenAIFunctions agent.
 type OpenAIFunctionsOptions struct {
 	*schema.CallbackOptions
@@ -23,9 +27,13 @@ type OpenAIFunctionsOptions struct {
 	SystemMessage *prompt.SystemMessageTemplate
 	ExtraMessages []prompt.MessageTemplate
 	MaxIterations int
+	// MaxConcurrency is the maximum number of parallel tool calls that are executed concurrently.
+	MaxConcurrency int
+	// ConfigureExecutor configures the options of the executor, e.g. error handling or early stopping.
+	ConfigureExecutor func(o *ExecutorOptions)
 }
 
-// OpenAIFunctions is an agent that uses OpenAI chatModels and schema.Tools to perform actions.
+// OpenAIFunctions is an agent that uses the native function calling of chatModels and schema.Tools to perform actions.
 type OpenAIFunctions struct {
 	model     schema.ChatModel
 	functions []schema.FunctionDefinition
@@ -33,26 +41,23 @@ type OpenAIFunctions struct {
 }
 
 // NewOpenAIFunctions creates a new instance of the OpenAIFunctions agent with the given model and tools.
-// It returns an error if the model is not an OpenAI chatModel or fails to convert tools to function definitions.
+// The model must support function calling. It returns an error if it fails to convert tools to function definitions.
 func NewOpenAIFunctions(model schema.ChatModel, tools []schema.Tool, optFns ...func(o *OpenAIFunctionsOptions)) (*Executor, error) {
 	opts := OpenAIFunctionsOptions{
 		CallbackOptions: &schema.CallbackOptions{
 			Verbose: golc.Verbose,
 		},
-		OutputKey:     "output",
-		SystemMessage: prompt.NewSystemMessageTemplate("You are a helpful AI assistant."),
-		ExtraMessages: []prompt.MessageTemplate{},
-		MaxIterations: DefaultMaxIterations,
+		OutputKey:      "output",
+		SystemMessage:  prompt.NewSystemMessageTemplate("You are a helpful AI assistant."),
+		ExtraMessages:  []prompt.MessageTemplate{},
+		MaxIterations:  DefaultMaxIterations,
+		MaxConcurrency: DefaultMaxConcurrency,
 	}
 
 	for _, fn := range optFns {
 		fn(&opts)
 	}
 
-	if model.Type() != "chatmodel.OpenAI" {
-		return nil, errors.New("agent only supports OpenAI chatModels")
-	}
-
 	functions := make([]schema.FunctionDefinition, len(tools))
 
 	for i, t := range tools {
@@ -72,31 +77,32 @@ func NewOpenAIFunctions(model schema.ChatModel, tools []schema.Tool, optFns ...f
 
 	return NewExecutor(agent, tools, func(o *ExecutorOptions) {
 		o.MaxIterations = opts.MaxIterations
+		o.MaxConcurrency = opts.MaxConcurrency
 		o.AgentChainType = "OpenAIFunctions"
+
+		if opts.ConfigureExecutor != nil {
+			opts.ConfigureExecutor(o)
+		}
 	})
 }
 
 // Plan executes the agent with the given context, intermediate steps, and inputs.
 // It returns the agent actions, agent finish, or an error, if any.
-func (a *OpenAIFunctions) Plan(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues) ([]*schema.AgentAction, *schema.AgentFinish, error) {
-	inputs["agentScratchpad"] = a.constructScratchPad(intermediateSteps)
-
-	templates := []prompt.MessageTemplate{a.opts.SystemMessage}
-	templates = append(templates, a.opts.ExtraMessages...)
-	templates = append(templates, prompt.NewHumanMessageTemplate("{{.input}}"))
-
-	chatTemplate := prompt.NewChatTemplate(templates)
-
-	placeholder := prompt.NewMessagesPlaceholder("agentScratchpad")
+func (a *OpenAIFunctions) Plan(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues, optFns ...func(o *schema.AgentPlanOptions)) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+	opts := schema.AgentPlanOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
 
-	wrapper := prompt.NewChatTemplateWrapper(chatTemplate, placeholder)
+	for _, fn := range optFns {
+		fn(&opts)
+	}
 
-	prompt, err := wrapper.FormatPrompt(inputs)
+	prompt, err := a.formatPrompt(intermediateSteps, inputs)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	result, err := model.ChatModelGenerate(ctx, a.model, prompt.Messages(), func(o *model.Options) {
+	result, err := model.ChatModelGenerate(ctx, a.model, prompt.Messages(), model.ChildOptions(opts.CallbackManger), func(o *model.Options) {
 		o.Functions = a.functions
 	})
 	if err != nil {
@@ -112,14 +118,32 @@ func (a *OpenAIFunctions) Plan(ctx context.Context, intermediateSteps []schema.A
 
 	ext := aiMsg.Extension()
 
-	if ext.FunctionCall != nil {
-		toolInput := schema.NewToolInputFromArguments(ext.FunctionCall.Arguments)
+	msgContent := ""
+	if aiMsg.Content() != "" {
+		msgContent = fmt.Sprintf("responded: %s", aiMsg.Content())
+	}
+
+	if len(ext.ToolCalls) > 0 {
+		actions := make([]*schema.AgentAction, len(ext.ToolCalls))
 
-		msgContent := ""
-		if aiMsg.Content() != "" {
-			msgContent = fmt.Sprintf("responded: %s", aiMsg.Content())
+		for i, tc := range ext.ToolCalls {
+			toolInput := schema.NewToolInputFromArguments(tc.Function.Arguments)
+
+			actions[i] = &schema.AgentAction{
+				Tool:       tc.Function.Name,
+				ToolInput:  toolInput,
+				Log:        fmt.Sprintf("\nInvoking `%s` with `%s`\n%s\n", tc.Function.Name, toolInput, msgContent),
+				MessageLog: schema.ChatMessages{aiMsg},
+				ToolCallID: tc.ID,
+			}
 		}
 
+		return actions, nil, nil
+	}
+
+	if ext.FunctionCall != nil {
+		toolInput := schema.NewToolInputFromArguments(ext.FunctionCall.Arguments)
+
 		log := fmt.Sprintf("\nInvoking `%s` with `%s`\n%s\n", ext.FunctionCall.Name, toolInput, msgContent)
 
 		return []*schema.AgentAction{
@@ -135,6 +159,56 @@ func (a *OpenAIFunctions) Plan(ctx context.Context, intermediateSteps []schema.A
 	}, nil
 }
 
+// GenerateFinalAnswer asks the model for a final answer based on the intermediate steps without
+// offering any functions. It's used by the executor with EarlyStoppingMethodGenerate.
+func (a *OpenAIFunctions) GenerateFinalAnswer(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues, optFns ...func(o *schema.AgentPlanOptions)) (*schema.AgentFinish, error) {
+	opts := schema.AgentPlanOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	prompt, err := a.formatPrompt(intermediateSteps, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := append(prompt.Messages(), schema.NewHumanChatMessage("I now need to return a final answer based on the previous steps."))
+
+	result, err := model.ChatModelGenerate(ctx, a.model, messages, model.ChildOptions(opts.CallbackManger))
+	if err != nil {
+		return nil, err
+	}
+
+	content := result.Generations[0].Message.Content()
+
+	return &schema.AgentFinish{
+		ReturnValues: map[string]any{
+			a.opts.OutputKey: content,
+		},
+		Log: content,
+	}, nil
+}
+
+// formatPrompt formats the chat prompt with the inputs and the scratch pad of the intermediate steps.
+func (a *OpenAIFunctions) formatPrompt(intermediateSteps []schema.AgentStep, inputs schema.ChainValues) (schema.PromptValue, error) {
+	inputs["agentScratchpad"] = a.constructScratchPad(intermediateSteps)
+
+	templates := []prompt.MessageTemplate{a.opts.SystemMessage}
+	templates = append(templates, a.opts.ExtraMessages...)
+	templates = append(templates, prompt.NewHumanMessageTemplate("{{.input}}"))
+
+	chatTemplate := prompt.NewChatTemplate(templates)
+
+	placeholder := prompt.NewMessagesPlaceholder("agentScratchpad")
+
+	wrapper := prompt.NewChatTemplateWrapper(chatTemplate, placeholder)
+
+	return wrapper.FormatPrompt(inputs)
+}
+
 // InputKeys returns the expected input keys for the agent.
 func (a *OpenAIFunctions) InputKeys() []string {
 	return []string{"input"}
@@ -146,11 +220,19 @@ func (a *OpenAIFunctions) OutputKeys() []string {
 }
 
 // constructScratchPad constructs the scratch pad from the given intermediate steps.
+// Steps of parallel tool calls share the message log, which is added only once and
+// followed by a tool message for each observation.
 func (a *OpenAIFunctions) constructScratchPad(steps []schema.AgentStep) schema.ChatMessages {
 	messages := schema.ChatMessages{}
 
-	for _, step := range steps {
-		if step.Action.MessageLog != nil {
+	for i, step := range steps {
+		if step.Action.ToolCallID != "" && step.Action.MessageLog != nil {
+			if i == 0 || !sameMessageLog(steps[i-1].Action.MessageLog, step.Action.MessageLog) {
+				messages = append(messages, step.Action.MessageLog...)
+			}
+
+			messages = append(messages, schema.NewToolChatMessage(step.Action.ToolCallID, step.Observation))
+		} else if step.Action.MessageLog != nil {
 			messages = append(messages, step.Action.MessageLog...)
 			messages = append(messages, schema.NewFunctionChatMessage(step.Action.Tool, step.Observation))
 		} else {
@@ -160,3 +242,37 @@ func (a *OpenAIFunctions) constructScratchPad(steps []schema.AgentStep) schema.C
 
 	return messages
 }
+
+// sameMessageLog reports whether both message logs hold the same messages. The messages are compared
+// by type, content and tool call ids, because the steps restored from a checkpoint don't share the
+// messages of the model response.
+func sameMessageLog(a, b schema.ChatMessages) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Type() != b[i].Type() || a[i].Content() != b[i].Content() || !slices.Equal(toolCallIDs(a[i]), toolCallIDs(b[i])) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// toolCallIDs returns the ids of the tool calls requested by an ai message.
+func toolCallIDs(message schema.ChatMessage) []string {
+	aiMessage, ok := message.(*schema.AIChatMessage)
+	if !ok {
+		return nil
+	}
+
+	toolCalls := aiMessage.Extension().ToolCalls
+
+	ids := make([]string, len(toolCalls))
+	for i, tc := range toolCalls {
+		ids[i] = tc.ID
+	}
+
+	return ids
+}
@@ -2,11 +2,15 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 
+	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/model/chatmodel"
 	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOpenAIFunctions(t *testing.T) {
@@ -67,14 +71,162 @@ func TestOpenAIFunctions(t *testing.T) {
 		assert.Equal(t, "finish text", output[agent.OutputKeys()[0]])
 	})
 
-	t.Run("TestPlanInvalidModel", func(t *testing.T) {
+	t.Run("TestPlanParallelToolCalls", func(t *testing.T) {
 		t.Parallel()
 
-		_, err := NewOpenAIFunctions(chatmodel.NewSimpleFake("foo"), []schema.Tool{
+		agent, err := NewOpenAIFunctions(chatmodel.NewFake(func(ctx context.Context, messages schema.ChatMessages) (*schema.ModelResult, error) {
+			var generation schema.Generation
+
+			if len(messages) == 2 {
+				generation = schema.Generation{
+					Message: schema.NewAIChatMessage("", func(o *schema.ChatMessageExtension) {
+						o.ToolCalls = []schema.ToolCall{
+							{ID: "call_1", Function: schema.FunctionCall{Name: "Mock", Arguments: `{"__arg1": "Berlin"}`}},
+							{ID: "call_2", Function: schema.FunctionCall{Name: "Mock", Arguments: `{"__arg1": "Paris"}`}},
+						}
+					}),
+				}
+			} else {
+				// system, human, ai with tool calls and one tool message per call
+				assert.Len(t, messages, 5)
+				assert.Len(t, messages[2].(*schema.AIChatMessage).Extension().ToolCalls, 2)
+
+				toolMsg1, ok := messages[3].(*schema.ToolChatMessage)
+				assert.True(t, ok)
+				assert.Equal(t, "call_1", toolMsg1.ToolCallID())
+				assert.Equal(t, "Berlin: sunny", toolMsg1.Content())
+
+				toolMsg2, ok := messages[4].(*schema.ToolChatMessage)
+				assert.True(t, ok)
+				assert.Equal(t, "call_2", toolMsg2.ToolCallID())
+				assert.Equal(t, "Paris: sunny", toolMsg2.Content())
+
+				generation = schema.Generation{
+					Text:    "finish text",
+					Message: schema.NewAIChatMessage("finish text"),
+				}
+			}
+
+			return &schema.ModelResult{
+				Generations: []schema.Generation{generation},
+				LLMOutput:   map[string]any{},
+			}, nil
+		}), []schema.Tool{
+			&mockTool{
+				ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+					return input.(string) + ": sunny", nil
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		output, err := agent.Call(context.Background(), schema.ChainValues{
+			"input": "user Input",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "finish text", output[agent.OutputKeys()[0]])
+	})
+
+	t.Run("TestResumeParallelToolCalls", func(t *testing.T) {
+		t.Parallel()
+
+		var berlinCalls atomic.Int32
+
+		store, err := NewFileCheckpointStore(t.TempDir())
+		require.NoError(t, err)
+
+		agent, err := NewOpenAIFunctions(chatmodel.NewFake(func(ctx context.Context, messages schema.ChatMessages) (*schema.ModelResult, error) {
+			var generation schema.Generation
+
+			if len(messages) == 2 {
+				generation = schema.Generation{
+					Message: schema.NewAIChatMessage("", func(o *schema.ChatMessageExtension) {
+						o.ToolCalls = []schema.ToolCall{
+							{ID: "call_1", Function: schema.FunctionCall{Name: "Mock", Arguments: `{"__arg1": "Berlin"}`}},
+							{ID: "call_2", Function: schema.FunctionCall{Name: "Mock", Arguments: `{"__arg1": "Paris"}`}},
+						}
+					}),
+				}
+			} else {
+				// The steps restored from the checkpoint add the ai message with the tool calls only once.
+				require.Len(t, messages, 5)
+				assert.Len(t, messages[2].(*schema.AIChatMessage).Extension().ToolCalls, 2)
+				assert.Equal(t, "call_1", messages[3].(*schema.ToolChatMessage).ToolCallID())
+				assert.Equal(t, "call_2", messages[4].(*schema.ToolChatMessage).ToolCallID())
+
+				generation = schema.Generation{
+					Text:    "finish text",
+					Message: schema.NewAIChatMessage("finish text"),
+				}
+			}
+
+			return &schema.ModelResult{
+				Generations: []schema.Generation{generation},
+				LLMOutput:   map[string]any{},
+			}, nil
+		}), []schema.Tool{
+			&mockTool{
+				ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+					if input.(string) == "Berlin" && berlinCalls.Add(1) == 1 {
+						return "", errors.New("connection lost")
+					}
+
+					return input.(string) + ": sunny", nil
+				},
+			},
+		}, func(o *OpenAIFunctionsOptions) {
+			o.ConfigureExecutor = func(o *ExecutorOptions) {
+				o.CheckpointStore = store
+			}
+		})
+		require.NoError(t, err)
+
+		_, err = agent.Call(context.Background(), schema.ChainValues{
+			"input": "user Input",
+		})
+		require.EqualError(t, err, "connection lost")
+
+		runIDs, err := store.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, runIDs, 1)
+
+		output, err := agent.Resume(context.Background(), runIDs[0])
+		require.NoError(t, err)
+		assert.Equal(t, "finish text", output[agent.OutputKeys()[0]])
+	})
+
+	t.Run("TestStream", func(t *testing.T) {
+		t.Parallel()
+
+		agent, err := NewOpenAIFunctions(chatmodel.NewSimpleFake("foo"), []schema.Tool{
 			&mockTool{},
 		})
-		assert.Error(t, err)
-		assert.EqualError(t, err, "agent only supports OpenAI chatModels")
+		assert.NoError(t, err)
+
+		chunks := []schema.StreamChunk{}
+		for chunk := range golc.Stream(context.Background(), agent, schema.ChainValues{"input": "user Input"}) {
+			chunks = append(chunks, chunk)
+		}
+
+		assert.Len(t, chunks, 2)
+		assert.Equal(t, schema.StreamChunk{Type: schema.StreamChunkTypeToken, Token: "foo"}, chunks[0])
+		assert.Equal(t, schema.StreamChunkTypeResult, chunks[1].Type)
+		assert.Equal(t, "foo", chunks[1].Outputs["output"])
+	})
+
+	t.Run("TestPlanNonOpenAIModel", func(t *testing.T) {
+		t.Parallel()
+
+		agent, err := NewOpenAIFunctions(chatmodel.NewSimpleFake("foo"), []schema.Tool{
+			&mockTool{},
+		})
+		assert.NoError(t, err)
+
+		output, err := agent.Call(context.Background(), schema.ChainValues{
+			"input": "user Input",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", output[agent.OutputKeys()[0]])
 	})
 
 	t.Run("TestPlanInvalidTool", func(t *testing.T) {
@@ -0,0 +1,330 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/chain"
+	"github.com/hupe1980/golc/prompt"
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure PlanAndExecute satisfies the agent interface.
+var _ schema.Agent = (*PlanAndExecute)(nil)
+
+const (
+	defaultPlanAndExecutePlannerPrompt = `Let's first understand the problem and devise a plan to solve the problem. The steps of the plan are executed by an assistant, which has access to the following tools:
+{{.toolDescriptions}}
+
+Please output the plan starting with the header "Plan:" and then followed by a numbered list of steps. Please make the plan the minimum number of steps required to accurately complete the task. If the task is a question, the final step should almost always be "Given the above steps taken, please respond to the users original question". At the end of your plan, say "<END_OF_PLAN>".
+
+Objective: {{.input}}`
+
+	defaultPlanAndExecuteReplannerPrompt = `For the given objective, come up with a simple step by step plan. The steps of the plan are executed by an assistant, which has access to the following tools:
+{{.toolDescriptions}}
+
+Your objective was this:
+{{.input}}
+
+Your original plan was this:
+{{.plan}}
+
+You have currently done the following steps:
+{{.pastSteps}}
+
+Update your plan accordingly. If no more steps are needed and you can return to the user, then respond with "Final Answer:" followed by the answer to the objective. Otherwise, output the remaining steps starting with the header "Plan:" and then followed by a numbered list of steps. Only add steps to the plan that still NEED to be done. Do not return previously done steps as part of the plan. At the end of your plan, say "<END_OF_PLAN>".`
+
+	defaultPlanAndExecuteStepPrompt = `Objective: {{.input}}
+
+Plan:
+{{.plan}}
+
+Completed steps:
+{{.pastSteps}}
+
+You are tasked with executing step 1 of the plan: {{.step}}`
+
+	planAndExecuteStepTool = "ExecuteStep"
+
+	endOfPlan = "<END_OF_PLAN>"
+)
+
+// PlanAndExecuteOptions represents the configuration options for the PlanAndExecute agent.
+type PlanAndExecuteOptions struct {
+	// PlannerPrompt is the prompt of the planner, which creates the initial plan.
+	PlannerPrompt string
+	// ReplannerPrompt is the prompt of the replanner, which revises the remaining steps of the plan.
+	ReplannerPrompt string
+	// StepPrompt is the prompt, which is passed as input to the step executor.
+	StepPrompt string
+	// OutputKey is the key to store the output of the agent in the ChainValues.
+	OutputKey string
+	// Replanner is the model used to revise the plan. Default is the planner.
+	Replanner schema.Model
+	// StepExecutor solves a single step of the plan. It's called with the step prompt as single input.
+	// Default is a ReactDescription agent with the planner model and the tools.
+	StepExecutor schema.Chain
+	// MaxIterations is the maximum number of executed steps.
+	MaxIterations int
+	// ConfigureExecutor configures the options of the executor, e.g. error handling or early stopping.
+	ConfigureExecutor func(o *ExecutorOptions)
+}
+
+// PlanAndExecute is an agent, which first creates a plan with a planner model and then solves
+// the steps of the plan one by one with a step executor. After every step, a replanner revises
+// the remaining steps based on the results of the steps done so far or returns the final answer.
+//
+// Every step is an action of the agent. The log of the action is the remaining plan and the observation
+// is the result of the step, so that the plans and step results are visible to the callbacks of the executor.
+type PlanAndExecute struct {
+	planner   schema.Chain
+	replanner schema.Chain
+	step      *prompt.Template
+	opts      PlanAndExecuteOptions
+}
+
+// NewPlanAndExecute creates a new instance of the PlanAndExecute agent with the given planner model and tools.
+func NewPlanAndExecute(planner schema.Model, tools []schema.Tool, optFns ...func(o *PlanAndExecuteOptions)) (*Executor, error) {
+	opts := PlanAndExecuteOptions{
+		PlannerPrompt:   defaultPlanAndExecutePlannerPrompt,
+		ReplannerPrompt: defaultPlanAndExecuteReplannerPrompt,
+		StepPrompt:      defaultPlanAndExecuteStepPrompt,
+		OutputKey:       "output",
+		MaxIterations:   DefaultMaxIterations,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.Replanner == nil {
+		opts.Replanner = planner
+	}
+
+	if opts.StepExecutor == nil {
+		stepExecutor, err := NewReactDescription(planner, tools)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.StepExecutor = stepExecutor
+	}
+
+	partialValues := map[string]any{
+		"toolDescriptions": toolDescriptions(tools),
+	}
+
+	plannerChain, err := chain.NewLLM(planner, prompt.NewTemplate(opts.PlannerPrompt, func(o *prompt.TemplateOptions) {
+		o.PartialValues = partialValues
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	replannerChain, err := chain.NewLLM(opts.Replanner, prompt.NewTemplate(opts.ReplannerPrompt, func(o *prompt.TemplateOptions) {
+		o.PartialValues = partialValues
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	agent := &PlanAndExecute{
+		planner:   plannerChain,
+		replanner: replannerChain,
+		step:      prompt.NewTemplate(opts.StepPrompt),
+		opts:      opts,
+	}
+
+	return NewExecutor(agent, []schema.Tool{&planStep{executor: opts.StepExecutor}}, func(o *ExecutorOptions) {
+		o.MaxIterations = opts.MaxIterations
+		o.AgentChainType = "PlanAndExecute"
+
+		if opts.ConfigureExecutor != nil {
+			opts.ConfigureExecutor(o)
+		}
+	})
+}
+
+// Plan creates the initial plan or revises the remaining steps of the plan after a step was executed.
+// It returns an action to execute the next step or the final answer.
+func (a *PlanAndExecute) Plan(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues, optFns ...func(o *schema.AgentPlanOptions)) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+	opts := schema.AgentPlanOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	// Ignore the steps, which feed parsing errors back to the agent.
+	executed := make([]schema.AgentStep, 0, len(intermediateSteps))
+
+	for _, step := range intermediateSteps {
+		if step.Action.Tool == planAndExecuteStepTool {
+			executed = append(executed, step)
+		}
+	}
+
+	planner := a.planner
+
+	inputs["pastSteps"] = a.formatPastSteps(executed)
+	if len(executed) > 0 {
+		planner = a.replanner
+		inputs["plan"] = executed[len(executed)-1].Action.Log
+	}
+
+	resp, err := golc.Call(ctx, planner, inputs, golc.ChildCallOptions(opts.CallbackManger))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output, ok := resp[planner.OutputKeys()[0]].(string)
+	if !ok {
+		return nil, nil, ErrInvalidChainReturnType
+	}
+
+	if _, answer, found := strings.Cut(output, finalAnswerAction); found {
+		return nil, &schema.AgentFinish{
+			ReturnValues: map[string]any{
+				a.opts.OutputKey: strings.TrimSpace(answer),
+			},
+			Log: output,
+		}, nil
+	}
+
+	plan := parsePlan(output)
+	if len(plan) == 0 {
+		return nil, nil, &OutputParserError{Output: output}
+	}
+
+	inputs["plan"] = formatPlan(plan)
+	inputs["step"] = plan[0]
+
+	task, err := a.step.Format(inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []*schema.AgentAction{
+		{Tool: planAndExecuteStepTool, ToolInput: schema.NewToolInputFromString(task), Log: formatPlan(plan)},
+	}, nil, nil
+}
+
+// InputKeys returns the expected input keys for the agent.
+func (a *PlanAndExecute) InputKeys() []string {
+	return []string{"input"}
+}
+
+// OutputKeys returns the output keys that the agent will return.
+func (a *PlanAndExecute) OutputKeys() []string {
+	return []string{a.opts.OutputKey}
+}
+
+// formatPastSteps formats the executed steps and their results. The executed step is
+// always the first step of the plan in the log of the action.
+func (a *PlanAndExecute) formatPastSteps(steps []schema.AgentStep) string {
+	if len(steps) == 0 {
+		return "None"
+	}
+
+	pastSteps := make([]string, len(steps))
+
+	for i, step := range steps {
+		var task string
+		if plan := parsePlan(step.Action.Log); len(plan) > 0 {
+			task = plan[0]
+		}
+
+		pastSteps[i] = fmt.Sprintf("Step: %s\nResult: %s", task, step.Observation)
+	}
+
+	return strings.Join(pastSteps, "\n\n")
+}
+
+var planStepPattern = regexp.MustCompile(`^\s*\d+[.)]\s+(.+)$`)
+
+// parsePlan parses a numbered list of steps, which optionally ends with <END_OF_PLAN>.
+func parsePlan(text string) []string {
+	text, _, _ = strings.Cut(text, endOfPlan)
+
+	plan := []string{}
+
+	for _, line := range strings.Split(text, "\n") {
+		if match := planStepPattern.FindStringSubmatch(line); match != nil {
+			plan = append(plan, strings.TrimSpace(match[1]))
+		}
+	}
+
+	return plan
+}
+
+// formatPlan formats the steps as numbered list.
+func formatPlan(plan []string) string {
+	lines := make([]string, len(plan))
+	for i, step := range plan {
+		lines[i] = fmt.Sprintf("%d. %s", i+1, step)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Compile time check to ensure planStep satisfies the ToolWithRunOptions interface.
+var _ schema.ToolWithRunOptions = (*planStep)(nil)
+
+// planStep is the tool, which executes a single step of the plan with the step executor.
+type planStep struct {
+	executor schema.Chain
+}
+
+// Name returns the name of the tool.
+func (t *planStep) Name() string {
+	return planAndExecuteStepTool
+}
+
+// Description returns the description of the tool.
+func (t *planStep) Description() string {
+	return "Executes a single step of the plan."
+}
+
+// ArgsType returns the type of the input argument expected by the tool.
+func (t *planStep) ArgsType() reflect.Type {
+	return reflect.TypeOf("") // string
+}
+
+// Run executes the step with the step executor and returns the result.
+func (t *planStep) Run(ctx context.Context, input any) (string, error) {
+	return t.RunWithOptions(ctx, input)
+}
+
+// RunWithOptions executes the step with the step executor as child of the tool run and returns the result.
+func (t *planStep) RunWithOptions(ctx context.Context, input any, optFns ...func(o *schema.ToolRunOptions)) (string, error) {
+	opts := schema.ToolRunOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	result, err := golc.SimpleCall(ctx, t.executor, input, golc.ChildSimpleCallOptions(opts.CallbackManger))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(result), nil
+}
+
+// Verbose returns the verbosity setting of the tool.
+func (t *planStep) Verbose() bool {
+	return false
+}
+
+// Callbacks returns the registered callbacks of the tool.
+func (t *planStep) Callbacks() []schema.Callback {
+	return nil
+}
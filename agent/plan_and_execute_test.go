@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/model/llm"
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestPlanAndExecute(t *testing.T) {
+	t.Parallel()
+
+	newFake := func(fn func(prompt string) string) *llm.Fake {
+		return llm.NewFake(func(ctx context.Context, prompt string) (*schema.ModelResult, error) {
+			return &schema.ModelResult{
+				Generations: []schema.Generation{{Text: fn(prompt)}},
+				LLMOutput:   map[string]any{},
+			}, nil
+		})
+	}
+
+	searchTool := &mockTool{
+		ToolName:        "Search",
+		ToolDescription: "Searches the web.",
+		ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+			if input.(string) == "capital of germany" {
+				return "Berlin", nil
+			}
+
+			return "3.7 million", nil
+		},
+	}
+
+	t.Run("Call", func(t *testing.T) {
+		t.Parallel()
+
+		planner := newFake(func(prompt string) string {
+			if !strings.Contains(prompt, "Your original plan was this:") {
+				assert.Contains(t, prompt, "- Search: Searches the web.")
+				assert.Contains(t, prompt, "Objective: How many people live in the capital of Germany?")
+
+				return "Plan:\n1. Find the capital of Germany\n2. Find the population of the capital\n<END_OF_PLAN>"
+			}
+
+			if strings.Contains(prompt, "Step: Find the population of Berlin\nResult: 3.7 million") {
+				return "Final Answer: 3.7 million people live in Berlin."
+			}
+
+			assert.Contains(t, prompt, "1. Find the capital of Germany\n2. Find the population of the capital")
+			assert.Contains(t, prompt, "Step: Find the capital of Germany\nResult: Berlin")
+
+			return "Plan:\n1. Find the population of Berlin\n<END_OF_PLAN>"
+		})
+
+		stepModel := newFake(func(prompt string) string {
+			// The scratchpad ends with the observation of the search tool.
+			if strings.HasSuffix(prompt, "\nThought:") {
+				observation := prompt[strings.LastIndex(prompt, "Observation: ")+len("Observation: ") : len(prompt)-len("\nThought:")]
+				return "Thought: I now know the final answer\nFinal Answer: " + observation
+			}
+
+			if strings.Contains(prompt, "executing step 1 of the plan: Find the capital of Germany") {
+				return "Action: Search\nAction Input: capital of germany"
+			}
+
+			assert.Contains(t, prompt, "Step: Find the capital of Germany\nResult: Berlin")
+
+			return "Action: Search\nAction Input: population of berlin"
+		})
+
+		stepExecutor, err := NewReactDescription(stepModel, []schema.Tool{searchTool})
+		require.NoError(t, err)
+
+		executor, err := NewPlanAndExecute(planner, []schema.Tool{searchTool}, func(o *PlanAndExecuteOptions) {
+			o.StepExecutor = stepExecutor
+		})
+		require.NoError(t, err)
+
+		handler := &planAndExecuteCallbackHandler{}
+
+		outputs, err := golc.Call(context.Background(), executor, schema.ChainValues{
+			"input": "How many people live in the capital of Germany?",
+		}, func(o *golc.CallOptions) {
+			o.Callbacks = []schema.Callback{handler}
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "3.7 million people live in Berlin.", outputs["output"])
+
+		assert.Equal(t, []string{
+			"1. Find the capital of Germany\n2. Find the population of the capital",
+			"1. Find the population of Berlin",
+		}, handler.plans)
+		assert.Equal(t, []string{"Berlin", "3.7 million"}, handler.results)
+	})
+
+	t.Run("InvalidPlan", func(t *testing.T) {
+		t.Parallel()
+
+		planner := newFake(func(prompt string) string {
+			return "I have no plan."
+		})
+
+		executor, err := NewPlanAndExecute(planner, []schema.Tool{searchTool})
+		require.NoError(t, err)
+
+		_, err = golc.SimpleCall(context.Background(), executor, "question")
+		assert.ErrorIs(t, err, ErrUnableToParseOutput)
+	})
+
+	t.Run("Type", func(t *testing.T) {
+		t.Parallel()
+
+		executor, err := NewPlanAndExecute(newFake(func(prompt string) string { return "" }), []schema.Tool{searchTool})
+		require.NoError(t, err)
+		assert.Equal(t, "PlanAndExecute", executor.Type())
+		assert.Equal(t, []string{"input"}, executor.InputKeys())
+		assert.Equal(t, []string{"output"}, executor.OutputKeys())
+	})
+}
+
+func TestParsePlan(t *testing.T) {
+	t.Parallel()
+
+	plan := parsePlan("Plan:\n1. First step\n2) Second step\n\n  3. Third step \n<END_OF_PLAN>\n4. Ignored")
+	assert.Equal(t, []string{"First step", "Second step", "Third step"}, plan)
+	assert.Equal(t, "1. First step\n2. Second step\n3. Third step", formatPlan(plan))
+
+	assert.Empty(t, parsePlan("no plan"))
+}
+
+// planAndExecuteCallbackHandler records the plans and step results of the outer plan-and-execute run.
+// The runs of the step executor are nested and ignored.
+type planAndExecuteCallbackHandler struct {
+	callback.NoopHandler
+	runID   string
+	plans   []string
+	results []string
+	mu      sync.Mutex
+}
+
+func (h *planAndExecuteCallbackHandler) AlwaysVerbose() bool {
+	return true
+}
+
+func (h *planAndExecuteCallbackHandler) OnChainStart(ctx context.Context, input *schema.ChainStartInput) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if input.ParentRunID == "" {
+		h.runID = input.RunID
+	}
+
+	return nil
+}
+
+func (h *planAndExecuteCallbackHandler) OnAgentAction(ctx context.Context, input *schema.AgentActionInput) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if input.RunID == h.runID {
+		h.plans = append(h.plans, input.Action.Log)
+	}
+
+	return nil
+}
+
+func (h *planAndExecuteCallbackHandler) OnToolEnd(ctx context.Context, input *schema.ToolEndInput) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if input.ParentRunID == h.runID {
+		h.results = append(h.results, input.Output)
+	}
+
+	return nil
+}
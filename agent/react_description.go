@@ -6,7 +6,7 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/chain"
 	"github.com/hupe1980/golc/prompt"
 	"github.com/hupe1980/golc/schema"
@@ -15,6 +15,9 @@ import (
 // Compile time check to ensure ReactDescription satisfies the agent interface.
 var _ schema.Agent = (*ReactDescription)(nil)
 
+// Compile time check to ensure ReactDescription satisfies the EarlyStopper interface.
+var _ EarlyStopper = (*ReactDescription)(nil)
+
 const (
 	defaultReactDescriptioPrefix = `Answer the following questions as best you can. You have access to the following tools:
 {{.toolDescriptions}}`
@@ -36,6 +39,8 @@ Question: {{.input}}
 Thought: {{.agentScratchpad}}`
 
 	finalAnswerAction = "Final Answer:"
+
+	finalAnswerThoughts = "\n\nI now need to return a final answer based on the previous steps:"
 )
 
 type ReactDescriptionOptions struct {
@@ -44,6 +49,8 @@ type ReactDescriptionOptions struct {
 	Suffix        string
 	OutputKey     string
 	MaxIterations int
+	// ConfigureExecutor configures the options of the executor, e.g. error handling or early stopping.
+	ConfigureExecutor func(o *ExecutorOptions)
 }
 
 type ReactDescription struct {
@@ -81,23 +88,46 @@ func NewReactDescription(llm schema.Model, tools []schema.Tool, optFns ...func(o
 	return NewExecutor(agent, tools, func(o *ExecutorOptions) {
 		o.MaxIterations = opts.MaxIterations
 		o.AgentChainType = "ReactDescription"
+
+		if opts.ConfigureExecutor != nil {
+			opts.ConfigureExecutor(o)
+		}
 	})
 }
 
-func (a *ReactDescription) Plan(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+func (a *ReactDescription) Plan(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues, optFns ...func(o *schema.AgentPlanOptions)) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+	opts := schema.AgentPlanOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
 	inputs["agentScratchpad"] = a.constructScratchPad(intermediateSteps)
 
-	resp, err := golc.Call(ctx, a.chain, inputs)
+	output, err := callAgentChain(ctx, a.chain, inputs, opts.CallbackManger)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	output, ok := resp[a.chain.OutputKeys()[0]].(string)
-	if !ok {
-		return nil, nil, ErrInvalidChainReturnType
+	return a.parseOutput(output)
+}
+
+// GenerateFinalAnswer asks the model for a final answer based on the intermediate steps.
+// It's used by the executor with EarlyStoppingMethodGenerate.
+func (a *ReactDescription) GenerateFinalAnswer(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues, optFns ...func(o *schema.AgentPlanOptions)) (*schema.AgentFinish, error) {
+	opts := schema.AgentPlanOptions{
+		CallbackManger: &callback.NoopManager{},
 	}
 
-	return a.parseOutput(output)
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return generateFinalAnswer(a.constructScratchPad(intermediateSteps), inputs, a.opts.OutputKey, func(inputs schema.ChainValues) (string, error) {
+		return callAgentChain(ctx, a.chain, inputs, opts.CallbackManger)
+	}, a.parseOutput)
 }
 
 func (a *ReactDescription) InputKeys() []string {
@@ -148,7 +178,7 @@ func (a *ReactDescription) parseOutput(output string) ([]*schema.AgentAction, *s
 	matches := r.FindStringSubmatch(output)
 
 	if len(matches) == 0 {
-		return nil, nil, fmt.Errorf("%w: %s", ErrUnableToParseOutput, output)
+		return nil, nil, &OutputParserError{Output: output}
 	}
 
 	toolInput := schema.NewToolInputFromString(strings.TrimSpace(matches[2]))
@@ -0,0 +1,361 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/chain"
+	"github.com/hupe1980/golc/prompt"
+	"github.com/hupe1980/golc/schema"
+	"github.com/hupe1980/golc/tool"
+)
+
+// Compile time check to ensure StructuredChat satisfies the agent interface.
+var _ schema.Agent = (*StructuredChat)(nil)
+
+// Compile time check to ensure StructuredChat satisfies the EarlyStopper interface.
+var _ EarlyStopper = (*StructuredChat)(nil)
+
+const (
+	defaultStructuredChatPrefix = `Respond to the human as helpfully and accurately as possible. You have access to the following tools:
+
+{{.toolDescriptions}}`
+
+	defaultStructuredChatInstructions = `Use a json blob to specify a tool by providing an action key (tool name) and an action_input key (tool input).
+
+Valid "action" values: "Final Answer" or {{.toolNames}}
+
+Provide only ONE action per $JSON_BLOB, as shown:
+
+` + "```" + `
+{
+  "action": $TOOL_NAME,
+  "action_input": $INPUT
+}
+` + "```" + `
+
+Follow this format:
+
+Question: input question to answer
+Thought: consider previous and subsequent steps
+Action:
+` + "```" + `
+$JSON_BLOB
+` + "```" + `
+Observation: action result
+... (repeat Thought/Action/Observation N times)
+Thought: I know what to respond
+Action:
+` + "```" + `
+{
+  "action": "Final Answer",
+  "action_input": "Final response to human"
+}
+` + "```"
+
+	defaultStructuredChatSuffix = `Begin! Reminder to ALWAYS respond with a valid json blob of a single action. Use tools if necessary. Respond directly if appropriate. Format is Action:` + "```$JSON_BLOB```" + `then Observation:.
+
+Question: {{.input}}
+Thought:{{.agentScratchpad}}`
+
+	structuredChatFinalAnswer = "Final Answer"
+
+	structuredChatRetryObservation = `Invalid or incomplete response. Respond with a valid json blob of a single action with the keys "action" and "action_input".`
+)
+
+// StructuredChatOptions represents the configuration options for the StructuredChat agent.
+type StructuredChatOptions struct {
+	Prefix       string
+	Instructions string
+	Suffix       string
+	// OutputKey is the key to store the output of the agent in the ChainValues.
+	OutputKey     string
+	MaxIterations int
+	// MaxRetries is the maximum number of times the model is asked again, if its output doesn't
+	// contain a valid json action blob.
+	MaxRetries int
+	// ConfigureExecutor configures the options of the executor, e.g. error handling or early stopping.
+	ConfigureExecutor func(o *ExecutorOptions)
+}
+
+// StructuredChat is an agent, which selects tools with a json action blob. The json schemas of the tool
+// arguments are rendered into the prompt, so that tools with multiple arguments can be used by models
+// without native function calling.
+type StructuredChat struct {
+	chain schema.Chain
+	opts  StructuredChatOptions
+}
+
+// NewStructuredChat creates a new instance of the StructuredChat agent with the given model and tools.
+// It returns an error if it fails to convert tools to function definitions.
+func NewStructuredChat(model schema.Model, tools []schema.Tool, optFns ...func(o *StructuredChatOptions)) (*Executor, error) {
+	opts := StructuredChatOptions{
+		Prefix:        defaultStructuredChatPrefix,
+		Instructions:  defaultStructuredChatInstructions,
+		Suffix:        defaultStructuredChatSuffix,
+		OutputKey:     "output",
+		MaxIterations: DefaultMaxIterations,
+		MaxRetries:    2,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	descriptions, err := structuredToolDescriptions(tools)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := prompt.NewTemplate(strings.Join([]string{opts.Prefix, opts.Instructions, opts.Suffix}, "\n\n"), func(o *prompt.TemplateOptions) {
+		o.PartialValues = map[string]any{
+			"toolNames":        toolNames(tools),
+			"toolDescriptions": descriptions,
+		}
+	})
+
+	llmChain, err := chain.NewLLM(model, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	agent := &StructuredChat{
+		chain: llmChain,
+		opts:  opts,
+	}
+
+	return NewExecutor(agent, tools, func(o *ExecutorOptions) {
+		o.MaxIterations = opts.MaxIterations
+		o.AgentChainType = "StructuredChat"
+
+		if opts.ConfigureExecutor != nil {
+			opts.ConfigureExecutor(o)
+		}
+	})
+}
+
+// Plan executes the agent with the given context, intermediate steps, and inputs.
+// If the output of the model doesn't contain a valid json action blob, the model is
+// asked again up to MaxRetries times.
+func (a *StructuredChat) Plan(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues, optFns ...func(o *schema.AgentPlanOptions)) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+	opts := schema.AgentPlanOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	scratchPad := a.constructScratchPad(intermediateSteps)
+
+	for retries := 0; ; retries++ {
+		inputs["agentScratchpad"] = scratchPad
+
+		output, err := a.call(ctx, inputs, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		actions, finish, err := a.parseOutput(output)
+		if err == nil || retries >= a.opts.MaxRetries {
+			return actions, finish, err
+		}
+
+		// Let the model correct its output.
+		scratchPad += fmt.Sprintf("%s\nObservation: %s\nThought:", output, structuredChatRetryObservation)
+	}
+}
+
+// GenerateFinalAnswer asks the model for a final answer based on the intermediate steps.
+// It's used by the executor with EarlyStoppingMethodGenerate.
+func (a *StructuredChat) GenerateFinalAnswer(ctx context.Context, intermediateSteps []schema.AgentStep, inputs schema.ChainValues, optFns ...func(o *schema.AgentPlanOptions)) (*schema.AgentFinish, error) {
+	opts := schema.AgentPlanOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return generateFinalAnswer(a.constructScratchPad(intermediateSteps), inputs, a.opts.OutputKey, func(inputs schema.ChainValues) (string, error) {
+		return a.call(ctx, inputs, opts)
+	}, a.parseOutput)
+}
+
+// InputKeys returns the expected input keys for the agent.
+func (a *StructuredChat) InputKeys() []string {
+	chainInputs := a.chain.InputKeys()
+
+	agentInput := make([]string, 0, len(chainInputs))
+
+	for _, v := range chainInputs {
+		if v == "agentScratchpad" {
+			continue
+		}
+
+		agentInput = append(agentInput, v)
+	}
+
+	return agentInput
+}
+
+// OutputKeys returns the output keys that the agent will return.
+func (a *StructuredChat) OutputKeys() []string {
+	return []string{a.opts.OutputKey}
+}
+
+// call calls the llm chain and returns its output. The model stops before it
+// makes up an observation.
+func (a *StructuredChat) call(ctx context.Context, inputs schema.ChainValues, opts schema.AgentPlanOptions) (string, error) {
+	resp, err := golc.Call(ctx, a.chain, inputs, golc.ChildCallOptions(opts.CallbackManger), func(co *golc.CallOptions) {
+		co.Stop = []string{"\nObservation:"}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	output, ok := resp[a.chain.OutputKeys()[0]].(string)
+	if !ok {
+		return "", ErrInvalidChainReturnType
+	}
+
+	return output, nil
+}
+
+// constructScratchPad constructs the scratchpad that lets the agent
+// continue its thought process.
+func (a *StructuredChat) constructScratchPad(steps []schema.AgentStep) string {
+	scratchPad := ""
+	for _, step := range steps {
+		scratchPad += step.Action.Log
+		scratchPad += fmt.Sprintf("\nObservation: %s\nThought:", step.Observation)
+	}
+
+	return scratchPad
+}
+
+func (a *StructuredChat) parseOutput(output string) ([]*schema.AgentAction, *schema.AgentFinish, error) {
+	blob, ok := extractActionBlob(output)
+	if !ok {
+		return nil, nil, &OutputParserError{Output: output}
+	}
+
+	if strings.EqualFold(blob.Action, structuredChatFinalAnswer) {
+		var answer string
+		if err := json.Unmarshal(blob.ActionInput, &answer); err != nil {
+			// The answer is no string, so the json is returned as it is.
+			answer = string(blob.ActionInput)
+		}
+
+		return nil, &schema.AgentFinish{
+			ReturnValues: map[string]any{
+				a.opts.OutputKey: answer,
+			},
+			Log: output,
+		}, nil
+	}
+
+	toolInput, err := structuredToolInput(blob.ActionInput)
+	if err != nil {
+		return nil, nil, &OutputParserError{Output: output}
+	}
+
+	return []*schema.AgentAction{
+		{Tool: blob.Action, ToolInput: toolInput, Log: output},
+	}, nil, nil
+}
+
+// actionBlob is the json action blob returned by the model.
+type actionBlob struct {
+	Action      string          `json:"action"`
+	ActionInput json.RawMessage `json:"action_input"`
+}
+
+var codeBlockPattern = regexp.MustCompile("(?s)```(?:json)?(.*?)```")
+
+// extractActionBlob returns the first valid action blob of the output. Action blobs in markdown
+// code blocks are preferred, but the blob may also be embedded in text.
+func extractActionBlob(output string) (*actionBlob, bool) {
+	candidates := []string{}
+	for _, match := range codeBlockPattern.FindAllStringSubmatch(output, -1) {
+		candidates = append(candidates, match[1])
+	}
+
+	candidates = append(candidates, output)
+
+	for _, candidate := range candidates {
+		for i, r := range candidate {
+			if r != '{' {
+				continue
+			}
+
+			blob := &actionBlob{}
+			if err := json.NewDecoder(strings.NewReader(candidate[i:])).Decode(blob); err != nil {
+				continue
+			}
+
+			if blob.Action != "" {
+				blob.Action = strings.TrimSpace(blob.Action)
+				return blob, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// structuredToolInput converts the action input into structured tool input. Plain values are
+// passed as the single argument of a tool, which expects a string.
+func structuredToolInput(actionInput json.RawMessage) (*schema.ToolInput, error) {
+	actionInput = bytes.TrimSpace(actionInput)
+
+	if bytes.HasPrefix(actionInput, []byte("{")) {
+		return schema.NewToolInputFromArguments(string(actionInput)), nil
+	}
+
+	var value any
+	if len(actionInput) > 0 {
+		if err := json.Unmarshal(actionInput, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	arg, ok := value.(string)
+	if !ok && value != nil {
+		arg = string(actionInput)
+	}
+
+	b, err := json.Marshal(map[string]string{"__arg1": arg})
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.NewToolInputFromArguments(string(b)), nil
+}
+
+// structuredToolDescriptions describes the tools including the json schema of their arguments.
+func structuredToolDescriptions(tools []schema.Tool) (string, error) {
+	descriptions := make([]string, len(tools))
+
+	for i, t := range tools {
+		f, err := tool.ToFunction(t)
+		if err != nil {
+			return "", err
+		}
+
+		args, err := json.Marshal(f.Parameters.Properties)
+		if err != nil {
+			return "", err
+		}
+
+		descriptions[i] = fmt.Sprintf("%s: %s, args: %s", t.Name(), t.Description(), args)
+	}
+
+	return strings.Join(descriptions, "\n"), nil
+}
@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/model/llm"
+	"github.com/hupe1980/golc/schema"
+)
+
+type weatherArgs struct {
+	City string `json:"city" description:"The name of the city"`
+	Unit string `json:"unit" enum:"celsius,fahrenheit"`
+}
+
+func TestStructuredChat(t *testing.T) {
+	t.Parallel()
+
+	weatherTool := &mockTool{
+		ToolName:        "Weather",
+		ToolDescription: "Returns the current weather.",
+		ToolArgsType:    weatherArgs{},
+		ToolRunFunc: func(ctx context.Context, input any) (string, error) {
+			args := input.(weatherArgs)
+			return args.City + ": 21 " + args.Unit, nil
+		},
+	}
+
+	newFake := func(outputs ...string) *llm.Fake {
+		calls := 0
+
+		return llm.NewFake(func(ctx context.Context, prompt string) (*schema.ModelResult, error) {
+			output := outputs[calls]
+			calls++
+
+			return &schema.ModelResult{
+				Generations: []schema.Generation{{Text: output}},
+				LLMOutput:   map[string]any{},
+			}, nil
+		})
+	}
+
+	t.Run("Call", func(t *testing.T) {
+		t.Parallel()
+
+		fake := newFake(
+			"Thought: I need the weather\nAction:\n```json\n{\n  \"action\": \"Weather\",\n  \"action_input\": {\"city\": \"Berlin\", \"unit\": \"celsius\"}\n}\n```",
+			"Thought: I know what to respond\nAction:\n```\n{\"action\": \"Final Answer\", \"action_input\": \"It's 21 celsius in Berlin.\"}\n```",
+		)
+
+		executor, err := NewStructuredChat(fake, []schema.Tool{weatherTool}, func(o *StructuredChatOptions) {
+			o.ConfigureExecutor = func(o *ExecutorOptions) {
+				o.ReturnIntermediateSteps = true
+			}
+		})
+		require.NoError(t, err)
+
+		outputs, err := golc.Call(context.Background(), executor, schema.ChainValues{"input": "How is the weather in Berlin?"})
+		require.NoError(t, err)
+		assert.Equal(t, "It's 21 celsius in Berlin.", outputs["output"])
+
+		steps := outputs[IntermediateStepsKey].([]schema.AgentStep)
+		require.Len(t, steps, 1)
+		assert.True(t, steps[0].Action.ToolInput.Structured())
+		assert.Equal(t, "Berlin: 21 celsius", steps[0].Observation)
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		t.Parallel()
+
+		var prompts []string
+
+		outputs := []string{
+			"I think it's sunny.",
+			"Action: {\"action\": \"Final Answer\", \"action_input\": \"sunny\"}",
+		}
+
+		fake := llm.NewFake(func(ctx context.Context, prompt string) (*schema.ModelResult, error) {
+			prompts = append(prompts, prompt)
+
+			return &schema.ModelResult{
+				Generations: []schema.Generation{{Text: outputs[len(prompts)-1]}},
+				LLMOutput:   map[string]any{},
+			}, nil
+		})
+
+		executor, err := NewStructuredChat(fake, []schema.Tool{weatherTool})
+		require.NoError(t, err)
+
+		output, err := golc.SimpleCall(context.Background(), executor, "How is the weather?")
+		require.NoError(t, err)
+		assert.Equal(t, "sunny", output)
+
+		require.Len(t, prompts, 2)
+		assert.True(t, strings.HasSuffix(prompts[1], "I think it's sunny.\nObservation: "+structuredChatRetryObservation+"\nThought:"))
+	})
+
+	t.Run("MaxRetries", func(t *testing.T) {
+		t.Parallel()
+
+		fake := newFake("no json", "still no json")
+
+		executor, err := NewStructuredChat(fake, []schema.Tool{weatherTool}, func(o *StructuredChatOptions) {
+			o.MaxRetries = 1
+		})
+		require.NoError(t, err)
+
+		_, err = golc.SimpleCall(context.Background(), executor, "How is the weather?")
+		assert.ErrorIs(t, err, ErrUnableToParseOutput)
+	})
+
+	t.Run("Prompt", func(t *testing.T) {
+		t.Parallel()
+
+		descriptions, err := structuredToolDescriptions([]schema.Tool{weatherTool, &mockTool{}})
+		require.NoError(t, err)
+		assert.Contains(t, descriptions, `Weather: Returns the current weather., args: {"city":{"type":"string","description":"The name of the city"}`)
+		assert.Contains(t, descriptions, `Mock: Mock, args: {"__arg1":{"type":"string","description":"__arg1"}}`)
+	})
+}
+
+func TestExtractActionBlob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		output      string
+		action      string
+		actionInput string
+	}{
+		{"CodeBlock", "Action:\n```json\n{\"action\": \"Search\", \"action_input\": \"golc\"}\n```", "Search", `"golc"`},
+		{"EmbeddedInText", "Action: {\"action\": \"Search\", \"action_input\": {\"q\": \"{golc}\"}} and more text", "Search", `{"q": "{golc}"}`},
+		{"SkipsInvalidBlobs", "{not json} ```\n{\"foo\": 1}\n``` {\"action\": \" Search \"}", "Search", ""},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			blob, ok := extractActionBlob(tc.output)
+			require.True(t, ok)
+			assert.Equal(t, tc.action, blob.Action)
+			assert.Equal(t, tc.actionInput, string(blob.ActionInput))
+		})
+	}
+
+	_, ok := extractActionBlob("Final answer: 42")
+	assert.False(t, ok)
+}
+
+func TestStructuredToolInput(t *testing.T) {
+	t.Parallel()
+
+	input, err := structuredToolInput([]byte(`{"city": "Berlin"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"city": "Berlin"}`, input.String())
+
+	input, err = structuredToolInput([]byte(`"golc"`))
+	require.NoError(t, err)
+
+	var arg string
+	require.NoError(t, input.Unmarshal(&arg))
+	assert.Equal(t, "golc", arg)
+
+	input, err = structuredToolInput([]byte(`42`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"__arg1":"42"}`, input.String())
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/hupe1980/golc/schema"
+	"github.com/hupe1980/golc/tool"
+)
+
+const defaultSupervisorPrefix = `You are a supervisor tasked with managing a team of workers to respond to the human. Break the request of the human down into subtasks and delegate each subtask to the best suited worker. The workers don't see the request of the human or the answers of the other workers, so give them all the context they need. When all subtasks are done, aggregate the answers of the workers into the final answer. You have access to the following workers:
+
+{{.toolDescriptions}}`
+
+// Worker is a named agent or chain, to which the supervisor delegates subtasks.
+type Worker struct {
+	// Name is the name of the worker, e.g. "researcher".
+	Name string
+	// Description describes the capabilities of the worker.
+	Description string
+	// Chain is the agent executor or chain of the worker. It's called with the subtask as its single input.
+	Chain schema.Chain
+}
+
+// WorkerTask contains the arguments of a delegation to a worker.
+type WorkerTask struct {
+	Task string `json:"task" description:"The subtask for the worker including all the context the worker needs"`
+}
+
+// SupervisorOptions represents the configuration options for the Supervisor agent.
+type SupervisorOptions struct {
+	// Prefix is the prompt prefix, which describes the workers.
+	Prefix string
+	// OutputKey is the key to store the output of the agent in the ChainValues.
+	OutputKey     string
+	MaxIterations int
+	// MaxRetries is the maximum number of times the model is asked again, if its output doesn't
+	// contain a valid json action blob.
+	MaxRetries int
+	// ConfigureExecutor configures the options of the executor, e.g. error handling or early stopping.
+	ConfigureExecutor func(o *ExecutorOptions)
+}
+
+// NewSupervisor creates a new supervisor agent with the given model and workers. The supervisor
+// is a StructuredChat agent, which delegates subtasks to the workers as tools and aggregates
+// their answers. The worker runs are children of the tool runs of the supervisor, so that the
+// callbacks trace which worker handled which subtask.
+func NewSupervisor(model schema.Model, workers []Worker, optFns ...func(o *SupervisorOptions)) (*Executor, error) {
+	opts := SupervisorOptions{
+		Prefix:        defaultSupervisorPrefix,
+		OutputKey:     "output",
+		MaxIterations: DefaultMaxIterations,
+		MaxRetries:    2,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	tools := make([]schema.Tool, len(workers))
+
+	for i, w := range workers {
+		if len(w.Chain.InputKeys()) != 1 {
+			return nil, fmt.Errorf("worker %s: number of input keys must be 1, got %d", w.Name, len(w.Chain.InputKeys()))
+		}
+
+		inputKey := w.Chain.InputKeys()[0]
+
+		tools[i] = tool.NewChain(w.Chain, w.Name, w.Description, func(o *tool.ChainOptions[WorkerTask]) {
+			o.Inputs = func(args WorkerTask) (schema.ChainValues, error) {
+				return schema.ChainValues{inputKey: args.Task}, nil
+			}
+		})
+	}
+
+	return NewStructuredChat(model, tools, func(o *StructuredChatOptions) {
+		o.Prefix = opts.Prefix
+		o.OutputKey = opts.OutputKey
+		o.MaxIterations = opts.MaxIterations
+		o.MaxRetries = opts.MaxRetries
+		o.ConfigureExecutor = func(o *ExecutorOptions) {
+			o.AgentChainType = "Supervisor"
+
+			if opts.ConfigureExecutor != nil {
+				opts.ConfigureExecutor(o)
+			}
+		}
+	})
+}
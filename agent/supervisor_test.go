@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/chain"
+	"github.com/hupe1980/golc/model/llm"
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestSupervisor(t *testing.T) {
+	t.Parallel()
+
+	newWorker := func(name string) Worker {
+		worker, err := chain.NewTransform([]string{"input"}, []string{"output"}, func(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+			return schema.ChainValues{"output": name + " did " + inputs["input"].(string)}, nil
+		})
+		require.NoError(t, err)
+
+		return Worker{Name: name, Description: "The " + name + ".", Chain: worker}
+	}
+
+	t.Run("Call", func(t *testing.T) {
+		t.Parallel()
+
+		outputs := []string{
+			"Action:\n```json\n{\"action\": \"researcher\", \"action_input\": {\"task\": \"research golc\"}}\n```",
+			"Action:\n```json\n{\"action\": \"writer\", \"action_input\": {\"task\": \"write about golc\"}}\n```",
+			"Action:\n```json\n{\"action\": \"Final Answer\", \"action_input\": \"golc is great\"}\n```",
+		}
+
+		var prompts []string
+
+		fake := llm.NewFake(func(ctx context.Context, prompt string) (*schema.ModelResult, error) {
+			prompts = append(prompts, prompt)
+
+			return &schema.ModelResult{
+				Generations: []schema.Generation{{Text: outputs[len(prompts)-1]}},
+				LLMOutput:   map[string]any{},
+			}, nil
+		})
+
+		supervisor, err := NewSupervisor(fake, []Worker{newWorker("researcher"), newWorker("writer")})
+		require.NoError(t, err)
+		assert.Equal(t, "Supervisor", supervisor.Type())
+
+		handler := &supervisorCallbackHandler{}
+
+		output, err := golc.SimpleCall(context.Background(), supervisor, "Write a blog post about golc", func(o *golc.SimpleCallOptions) {
+			o.Callbacks = []schema.Callback{handler}
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "golc is great", output)
+
+		assert.Contains(t, prompts[0], `researcher: The researcher., args: {"task":{"type":"string"`)
+		assert.Contains(t, prompts[2], "Observation: researcher did research golc")
+		assert.Contains(t, prompts[2], "Observation: writer did write about golc")
+
+		// The worker chains run within the tool runs of the supervisor.
+		assert.Equal(t, []string{
+			"ChainStart Supervisor",
+			"ToolStart researcher", "ChainStart Transform", "ChainEnd", "ToolEnd",
+			"ToolStart writer", "ChainStart Transform", "ChainEnd", "ToolEnd",
+			"ChainEnd",
+		}, handler.events)
+	})
+
+	t.Run("InvalidWorker", func(t *testing.T) {
+		t.Parallel()
+
+		worker, err := chain.NewTransform([]string{"a", "b"}, []string{"output"}, nil)
+		require.NoError(t, err)
+
+		_, err = NewSupervisor(llm.NewSimpleFake(""), []Worker{{Name: "invalid", Chain: worker}})
+		assert.ErrorContains(t, err, "worker invalid: number of input keys must be 1, got 2")
+	})
+}
+
+type supervisorCallbackHandler struct {
+	callback.NoopHandler
+	events []string
+	mu     sync.Mutex
+}
+
+func (h *supervisorCallbackHandler) AlwaysVerbose() bool {
+	return true
+}
+
+func (h *supervisorCallbackHandler) record(event string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.events = append(h.events, event)
+}
+
+func (h *supervisorCallbackHandler) OnChainStart(ctx context.Context, input *schema.ChainStartInput) error {
+	// Ignore the llm chain of the supervisor.
+	if input.ChainType != "LLM" {
+		h.record("ChainStart " + input.ChainType)
+	}
+
+	return nil
+}
+
+func (h *supervisorCallbackHandler) OnChainEnd(ctx context.Context, input *schema.ChainEndInput) error {
+	if _, ok := input.Outputs["text"]; !ok {
+		h.record("ChainEnd")
+	}
+
+	return nil
+}
+
+func (h *supervisorCallbackHandler) OnToolStart(ctx context.Context, input *schema.ToolStartInput) error {
+	h.record("ToolStart " + input.ToolName)
+	return nil
+}
+
+func (h *supervisorCallbackHandler) OnToolEnd(ctx context.Context, input *schema.ToolEndInput) error {
+	h.record("ToolEnd")
+	return nil
+}
@@ -0,0 +1,514 @@
+package callback
+
+import (
+	"context"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure AsyncHandler satisfies the Callback interface.
+var _ schema.Callback = (*AsyncHandler)(nil)
+
+// AsyncHandlerOptions contains options for the AsyncHandler.
+type AsyncHandlerOptions struct {
+	// Workers is the number of workers, which deliver the events to the handler. Default is 1.
+	Workers int
+	// QueueSize is the capacity of the queue of each worker. Default is 1024.
+	QueueSize int
+	// Block blocks the caller, if the queue is full, until the event is queued or the context of the
+	// event is done. By default the event is dropped.
+	Block bool
+	// OnError is called with the errors returned by the handler. By default the errors are ignored.
+	OnError func(err error)
+	// MeterProvider creates the counter of the dropped events. Default is the global meter provider.
+	MeterProvider metric.MeterProvider
+}
+
+// AsyncHandler is a callback handler, which delivers the events to another handler on bounded background
+// queues, so that slow handlers, e.g. handlers doing http requests, don't add latency to the runs. Events of
+// a run and of all its child runs are delivered in order by the same worker. Events, which don't fit into a
+// full queue, are dropped and counted. Flush or Close the handler on shutdown to deliver the queued events.
+// The inputs are copied when queued, including the values, messages, tags and metadata, because callers may
+// change them after the callback returned.
+type AsyncHandler struct {
+	handler  schema.Callback
+	queues   []chan asyncEvent
+	workers  map[string]int
+	children map[string][]string
+	dropped  atomic.Uint64
+	counter  metric.Int64Counter
+	closed   bool
+	closeMu  sync.RWMutex
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	opts     AsyncHandlerOptions
+}
+
+// asyncEvent is a queued event. Events with a flushed channel are markers for Flush.
+type asyncEvent struct {
+	ctx     context.Context
+	fn      func(ctx context.Context) error
+	flushed chan struct{}
+}
+
+// NewAsyncHandler creates a new instance of the AsyncHandler, which delivers the events to the handler.
+func NewAsyncHandler(handler schema.Callback, optFns ...func(o *AsyncHandlerOptions)) (*AsyncHandler, error) {
+	opts := AsyncHandlerOptions{
+		Workers:       1,
+		QueueSize:     1024,
+		MeterProvider: otel.GetMeterProvider(),
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+
+	counter, err := opts.MeterProvider.Meter(openTelemetryInstrumentationName).Int64Counter("golc.callback.dropped_events",
+		metric.WithDescription("Number of callback events dropped by asynchronous handlers."),
+		metric.WithUnit("{event}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cb := &AsyncHandler{
+		handler:  handler,
+		queues:   make([]chan asyncEvent, opts.Workers),
+		workers:  map[string]int{},
+		children: map[string][]string{},
+		counter:  counter,
+		opts:     opts,
+	}
+
+	for i := range cb.queues {
+		cb.queues[i] = make(chan asyncEvent, opts.QueueSize)
+
+		cb.wg.Add(1)
+
+		go cb.work(cb.queues[i])
+	}
+
+	return cb, nil
+}
+
+// AlwaysVerbose returns the verbosity of the handler.
+func (cb *AsyncHandler) AlwaysVerbose() bool {
+	return cb.handler.AlwaysVerbose()
+}
+
+// RaiseError returns false, because the errors of the handler occur after the events were dispatched.
+func (cb *AsyncHandler) RaiseError() bool {
+	return false
+}
+
+// Dropped returns the number of dropped events.
+func (cb *AsyncHandler) Dropped() uint64 {
+	return cb.dropped.Load()
+}
+
+// Flush waits until the events, which were queued before, are delivered or the context is done.
+func (cb *AsyncHandler) Flush(ctx context.Context) error {
+	cb.closeMu.RLock()
+
+	if cb.closed {
+		cb.closeMu.RUnlock()
+		return nil
+	}
+
+	markers := make([]chan struct{}, 0, len(cb.queues))
+
+	for _, q := range cb.queues {
+		flushed := make(chan struct{})
+
+		select {
+		case q <- asyncEvent{flushed: flushed}:
+			markers = append(markers, flushed)
+		case <-ctx.Done():
+			cb.closeMu.RUnlock()
+			return ctx.Err()
+		}
+	}
+
+	cb.closeMu.RUnlock()
+
+	for _, flushed := range markers {
+		select {
+		case <-flushed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Close stops accepting events and waits until the queued events are delivered or the context is done.
+// Events after closing the handler are dropped.
+func (cb *AsyncHandler) Close(ctx context.Context) error {
+	cb.closeMu.Lock()
+
+	if !cb.closed {
+		cb.closed = true
+
+		for _, q := range cb.queues {
+			close(q)
+		}
+	}
+
+	cb.closeMu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		cb.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OnLLMStart queues the llm start event.
+func (cb *AsyncHandler) OnLLMStart(ctx context.Context, input *schema.LLMStartInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	if input.LLMStartManagerInput != nil {
+		input.LLMStartManagerInput = shallowCopy(input.LLMStartManagerInput)
+		input.InvocationParams = maps.Clone(input.InvocationParams)
+	}
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, false, func(ctx context.Context) error {
+		return cb.handler.OnLLMStart(ctx, input)
+	})
+
+	return nil
+}
+
+// OnChatModelStart queues the chat model start event.
+func (cb *AsyncHandler) OnChatModelStart(ctx context.Context, input *schema.ChatModelStartInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	if input.ChatModelStartManagerInput != nil {
+		input.ChatModelStartManagerInput = shallowCopy(input.ChatModelStartManagerInput)
+		input.Messages = slices.Clone(input.Messages)
+		input.InvocationParams = maps.Clone(input.InvocationParams)
+	}
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, false, func(ctx context.Context) error {
+		return cb.handler.OnChatModelStart(ctx, input)
+	})
+
+	return nil
+}
+
+// OnModelNewToken queues the new token event.
+func (cb *AsyncHandler) OnModelNewToken(ctx context.Context, input *schema.ModelNewTokenInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, false, func(ctx context.Context) error {
+		return cb.handler.OnModelNewToken(ctx, input)
+	})
+
+	return nil
+}
+
+// OnModelEnd queues the model end event.
+func (cb *AsyncHandler) OnModelEnd(ctx context.Context, input *schema.ModelEndInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, true, func(ctx context.Context) error {
+		return cb.handler.OnModelEnd(ctx, input)
+	})
+
+	return nil
+}
+
+// OnModelError queues the model error event.
+func (cb *AsyncHandler) OnModelError(ctx context.Context, input *schema.ModelErrorInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, true, func(ctx context.Context) error {
+		return cb.handler.OnModelError(ctx, input)
+	})
+
+	return nil
+}
+
+// OnChainStart queues the chain start event.
+func (cb *AsyncHandler) OnChainStart(ctx context.Context, input *schema.ChainStartInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	if input.ChainStartManagerInput != nil {
+		input.ChainStartManagerInput = shallowCopy(input.ChainStartManagerInput)
+		input.Inputs = maps.Clone(input.Inputs)
+	}
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, false, func(ctx context.Context) error {
+		return cb.handler.OnChainStart(ctx, input)
+	})
+
+	return nil
+}
+
+// OnChainEnd queues the chain end event.
+func (cb *AsyncHandler) OnChainEnd(ctx context.Context, input *schema.ChainEndInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	if input.ChainEndManagerInput != nil {
+		input.ChainEndManagerInput = shallowCopy(input.ChainEndManagerInput)
+		input.Outputs = maps.Clone(input.Outputs)
+	}
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, true, func(ctx context.Context) error {
+		return cb.handler.OnChainEnd(ctx, input)
+	})
+
+	return nil
+}
+
+// OnChainError queues the chain error event.
+func (cb *AsyncHandler) OnChainError(ctx context.Context, input *schema.ChainErrorInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, true, func(ctx context.Context) error {
+		return cb.handler.OnChainError(ctx, input)
+	})
+
+	return nil
+}
+
+// OnAgentAction queues the agent action event.
+func (cb *AsyncHandler) OnAgentAction(ctx context.Context, input *schema.AgentActionInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, false, func(ctx context.Context) error {
+		return cb.handler.OnAgentAction(ctx, input)
+	})
+
+	return nil
+}
+
+// OnAgentFinish queues the agent finish event.
+func (cb *AsyncHandler) OnAgentFinish(ctx context.Context, input *schema.AgentFinishInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, false, func(ctx context.Context) error {
+		return cb.handler.OnAgentFinish(ctx, input)
+	})
+
+	return nil
+}
+
+// OnToolStart queues the tool start event.
+func (cb *AsyncHandler) OnToolStart(ctx context.Context, input *schema.ToolStartInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, false, func(ctx context.Context) error {
+		return cb.handler.OnToolStart(ctx, input)
+	})
+
+	return nil
+}
+
+// OnToolEnd queues the tool end event.
+func (cb *AsyncHandler) OnToolEnd(ctx context.Context, input *schema.ToolEndInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, true, func(ctx context.Context) error {
+		return cb.handler.OnToolEnd(ctx, input)
+	})
+
+	return nil
+}
+
+// OnToolError queues the tool error event.
+func (cb *AsyncHandler) OnToolError(ctx context.Context, input *schema.ToolErrorInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, true, func(ctx context.Context) error {
+		return cb.handler.OnToolError(ctx, input)
+	})
+
+	return nil
+}
+
+// OnText queues the text event.
+func (cb *AsyncHandler) OnText(ctx context.Context, input *schema.TextInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, false, func(ctx context.Context) error {
+		return cb.handler.OnText(ctx, input)
+	})
+
+	return nil
+}
+
+// OnRetrieverStart queues the retriever start event.
+func (cb *AsyncHandler) OnRetrieverStart(ctx context.Context, input *schema.RetrieverStartInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, false, func(ctx context.Context) error {
+		return cb.handler.OnRetrieverStart(ctx, input)
+	})
+
+	return nil
+}
+
+// OnRetrieverEnd queues the retriever end event.
+func (cb *AsyncHandler) OnRetrieverEnd(ctx context.Context, input *schema.RetrieverEndInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, true, func(ctx context.Context) error {
+		return cb.handler.OnRetrieverEnd(ctx, input)
+	})
+
+	return nil
+}
+
+// OnRetrieverError queues the retriever error event.
+func (cb *AsyncHandler) OnRetrieverError(ctx context.Context, input *schema.RetrieverErrorInput) error {
+	input = shallowCopy(input)
+	input.Tags, input.Metadata = slices.Clone(input.Tags), maps.Clone(input.Metadata)
+
+	cb.enqueue(ctx, input.RunID, input.ParentRunID, true, func(ctx context.Context) error {
+		return cb.handler.OnRetrieverError(ctx, input)
+	})
+
+	return nil
+}
+
+// enqueue queues the event for the worker of the run. The event is dropped, if the queue is full and
+// the handler does not block, if the context is done while blocking, or if the handler is closed.
+func (cb *AsyncHandler) enqueue(ctx context.Context, runID, parentRunID string, end bool, fn func(ctx context.Context) error) {
+	cb.closeMu.RLock()
+	defer cb.closeMu.RUnlock()
+
+	if cb.closed {
+		cb.drop(ctx)
+		return
+	}
+
+	// The event is delivered after the run returned, so the cancellation of the run must not cancel the delivery.
+	event := asyncEvent{ctx: context.WithoutCancel(ctx), fn: fn}
+	queue := cb.queues[cb.worker(runID, parentRunID, end)]
+
+	if cb.opts.Block {
+		select {
+		case queue <- event:
+		case <-ctx.Done():
+			cb.drop(ctx)
+		}
+
+		return
+	}
+
+	select {
+	case queue <- event:
+	default:
+		cb.drop(ctx)
+	}
+}
+
+// worker returns the worker of the run. Child runs are assigned to the worker of their parent run, so that
+// the events of a run tree are delivered in order. The assignments of the run and of all its child runs are
+// removed, when the run ends, so that child runs without end event, e.g. dropped events, don't leak.
+func (cb *AsyncHandler) worker(runID, parentRunID string, end bool) int {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	worker, ok := cb.workers[runID]
+	if !ok {
+		if worker, ok = cb.workers[parentRunID]; ok {
+			if !end {
+				cb.children[parentRunID] = append(cb.children[parentRunID], runID)
+			}
+		} else {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(runID))
+			worker = int(h.Sum32() % uint32(len(cb.queues)))
+		}
+	}
+
+	if end {
+		cb.removeWorker(runID)
+	} else {
+		cb.workers[runID] = worker
+	}
+
+	return worker
+}
+
+// removeWorker removes the worker assignments of the run and of all its child runs.
+func (cb *AsyncHandler) removeWorker(runID string) {
+	for _, child := range cb.children[runID] {
+		cb.removeWorker(child)
+	}
+
+	delete(cb.children, runID)
+	delete(cb.workers, runID)
+}
+
+// shallowCopy returns a copy of the value, so that the queued event doesn't share the value with the caller.
+func shallowCopy[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+
+	c := *v
+
+	return &c
+}
+
+// drop counts the dropped event.
+func (cb *AsyncHandler) drop(ctx context.Context) {
+	cb.dropped.Add(1)
+	cb.counter.Add(ctx, 1)
+}
+
+// work delivers the events of the queue to the handler until the queue is closed.
+func (cb *AsyncHandler) work(queue <-chan asyncEvent) {
+	defer cb.wg.Done()
+
+	for event := range queue {
+		if event.flushed != nil {
+			close(event.flushed)
+			continue
+		}
+
+		if err := event.fn(event.ctx); err != nil && cb.opts.OnError != nil {
+			cb.opts.OnError(err)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestAsyncHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OrderedDelivery", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		handler := &asyncRecordingHandler{}
+
+		async, err := NewAsyncHandler(handler, func(o *AsyncHandlerOptions) {
+			o.Workers = 4
+			o.Block = true
+		})
+		require.NoError(t, err)
+
+		expected := []string{}
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 8; i++ {
+			chainID, llmID := fmt.Sprintf("chain-%d", i), fmt.Sprintf("llm-%d", i)
+
+			expected = append(expected, "ChainStart:"+chainID, "LLMStart:"+llmID)
+			for j := 0; j < 10; j++ {
+				expected = append(expected, fmt.Sprintf("NewToken:%s:%d", llmID, j))
+			}
+
+			expected = append(expected, "ModelEnd:"+llmID, "ChainEnd:"+chainID)
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				require.NoError(t, async.OnChainStart(ctx, &schema.ChainStartInput{RunID: chainID}))
+				require.NoError(t, async.OnLLMStart(ctx, &schema.LLMStartInput{RunID: llmID, ParentRunID: chainID}))
+
+				for j := 0; j < 10; j++ {
+					require.NoError(t, async.OnModelNewToken(ctx, &schema.ModelNewTokenInput{
+						ModelNewTokenManagerInput: &schema.ModelNewTokenManagerInput{Token: fmt.Sprint(j)},
+						RunID:                     llmID,
+						ParentRunID:               chainID,
+					}))
+				}
+
+				require.NoError(t, async.OnModelEnd(ctx, &schema.ModelEndInput{RunID: llmID, ParentRunID: chainID}))
+				require.NoError(t, async.OnChainEnd(ctx, &schema.ChainEndInput{RunID: chainID}))
+			}()
+		}
+
+		wg.Wait()
+		require.NoError(t, async.Flush(ctx))
+
+		events := handler.Events()
+		assert.ElementsMatch(t, expected, events)
+
+		// The events of each run tree are delivered in the order they were dispatched.
+		for i := 0; i < 8; i++ {
+			chainID, llmID := fmt.Sprintf("chain-%d", i), fmt.Sprintf("llm-%d", i)
+
+			tree := []string{}
+
+			for _, e := range events {
+				if runID := strings.Split(e, ":")[1]; runID == chainID || runID == llmID {
+					tree = append(tree, e)
+				}
+			}
+
+			assert.Equal(t, "ChainStart:"+chainID, tree[0])
+			assert.Equal(t, "LLMStart:"+llmID, tree[1])
+			assert.Equal(t, "ModelEnd:"+llmID, tree[len(tree)-2])
+			assert.Equal(t, "ChainEnd:"+chainID, tree[len(tree)-1])
+
+			for j := 0; j < 10; j++ {
+				assert.Equal(t, fmt.Sprintf("NewToken:%s:%d", llmID, j), tree[2+j])
+			}
+		}
+
+		require.NoError(t, async.Close(ctx))
+	})
+
+	t.Run("DroppedEvents", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		reader := sdkmetric.NewManualReader()
+		handler := &asyncRecordingHandler{
+			started: make(chan struct{}),
+			release: make(chan struct{}),
+		}
+
+		async, err := NewAsyncHandler(handler, func(o *AsyncHandlerOptions) {
+			o.QueueSize = 1
+			o.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		})
+		require.NoError(t, err)
+
+		// The first event blocks the worker, the second event fills the queue and the third event is dropped.
+		require.NoError(t, async.OnChainStart(ctx, &schema.ChainStartInput{RunID: "chain"}))
+		<-handler.started
+
+		start := time.Now()
+
+		require.NoError(t, async.OnText(ctx, &schema.TextInput{TextManagerInput: &schema.TextManagerInput{Text: "queued"}, RunID: "chain"}))
+		require.NoError(t, async.OnText(ctx, &schema.TextInput{TextManagerInput: &schema.TextManagerInput{Text: "dropped"}, RunID: "chain"}))
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, uint64(1), async.Dropped())
+
+		close(handler.release)
+		require.NoError(t, async.Close(ctx))
+
+		assert.Equal(t, []string{"ChainStart:chain", "Text:chain"}, handler.Events())
+
+		rm := metricdata.ResourceMetrics{}
+		require.NoError(t, reader.Collect(ctx, &rm))
+		require.Len(t, rm.ScopeMetrics, 1)
+		require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+
+		dropped := rm.ScopeMetrics[0].Metrics[0]
+		assert.Equal(t, "golc.callback.dropped_events", dropped.Name)
+		assert.Equal(t, int64(1), dropped.Data.(metricdata.Sum[int64]).DataPoints[0].Value)
+	})
+
+	t.Run("BlockCanceled", func(t *testing.T) {
+		t.Parallel()
+
+		handler := &asyncRecordingHandler{
+			started: make(chan struct{}),
+			release: make(chan struct{}),
+		}
+
+		async, err := NewAsyncHandler(handler, func(o *AsyncHandlerOptions) {
+			o.QueueSize = 1
+			o.Block = true
+		})
+		require.NoError(t, err)
+
+		ctx := context.Background()
+
+		// The first event blocks the worker and the second event fills the queue.
+		require.NoError(t, async.OnChainStart(ctx, &schema.ChainStartInput{RunID: "chain"}))
+		<-handler.started
+		require.NoError(t, async.OnText(ctx, &schema.TextInput{TextManagerInput: &schema.TextManagerInput{Text: "queued"}, RunID: "chain"}))
+
+		// The third event blocks until the context is done and is dropped.
+		cancelCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		require.NoError(t, async.OnText(cancelCtx, &schema.TextInput{TextManagerInput: &schema.TextManagerInput{Text: "dropped"}, RunID: "chain"}))
+		assert.Equal(t, uint64(1), async.Dropped())
+
+		close(handler.release)
+		require.NoError(t, async.Close(ctx))
+
+		assert.Equal(t, []string{"ChainStart:chain", "Text:chain"}, handler.Events())
+	})
+
+	t.Run("WorkerCleanup", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+
+		async, err := NewAsyncHandler(&asyncRecordingHandler{}, func(o *AsyncHandlerOptions) {
+			o.Workers = 2
+		})
+		require.NoError(t, err)
+
+		// The child runs don't end, e.g. because their end events were dropped.
+		require.NoError(t, async.OnChainStart(ctx, &schema.ChainStartInput{RunID: "chain"}))
+		require.NoError(t, async.OnToolStart(ctx, &schema.ToolStartInput{RunID: "tool", ParentRunID: "chain"}))
+		require.NoError(t, async.OnLLMStart(ctx, &schema.LLMStartInput{RunID: "llm", ParentRunID: "tool"}))
+		require.NoError(t, async.OnChainEnd(ctx, &schema.ChainEndInput{RunID: "chain"}))
+
+		async.mu.Lock()
+		assert.Empty(t, async.workers)
+		assert.Empty(t, async.children)
+		async.mu.Unlock()
+
+		require.NoError(t, async.Close(ctx))
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		handler := &asyncRecordingHandler{}
+
+		async, err := NewAsyncHandler(handler)
+		require.NoError(t, err)
+
+		require.NoError(t, async.OnToolStart(ctx, &schema.ToolStartInput{RunID: "tool"}))
+		require.NoError(t, async.Close(ctx))
+		require.NoError(t, async.Close(ctx))
+		require.NoError(t, async.Flush(ctx))
+
+		require.NoError(t, async.OnToolEnd(ctx, &schema.ToolEndInput{RunID: "tool"}))
+
+		assert.Equal(t, []string{"ToolStart:tool"}, handler.Events())
+		assert.Equal(t, uint64(1), async.Dropped())
+	})
+
+	t.Run("OnError", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+
+		var errs []error
+
+		async, err := NewAsyncHandler(&asyncRecordingHandler{err: errors.New("callback error")}, func(o *AsyncHandlerOptions) {
+			o.OnError = func(err error) {
+				errs = append(errs, err)
+			}
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, async.OnRetrieverStart(ctx, &schema.RetrieverStartInput{RunID: "retriever"}))
+		require.NoError(t, async.Close(ctx))
+
+		require.Len(t, errs, 1)
+		assert.EqualError(t, errs[0], "callback error")
+		assert.False(t, async.RaiseError())
+		assert.True(t, async.AlwaysVerbose())
+	})
+}
+
+type asyncRecordingHandler struct {
+	NoopHandler
+	events  []string
+	err     error
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+}
+
+func (h *asyncRecordingHandler) AlwaysVerbose() bool {
+	return true
+}
+
+func (h *asyncRecordingHandler) Events() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]string{}, h.events...)
+}
+
+func (h *asyncRecordingHandler) record(event string) error {
+	if h.started != nil {
+		h.once.Do(func() {
+			close(h.started)
+			<-h.release
+		})
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.events = append(h.events, event)
+
+	return h.err
+}
+
+func (h *asyncRecordingHandler) OnChainStart(ctx context.Context, input *schema.ChainStartInput) error {
+	return h.record("ChainStart:" + input.RunID)
+}
+
+func (h *asyncRecordingHandler) OnChainEnd(ctx context.Context, input *schema.ChainEndInput) error {
+	return h.record("ChainEnd:" + input.RunID)
+}
+
+func (h *asyncRecordingHandler) OnLLMStart(ctx context.Context, input *schema.LLMStartInput) error {
+	return h.record("LLMStart:" + input.RunID)
+}
+
+func (h *asyncRecordingHandler) OnModelNewToken(ctx context.Context, input *schema.ModelNewTokenInput) error {
+	return h.record(fmt.Sprintf("NewToken:%s:%s", input.RunID, input.Token))
+}
+
+func (h *asyncRecordingHandler) OnModelEnd(ctx context.Context, input *schema.ModelEndInput) error {
+	return h.record("ModelEnd:" + input.RunID)
+}
+
+func (h *asyncRecordingHandler) OnText(ctx context.Context, input *schema.TextInput) error {
+	return h.record("Text:" + input.RunID)
+}
+
+func (h *asyncRecordingHandler) OnToolStart(ctx context.Context, input *schema.ToolStartInput) error {
+	return h.record("ToolStart:" + input.RunID)
+}
+
+func (h *asyncRecordingHandler) OnToolEnd(ctx context.Context, input *schema.ToolEndInput) error {
+	return h.record("ToolEnd:" + input.RunID)
+}
+
+func (h *asyncRecordingHandler) OnRetrieverStart(ctx context.Context, input *schema.RetrieverStartInput) error {
+	return h.record("RetrieverStart:" + input.RunID)
+}
@@ -0,0 +1,381 @@
+package callback
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure CostHandler satisfies the Callback interface.
+var _ schema.Callback = (*CostHandler)(nil)
+
+// ModelPrice is the price of a model in USD per 1K tokens.
+type ModelPrice struct {
+	PromptPer1K     float64
+	CompletionPer1K float64
+}
+
+// Cost returns the cost of the prompt and completion tokens.
+func (p ModelPrice) Cost(promptTokens, completionTokens int) float64 {
+	return (p.PromptPer1K*float64(promptTokens) + p.CompletionPer1K*float64(completionTokens)) / 1000
+}
+
+// Usage is the aggregated token usage and cost of model runs.
+type Usage struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+	TotalTokens      int     `json:"totalTokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// add adds the other usage to the usage.
+func (u *Usage) add(other Usage) {
+	u.Requests += other.Requests
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.Cost += other.Cost
+}
+
+// CostHandlerOptions contains options for the CostHandler.
+type CostHandlerOptions struct {
+	// Prices maps model names to the prices of the models. A key matches a model name, which is equal
+	// to the key or starts with the key, e.g. "anthropic.claude-v2" matches "anthropic.claude-v2:1".
+	// The longest matching key wins. Models without price are counted with a cost of zero.
+	Prices map[string]ModelPrice
+}
+
+// CostHandler is a callback handler, which tracks the token usage and the cost of model runs. The
+// usage is aggregated in total, per model, per run including all nested runs, per chain type and per tag.
+// The token usage is read from the normalized "TokenUsage" of the model results, so models, which
+// don't report the token usage, are counted as requests only.
+type CostHandler struct {
+	NoopHandler
+	total      Usage
+	models     map[string]*Usage
+	runs       map[string]*Usage
+	chainTypes map[string]*Usage
+	tags       map[string]*Usage
+	active     map[string]costRun
+	mu         sync.Mutex
+	opts       CostHandlerOptions
+}
+
+// costRun holds a run, which is not finished yet.
+type costRun struct {
+	parentRunID string
+	chainType   string
+	modelName   string
+}
+
+// NewCostHandler creates a new instance of the CostHandler.
+func NewCostHandler(optFns ...func(o *CostHandlerOptions)) *CostHandler {
+	opts := CostHandlerOptions{
+		Prices: map[string]ModelPrice{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	cb := &CostHandler{
+		opts: opts,
+	}
+
+	cb.reset()
+
+	return cb
+}
+
+// AlwaysVerbose returns true, so that the usage is tracked independent of the verbosity.
+func (cb *CostHandler) AlwaysVerbose() bool {
+	return true
+}
+
+// String returns the total usage as string.
+func (cb *CostHandler) String() string {
+	total := cb.Total()
+
+	return fmt.Sprintf("Tokens Used: %d\nPrompt Tokens: %d\nCompletion Tokens: %d\nSuccessful Requests: %d\nTotal Cost (USD): $%.4f",
+		total.TotalTokens, total.PromptTokens, total.CompletionTokens, total.Requests, total.Cost)
+}
+
+// Total returns the total usage.
+func (cb *CostHandler) Total() Usage {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.total
+}
+
+// RunUsage returns the usage of the run including all nested runs. The usage of nested runs is only
+// available while the runs are active, the usage of root runs is kept until Reset is called.
+func (cb *CostHandler) RunUsage(runID string) (Usage, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	usage, ok := cb.runs[runID]
+	if !ok {
+		return Usage{}, false
+	}
+
+	return *usage, true
+}
+
+// ModelUsage returns the usage per model name.
+func (cb *CostHandler) ModelUsage() map[string]Usage {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return copyUsages(cb.models)
+}
+
+// ChainTypeUsage returns the usage per chain type. The usage of a model run counts for every
+// chain type of the chains the model run is nested in.
+func (cb *CostHandler) ChainTypeUsage() map[string]Usage {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return copyUsages(cb.chainTypes)
+}
+
+// TagUsage returns the usage per tag of the model runs.
+func (cb *CostHandler) TagUsage() map[string]Usage {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return copyUsages(cb.tags)
+}
+
+// Reset removes the tracked usage.
+func (cb *CostHandler) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.reset()
+}
+
+// OnLLMStart starts tracking the llm run.
+func (cb *CostHandler) OnLLMStart(ctx context.Context, input *schema.LLMStartInput) error {
+	cb.startRun(input.RunID, costRun{parentRunID: input.ParentRunID, modelName: invocationModelName(input.InvocationParams)})
+	return nil
+}
+
+// OnChatModelStart starts tracking the chat model run.
+func (cb *CostHandler) OnChatModelStart(ctx context.Context, input *schema.ChatModelStartInput) error {
+	cb.startRun(input.RunID, costRun{parentRunID: input.ParentRunID, modelName: invocationModelName(input.InvocationParams)})
+	return nil
+}
+
+// OnModelEnd adds the token usage and the cost of the model run.
+func (cb *CostHandler) OnModelEnd(ctx context.Context, input *schema.ModelEndInput) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	run, ok := cb.active[input.RunID]
+	if !ok {
+		return nil
+	}
+
+	delete(cb.active, input.RunID)
+
+	usage := Usage{Requests: 1}
+
+	if input.Result != nil {
+		for _, key := range []string{"ModelName", "modelName"} {
+			if name, ok := input.Result.LLMOutput[key].(string); ok && name != "" {
+				run.modelName = name
+				break
+			}
+		}
+
+		if tokenUsage, ok := input.Result.LLMOutput["TokenUsage"].(map[string]int); ok {
+			usage.PromptTokens = tokenUsage["PromptTokens"]
+			usage.CompletionTokens = tokenUsage["CompletionTokens"]
+
+			usage.TotalTokens = tokenUsage["TotalTokens"]
+			if usage.TotalTokens == 0 {
+				usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+			}
+		}
+	}
+
+	if price, ok := cb.price(run.modelName); ok {
+		usage.Cost = price.Cost(usage.PromptTokens, usage.CompletionTokens)
+	}
+
+	cb.total.add(usage)
+	addUsage(cb.models, run.modelName, usage)
+
+	if run.parentRunID == "" {
+		addUsage(cb.runs, input.RunID, usage)
+	}
+
+	chainTypes := map[string]bool{}
+
+	for parentRunID := run.parentRunID; parentRunID != ""; {
+		parent, ok := cb.active[parentRunID]
+		if !ok {
+			break
+		}
+
+		addUsage(cb.runs, parentRunID, usage)
+
+		if parent.chainType != "" && !chainTypes[parent.chainType] {
+			chainTypes[parent.chainType] = true
+			addUsage(cb.chainTypes, parent.chainType, usage)
+		}
+
+		parentRunID = parent.parentRunID
+	}
+
+	for _, tag := range input.Tags {
+		addUsage(cb.tags, tag, usage)
+	}
+
+	return nil
+}
+
+// OnModelError stops tracking the model run.
+func (cb *CostHandler) OnModelError(ctx context.Context, input *schema.ModelErrorInput) error {
+	cb.endRun(input.RunID)
+	return nil
+}
+
+// OnChainStart starts tracking the chain run.
+func (cb *CostHandler) OnChainStart(ctx context.Context, input *schema.ChainStartInput) error {
+	cb.startRun(input.RunID, costRun{parentRunID: input.ParentRunID, chainType: input.ChainType})
+	return nil
+}
+
+// OnChainEnd stops tracking the chain run.
+func (cb *CostHandler) OnChainEnd(ctx context.Context, input *schema.ChainEndInput) error {
+	cb.endRun(input.RunID)
+	return nil
+}
+
+// OnChainError stops tracking the chain run.
+func (cb *CostHandler) OnChainError(ctx context.Context, input *schema.ChainErrorInput) error {
+	cb.endRun(input.RunID)
+	return nil
+}
+
+// OnToolStart starts tracking the tool run, so that the usage of nested runs is added to the parents of the tool run.
+func (cb *CostHandler) OnToolStart(ctx context.Context, input *schema.ToolStartInput) error {
+	cb.startRun(input.RunID, costRun{parentRunID: input.ParentRunID})
+	return nil
+}
+
+// OnToolEnd stops tracking the tool run.
+func (cb *CostHandler) OnToolEnd(ctx context.Context, input *schema.ToolEndInput) error {
+	cb.endRun(input.RunID)
+	return nil
+}
+
+// OnToolError stops tracking the tool run.
+func (cb *CostHandler) OnToolError(ctx context.Context, input *schema.ToolErrorInput) error {
+	cb.endRun(input.RunID)
+	return nil
+}
+
+// OnRetrieverStart starts tracking the retriever run, so that the usage of nested runs is added to the parents of the retriever run.
+func (cb *CostHandler) OnRetrieverStart(ctx context.Context, input *schema.RetrieverStartInput) error {
+	cb.startRun(input.RunID, costRun{parentRunID: input.ParentRunID})
+	return nil
+}
+
+// OnRetrieverEnd stops tracking the retriever run.
+func (cb *CostHandler) OnRetrieverEnd(ctx context.Context, input *schema.RetrieverEndInput) error {
+	cb.endRun(input.RunID)
+	return nil
+}
+
+// OnRetrieverError stops tracking the retriever run.
+func (cb *CostHandler) OnRetrieverError(ctx context.Context, input *schema.RetrieverErrorInput) error {
+	cb.endRun(input.RunID)
+	return nil
+}
+
+// startRun starts tracking the run.
+func (cb *CostHandler) startRun(runID string, run costRun) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.active[runID] = run
+}
+
+// endRun stops tracking the run. Only the usage of root runs is kept, so that the
+// tracked usage doesn't grow with the number of nested runs.
+func (cb *CostHandler) endRun(runID string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if run, ok := cb.active[runID]; ok && run.parentRunID != "" {
+		delete(cb.runs, runID)
+	}
+
+	delete(cb.active, runID)
+}
+
+// price returns the price of the model with the longest matching key.
+func (cb *CostHandler) price(modelName string) (ModelPrice, bool) {
+	modelName = strings.ToLower(modelName)
+
+	var (
+		price  ModelPrice
+		length = -1
+	)
+
+	for key, p := range cb.opts.Prices {
+		key = strings.ToLower(key)
+		if strings.HasPrefix(modelName, key) && len(key) > length {
+			price, length = p, len(key)
+		}
+	}
+
+	return price, length >= 0
+}
+
+// reset initializes the tracked usage.
+func (cb *CostHandler) reset() {
+	cb.total = Usage{}
+	cb.models = map[string]*Usage{}
+	cb.runs = map[string]*Usage{}
+	cb.chainTypes = map[string]*Usage{}
+	cb.tags = map[string]*Usage{}
+	cb.active = map[string]costRun{}
+}
+
+// addUsage adds the usage to the usage of the key.
+func addUsage(usages map[string]*Usage, key string, usage Usage) {
+	if _, ok := usages[key]; !ok {
+		usages[key] = &Usage{}
+	}
+
+	usages[key].add(usage)
+}
+
+// copyUsages returns a copy of the usages.
+func copyUsages(usages map[string]*Usage) map[string]Usage {
+	result := make(map[string]Usage, len(usages))
+	for k, v := range usages {
+		result[k] = *v
+	}
+
+	return result
+}
+
+// invocationModelName returns the model name from the invocation params of a model, if any.
+func invocationModelName(invocationParams map[string]any) string {
+	for _, key := range []string{"model_name", "model", "model_id"} {
+		if name, ok := invocationParams[key].(string); ok && name != "" {
+			return name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,200 @@
+package callback
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestCostHandler(t *testing.T) {
+	t.Parallel()
+
+	newHandler := func() *CostHandler {
+		return NewCostHandler(func(o *CostHandlerOptions) {
+			o.Prices = map[string]ModelPrice{
+				"anthropic.claude":         {PromptPer1K: 0.008, CompletionPer1K: 0.024},
+				"anthropic.claude-instant": {PromptPer1K: 0.0008, CompletionPer1K: 0.0024},
+				"llama2":                   {PromptPer1K: 0, CompletionPer1K: 0},
+			}
+		})
+	}
+
+	runModel := func(t *testing.T, handler *CostHandler, runID, parentRunID, modelID string, tags []string, tokenUsage map[string]int) {
+		ctx := context.Background()
+
+		require.NoError(t, handler.OnChatModelStart(ctx, &schema.ChatModelStartInput{
+			ChatModelStartManagerInput: &schema.ChatModelStartManagerInput{
+				ChatModelType:    "Bedrock",
+				InvocationParams: map[string]any{"model_id": modelID},
+			},
+			RunID:       runID,
+			ParentRunID: parentRunID,
+			Tags:        tags,
+		}))
+
+		llmOutput := map[string]any{}
+		if tokenUsage != nil {
+			llmOutput["TokenUsage"] = tokenUsage
+		}
+
+		require.NoError(t, handler.OnModelEnd(ctx, &schema.ModelEndInput{
+			ModelEndManagerInput: &schema.ModelEndManagerInput{
+				Result: &schema.ModelResult{LLMOutput: llmOutput},
+			},
+			RunID: runID,
+			Tags:  tags,
+		}))
+	}
+
+	t.Run("Aggregation", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		handler := newHandler()
+
+		require.NoError(t, handler.OnChainStart(ctx, &schema.ChainStartInput{
+			ChainStartManagerInput: &schema.ChainStartManagerInput{ChainType: "ConversationalReactDescription"},
+			RunID:                  "agent",
+			Tags:                   []string{"team-a"},
+		}))
+		require.NoError(t, handler.OnChainStart(ctx, &schema.ChainStartInput{
+			ChainStartManagerInput: &schema.ChainStartManagerInput{ChainType: "LLM"},
+			RunID:                  "llm-chain",
+			ParentRunID:            "agent",
+			Tags:                   []string{"team-a"},
+		}))
+
+		runModel(t, handler, "model-1", "llm-chain", "anthropic.claude-v2:1", []string{"team-a"}, map[string]int{"PromptTokens": 1000, "CompletionTokens": 500, "TotalTokens": 1500})
+
+		llmChain, ok := handler.RunUsage("llm-chain")
+		require.True(t, ok)
+		assert.InDelta(t, 0.02, llmChain.Cost, 1e-9)
+
+		require.NoError(t, handler.OnChainEnd(ctx, &schema.ChainEndInput{ChainEndManagerInput: &schema.ChainEndManagerInput{}, RunID: "llm-chain"}))
+		require.NoError(t, handler.OnToolStart(ctx, &schema.ToolStartInput{
+			ToolStartManagerInput: &schema.ToolStartManagerInput{ToolName: "Summarize"},
+			RunID:                 "tool",
+			ParentRunID:           "agent",
+			Tags:                  []string{"team-a"},
+		}))
+
+		runModel(t, handler, "model-2", "tool", "anthropic.claude-instant-v1", []string{"team-a"}, map[string]int{"PromptTokens": 1000, "CompletionTokens": 1000})
+
+		require.NoError(t, handler.OnToolEnd(ctx, &schema.ToolEndInput{ToolEndManagerInput: &schema.ToolEndManagerInput{}, RunID: "tool"}))
+		require.NoError(t, handler.OnChainEnd(ctx, &schema.ChainEndInput{ChainEndManagerInput: &schema.ChainEndManagerInput{}, RunID: "agent"}))
+
+		runModel(t, handler, "model-3", "", "llama2", []string{"team-b"}, map[string]int{"PromptTokens": 10, "CompletionTokens": 20, "TotalTokens": 30})
+		runModel(t, handler, "model-4", "", "gemini-pro", []string{"team-b"}, nil)
+
+		total := handler.Total()
+		assert.Equal(t, 4, total.Requests)
+		assert.Equal(t, 2010, total.PromptTokens)
+		assert.Equal(t, 1520, total.CompletionTokens)
+		assert.Equal(t, 3530, total.TotalTokens)
+		assert.InDelta(t, 0.0232, total.Cost, 1e-9)
+
+		agent, ok := handler.RunUsage("agent")
+		require.True(t, ok)
+		assert.Equal(t, 2, agent.Requests)
+		assert.Equal(t, 3500, agent.TotalTokens)
+		assert.InDelta(t, 0.0232, agent.Cost, 1e-9)
+
+		// Only the usage of root runs is kept after the runs have finished.
+		for _, runID := range []string{"llm-chain", "model-1", "tool", "model-2", "unknown"} {
+			_, ok = handler.RunUsage(runID)
+			assert.False(t, ok, runID)
+		}
+
+		model3, ok := handler.RunUsage("model-3")
+		require.True(t, ok)
+		assert.Equal(t, 30, model3.TotalTokens)
+
+		chainTypes := handler.ChainTypeUsage()
+		assert.Len(t, chainTypes, 2)
+		assert.Equal(t, 2, chainTypes["ConversationalReactDescription"].Requests)
+		assert.Equal(t, 1, chainTypes["LLM"].Requests)
+
+		tags := handler.TagUsage()
+		assert.InDelta(t, 0.0232, tags["team-a"].Cost, 1e-9)
+		assert.Equal(t, 2, tags["team-b"].Requests)
+		assert.Equal(t, 30, tags["team-b"].TotalTokens)
+
+		models := handler.ModelUsage()
+		assert.InDelta(t, 0.0032, models["anthropic.claude-instant-v1"].Cost, 1e-9)
+		assert.Equal(t, 1, models["gemini-pro"].Requests)
+		assert.Equal(t, 0, models["gemini-pro"].TotalTokens)
+	})
+
+	t.Run("Retriever", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		handler := newHandler()
+
+		require.NoError(t, handler.OnChainStart(ctx, &schema.ChainStartInput{
+			ChainStartManagerInput: &schema.ChainStartManagerInput{ChainType: "RetrievalQA"},
+			RunID:                  "chain",
+		}))
+		require.NoError(t, handler.OnRetrieverStart(ctx, &schema.RetrieverStartInput{
+			RetrieverStartManagerInput: &schema.RetrieverStartManagerInput{},
+			RunID:                      "retriever",
+			ParentRunID:                "chain",
+		}))
+
+		runModel(t, handler, "model", "retriever", "anthropic.claude-v2", nil, map[string]int{"PromptTokens": 1000, "CompletionTokens": 0})
+
+		retriever, ok := handler.RunUsage("retriever")
+		require.True(t, ok)
+		assert.Equal(t, 1000, retriever.TotalTokens)
+
+		require.NoError(t, handler.OnRetrieverEnd(ctx, &schema.RetrieverEndInput{RetrieverEndManagerInput: &schema.RetrieverEndManagerInput{}, RunID: "retriever"}))
+
+		_, ok = handler.RunUsage("retriever")
+		assert.False(t, ok)
+
+		chain, ok := handler.RunUsage("chain")
+		require.True(t, ok)
+		assert.InDelta(t, 0.008, chain.Cost, 1e-9)
+		assert.Equal(t, 1, handler.ChainTypeUsage()["RetrievalQA"].Requests)
+	})
+
+	t.Run("ModelNameFromResult", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		handler := newHandler()
+
+		require.NoError(t, handler.OnLLMStart(ctx, &schema.LLMStartInput{
+			LLMStartManagerInput: &schema.LLMStartManagerInput{LLMType: "Anthropic"},
+			RunID:                "llm",
+		}))
+		require.NoError(t, handler.OnModelEnd(ctx, &schema.ModelEndInput{
+			ModelEndManagerInput: &schema.ModelEndManagerInput{
+				Result: &schema.ModelResult{
+					LLMOutput: map[string]any{
+						"ModelName":  "anthropic.claude-v2",
+						"TokenUsage": map[string]int{"PromptTokens": 1000, "CompletionTokens": 0, "TotalTokens": 1000},
+					},
+				},
+			},
+			RunID: "llm",
+		}))
+
+		assert.InDelta(t, 0.008, handler.Total().Cost, 1e-9)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		t.Parallel()
+
+		handler := newHandler()
+		runModel(t, handler, "model", "", "llama2", nil, map[string]int{"PromptTokens": 1})
+
+		handler.Reset()
+		assert.Equal(t, Usage{}, handler.Total())
+		assert.Empty(t, handler.ModelUsage())
+	})
+}
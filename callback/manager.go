@@ -24,6 +24,10 @@ var _ schema.CallbackManagerForRetrieverRun = (*manager)(nil)
 
 type ManagerOptions struct {
 	ParentRunID string
+	// Tags are the user-supplied tags of the runs. They are passed to the callbacks and inherited by child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the runs. It is passed to the callbacks and inherited by child runs.
+	Metadata map[string]any
 }
 
 type manager struct {
@@ -32,6 +36,8 @@ type manager struct {
 	localCallbacks       []schema.Callback
 	runID                string
 	parentRunID          string
+	tags                 []string
+	metadata             map[string]any
 	verbose              bool
 }
 
@@ -53,6 +59,8 @@ func newManager(runID string, inheritableCallbacks, localCallbacks []schema.Call
 		localCallbacks:       localCallbacks,
 		runID:                runID,
 		parentRunID:          opts.ParentRunID,
+		tags:                 opts.Tags,
+		metadata:             opts.Metadata,
 		verbose:              verbose,
 	}
 }
@@ -81,10 +89,25 @@ func (m *manager) GetInheritableCallbacks() []schema.Callback {
 	return m.inheritableCallbacks
 }
 
+func (m *manager) GetInheritableTags() []string {
+	return m.tags
+}
+
+func (m *manager) GetInheritableMetadata() map[string]any {
+	return m.metadata
+}
+
 func (m *manager) RunID() string {
 	return m.runID
 }
 
+// childOptions passes the parent run id, tags and metadata to the manager of a started run.
+func (m *manager) childOptions(o *ManagerOptions) {
+	o.ParentRunID = m.parentRunID
+	o.Tags = m.tags
+	o.Metadata = m.metadata
+}
+
 func (m *manager) OnLLMStart(ctx context.Context, input *schema.LLMStartManagerInput) (schema.CallbackManagerForModelRun, error) {
 	runID := uuid.New().String()
 
@@ -93,6 +116,9 @@ func (m *manager) OnLLMStart(ctx context.Context, input *schema.LLMStartManagerI
 			if err := c.OnLLMStart(ctx, &schema.LLMStartInput{
 				LLMStartManagerInput: input,
 				RunID:                runID,
+				ParentRunID:          m.parentRunID,
+				Tags:                 m.tags,
+				Metadata:             m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return nil, err
@@ -101,7 +127,7 @@ func (m *manager) OnLLMStart(ctx context.Context, input *schema.LLMStartManagerI
 		}
 	}
 
-	return NewManagerForModelRun(runID, m.inheritableCallbacks, m.localCallbacks, m.verbose), nil
+	return NewManagerForModelRun(runID, m.inheritableCallbacks, m.localCallbacks, m.verbose, m.childOptions), nil
 }
 
 func (m *manager) OnChatModelStart(ctx context.Context, input *schema.ChatModelStartManagerInput) (schema.CallbackManagerForModelRun, error) {
@@ -112,6 +138,9 @@ func (m *manager) OnChatModelStart(ctx context.Context, input *schema.ChatModelS
 			if err := c.OnChatModelStart(ctx, &schema.ChatModelStartInput{
 				ChatModelStartManagerInput: input,
 				RunID:                      runID,
+				ParentRunID:                m.parentRunID,
+				Tags:                       m.tags,
+				Metadata:                   m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return nil, err
@@ -120,7 +149,7 @@ func (m *manager) OnChatModelStart(ctx context.Context, input *schema.ChatModelS
 		}
 	}
 
-	return NewManagerForModelRun(runID, m.inheritableCallbacks, m.localCallbacks, m.verbose), nil
+	return NewManagerForModelRun(runID, m.inheritableCallbacks, m.localCallbacks, m.verbose, m.childOptions), nil
 }
 
 func (m *manager) OnModelNewToken(ctx context.Context, input *schema.ModelNewTokenManagerInput) error {
@@ -129,6 +158,9 @@ func (m *manager) OnModelNewToken(ctx context.Context, input *schema.ModelNewTok
 			if err := c.OnModelNewToken(ctx, &schema.ModelNewTokenInput{
 				ModelNewTokenManagerInput: input,
 				RunID:                     m.runID,
+				ParentRunID:               m.parentRunID,
+				Tags:                      m.tags,
+				Metadata:                  m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -146,6 +178,9 @@ func (m *manager) OnModelEnd(ctx context.Context, input *schema.ModelEndManagerI
 			if err := c.OnModelEnd(ctx, &schema.ModelEndInput{
 				ModelEndManagerInput: input,
 				RunID:                m.runID,
+				ParentRunID:          m.parentRunID,
+				Tags:                 m.tags,
+				Metadata:             m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -163,6 +198,9 @@ func (m *manager) OnModelError(ctx context.Context, input *schema.ModelErrorMana
 			if err := c.OnModelError(ctx, &schema.ModelErrorInput{
 				ModelErrorManagerInput: input,
 				RunID:                  m.runID,
+				ParentRunID:            m.parentRunID,
+				Tags:                   m.tags,
+				Metadata:               m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -182,6 +220,9 @@ func (m *manager) OnChainStart(ctx context.Context, input *schema.ChainStartMana
 			if err := c.OnChainStart(ctx, &schema.ChainStartInput{
 				ChainStartManagerInput: input,
 				RunID:                  runID,
+				ParentRunID:            m.parentRunID,
+				Tags:                   m.tags,
+				Metadata:               m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return nil, err
@@ -190,7 +231,7 @@ func (m *manager) OnChainStart(ctx context.Context, input *schema.ChainStartMana
 		}
 	}
 
-	return NewManagerForChainRun(runID, m.inheritableCallbacks, m.localCallbacks, m.verbose), nil
+	return NewManagerForChainRun(runID, m.inheritableCallbacks, m.localCallbacks, m.verbose, m.childOptions), nil
 }
 
 func (m *manager) OnChainEnd(ctx context.Context, input *schema.ChainEndManagerInput) error {
@@ -199,6 +240,9 @@ func (m *manager) OnChainEnd(ctx context.Context, input *schema.ChainEndManagerI
 			if err := c.OnChainEnd(ctx, &schema.ChainEndInput{
 				ChainEndManagerInput: input,
 				RunID:                m.runID,
+				ParentRunID:          m.parentRunID,
+				Tags:                 m.tags,
+				Metadata:             m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -216,6 +260,9 @@ func (m *manager) OnChainError(ctx context.Context, input *schema.ChainErrorMana
 			if err := c.OnChainError(ctx, &schema.ChainErrorInput{
 				ChainErrorManagerInput: input,
 				RunID:                  m.runID,
+				ParentRunID:            m.parentRunID,
+				Tags:                   m.tags,
+				Metadata:               m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -233,6 +280,9 @@ func (m *manager) OnAgentAction(ctx context.Context, input *schema.AgentActionMa
 			if err := c.OnAgentAction(ctx, &schema.AgentActionInput{
 				AgentActionManagerInput: input,
 				RunID:                   m.runID,
+				ParentRunID:             m.parentRunID,
+				Tags:                    m.tags,
+				Metadata:                m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -250,6 +300,9 @@ func (m *manager) OnAgentFinish(ctx context.Context, input *schema.AgentFinishMa
 			if err := c.OnAgentFinish(ctx, &schema.AgentFinishInput{
 				AgentFinishManagerInput: input,
 				RunID:                   m.runID,
+				ParentRunID:             m.parentRunID,
+				Tags:                    m.tags,
+				Metadata:                m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -269,6 +322,9 @@ func (m *manager) OnToolStart(ctx context.Context, input *schema.ToolStartManage
 			if err := c.OnToolStart(ctx, &schema.ToolStartInput{
 				ToolStartManagerInput: input,
 				RunID:                 runID,
+				ParentRunID:           m.parentRunID,
+				Tags:                  m.tags,
+				Metadata:              m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return nil, err
@@ -277,7 +333,7 @@ func (m *manager) OnToolStart(ctx context.Context, input *schema.ToolStartManage
 		}
 	}
 
-	return NewManagerForToolRun(runID, m.inheritableCallbacks, m.localCallbacks, m.verbose), nil
+	return NewManagerForToolRun(runID, m.inheritableCallbacks, m.localCallbacks, m.verbose, m.childOptions), nil
 }
 
 func (m *manager) OnToolEnd(ctx context.Context, input *schema.ToolEndManagerInput) error {
@@ -286,6 +342,9 @@ func (m *manager) OnToolEnd(ctx context.Context, input *schema.ToolEndManagerInp
 			if err := c.OnToolEnd(ctx, &schema.ToolEndInput{
 				ToolEndManagerInput: input,
 				RunID:               m.runID,
+				ParentRunID:         m.parentRunID,
+				Tags:                m.tags,
+				Metadata:            m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -303,6 +362,9 @@ func (m *manager) OnToolError(ctx context.Context, input *schema.ToolErrorManage
 			if err := c.OnToolError(ctx, &schema.ToolErrorInput{
 				ToolErrorManagerInput: input,
 				RunID:                 m.runID,
+				ParentRunID:           m.parentRunID,
+				Tags:                  m.tags,
+				Metadata:              m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -320,6 +382,9 @@ func (m *manager) OnText(ctx context.Context, input *schema.TextManagerInput) er
 			if err := c.OnText(ctx, &schema.TextInput{
 				TextManagerInput: input,
 				RunID:            m.runID,
+				ParentRunID:      m.parentRunID,
+				Tags:             m.tags,
+				Metadata:         m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -339,6 +404,9 @@ func (m *manager) OnRetrieverStart(ctx context.Context, input *schema.RetrieverS
 			if err := c.OnRetrieverStart(ctx, &schema.RetrieverStartInput{
 				RetrieverStartManagerInput: input,
 				RunID:                      runID,
+				ParentRunID:                m.parentRunID,
+				Tags:                       m.tags,
+				Metadata:                   m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return nil, err
@@ -347,7 +415,7 @@ func (m *manager) OnRetrieverStart(ctx context.Context, input *schema.RetrieverS
 		}
 	}
 
-	return NewManagerForRetrieverRun(runID, m.inheritableCallbacks, m.localCallbacks, m.verbose), nil
+	return NewManagerForRetrieverRun(runID, m.inheritableCallbacks, m.localCallbacks, m.verbose, m.childOptions), nil
 }
 
 func (m *manager) OnRetrieverEnd(ctx context.Context, input *schema.RetrieverEndManagerInput) error {
@@ -356,6 +424,9 @@ func (m *manager) OnRetrieverEnd(ctx context.Context, input *schema.RetrieverEnd
 			if err := c.OnRetrieverEnd(ctx, &schema.RetrieverEndInput{
 				RetrieverEndManagerInput: input,
 				RunID:                    m.runID,
+				ParentRunID:              m.parentRunID,
+				Tags:                     m.tags,
+				Metadata:                 m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -373,6 +444,9 @@ func (m *manager) OnRetrieverError(ctx context.Context, input *schema.RetrieverE
 			if err := c.OnRetrieverError(ctx, &schema.RetrieverErrorInput{
 				RetrieverErrorManagerInput: input,
 				RunID:                      m.runID,
+				ParentRunID:                m.parentRunID,
+				Tags:                       m.tags,
+				Metadata:                   m.metadata,
 			}); err != nil {
 				if c.RaiseError() {
 					return err
@@ -446,6 +520,14 @@ func (m *NoopManager) GetInheritableCallbacks() []schema.Callback {
 	return nil
 }
 
+func (m *NoopManager) GetInheritableTags() []string {
+	return nil
+}
+
+func (m *NoopManager) GetInheritableMetadata() map[string]any {
+	return nil
+}
+
 func (m *NoopManager) RunID() string {
 	return ""
 }
@@ -0,0 +1,311 @@
+package callback
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure OpenTelemetryHandler satisfies the Callback interface.
+var _ schema.Callback = (*OpenTelemetryHandler)(nil)
+
+const openTelemetryInstrumentationName = "github.com/hupe1980/golc"
+
+// Attribute keys of the spans and metrics created by the OpenTelemetryHandler.
+const (
+	AttributeRunType      = attribute.Key("golc.run.type")
+	AttributeRunName      = attribute.Key("golc.run.name")
+	AttributeRunID        = attribute.Key("golc.run.id")
+	AttributeModelName    = attribute.Key("gen_ai.request.model")
+	AttributeInputTokens  = attribute.Key("gen_ai.usage.input_tokens")
+	AttributeOutputTokens = attribute.Key("gen_ai.usage.output_tokens")
+	AttributeTotalTokens  = attribute.Key("golc.usage.total_tokens")
+	AttributeTokenType    = attribute.Key("gen_ai.token.type")
+	AttributeToolName     = attribute.Key("golc.tool.name")
+	AttributeDocuments    = attribute.Key("golc.retriever.documents")
+	AttributeError        = attribute.Key("error")
+)
+
+// OpenTelemetryHandlerOptions contains options for the OpenTelemetryHandler.
+type OpenTelemetryHandlerOptions struct {
+	// TracerProvider creates the tracer of the handler. Default is the global tracer provider.
+	TracerProvider trace.TracerProvider
+	// MeterProvider creates the meter of the handler. Default is the global meter provider.
+	MeterProvider metric.MeterProvider
+}
+
+// OpenTelemetryHandler is a callback handler, which creates OpenTelemetry spans for chain, model, tool and
+// retriever runs. The spans are nested by the parent run ids, and spans of root runs are children of
+// the span in the context, if any. In addition, it records the duration of the runs and the token usage
+// of the models as histograms.
+type OpenTelemetryHandler struct {
+	NoopHandler
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	tokens   metric.Int64Histogram
+	runs     map[string]*openTelemetryRun
+	mu       sync.Mutex
+	opts     OpenTelemetryHandlerOptions
+}
+
+// openTelemetryRun holds the span of a run, which is not finished yet.
+type openTelemetryRun struct {
+	// ctx is the context returned by the tracer, which carries the span of the run.
+	ctx     context.Context
+	span    trace.Span
+	runType string
+	name    string
+	start   time.Time
+}
+
+// NewOpenTelemetryHandler creates a new instance of the OpenTelemetryHandler.
+func NewOpenTelemetryHandler(optFns ...func(o *OpenTelemetryHandlerOptions)) (*OpenTelemetryHandler, error) {
+	opts := OpenTelemetryHandlerOptions{
+		TracerProvider: otel.GetTracerProvider(),
+		MeterProvider:  otel.GetMeterProvider(),
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	meter := opts.MeterProvider.Meter(openTelemetryInstrumentationName)
+
+	duration, err := meter.Float64Histogram("golc.run.duration",
+		metric.WithDescription("Duration of chain, model, tool and retriever runs."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := meter.Int64Histogram("golc.model.tokens",
+		metric.WithDescription("Number of tokens used by model runs."),
+		metric.WithUnit("{token}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenTelemetryHandler{
+		tracer:   opts.TracerProvider.Tracer(openTelemetryInstrumentationName),
+		duration: duration,
+		tokens:   tokens,
+		runs:     map[string]*openTelemetryRun{},
+		opts:     opts,
+	}, nil
+}
+
+// AlwaysVerbose returns true, so that the runs are traced independent of the verbosity.
+func (cb *OpenTelemetryHandler) AlwaysVerbose() bool {
+	return true
+}
+
+// OnLLMStart starts a span for the llm run.
+func (cb *OpenTelemetryHandler) OnLLMStart(ctx context.Context, input *schema.LLMStartInput) error {
+	cb.startSpan(ctx, input.RunID, input.ParentRunID, "llm", input.LLMType, modelNameAttributes(input.InvocationParams)...)
+	return nil
+}
+
+// OnChatModelStart starts a span for the chat model run.
+func (cb *OpenTelemetryHandler) OnChatModelStart(ctx context.Context, input *schema.ChatModelStartInput) error {
+	cb.startSpan(ctx, input.RunID, input.ParentRunID, "chat_model", input.ChatModelType, modelNameAttributes(input.InvocationParams)...)
+	return nil
+}
+
+// OnModelEnd ends the span of the model run and records the token usage.
+func (cb *OpenTelemetryHandler) OnModelEnd(ctx context.Context, input *schema.ModelEndInput) error {
+	attrs := []attribute.KeyValue{}
+
+	if input.Result != nil {
+		if tokenUsage, ok := input.Result.LLMOutput["TokenUsage"].(map[string]int); ok {
+			attrs = append(attrs,
+				AttributeInputTokens.Int(tokenUsage["PromptTokens"]),
+				AttributeOutputTokens.Int(tokenUsage["CompletionTokens"]),
+				AttributeTotalTokens.Int(tokenUsage["TotalTokens"]),
+			)
+
+			cb.recordTokens(input.RunID, tokenUsage)
+		}
+	}
+
+	cb.endSpan(input.RunID, nil, attrs...)
+
+	return nil
+}
+
+// OnModelError ends the span of the model run with the error.
+func (cb *OpenTelemetryHandler) OnModelError(ctx context.Context, input *schema.ModelErrorInput) error {
+	cb.endSpan(input.RunID, input.Error)
+	return nil
+}
+
+// OnChainStart starts a span for the chain run.
+func (cb *OpenTelemetryHandler) OnChainStart(ctx context.Context, input *schema.ChainStartInput) error {
+	cb.startSpan(ctx, input.RunID, input.ParentRunID, "chain", input.ChainType)
+	return nil
+}
+
+// OnChainEnd ends the span of the chain run.
+func (cb *OpenTelemetryHandler) OnChainEnd(ctx context.Context, input *schema.ChainEndInput) error {
+	cb.endSpan(input.RunID, nil)
+	return nil
+}
+
+// OnChainError ends the span of the chain run with the error.
+func (cb *OpenTelemetryHandler) OnChainError(ctx context.Context, input *schema.ChainErrorInput) error {
+	cb.endSpan(input.RunID, input.Error)
+	return nil
+}
+
+// OnAgentAction adds an event for the action to the span of the agent run.
+func (cb *OpenTelemetryHandler) OnAgentAction(ctx context.Context, input *schema.AgentActionInput) error {
+	cb.addEvent(input.RunID, "agent_action", AttributeToolName.String(input.Action.Tool))
+	return nil
+}
+
+// OnAgentFinish adds an event for the finish to the span of the agent run.
+func (cb *OpenTelemetryHandler) OnAgentFinish(ctx context.Context, input *schema.AgentFinishInput) error {
+	cb.addEvent(input.RunID, "agent_finish")
+	return nil
+}
+
+// OnToolStart starts a span for the tool run.
+func (cb *OpenTelemetryHandler) OnToolStart(ctx context.Context, input *schema.ToolStartInput) error {
+	cb.startSpan(ctx, input.RunID, input.ParentRunID, "tool", input.ToolName, AttributeToolName.String(input.ToolName))
+	return nil
+}
+
+// OnToolEnd ends the span of the tool run.
+func (cb *OpenTelemetryHandler) OnToolEnd(ctx context.Context, input *schema.ToolEndInput) error {
+	cb.endSpan(input.RunID, nil)
+	return nil
+}
+
+// OnToolError ends the span of the tool run with the error.
+func (cb *OpenTelemetryHandler) OnToolError(ctx context.Context, input *schema.ToolErrorInput) error {
+	cb.endSpan(input.RunID, input.Error)
+	return nil
+}
+
+// OnRetrieverStart starts a span for the retriever run.
+func (cb *OpenTelemetryHandler) OnRetrieverStart(ctx context.Context, input *schema.RetrieverStartInput) error {
+	cb.startSpan(ctx, input.RunID, input.ParentRunID, "retriever", input.RetrieverType)
+	return nil
+}
+
+// OnRetrieverEnd ends the span of the retriever run.
+func (cb *OpenTelemetryHandler) OnRetrieverEnd(ctx context.Context, input *schema.RetrieverEndInput) error {
+	cb.endSpan(input.RunID, nil, AttributeDocuments.Int(len(input.Docs)))
+	return nil
+}
+
+// OnRetrieverError ends the span of the retriever run with the error.
+func (cb *OpenTelemetryHandler) OnRetrieverError(ctx context.Context, input *schema.RetrieverErrorInput) error {
+	cb.endSpan(input.RunID, input.Error)
+	return nil
+}
+
+// startSpan starts the span of a run as child of the span of the parent run or of the span in the context.
+// Child spans are started with the context returned by the tracer for the parent span, so that everything
+// carried by it, e.g. baggage, is propagated to the children.
+func (cb *OpenTelemetryHandler) startSpan(ctx context.Context, runID, parentRunID, runType, name string, attrs ...attribute.KeyValue) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if parent, ok := cb.runs[parentRunID]; ok {
+		ctx = parent.ctx
+	}
+
+	attrs = append(attrs,
+		AttributeRunType.String(runType),
+		AttributeRunName.String(name),
+		AttributeRunID.String(runID),
+	)
+
+	ctx, span := cb.tracer.Start(ctx, fmt.Sprintf("%s %s", runType, name), trace.WithAttributes(attrs...))
+
+	cb.runs[runID] = &openTelemetryRun{
+		ctx:     ctx,
+		span:    span,
+		runType: runType,
+		name:    name,
+		start:   time.Now(),
+	}
+}
+
+// endSpan ends the span of a run and records the duration of the run with the context of the span.
+func (cb *OpenTelemetryHandler) endSpan(runID string, err error, attrs ...attribute.KeyValue) {
+	cb.mu.Lock()
+	run, ok := cb.runs[runID]
+	delete(cb.runs, runID)
+	cb.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	run.span.SetAttributes(attrs...)
+
+	if err != nil {
+		run.span.RecordError(err)
+		run.span.SetStatus(codes.Error, err.Error())
+	}
+
+	run.span.End()
+
+	cb.duration.Record(run.ctx, time.Since(run.start).Seconds(), metric.WithAttributes(
+		AttributeRunType.String(run.runType),
+		AttributeRunName.String(run.name),
+		AttributeError.Bool(err != nil),
+	))
+}
+
+// addEvent adds an event to the span of a run.
+func (cb *OpenTelemetryHandler) addEvent(runID, name string, attrs ...attribute.KeyValue) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if run, ok := cb.runs[runID]; ok {
+		run.span.AddEvent(name, trace.WithAttributes(attrs...))
+	}
+}
+
+// recordTokens records the input and output tokens of a model run.
+func (cb *OpenTelemetryHandler) recordTokens(runID string, tokenUsage map[string]int) {
+	cb.mu.Lock()
+	run, ok := cb.runs[runID]
+	cb.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	cb.tokens.Record(run.ctx, int64(tokenUsage["PromptTokens"]), metric.WithAttributes(
+		AttributeRunName.String(run.name),
+		AttributeTokenType.String("input"),
+	))
+
+	cb.tokens.Record(run.ctx, int64(tokenUsage["CompletionTokens"]), metric.WithAttributes(
+		AttributeRunName.String(run.name),
+		AttributeTokenType.String("output"),
+	))
+}
+
+// modelNameAttributes returns the model name attribute from the invocation params of the model, if any.
+func modelNameAttributes(invocationParams map[string]any) []attribute.KeyValue {
+	if name := invocationModelName(invocationParams); name != "" {
+		return []attribute.KeyValue{AttributeModelName.String(name)}
+	}
+
+	return []attribute.KeyValue{}
+}
@@ -0,0 +1,171 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestOpenTelemetryHandler(t *testing.T) {
+	t.Parallel()
+
+	newHandler := func(t *testing.T) (*OpenTelemetryHandler, *tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+		exporter := tracetest.NewInMemoryExporter()
+		reader := sdkmetric.NewManualReader()
+
+		handler, err := NewOpenTelemetryHandler(func(o *OpenTelemetryHandlerOptions) {
+			o.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			o.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		})
+		require.NoError(t, err)
+
+		return handler, exporter, reader
+	}
+
+	t.Run("NestedRuns", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		handler, exporter, reader := newHandler(t)
+
+		require.NoError(t, handler.OnChainStart(ctx, &schema.ChainStartInput{
+			ChainStartManagerInput: &schema.ChainStartManagerInput{ChainType: "LLM"},
+			RunID:                  "chain",
+		}))
+		require.NoError(t, handler.OnLLMStart(ctx, &schema.LLMStartInput{
+			LLMStartManagerInput: &schema.LLMStartManagerInput{
+				LLMType:          "OpenAI",
+				InvocationParams: map[string]any{"model_name": "gpt-3.5-turbo-instruct"},
+			},
+			RunID:       "llm",
+			ParentRunID: "chain",
+		}))
+		require.NoError(t, handler.OnModelEnd(ctx, &schema.ModelEndInput{
+			ModelEndManagerInput: &schema.ModelEndManagerInput{
+				Result: &schema.ModelResult{
+					LLMOutput: map[string]any{
+						"TokenUsage": map[string]int{"PromptTokens": 10, "CompletionTokens": 5, "TotalTokens": 15},
+					},
+				},
+			},
+			RunID: "llm",
+		}))
+		require.NoError(t, handler.OnToolStart(ctx, &schema.ToolStartInput{
+			ToolStartManagerInput: &schema.ToolStartManagerInput{ToolName: "Search"},
+			RunID:                 "tool",
+			ParentRunID:           "chain",
+		}))
+		require.NoError(t, handler.OnToolEnd(ctx, &schema.ToolEndInput{ToolEndManagerInput: &schema.ToolEndManagerInput{}, RunID: "tool"}))
+		require.NoError(t, handler.OnChainEnd(ctx, &schema.ChainEndInput{ChainEndManagerInput: &schema.ChainEndManagerInput{}, RunID: "chain"}))
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 3)
+
+		llmSpan, toolSpan, chainSpan := spans[0], spans[1], spans[2]
+
+		assert.Equal(t, "chain LLM", chainSpan.Name)
+		assert.False(t, chainSpan.Parent.IsValid())
+
+		assert.Equal(t, "llm OpenAI", llmSpan.Name)
+		assert.Equal(t, chainSpan.SpanContext.SpanID(), llmSpan.Parent.SpanID())
+		assert.Contains(t, llmSpan.Attributes, AttributeModelName.String("gpt-3.5-turbo-instruct"))
+		assert.Contains(t, llmSpan.Attributes, AttributeInputTokens.Int(10))
+		assert.Contains(t, llmSpan.Attributes, AttributeOutputTokens.Int(5))
+		assert.Contains(t, llmSpan.Attributes, AttributeTotalTokens.Int(15))
+
+		assert.Equal(t, "tool Search", toolSpan.Name)
+		assert.Equal(t, chainSpan.SpanContext.SpanID(), toolSpan.Parent.SpanID())
+		assert.Contains(t, toolSpan.Attributes, AttributeToolName.String("Search"))
+
+		rm := metricdata.ResourceMetrics{}
+		require.NoError(t, reader.Collect(ctx, &rm))
+		require.Len(t, rm.ScopeMetrics, 1)
+
+		metrics := map[string]metricdata.Metrics{}
+		for _, m := range rm.ScopeMetrics[0].Metrics {
+			metrics[m.Name] = m
+		}
+
+		duration := metrics["golc.run.duration"].Data.(metricdata.Histogram[float64])
+		assert.Len(t, duration.DataPoints, 3)
+
+		tokens := metrics["golc.model.tokens"].Data.(metricdata.Histogram[int64])
+		require.Len(t, tokens.DataPoints, 2)
+
+		for _, dp := range tokens.DataPoints {
+			tokenType, _ := dp.Attributes.Value(AttributeTokenType)
+
+			switch tokenType.AsString() {
+			case "input":
+				assert.Equal(t, int64(10), dp.Sum)
+			case "output":
+				assert.Equal(t, int64(5), dp.Sum)
+			default:
+				t.Errorf("unexpected token type %q", tokenType.AsString())
+			}
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		handler, exporter, _ := newHandler(t)
+
+		require.NoError(t, handler.OnRetrieverStart(ctx, &schema.RetrieverStartInput{
+			RetrieverStartManagerInput: &schema.RetrieverStartManagerInput{RetrieverType: "retriever.BM25", Query: "golc"},
+			RunID:                      "retriever",
+		}))
+		require.NoError(t, handler.OnRetrieverError(ctx, &schema.RetrieverErrorInput{
+			RetrieverErrorManagerInput: &schema.RetrieverErrorManagerInput{Error: errors.New("boom")},
+			RunID:                      "retriever",
+		}))
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "retriever retriever.BM25", spans[0].Name)
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+		assert.Equal(t, "boom", spans[0].Status.Description)
+		require.Len(t, spans[0].Events, 1)
+		assert.Equal(t, "exception", spans[0].Events[0].Name)
+	})
+
+	t.Run("AgentEvents", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		handler, exporter, _ := newHandler(t)
+
+		require.NoError(t, handler.OnChainStart(ctx, &schema.ChainStartInput{
+			ChainStartManagerInput: &schema.ChainStartManagerInput{ChainType: "ReactDescription"},
+			RunID:                  "agent",
+		}))
+		require.NoError(t, handler.OnAgentAction(ctx, &schema.AgentActionInput{
+			AgentActionManagerInput: &schema.AgentActionManagerInput{Action: &schema.AgentAction{Tool: "Search"}},
+			RunID:                   "agent",
+		}))
+		require.NoError(t, handler.OnAgentFinish(ctx, &schema.AgentFinishInput{
+			AgentFinishManagerInput: &schema.AgentFinishManagerInput{Finish: &schema.AgentFinish{}},
+			RunID:                   "agent",
+		}))
+		require.NoError(t, handler.OnChainEnd(ctx, &schema.ChainEndInput{ChainEndManagerInput: &schema.ChainEndManagerInput{}, RunID: "agent"}))
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		require.Len(t, spans[0].Events, 2)
+		assert.Equal(t, "agent_action", spans[0].Events[0].Name)
+		assert.Equal(t, []attribute.KeyValue{AttributeToolName.String("Search")}, spans[0].Events[0].Attributes)
+		assert.Equal(t, "agent_finish", spans[0].Events[1].Name)
+	})
+}
@@ -0,0 +1,542 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hupe1980/golc/internal/deepcopy"
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure Tracer satisfies the Callback interface.
+var _ schema.Callback = (*Tracer)(nil)
+
+// RunType is the type of a traced run.
+type RunType string
+
+const (
+	RunTypeChain     RunType = "chain"
+	RunTypeLLM       RunType = "llm"
+	RunTypeChatModel RunType = "chat_model"
+	RunTypeTool      RunType = "tool"
+	RunTypeRetriever RunType = "retriever"
+)
+
+// Run is a traced chain, model, tool or retriever run including its child runs.
+type Run struct {
+	ID         string         `json:"id"`
+	ParentID   string         `json:"parentID,omitempty"`
+	Type       RunType        `json:"type"`
+	Name       string         `json:"name"`
+	Tags       []string       `json:"tags,omitempty"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+	Inputs     map[string]any `json:"inputs,omitempty"`
+	Outputs    map[string]any `json:"outputs,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	TokenUsage map[string]int `json:"tokenUsage,omitempty"`
+	Events     []RunEvent     `json:"events,omitempty"`
+	StartTime  *time.Time     `json:"startTime,omitempty"`
+	EndTime    *time.Time     `json:"endTime,omitempty"`
+	Children   []*Run         `json:"children,omitempty"`
+}
+
+// Duration returns the duration of the run. It's zero, if the run has not finished.
+func (r *Run) Duration() time.Duration {
+	if r.StartTime == nil || r.EndTime == nil {
+		return 0
+	}
+
+	return r.EndTime.Sub(*r.StartTime)
+}
+
+// RunEvent is an event within a run, e.g. an agent action.
+type RunEvent struct {
+	Name string         `json:"name"`
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// Tracer is a callback handler, which records the runs as an in-memory tree. The runs contain
+// the inputs, outputs, timings, errors and token usage and can be exported as JSON, e.g. to
+// debug a failed run or to compare the trace with a golden file in tests.
+type Tracer struct {
+	NoopHandler
+	roots []*Run
+	runs  map[string]*Run
+	mu    sync.Mutex
+}
+
+// NewTracer creates a new instance of the Tracer.
+func NewTracer() *Tracer {
+	return &Tracer{
+		roots: []*Run{},
+		runs:  map[string]*Run{},
+	}
+}
+
+// AlwaysVerbose returns true, so that the runs are traced independent of the verbosity.
+func (cb *Tracer) AlwaysVerbose() bool {
+	return true
+}
+
+// Runs returns deep copies of the root runs in the order they were started. The copies are
+// snapshots, which are safe to read while the traced runs are still running.
+func (cb *Tracer) Runs() []*Run {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return deepcopy.Copy(cb.roots).([]*Run)
+}
+
+// Reset removes all traced runs.
+func (cb *Tracer) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.roots = []*Run{}
+	cb.runs = map[string]*Run{}
+}
+
+// WriteJSON writes the root runs as indented JSON to the writer.
+func (cb *Tracer) WriteJSON(w io.Writer) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return WriteRuns(w, cb.roots)
+}
+
+// OnLLMStart starts a llm run.
+func (cb *Tracer) OnLLMStart(ctx context.Context, input *schema.LLMStartInput) error {
+	cb.startRun(&Run{
+		ID:       input.RunID,
+		ParentID: input.ParentRunID,
+		Type:     RunTypeLLM,
+		Name:     input.LLMType,
+		Tags:     input.Tags,
+		Metadata: input.Metadata,
+		Inputs:   map[string]any{"prompt": input.Prompt},
+	})
+
+	return nil
+}
+
+// OnChatModelStart starts a chat model run.
+func (cb *Tracer) OnChatModelStart(ctx context.Context, input *schema.ChatModelStartInput) error {
+	cb.startRun(&Run{
+		ID:       input.RunID,
+		ParentID: input.ParentRunID,
+		Type:     RunTypeChatModel,
+		Name:     input.ChatModelType,
+		Tags:     input.Tags,
+		Metadata: input.Metadata,
+		Inputs:   map[string]any{"messages": traceValue(input.Messages)},
+	})
+
+	return nil
+}
+
+// OnModelEnd ends a model run with the generations and the token usage.
+func (cb *Tracer) OnModelEnd(ctx context.Context, input *schema.ModelEndInput) error {
+	cb.endRun(input.RunID, func(r *Run) {
+		if input.Result == nil {
+			return
+		}
+
+		generations := make([]any, len(input.Result.Generations))
+		for i, g := range input.Result.Generations {
+			if g.Message != nil {
+				generations[i] = traceValue(g.Message)
+			} else {
+				generations[i] = g.Text
+			}
+		}
+
+		r.Outputs = map[string]any{"generations": generations}
+
+		if tokenUsage, ok := input.Result.LLMOutput["TokenUsage"].(map[string]int); ok {
+			r.TokenUsage = tokenUsage
+		}
+	})
+
+	return nil
+}
+
+// OnModelError ends a model run with the error.
+func (cb *Tracer) OnModelError(ctx context.Context, input *schema.ModelErrorInput) error {
+	cb.endRunWithError(input.RunID, input.Error)
+	return nil
+}
+
+// OnChainStart starts a chain run.
+func (cb *Tracer) OnChainStart(ctx context.Context, input *schema.ChainStartInput) error {
+	cb.startRun(&Run{
+		ID:       input.RunID,
+		ParentID: input.ParentRunID,
+		Type:     RunTypeChain,
+		Name:     input.ChainType,
+		Tags:     input.Tags,
+		Metadata: input.Metadata,
+		Inputs:   traceValues(input.Inputs),
+	})
+
+	return nil
+}
+
+// OnChainEnd ends a chain run with the outputs.
+func (cb *Tracer) OnChainEnd(ctx context.Context, input *schema.ChainEndInput) error {
+	cb.endRun(input.RunID, func(r *Run) {
+		r.Outputs = traceValues(input.Outputs)
+	})
+
+	return nil
+}
+
+// OnChainError ends a chain run with the error.
+func (cb *Tracer) OnChainError(ctx context.Context, input *schema.ChainErrorInput) error {
+	cb.endRunWithError(input.RunID, input.Error)
+	return nil
+}
+
+// OnAgentAction adds the action as event to the agent run.
+func (cb *Tracer) OnAgentAction(ctx context.Context, input *schema.AgentActionInput) error {
+	cb.addEvent(input.RunID, RunEvent{
+		Name: "agentAction",
+		Data: map[string]any{
+			"tool":      input.Action.Tool,
+			"toolInput": toolInputString(input.Action.ToolInput),
+			"log":       input.Action.Log,
+		},
+	})
+
+	return nil
+}
+
+// OnAgentFinish adds the finish as event to the agent run.
+func (cb *Tracer) OnAgentFinish(ctx context.Context, input *schema.AgentFinishInput) error {
+	cb.addEvent(input.RunID, RunEvent{
+		Name: "agentFinish",
+		Data: map[string]any{
+			"returnValues": traceValues(input.Finish.ReturnValues),
+			"log":          input.Finish.Log,
+		},
+	})
+
+	return nil
+}
+
+// OnToolStart starts a tool run.
+func (cb *Tracer) OnToolStart(ctx context.Context, input *schema.ToolStartInput) error {
+	cb.startRun(&Run{
+		ID:       input.RunID,
+		ParentID: input.ParentRunID,
+		Type:     RunTypeTool,
+		Name:     input.ToolName,
+		Tags:     input.Tags,
+		Metadata: input.Metadata,
+		Inputs:   map[string]any{"input": toolInputString(input.Input)},
+	})
+
+	return nil
+}
+
+// OnToolEnd ends a tool run with the output.
+func (cb *Tracer) OnToolEnd(ctx context.Context, input *schema.ToolEndInput) error {
+	cb.endRun(input.RunID, func(r *Run) {
+		r.Outputs = map[string]any{"output": input.Output}
+	})
+
+	return nil
+}
+
+// OnToolError ends a tool run with the error.
+func (cb *Tracer) OnToolError(ctx context.Context, input *schema.ToolErrorInput) error {
+	cb.endRunWithError(input.RunID, input.Error)
+	return nil
+}
+
+// OnText adds the text as event to the run.
+func (cb *Tracer) OnText(ctx context.Context, input *schema.TextInput) error {
+	cb.addEvent(input.RunID, RunEvent{
+		Name: "text",
+		Data: map[string]any{"text": input.Text},
+	})
+
+	return nil
+}
+
+// OnRetrieverStart starts a retriever run.
+func (cb *Tracer) OnRetrieverStart(ctx context.Context, input *schema.RetrieverStartInput) error {
+	cb.startRun(&Run{
+		ID:       input.RunID,
+		ParentID: input.ParentRunID,
+		Type:     RunTypeRetriever,
+		Name:     input.RetrieverType,
+		Tags:     input.Tags,
+		Metadata: input.Metadata,
+		Inputs:   map[string]any{"query": input.Query},
+	})
+
+	return nil
+}
+
+// OnRetrieverEnd ends a retriever run with the documents.
+func (cb *Tracer) OnRetrieverEnd(ctx context.Context, input *schema.RetrieverEndInput) error {
+	cb.endRun(input.RunID, func(r *Run) {
+		r.Outputs = map[string]any{"documents": traceValue(input.Docs)}
+	})
+
+	return nil
+}
+
+// OnRetrieverError ends a retriever run with the error.
+func (cb *Tracer) OnRetrieverError(ctx context.Context, input *schema.RetrieverErrorInput) error {
+	cb.endRunWithError(input.RunID, input.Error)
+	return nil
+}
+
+// startRun adds the run as child of its parent run or as root run, if the parent is unknown.
+func (cb *Tracer) startRun(run *Run) {
+	now := time.Now()
+	run.StartTime = &now
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.runs[run.ID] = run
+
+	if parent, ok := cb.runs[run.ParentID]; ok {
+		parent.Children = append(parent.Children, run)
+		return
+	}
+
+	cb.roots = append(cb.roots, run)
+}
+
+// endRun sets the end time of the run and updates it with the given function.
+func (cb *Tracer) endRun(runID string, fn func(r *Run)) {
+	now := time.Now()
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	run, ok := cb.runs[runID]
+	if !ok {
+		return
+	}
+
+	run.EndTime = &now
+
+	fn(run)
+}
+
+// endRunWithError ends the run with the error.
+func (cb *Tracer) endRunWithError(runID string, err error) {
+	cb.endRun(runID, func(r *Run) {
+		r.Error = err.Error()
+	})
+}
+
+// addEvent adds the event to the run.
+func (cb *Tracer) addEvent(runID string, event RunEvent) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if run, ok := cb.runs[runID]; ok {
+		run.Events = append(run.Events, event)
+	}
+}
+
+// WriteRuns writes the runs as indented JSON to the writer.
+func WriteRuns(w io.Writer, runs []*Run) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(runs)
+}
+
+// ReadRuns reads runs, which were written by WriteRuns.
+func ReadRuns(r io.Reader) ([]*Run, error) {
+	runs := []*Run{}
+	if err := json.NewDecoder(r).Decode(&runs); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// NormalizeRuns returns a copy of the runs without timings and with sequential ids in the
+// order of a depth-first traversal. Normalized runs are deterministic, so that they can be
+// compared with golden files in tests.
+func NormalizeRuns(runs []*Run) ([]*Run, error) {
+	b, err := json.Marshal(runs)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := []*Run{}
+	if err := json.Unmarshal(b, &normalized); err != nil {
+		return nil, err
+	}
+
+	ids := map[string]string{}
+
+	var normalize func(runs []*Run)
+	normalize = func(runs []*Run) {
+		for _, r := range runs {
+			ids[r.ID] = fmt.Sprintf("run-%d", len(ids)+1)
+
+			r.ID = ids[r.ID]
+			r.ParentID = ids[r.ParentID]
+			r.StartTime = nil
+			r.EndTime = nil
+
+			normalize(r.Children)
+		}
+	}
+
+	normalize(normalized)
+
+	return normalized, nil
+}
+
+// PrintRunsOptions contains options for printing runs.
+type PrintRunsOptions struct {
+	// ShowIO prints the inputs and outputs of the runs.
+	ShowIO bool
+	// MaxValueLength is the maximum length of printed inputs and outputs. Longer values are truncated.
+	MaxValueLength int
+}
+
+// PrintRuns prints the runs as a tree with the durations, token usage and errors of the runs.
+func PrintRuns(w io.Writer, runs []*Run, optFns ...func(o *PrintRunsOptions)) error {
+	opts := PrintRunsOptions{
+		ShowIO:         true,
+		MaxValueLength: 120,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	var printRuns func(runs []*Run, indent string) error
+	printRuns = func(runs []*Run, indent string) error {
+		for i, r := range runs {
+			branch, childIndent := "├─ ", indent+"│  "
+			if i == len(runs)-1 {
+				branch, childIndent = "└─ ", indent+"   "
+			}
+
+			line := fmt.Sprintf("%s%s%s %s (%s)", indent, branch, r.Type, r.Name, r.Duration().Round(time.Millisecond))
+
+			if total, ok := r.TokenUsage["TotalTokens"]; ok {
+				line += fmt.Sprintf(" tokens=%d", total)
+			}
+
+			if r.Error != "" {
+				line += fmt.Sprintf(" error=%q", r.Error)
+			}
+
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+
+			if opts.ShowIO {
+				for _, v := range []struct {
+					label  string
+					values map[string]any
+				}{{"inputs", r.Inputs}, {"outputs", r.Outputs}} {
+					if len(v.values) == 0 {
+						continue
+					}
+
+					b, err := json.Marshal(v.values)
+					if err != nil {
+						return err
+					}
+
+					if _, err := fmt.Fprintf(w, "%s%s: %s\n", childIndent, v.label, truncate(string(b), opts.MaxValueLength)); err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := printRuns(r.Children, childIndent); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return printRuns(runs, "")
+}
+
+// truncate shortens the string to the max length.
+func truncate(s string, maxLength int) string {
+	if maxLength <= 0 || len(s) <= maxLength {
+		return s
+	}
+
+	return s[:maxLength] + "..."
+}
+
+// traceValues converts the chain values into values, which can be exported as JSON.
+func traceValues(values schema.ChainValues) map[string]any {
+	if values == nil {
+		return nil
+	}
+
+	traced := make(map[string]any, len(values))
+	for k, v := range values {
+		traced[k] = traceValue(v)
+	}
+
+	return traced
+}
+
+// traceValue converts the value into a value, which can be exported as JSON. Chat messages
+// and agent steps are converted into maps, values without JSON representation into strings.
+func traceValue(value any) any {
+	switch v := value.(type) {
+	case schema.ChatMessage:
+		return map[string]any{"type": v.Type(), "content": v.Content()}
+	case schema.ChatMessages:
+		messages := make([]any, len(v))
+		for i, m := range v {
+			messages[i] = traceValue(m)
+		}
+
+		return messages
+	case []schema.AgentStep:
+		steps := make([]any, len(v))
+		for i, s := range v {
+			steps[i] = map[string]any{
+				"tool":        s.Action.Tool,
+				"toolInput":   toolInputString(s.Action.ToolInput),
+				"log":         s.Action.Log,
+				"observation": s.Observation,
+			}
+		}
+
+		return steps
+	}
+
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return value
+}
+
+// toolInputString returns the string representation of the tool input.
+func toolInputString(input *schema.ToolInput) string {
+	if input == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(input.String())
+}
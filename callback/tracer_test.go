@@ -0,0 +1,189 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files")
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	newTrace := func(t *testing.T) *Tracer {
+		ctx := context.Background()
+		tracer := NewTracer()
+
+		require.NoError(t, tracer.OnChainStart(ctx, &schema.ChainStartInput{
+			ChainStartManagerInput: &schema.ChainStartManagerInput{
+				ChainType: "ConversationalRetrieval",
+				Inputs:    schema.ChainValues{"query": "What is golc?"},
+			},
+			RunID: "a7f3",
+			Tags:  []string{"rag"},
+		}))
+		require.NoError(t, tracer.OnRetrieverStart(ctx, &schema.RetrieverStartInput{
+			RetrieverStartManagerInput: &schema.RetrieverStartManagerInput{RetrieverType: "retriever.VectorStore", Query: "What is golc?"},
+			RunID:                      "c91e",
+			ParentRunID:                "a7f3",
+			Tags:                       []string{"rag"},
+		}))
+		require.NoError(t, tracer.OnRetrieverEnd(ctx, &schema.RetrieverEndInput{
+			RetrieverEndManagerInput: &schema.RetrieverEndManagerInput{
+				Docs: []schema.Document{{PageContent: "golc is a framework for llm apps."}},
+			},
+			RunID: "c91e",
+		}))
+		require.NoError(t, tracer.OnChatModelStart(ctx, &schema.ChatModelStartInput{
+			ChatModelStartManagerInput: &schema.ChatModelStartManagerInput{
+				ChatModelType: "OpenAI",
+				Messages:      schema.ChatMessages{schema.NewHumanChatMessage("What is golc?")},
+			},
+			RunID:       "5b20",
+			ParentRunID: "a7f3",
+			Tags:        []string{"rag"},
+		}))
+		require.NoError(t, tracer.OnModelEnd(ctx, &schema.ModelEndInput{
+			ModelEndManagerInput: &schema.ModelEndManagerInput{
+				Result: &schema.ModelResult{
+					Generations: []schema.Generation{{Text: "A framework.", Message: schema.NewAIChatMessage("A framework.")}},
+					LLMOutput: map[string]any{
+						"TokenUsage": map[string]int{"PromptTokens": 10, "CompletionTokens": 5, "TotalTokens": 15},
+					},
+				},
+			},
+			RunID: "5b20",
+		}))
+		require.NoError(t, tracer.OnToolStart(ctx, &schema.ToolStartInput{
+			ToolStartManagerInput: &schema.ToolStartManagerInput{
+				ToolName: "Search",
+				Input:    schema.NewToolInputFromString("golc"),
+			},
+			RunID:       "e402",
+			ParentRunID: "a7f3",
+		}))
+		require.NoError(t, tracer.OnToolError(ctx, &schema.ToolErrorInput{
+			ToolErrorManagerInput: &schema.ToolErrorManagerInput{Error: errors.New("rate limit exceeded")},
+			RunID:                 "e402",
+		}))
+		require.NoError(t, tracer.OnChainEnd(ctx, &schema.ChainEndInput{
+			ChainEndManagerInput: &schema.ChainEndManagerInput{
+				Outputs: schema.ChainValues{"answer": "A framework."},
+			},
+			RunID: "a7f3",
+		}))
+
+		return tracer
+	}
+
+	t.Run("Tree", func(t *testing.T) {
+		t.Parallel()
+
+		runs := newTrace(t).Runs()
+		require.Len(t, runs, 1)
+
+		root := runs[0]
+		assert.Equal(t, RunTypeChain, root.Type)
+		assert.Equal(t, "ConversationalRetrieval", root.Name)
+		assert.NotNil(t, root.EndTime)
+		require.Len(t, root.Children, 3)
+
+		assert.Equal(t, RunTypeRetriever, root.Children[0].Type)
+		assert.Equal(t, RunTypeChatModel, root.Children[1].Type)
+		assert.Equal(t, 15, root.Children[1].TokenUsage["TotalTokens"])
+		assert.Equal(t, RunTypeTool, root.Children[2].Type)
+		assert.Equal(t, "rate limit exceeded", root.Children[2].Error)
+	})
+
+	t.Run("Golden", func(t *testing.T) {
+		t.Parallel()
+
+		runs, err := NormalizeRuns(newTrace(t).Runs())
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, WriteRuns(&buf, runs))
+
+		golden := "testdata/tracer.golden.json"
+
+		if *updateGolden {
+			require.NoError(t, os.WriteFile(golden, buf.Bytes(), 0600))
+		}
+
+		expected, err := os.ReadFile(golden)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(expected), buf.String())
+
+		replayed, err := ReadRuns(bytes.NewReader(expected))
+		require.NoError(t, err)
+		assert.Equal(t, runs, replayed)
+	})
+
+	t.Run("PrintRuns", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		require.NoError(t, PrintRuns(&buf, newTrace(t).Runs(), func(o *PrintRunsOptions) {
+			o.ShowIO = false
+		}))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 4)
+		assert.True(t, strings.HasPrefix(lines[0], "└─ chain ConversationalRetrieval"))
+		assert.True(t, strings.HasPrefix(lines[1], "   ├─ retriever retriever.VectorStore"))
+		assert.Contains(t, lines[2], "tokens=15")
+		assert.Contains(t, lines[3], `error="rate limit exceeded"`)
+	})
+
+	t.Run("RunsSnapshot", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		tracer := NewTracer()
+
+		require.NoError(t, tracer.OnChainStart(ctx, &schema.ChainStartInput{
+			ChainStartManagerInput: &schema.ChainStartManagerInput{
+				ChainType: "LLMChain",
+				Inputs:    schema.ChainValues{"input": "foo"},
+			},
+			RunID: "b2d1",
+		}))
+
+		runs := tracer.Runs()
+		require.Len(t, runs, 1)
+
+		// Changes to the snapshot don't affect the tracer and vice versa.
+		runs[0].Inputs["input"] = "changed"
+
+		require.NoError(t, tracer.OnChainEnd(ctx, &schema.ChainEndInput{
+			ChainEndManagerInput: &schema.ChainEndManagerInput{Outputs: schema.ChainValues{"output": "bar"}},
+			RunID:                "b2d1",
+		}))
+
+		assert.Nil(t, runs[0].EndTime)
+		assert.Nil(t, runs[0].Outputs)
+
+		runs = tracer.Runs()
+		assert.Equal(t, map[string]any{"input": "foo"}, runs[0].Inputs)
+		assert.Equal(t, map[string]any{"output": "bar"}, runs[0].Outputs)
+		assert.NotNil(t, runs[0].EndTime)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		t.Parallel()
+
+		tracer := newTrace(t)
+		tracer.Reset()
+		assert.Empty(t, tracer.Runs())
+	})
+}
@@ -75,9 +75,7 @@ func (c *ChatModel) Call(ctx context.Context, inputs schema.ChainValues, optFns
 		return nil, err
 	}
 
-	result, err := model.GeneratePrompt(ctx, c.chatModel, pv, func(o *model.Options) {
-		o.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		o.ParentRunID = opts.CallbackManger.RunID()
+	result, err := model.GeneratePrompt(ctx, c.chatModel, pv, model.ChildOptions(opts.CallbackManger), func(o *model.Options) {
 		o.Stop = opts.Stop
 		o.Functions = c.functions
 		o.ForceFunctionCall = c.opts.ForceFunctionCall
@@ -3,8 +3,10 @@ package chain
 import "errors"
 
 var (
-	ErrNoInputValues        = errors.New("no input values")
-	ErrInvalidInputValues   = errors.New("invalid input values")
-	ErrInputValuesWrongType = errors.New("input key is of wrong type")
-	ErrNoOutputParser       = errors.New("no output parser")
+	ErrNoInputValues           = errors.New("no input values")
+	ErrInvalidInputValues      = errors.New("invalid input values")
+	ErrInputValuesWrongType    = errors.New("input key is of wrong type")
+	ErrNoOutputParser          = errors.New("no output parser")
+	ErrGraphMaxStepsExceeded   = errors.New("graph exceeded max steps")
+	ErrGraphConflictingUpdates = errors.New("graph nodes updated the same key differently")
 )
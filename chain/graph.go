@@ -0,0 +1,601 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/schema"
+)
+
+// GraphEnd is the name of the virtual node, which terminates a branch of the graph.
+const GraphEnd = "__end__"
+
+// GraphNodeFunc is a node function, which receives the current state and returns the updated state.
+type GraphNodeFunc[S any] func(ctx context.Context, state S) (S, error)
+
+// GraphRouterFunc selects the next node based on the state. Returning GraphEnd terminates the branch.
+type GraphRouterFunc[S any] func(ctx context.Context, state S) (string, error)
+
+// GraphChainNodeOptions contains options for a node, which runs a chain.
+type GraphChainNodeOptions[S any] struct {
+	// Inputs maps the state to the inputs of the chain.
+	// It's required unless the state is of type schema.ChainValues.
+	Inputs func(state S) (schema.ChainValues, error)
+
+	// Update applies the outputs of the chain to the state.
+	// It's required unless the state is of type schema.ChainValues.
+	Update func(state S, outputs schema.ChainValues) (S, error)
+}
+
+// Compile time check to ensure Graph satisfies the Chain interface.
+var _ schema.Chain = (*Graph[schema.ChainValues])(nil)
+
+// GraphOptions contains options for configuring the Graph chain.
+type GraphOptions[S any] struct {
+	*schema.CallbackOptions
+	Memory schema.Memory
+
+	// MaxSteps is the maximum number of steps, which protects against endless loops. Default is 25.
+	MaxSteps int
+
+	// MaxConcurrency is the maximum number of nodes executed concurrently within a step. Zero means no limit.
+	MaxConcurrency int
+
+	// InputState converts the inputs of the chain to the initial state.
+	// It's required unless the state is of type schema.ChainValues.
+	InputState func(inputs schema.ChainValues) (S, error)
+
+	// OutputValues converts the final state to the outputs of the chain.
+	// It's required unless the state is of type schema.ChainValues.
+	OutputValues func(state S) (schema.ChainValues, error)
+
+	// Merge combines the states of the nodes executed concurrently within a step.
+	// It's required for parallel branches unless the state is of type schema.ChainValues,
+	// in which case the keys changed or deleted by the nodes are applied to the state.
+	// If nodes write different values to the same key, or one node deletes a key another
+	// node changes, the merge fails with ErrGraphConflictingUpdates.
+	Merge func(state S, updates []S) (S, error)
+}
+
+// Graph is a chain, which runs its nodes as a state machine. Nodes are chains or functions over a
+// typed state and are connected by static or conditional edges. All nodes scheduled for the same
+// step run concurrently and their states are merged before the edges are evaluated.
+type Graph[S any] struct {
+	nodes            map[string]*graphNode[S]
+	edges            map[string][]string
+	conditionalEdges map[string]GraphRouterFunc[S]
+	joins            []*graphJoin
+	entryPoints      []string
+	inputKeys        []string
+	outputKeys       []string
+	opts             GraphOptions[S]
+}
+
+// NewGraph creates a new instance of the Graph chain.
+func NewGraph[S any](inputKeys, outputKeys []string, optFns ...func(o *GraphOptions[S])) (*Graph[S], error) {
+	opts := GraphOptions[S]{
+		CallbackOptions: &schema.CallbackOptions{
+			Verbose: golc.Verbose,
+		},
+		MaxSteps: 25,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	var zero S
+	if _, ok := any(zero).(schema.ChainValues); !ok {
+		if opts.InputState == nil || opts.OutputValues == nil {
+			return nil, fmt.Errorf("input state and output values functions are required for state of type %T", zero)
+		}
+	}
+
+	return &Graph[S]{
+		nodes:            make(map[string]*graphNode[S]),
+		edges:            make(map[string][]string),
+		conditionalEdges: make(map[string]GraphRouterFunc[S]),
+		inputKeys:        inputKeys,
+		outputKeys:       outputKeys,
+		opts:             opts,
+	}, nil
+}
+
+// AddNode adds a node, which runs the given function.
+func (c *Graph[S]) AddNode(name string, fn GraphNodeFunc[S]) error {
+	if err := c.validateNodeName(name); err != nil {
+		return err
+	}
+
+	c.addNode(name, func(ctx context.Context, state S, _ schema.CallOptions) (S, error) {
+		return fn(ctx, state)
+	})
+
+	return nil
+}
+
+// AddChainNode adds a node, which runs the given chain.
+func (c *Graph[S]) AddChainNode(name string, chain schema.Chain, optFns ...func(o *GraphChainNodeOptions[S])) error {
+	opts := GraphChainNodeOptions[S]{}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	var zero S
+	if _, ok := any(zero).(schema.ChainValues); !ok {
+		if opts.Inputs == nil || opts.Update == nil {
+			return fmt.Errorf("inputs and update functions are required for state of type %T", zero)
+		}
+	}
+
+	if err := c.validateNodeName(name); err != nil {
+		return err
+	}
+
+	c.addNode(name, func(ctx context.Context, state S, callOpts schema.CallOptions) (S, error) {
+		var inputs schema.ChainValues
+
+		if opts.Inputs != nil {
+			var err error
+
+			inputs, err = opts.Inputs(state)
+			if err != nil {
+				return state, err
+			}
+		} else {
+			inputs = util.CopyMap(any(state).(schema.ChainValues))
+		}
+
+		outputs, err := golc.Call(ctx, chain, inputs, golc.ChildCallOptions(callOpts.CallbackManger))
+		if err != nil {
+			return state, err
+		}
+
+		if opts.Update != nil {
+			return opts.Update(state, outputs)
+		}
+
+		values := schema.ChainValues(util.CopyMap(any(state).(schema.ChainValues)))
+		for k, v := range outputs {
+			values[k] = v
+		}
+
+		return any(values).(S), nil
+	})
+
+	return nil
+}
+
+func (c *Graph[S]) addNode(name string, run graphNodeRunFunc[S]) {
+	c.nodes[name] = &graphNode[S]{
+		name:    name,
+		run:     run,
+		verbose: c.opts.CallbackOptions.Verbose,
+	}
+}
+
+// AddEdge adds an edge from one node to another. A node with several outgoing edges starts parallel branches.
+func (c *Graph[S]) AddEdge(from, to string) error {
+	if err := c.validateEdge(from, to); err != nil {
+		return err
+	}
+
+	c.edges[from] = append(c.edges[from], to)
+
+	return nil
+}
+
+// AddConditionalEdge adds an edge, whose target is selected by the router after the source node ran.
+func (c *Graph[S]) AddConditionalEdge(from string, router GraphRouterFunc[S]) error {
+	if _, ok := c.nodes[from]; !ok {
+		return fmt.Errorf("unknown node: %s", from)
+	}
+
+	if _, ok := c.conditionalEdges[from]; ok {
+		return fmt.Errorf("node %s already has a conditional edge", from)
+	}
+
+	c.conditionalEdges[from] = router
+
+	return nil
+}
+
+// AddJoinEdge adds an edge, which schedules the target node once all source nodes ran.
+// It allows to join parallel branches of different length.
+func (c *Graph[S]) AddJoinEdge(from []string, to string) error {
+	if len(from) == 0 {
+		return fmt.Errorf("join edge to %s without source nodes", to)
+	}
+
+	for _, f := range from {
+		if err := c.validateEdge(f, to); err != nil {
+			return err
+		}
+	}
+
+	c.joins = append(c.joins, &graphJoin{
+		sources: from,
+		target:  to,
+	})
+
+	return nil
+}
+
+// SetEntryPoint sets the nodes, which run in the first step of the graph.
+func (c *Graph[S]) SetEntryPoint(names ...string) error {
+	for _, name := range names {
+		if _, ok := c.nodes[name]; !ok {
+			return fmt.Errorf("unknown node: %s", name)
+		}
+	}
+
+	c.entryPoints = names
+
+	return nil
+}
+
+// Call executes the graph chain with the given context and inputs.
+// It returns the outputs of the chain or an error, if any.
+func (c *Graph[S]) Call(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+	opts := schema.CallOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if len(c.entryPoints) == 0 {
+		return nil, fmt.Errorf("graph without entry point")
+	}
+
+	state, err := c.inputState(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	joins := make([]*graphJoin, len(c.joins))
+	for i, j := range c.joins {
+		joins[i] = &graphJoin{sources: j.sources, target: j.target, done: make(map[string]bool, len(j.sources))}
+	}
+
+	active := c.entryPoints
+
+	for step := 0; len(active) > 0; step++ {
+		if step >= c.opts.MaxSteps {
+			return nil, fmt.Errorf("%w: %d", ErrGraphMaxStepsExceeded, c.opts.MaxSteps)
+		}
+
+		updates, err := c.runStep(ctx, active, state, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		state, err = c.merge(state, updates)
+		if err != nil {
+			return nil, err
+		}
+
+		active, err = c.next(ctx, active, state, joins)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.outputValues(state)
+}
+
+// runStep runs the given nodes concurrently and returns their states in the same order.
+func (c *Graph[S]) runStep(ctx context.Context, names []string, state S, opts schema.CallOptions) ([]S, error) {
+	updates := make([]S, len(names))
+
+	errs, errctx := errgroup.WithContext(ctx)
+	if c.opts.MaxConcurrency > 0 {
+		errs.SetLimit(c.opts.MaxConcurrency)
+	}
+
+	for i, name := range names {
+		i, node := i, c.nodes[name]
+
+		errs.Go(func() error {
+			outputs, err := golc.Call(errctx, node, schema.ChainValues{
+				graphNodeKey:  node.name,
+				graphStateKey: copyGraphState(state),
+			}, golc.ChildCallOptions(opts.CallbackManger))
+			if err != nil {
+				return fmt.Errorf("node %s: %w", node.name, err)
+			}
+
+			updates[i] = outputs[graphStateKey].(S)
+
+			return nil
+		})
+	}
+
+	if err := errs.Wait(); err != nil {
+		return nil, err
+	}
+
+	return updates, nil
+}
+
+// next evaluates the edges of the nodes, which ran in the last step, and returns the nodes of the next step.
+func (c *Graph[S]) next(ctx context.Context, ran []string, state S, joins []*graphJoin) ([]string, error) {
+	scheduled := make(map[string]struct{})
+
+	for _, name := range ran {
+		for _, to := range c.edges[name] {
+			scheduled[to] = struct{}{}
+		}
+
+		if router, ok := c.conditionalEdges[name]; ok {
+			to, err := router(ctx, copyGraphState(state))
+			if err != nil {
+				return nil, err
+			}
+
+			if to != GraphEnd {
+				if _, ok := c.nodes[to]; !ok {
+					return nil, fmt.Errorf("router of node %s returned unknown node: %s", name, to)
+				}
+			}
+
+			scheduled[to] = struct{}{}
+		}
+
+		for _, j := range joins {
+			if util.Contains(j.sources, name) {
+				j.done[name] = true
+			}
+		}
+	}
+
+	for _, j := range joins {
+		if len(j.done) == len(j.sources) {
+			scheduled[j.target] = struct{}{}
+			j.done = make(map[string]bool, len(j.sources))
+		}
+	}
+
+	delete(scheduled, GraphEnd)
+
+	next := util.Keys(scheduled)
+	sort.Strings(next)
+
+	return next, nil
+}
+
+func (c *Graph[S]) merge(state S, updates []S) (S, error) {
+	if len(updates) == 1 {
+		return updates[0], nil
+	}
+
+	if c.opts.Merge != nil {
+		return c.opts.Merge(state, updates)
+	}
+
+	values, ok := any(state).(schema.ChainValues)
+	if !ok {
+		return state, fmt.Errorf("merge function is required for parallel branches with state of type %T", state)
+	}
+
+	type write struct {
+		value   any
+		deleted bool
+	}
+
+	writes := make(map[string]write)
+
+	record := func(key string, w write) error {
+		if prev, ok := writes[key]; ok && (prev.deleted != w.deleted || !reflect.DeepEqual(prev.value, w.value)) {
+			return fmt.Errorf("%w: key %s", ErrGraphConflictingUpdates, key)
+		}
+
+		writes[key] = w
+
+		return nil
+	}
+
+	for _, u := range updates {
+		update := any(u).(schema.ChainValues)
+
+		for k, v := range update {
+			if old, ok := values[k]; ok && reflect.DeepEqual(old, v) {
+				continue
+			}
+
+			if err := record(k, write{value: v}); err != nil {
+				return state, err
+			}
+		}
+
+		for k := range values {
+			if _, ok := update[k]; !ok {
+				if err := record(k, write{deleted: true}); err != nil {
+					return state, err
+				}
+			}
+		}
+	}
+
+	merged := schema.ChainValues(util.CopyMap(values))
+
+	for k, w := range writes {
+		if w.deleted {
+			delete(merged, k)
+		} else {
+			merged[k] = w.value
+		}
+	}
+
+	return any(merged).(S), nil
+}
+
+func (c *Graph[S]) inputState(inputs schema.ChainValues) (S, error) {
+	if c.opts.InputState != nil {
+		return c.opts.InputState(inputs)
+	}
+
+	return any(schema.ChainValues(util.CopyMap(inputs))).(S), nil
+}
+
+func (c *Graph[S]) outputValues(state S) (schema.ChainValues, error) {
+	if c.opts.OutputValues != nil {
+		return c.opts.OutputValues(state)
+	}
+
+	values := any(state).(schema.ChainValues)
+
+	result := make(schema.ChainValues, len(c.outputKeys))
+	for _, k := range c.outputKeys {
+		result[k] = values[k]
+	}
+
+	return result, nil
+}
+
+func (c *Graph[S]) validateNodeName(name string) error {
+	if name == "" || name == GraphEnd {
+		return fmt.Errorf("invalid node name: %q", name)
+	}
+
+	if _, ok := c.nodes[name]; ok {
+		return fmt.Errorf("node %s already exists", name)
+	}
+
+	return nil
+}
+
+func (c *Graph[S]) validateEdge(from, to string) error {
+	if _, ok := c.nodes[from]; !ok {
+		return fmt.Errorf("unknown node: %s", from)
+	}
+
+	if _, ok := c.nodes[to]; !ok && to != GraphEnd {
+		return fmt.Errorf("unknown node: %s", to)
+	}
+
+	return nil
+}
+
+// Memory returns the memory associated with the chain.
+func (c *Graph[S]) Memory() schema.Memory {
+	return c.opts.Memory
+}
+
+// Type returns the type of the chain.
+func (c *Graph[S]) Type() string {
+	return "Graph"
+}
+
+// Verbose returns the verbosity setting of the chain.
+func (c *Graph[S]) Verbose() bool {
+	return c.opts.CallbackOptions.Verbose
+}
+
+// Callbacks returns the callbacks associated with the chain.
+func (c *Graph[S]) Callbacks() []schema.Callback {
+	return c.opts.CallbackOptions.Callbacks
+}
+
+// InputKeys returns the expected input keys.
+func (c *Graph[S]) InputKeys() []string {
+	return c.inputKeys
+}
+
+// OutputKeys returns the output keys the chain will return.
+func (c *Graph[S]) OutputKeys() []string {
+	return c.outputKeys
+}
+
+const (
+	graphNodeKey  = "node"
+	graphStateKey = "state"
+)
+
+// graphJoin tracks the source nodes of a join edge, which already ran.
+type graphJoin struct {
+	sources []string
+	target  string
+	done    map[string]bool
+}
+
+// copyGraphState returns a shallow copy of the state, if it's of type schema.ChainValues,
+// so that nodes running concurrently don't share the same map.
+func copyGraphState[S any](state S) S {
+	if values, ok := any(state).(schema.ChainValues); ok {
+		return any(schema.ChainValues(util.CopyMap(values))).(S)
+	}
+
+	return state
+}
+
+// Compile time check to ensure graphNode satisfies the Chain interface.
+var _ schema.Chain = (*graphNode[schema.ChainValues])(nil)
+
+// graphNodeRunFunc runs a node with the call options of its chain run.
+type graphNodeRunFunc[S any] func(ctx context.Context, state S, opts schema.CallOptions) (S, error)
+
+// graphNode wraps a node in a chain, so that every node run is reported to the callbacks.
+type graphNode[S any] struct {
+	name    string
+	run     graphNodeRunFunc[S]
+	verbose bool
+}
+
+func (n *graphNode[S]) Call(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+	opts := schema.CallOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	state, ok := inputs[graphStateKey].(S)
+	if !ok {
+		return nil, ErrInputValuesWrongType
+	}
+
+	state, err := n.run(ctx, state, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.ChainValues{
+		graphNodeKey:  n.name,
+		graphStateKey: state,
+	}, nil
+}
+
+func (n *graphNode[S]) Memory() schema.Memory {
+	return nil
+}
+
+func (n *graphNode[S]) Type() string {
+	return "GraphNode"
+}
+
+func (n *graphNode[S]) Verbose() bool {
+	return n.verbose
+}
+
+func (n *graphNode[S]) Callbacks() []schema.Callback {
+	return nil
+}
+
+func (n *graphNode[S]) InputKeys() []string {
+	return []string{graphNodeKey, graphStateKey}
+}
+
+func (n *graphNode[S]) OutputKeys() []string {
+	return []string{graphNodeKey, graphStateKey}
+}
@@ -0,0 +1,299 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestGraph(t *testing.T) {
+	t.Run("ConditionalEdge", func(t *testing.T) {
+		billing := &MockChain{
+			CallFunc: func(ctx context.Context, inputs schema.ChainValues) (schema.ChainValues, error) {
+				return schema.ChainValues{"answer": "billing: " + inputs["question"].(string)}, nil
+			},
+			InputKeysFunc:  func() []string { return []string{"question"} },
+			OutputKeysFunc: func() []string { return []string{"answer"} },
+		}
+
+		graph, err := NewGraph[schema.ChainValues]([]string{"question"}, []string{"answer"})
+		require.NoError(t, err)
+
+		require.NoError(t, graph.AddNode("classify", func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+			if state["question"] == "Where is my invoice?" {
+				state["topic"] = "billing"
+			} else {
+				state["topic"] = "support"
+			}
+
+			return state, nil
+		}))
+		require.NoError(t, graph.AddChainNode("billing", billing))
+		require.NoError(t, graph.AddNode("support", func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+			state["answer"] = "support: " + state["question"].(string)
+			return state, nil
+		}))
+		require.NoError(t, graph.AddConditionalEdge("classify", func(ctx context.Context, state schema.ChainValues) (string, error) {
+			return state["topic"].(string), nil
+		}))
+		require.NoError(t, graph.SetEntryPoint("classify"))
+
+		outputs, err := golc.Call(context.Background(), graph, schema.ChainValues{"question": "Where is my invoice?"})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"answer": "billing: Where is my invoice?"}, outputs)
+
+		outputs, err = golc.Call(context.Background(), graph, schema.ChainValues{"question": "The app crashes"})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"answer": "support: The app crashes"}, outputs)
+	})
+
+	t.Run("ParallelBranchesAndJoin", func(t *testing.T) {
+		graph, err := NewGraph[schema.ChainValues]([]string{"input"}, []string{"result"})
+		require.NoError(t, err)
+
+		setter := func(key, value string) GraphNodeFunc[schema.ChainValues] {
+			return func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+				state[key] = value
+				return state, nil
+			}
+		}
+
+		calls := 0
+
+		require.NoError(t, graph.AddNode("start", setter("start", "done")))
+		require.NoError(t, graph.AddNode("short", setter("short", "a")))
+		require.NoError(t, graph.AddNode("long1", setter("long", "b")))
+		require.NoError(t, graph.AddNode("long2", setter("long", "c")))
+		require.NoError(t, graph.AddNode("join", func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+			calls++
+			state["result"] = state["input"].(string) + state["short"].(string) + state["long"].(string)
+
+			return state, nil
+		}))
+
+		require.NoError(t, graph.AddEdge("start", "short"))
+		require.NoError(t, graph.AddEdge("start", "long1"))
+		require.NoError(t, graph.AddEdge("long1", "long2"))
+		require.NoError(t, graph.AddJoinEdge([]string{"short", "long2"}, "join"))
+		require.NoError(t, graph.AddEdge("join", GraphEnd))
+		require.NoError(t, graph.SetEntryPoint("start"))
+
+		outputs, err := golc.Call(context.Background(), graph, schema.ChainValues{"input": "x"})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"result": "xac"}, outputs)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("ParallelBranchesConflict", func(t *testing.T) {
+		graph, err := NewGraph[schema.ChainValues]([]string{"input"}, []string{"result"})
+		require.NoError(t, err)
+
+		setter := func(key, value string) GraphNodeFunc[schema.ChainValues] {
+			return func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+				state[key] = value
+				return state, nil
+			}
+		}
+
+		require.NoError(t, graph.AddNode("start", setter("result", "start")))
+		require.NoError(t, graph.AddNode("a", setter("result", "a")))
+		require.NoError(t, graph.AddNode("b", setter("result", "b")))
+		require.NoError(t, graph.AddEdge("start", "a"))
+		require.NoError(t, graph.AddEdge("start", "b"))
+		require.NoError(t, graph.SetEntryPoint("start"))
+
+		_, err = golc.Call(context.Background(), graph, schema.ChainValues{"input": "x"})
+		assert.ErrorIs(t, err, ErrGraphConflictingUpdates)
+	})
+
+	t.Run("ParallelBranchesDelete", func(t *testing.T) {
+		graph, err := NewGraph[schema.ChainValues]([]string{"input"}, []string{"result"})
+		require.NoError(t, err)
+
+		require.NoError(t, graph.AddNode("start", func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+			state["scratch"] = "tmp"
+			return state, nil
+		}))
+		require.NoError(t, graph.AddNode("cleanup", func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+			delete(state, "scratch")
+			return state, nil
+		}))
+		require.NoError(t, graph.AddNode("work", func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+			state["result"] = state["input"].(string) + state["scratch"].(string)
+			return state, nil
+		}))
+		require.NoError(t, graph.AddNode("check", func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+			_, ok := state["scratch"]
+			state["result"] = fmt.Sprintf("%s, scratch kept: %t", state["result"], ok)
+
+			return state, nil
+		}))
+		require.NoError(t, graph.AddEdge("start", "cleanup"))
+		require.NoError(t, graph.AddEdge("start", "work"))
+		require.NoError(t, graph.AddJoinEdge([]string{"cleanup", "work"}, "check"))
+		require.NoError(t, graph.SetEntryPoint("start"))
+
+		outputs, err := golc.Call(context.Background(), graph, schema.ChainValues{"input": "x"})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"result": "xtmp, scratch kept: false"}, outputs)
+	})
+
+	t.Run("Loop", func(t *testing.T) {
+		graph, err := NewGraph[schema.ChainValues]([]string{"count"}, []string{"count"})
+		require.NoError(t, err)
+
+		require.NoError(t, graph.AddNode("increment", func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+			state["count"] = state["count"].(int) + 1
+			return state, nil
+		}))
+		require.NoError(t, graph.AddConditionalEdge("increment", func(ctx context.Context, state schema.ChainValues) (string, error) {
+			if state["count"].(int) < 5 {
+				return "increment", nil
+			}
+
+			return GraphEnd, nil
+		}))
+		require.NoError(t, graph.SetEntryPoint("increment"))
+
+		outputs, err := golc.Call(context.Background(), graph, schema.ChainValues{"count": 0})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"count": 5}, outputs)
+
+		graph.opts.MaxSteps = 3
+
+		_, err = golc.Call(context.Background(), graph, schema.ChainValues{"count": 0})
+		assert.ErrorIs(t, err, ErrGraphMaxStepsExceeded)
+	})
+
+	t.Run("TypedState", func(t *testing.T) {
+		type state struct {
+			Question string
+			Answers  []string
+		}
+
+		graph, err := NewGraph[state]([]string{"question"}, []string{"answers"}, func(o *GraphOptions[state]) {
+			o.InputState = func(inputs schema.ChainValues) (state, error) {
+				return state{Question: inputs["question"].(string)}, nil
+			}
+			o.OutputValues = func(s state) (schema.ChainValues, error) {
+				return schema.ChainValues{"answers": s.Answers}, nil
+			}
+			o.Merge = func(s state, updates []state) (state, error) {
+				for _, u := range updates {
+					s.Answers = append(s.Answers, u.Answers...)
+				}
+
+				return s, nil
+			}
+		})
+		require.NoError(t, err)
+
+		echo := &MockChain{
+			CallFunc: func(ctx context.Context, inputs schema.ChainValues) (schema.ChainValues, error) {
+				return schema.ChainValues{"text": "echo: " + inputs["text"].(string)}, nil
+			},
+		}
+
+		require.NoError(t, graph.AddNode("upper", func(ctx context.Context, s state) (state, error) {
+			s.Answers = append(s.Answers, "upper: "+s.Question)
+			return s, nil
+		}))
+		require.NoError(t, graph.AddChainNode("echo", echo, func(o *GraphChainNodeOptions[state]) {
+			o.Inputs = func(s state) (schema.ChainValues, error) {
+				return schema.ChainValues{"text": s.Question}, nil
+			}
+			o.Update = func(s state, outputs schema.ChainValues) (state, error) {
+				s.Answers = append(s.Answers, outputs["text"].(string))
+				return s, nil
+			}
+		}))
+		require.NoError(t, graph.SetEntryPoint("echo", "upper"))
+
+		outputs, err := golc.Call(context.Background(), graph, schema.ChainValues{"question": "hi"})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"answers": []string{"echo: hi", "upper: hi"}}, outputs)
+	})
+
+	t.Run("Callbacks", func(t *testing.T) {
+		graph, err := NewGraph[schema.ChainValues]([]string{"input"}, []string{"input"})
+		require.NoError(t, err)
+
+		noop := func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+			return state, nil
+		}
+
+		require.NoError(t, graph.AddNode("a", noop))
+		require.NoError(t, graph.AddNode("b", noop))
+		require.NoError(t, graph.AddEdge("a", "b"))
+		require.NoError(t, graph.SetEntryPoint("a"))
+
+		handler := &graphCallbackHandler{}
+
+		_, err = golc.Call(context.Background(), graph, schema.ChainValues{"input": "x"}, func(o *golc.CallOptions) {
+			o.Callbacks = []schema.Callback{handler}
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Graph", "GraphNode:a", "GraphNode:b"}, handler.runs)
+	})
+
+	t.Run("NodeError", func(t *testing.T) {
+		graph, err := NewGraph[schema.ChainValues](nil, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, graph.AddNode("fail", func(ctx context.Context, state schema.ChainValues) (schema.ChainValues, error) {
+			return nil, errors.New("boom")
+		}))
+		require.NoError(t, graph.SetEntryPoint("fail"))
+
+		_, err = graph.Call(context.Background(), schema.ChainValues{})
+		assert.EqualError(t, err, "node fail: boom")
+	})
+
+	t.Run("InvalidGraph", func(t *testing.T) {
+		_, err := NewGraph[struct{}](nil, nil)
+		assert.Error(t, err)
+
+		graph, err := NewGraph[schema.ChainValues](nil, nil)
+		require.NoError(t, err)
+
+		_, err = graph.Call(context.Background(), schema.ChainValues{})
+		assert.Error(t, err)
+
+		assert.Error(t, graph.AddEdge("a", "b"))
+		assert.Error(t, graph.AddNode(GraphEnd, nil))
+		assert.Error(t, graph.SetEntryPoint("a"))
+	})
+}
+
+type graphCallbackHandler struct {
+	callback.NoopHandler
+	runs []string
+	mu   sync.Mutex
+}
+
+func (h *graphCallbackHandler) AlwaysVerbose() bool {
+	return true
+}
+
+func (h *graphCallbackHandler) OnChainStart(ctx context.Context, input *schema.ChainStartInput) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	name := input.ChainType
+	if node, ok := input.Inputs["node"]; ok {
+		name += ":" + node.(string)
+	}
+
+	h.runs = append(h.runs, name)
+
+	return nil
+}
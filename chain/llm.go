@@ -88,10 +88,8 @@ func (c *LLM) Call(ctx context.Context, inputs schema.ChainValues, optFns ...fun
 		return nil, cbErr
 	}
 
-	res, err := model.GeneratePrompt(ctx, c.model, promptValue, func(o *model.Options) {
+	res, err := model.GeneratePrompt(ctx, c.model, promptValue, model.ChildOptions(opts.CallbackManger), func(o *model.Options) {
 		o.Stop = opts.Stop
-		o.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		o.ParentRunID = opts.CallbackManger.RunID()
 	})
 	if err != nil {
 		return nil, err
@@ -5,8 +5,10 @@ import (
 	"testing"
 
 	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/model/llm"
 	"github.com/hupe1980/golc/prompt"
+	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/require"
 )
 
@@ -21,4 +23,90 @@ func TestLLM(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, output, "This is a valid question.")
 	})
+
+	t.Run("Stream", func(t *testing.T) {
+		fake := llm.NewSimpleFake("This is a valid question.")
+
+		llmChain, err := NewLLM(fake, prompt.NewTemplate("{{.input}}"))
+		require.NoError(t, err)
+
+		chunks := []schema.StreamChunk{}
+		for chunk := range golc.Stream(context.Background(), llmChain, schema.ChainValues{"input": "Please provide a valid question."}) {
+			chunks = append(chunks, chunk)
+		}
+
+		require.Len(t, chunks, 2)
+		require.Equal(t, schema.StreamChunk{Type: schema.StreamChunkTypeToken, Token: "This is a valid question."}, chunks[0])
+		require.Equal(t, schema.StreamChunkTypeResult, chunks[1].Type)
+		require.Equal(t, "This is a valid question.", chunks[1].Outputs["text"])
+	})
+}
+
+type runRecorder struct {
+	callback.NoopHandler
+	chainStarts []*schema.ChainStartInput
+	chainEnds   []*schema.ChainEndInput
+	llmStarts   []*schema.LLMStartInput
+	modelEnds   []*schema.ModelEndInput
+}
+
+func (r *runRecorder) AlwaysVerbose() bool { return true }
+
+func (r *runRecorder) OnChainStart(ctx context.Context, input *schema.ChainStartInput) error {
+	r.chainStarts = append(r.chainStarts, input)
+	return nil
+}
+
+func (r *runRecorder) OnChainEnd(ctx context.Context, input *schema.ChainEndInput) error {
+	r.chainEnds = append(r.chainEnds, input)
+	return nil
+}
+
+func (r *runRecorder) OnLLMStart(ctx context.Context, input *schema.LLMStartInput) error {
+	r.llmStarts = append(r.llmStarts, input)
+	return nil
+}
+
+func (r *runRecorder) OnModelEnd(ctx context.Context, input *schema.ModelEndInput) error {
+	r.modelEnds = append(r.modelEnds, input)
+	return nil
+}
+
+func TestLLMCallbacks(t *testing.T) {
+	fake := llm.NewSimpleFake("answer")
+
+	llmChain, err := NewLLM(fake, prompt.NewTemplate("{{.input}}"))
+	require.NoError(t, err)
+
+	recorder := &runRecorder{}
+
+	_, err = golc.Call(context.Background(), llmChain, schema.ChainValues{"input": "question"}, func(o *golc.CallOptions) {
+		o.Callbacks = []schema.Callback{recorder}
+		o.ParentRunID = "root"
+		o.Tags = []string{"test"}
+		o.Metadata = map[string]any{"user": "golc"}
+	})
+	require.NoError(t, err)
+
+	require.Len(t, recorder.chainStarts, 1)
+	require.Len(t, recorder.chainEnds, 1)
+	require.Len(t, recorder.llmStarts, 1)
+	require.Len(t, recorder.modelEnds, 1)
+
+	chainRunID := recorder.chainStarts[0].RunID
+
+	require.Equal(t, "root", recorder.chainStarts[0].ParentRunID)
+	require.Equal(t, "root", recorder.chainEnds[0].ParentRunID)
+	require.Equal(t, chainRunID, recorder.chainEnds[0].RunID)
+
+	require.Equal(t, chainRunID, recorder.llmStarts[0].ParentRunID)
+	require.Equal(t, chainRunID, recorder.modelEnds[0].ParentRunID)
+	require.Equal(t, recorder.llmStarts[0].RunID, recorder.modelEnds[0].RunID)
+
+	for _, tags := range [][]string{recorder.chainStarts[0].Tags, recorder.chainEnds[0].Tags, recorder.llmStarts[0].Tags, recorder.modelEnds[0].Tags} {
+		require.Equal(t, []string{"test"}, tags)
+	}
+
+	require.Equal(t, map[string]any{"user": "golc"}, recorder.llmStarts[0].Metadata)
+	require.Equal(t, map[string]any{"user": "golc"}, recorder.modelEnds[0].Metadata)
 }
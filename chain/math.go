@@ -113,10 +113,7 @@ func (c *Math) Call(ctx context.Context, inputs schema.ChainValues, optFns ...fu
 		return nil, cbErr
 	}
 
-	t, err := golc.SimpleCall(ctx, c.llmChain, question, func(sco *golc.SimpleCallOptions) {
-		sco.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		sco.ParentRunID = opts.CallbackManger.RunID()
-	})
+	t, err := golc.SimpleCall(ctx, c.llmChain, question, golc.ChildSimpleCallOptions(opts.CallbackManger))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,449 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/metric"
+	"github.com/hupe1980/golc/model"
+	"github.com/hupe1980/golc/outputparser"
+	"github.com/hupe1980/golc/prompt"
+	"github.com/hupe1980/golc/schema"
+)
+
+const defaultRouterTemplate = `Given a raw text input to a language model select the model prompt best suited for the input. You will be given the names of the available prompts and a description of what the prompt is best suited for. You may also revise the original input if you think that revising it will ultimately lead to a better response from the language model.
+
+<< FORMATTING >>
+{{.formatInstructions}}
+
+REMEMBER: "destination" MUST be one of the candidate prompt names specified below OR it can be "DEFAULT" if the input is not well suited for any of the candidate prompts.
+REMEMBER: "next_inputs" can just be the original input if you don't think any modifications are needed.
+
+<< CANDIDATE PROMPTS >>
+{{.destinations}}
+
+<< INPUT >>
+{{.input}}
+
+<< OUTPUT (must include ` + "```json" + ` at the start of the response) >>
+<< OUTPUT (must end with ` + "```" + `) >>
+`
+
+// RouterDestination is a chain, the Router can dispatch the inputs to.
+type RouterDestination struct {
+	// Name is the unique name of the destination.
+	Name string
+	// Description describes which inputs the destination is best suited for.
+	Description string
+	// Chain is the chain called for the inputs routed to the destination.
+	Chain schema.Chain
+}
+
+// Route is the routing decision of a RouteSelector.
+type Route struct {
+	// Destination is the name of the selected destination. It's empty if no destination is suitable.
+	Destination string
+	// NextInputs overwrite the inputs passed to the destination chain. If nil, the inputs are passed unchanged.
+	NextInputs schema.ChainValues
+}
+
+// RouteSelector selects the destination for the inputs of a Router.
+type RouteSelector interface {
+	// Route selects one of the destinations for the given inputs.
+	Route(ctx context.Context, inputs schema.ChainValues, destinations []RouterDestination, optFns ...func(o *schema.CallOptions)) (*Route, error)
+	// InputKeys returns the expected input keys.
+	InputKeys() []string
+}
+
+// Compile time check to ensure Router satisfies the Chain interface.
+var _ schema.Chain = (*Router)(nil)
+
+// RouterOptions contains options for the Router chain.
+type RouterOptions struct {
+	*schema.CallbackOptions
+	Memory schema.Memory
+
+	// DefaultChain is called if no destination is suitable for the inputs or the selected destination
+	// is unknown. If nil, an error is returned instead.
+	DefaultChain schema.Chain
+
+	// OutputKeys are the output keys of the chain. Default are the output keys of all destinations.
+	OutputKeys []string
+}
+
+// Router is a chain, which dispatches the inputs to one of several specialized destination chains.
+// The destination is selected by a RouteSelector, e.g. a LLM classification or the embedding similarity
+// of the inputs and the destination descriptions.
+type Router struct {
+	selector     RouteSelector
+	destinations []RouterDestination
+	opts         RouterOptions
+}
+
+// NewRouter creates a new instance of the Router chain.
+func NewRouter(selector RouteSelector, destinations []RouterDestination, optFns ...func(o *RouterOptions)) (*Router, error) {
+	opts := RouterOptions{
+		CallbackOptions: &schema.CallbackOptions{
+			Verbose: golc.Verbose,
+		},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if len(destinations) == 0 {
+		return nil, errors.New("router without destinations")
+	}
+
+	names := make(map[string]struct{}, len(destinations))
+
+	for _, d := range destinations {
+		if d.Name == "" || d.Chain == nil {
+			return nil, fmt.Errorf("invalid destination: %q", d.Name)
+		}
+
+		if _, ok := names[d.Name]; ok {
+			return nil, fmt.Errorf("duplicate destination: %s", d.Name)
+		}
+
+		names[d.Name] = struct{}{}
+	}
+
+	if len(opts.OutputKeys) == 0 {
+		for _, d := range destinations {
+			opts.OutputKeys = appendUnique(opts.OutputKeys, d.Chain.OutputKeys()...)
+		}
+
+		if opts.DefaultChain != nil {
+			opts.OutputKeys = appendUnique(opts.OutputKeys, opts.DefaultChain.OutputKeys()...)
+		}
+	}
+
+	return &Router{
+		selector:     selector,
+		destinations: destinations,
+		opts:         opts,
+	}, nil
+}
+
+// Call executes the router chain with the given context and inputs.
+// It returns the outputs of the selected destination chain or an error, if any.
+func (c *Router) Call(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+	opts := schema.CallOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	route, err := c.selector.Route(ctx, inputs, c.destinations, func(o *schema.CallOptions) {
+		o.CallbackManger = opts.CallbackManger
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chain, name := c.opts.DefaultChain, "DEFAULT"
+
+	d, ok := c.destination(route.Destination)
+	if ok {
+		chain, name = d.Chain, d.Name
+	} else if chain == nil {
+		if route.Destination != "" {
+			return nil, fmt.Errorf("unknown destination: %s", route.Destination)
+		}
+
+		return nil, errors.New("no suitable destination and no default chain")
+	}
+
+	nextInputs := util.CopyMap(inputs)
+	for k, v := range route.NextInputs {
+		nextInputs[k] = v
+	}
+
+	text := fmt.Sprintf("\nRouting to %s with inputs: %v", name, nextInputs)
+	if !ok && route.Destination != "" {
+		text = fmt.Sprintf("\nUnknown destination %s, routing to %s with inputs: %v", route.Destination, name, nextInputs)
+	}
+
+	if cbErr := opts.CallbackManger.OnText(ctx, &schema.TextManagerInput{
+		Text: text,
+	}); cbErr != nil {
+		return nil, cbErr
+	}
+
+	return golc.Call(ctx, chain, nextInputs, golc.ChildCallOptions(opts.CallbackManger), func(co *golc.CallOptions) {
+		co.Stop = opts.Stop
+	})
+}
+
+func (c *Router) destination(name string) (RouterDestination, bool) {
+	for _, d := range c.destinations {
+		if d.Name == name {
+			return d, true
+		}
+	}
+
+	return RouterDestination{}, false
+}
+
+// Memory returns the memory associated with the chain.
+func (c *Router) Memory() schema.Memory {
+	return c.opts.Memory
+}
+
+// Type returns the type of the chain.
+func (c *Router) Type() string {
+	return "Router"
+}
+
+// Verbose returns the verbosity setting of the chain.
+func (c *Router) Verbose() bool {
+	return c.opts.CallbackOptions.Verbose
+}
+
+// Callbacks returns the callbacks associated with the chain.
+func (c *Router) Callbacks() []schema.Callback {
+	return c.opts.CallbackOptions.Callbacks
+}
+
+// InputKeys returns the expected input keys.
+func (c *Router) InputKeys() []string {
+	return c.selector.InputKeys()
+}
+
+// OutputKeys returns the output keys the chain will return.
+func (c *Router) OutputKeys() []string {
+	return c.opts.OutputKeys
+}
+
+// Compile time check to ensure LLMRouteSelector satisfies the RouteSelector interface.
+var _ RouteSelector = (*LLMRouteSelector)(nil)
+
+// LLMRouteSelectorOptions contains options for the LLMRouteSelector.
+type LLMRouteSelectorOptions struct {
+	// InputKey is the key of the input, which is classified. Default is "input".
+	InputKey string
+
+	// Prompt is the classification prompt. It receives the input values and the variables
+	// "destinations" and "formatInstructions".
+	Prompt schema.PromptTemplate
+
+	// OutputParser parses the generation of the model. It must return a *outputparser.RouterOutput
+	// or the name of the destination as string. Default is outputparser.Router.
+	OutputParser schema.OutputParser[any]
+}
+
+// LLMRouteSelector is a RouteSelector, which lets a model classify the inputs.
+type LLMRouteSelector struct {
+	model schema.Model
+	opts  LLMRouteSelectorOptions
+}
+
+// NewLLMRouteSelector creates a new instance of the LLMRouteSelector.
+func NewLLMRouteSelector(model schema.Model, optFns ...func(o *LLMRouteSelectorOptions)) *LLMRouteSelector {
+	opts := LLMRouteSelectorOptions{
+		InputKey: "input",
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.Prompt == nil {
+		opts.Prompt = prompt.NewTemplate(defaultRouterTemplate)
+	}
+
+	if opts.OutputParser == nil {
+		opts.OutputParser = outputparser.NewRouter(func(o *outputparser.RouterOptions) {
+			o.NextInputsKey = opts.InputKey
+		})
+	}
+
+	return &LLMRouteSelector{
+		model: model,
+		opts:  opts,
+	}
+}
+
+// Route lets the model select one of the destinations for the given inputs.
+func (s *LLMRouteSelector) Route(ctx context.Context, inputs schema.ChainValues, destinations []RouterDestination, optFns ...func(o *schema.CallOptions)) (*Route, error) {
+	opts := schema.CallOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	values := util.CopyMap(inputs)
+	values["destinations"] = strings.Join(util.Map(destinations, func(d RouterDestination, _ int) string {
+		return fmt.Sprintf("%s: %s", d.Name, d.Description)
+	}), "\n")
+	values["formatInstructions"] = s.opts.OutputParser.GetFormatInstructions()
+
+	promptValue, err := s.opts.Prompt.FormatPrompt(values)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := model.GeneratePrompt(ctx, s.model, promptValue, model.ChildOptions(opts.CallbackManger))
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := s.opts.OutputParser.ParseResult(res.Generations[0])
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := parsed.(type) {
+	case *outputparser.RouterOutput:
+		return &Route{
+			Destination: v.Destination,
+			NextInputs:  v.NextInputs,
+		}, nil
+	case string:
+		return &Route{
+			Destination: strings.TrimSpace(v),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unexpected routing output of type %T", parsed)
+	}
+}
+
+// InputKeys returns the expected input keys.
+func (s *LLMRouteSelector) InputKeys() []string {
+	return []string{s.opts.InputKey}
+}
+
+// Compile time check to ensure EmbeddingRouteSelector satisfies the RouteSelector interface.
+var _ RouteSelector = (*EmbeddingRouteSelector)(nil)
+
+// EmbeddingRouteSelectorOptions contains options for the EmbeddingRouteSelector.
+type EmbeddingRouteSelectorOptions struct {
+	// InputKey is the key of the input, which is compared with the destination descriptions. Default is "input".
+	InputKey string
+
+	// ScoreThreshold is the minimum cosine similarity of a destination. If no destination reaches the
+	// threshold, the default chain is selected. If zero, the most similar destination is always selected.
+	ScoreThreshold float32
+}
+
+// EmbeddingRouteSelector is a RouteSelector, which selects the destination, whose description is most
+// similar to the input. The embeddings of the descriptions are computed once and cached.
+type EmbeddingRouteSelector struct {
+	embedder   schema.Embedder
+	embeddings map[string][]float32
+	mu         sync.Mutex
+	opts       EmbeddingRouteSelectorOptions
+}
+
+// NewEmbeddingRouteSelector creates a new instance of the EmbeddingRouteSelector.
+func NewEmbeddingRouteSelector(embedder schema.Embedder, optFns ...func(o *EmbeddingRouteSelectorOptions)) *EmbeddingRouteSelector {
+	opts := EmbeddingRouteSelectorOptions{
+		InputKey: "input",
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return &EmbeddingRouteSelector{
+		embedder:   embedder,
+		embeddings: make(map[string][]float32),
+		opts:       opts,
+	}
+}
+
+// Route selects the destination, whose description is most similar to the input.
+func (s *EmbeddingRouteSelector) Route(ctx context.Context, inputs schema.ChainValues, destinations []RouterDestination, optFns ...func(o *schema.CallOptions)) (*Route, error) {
+	input, err := inputs.GetString(s.opts.InputKey)
+	if err != nil {
+		return nil, err
+	}
+
+	embeddings, err := s.descriptionEmbeddings(ctx, destinations)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := s.embedder.EmbedText(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, bestScore := "", float32(-1)
+
+	for i, d := range destinations {
+		score, err := metric.CosineSimilarity(query, embeddings[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if destination == "" || score > bestScore {
+			destination, bestScore = d.Name, score
+		}
+	}
+
+	if s.opts.ScoreThreshold > 0 && bestScore < s.opts.ScoreThreshold {
+		return &Route{}, nil
+	}
+
+	return &Route{
+		Destination: destination,
+	}, nil
+}
+
+// descriptionEmbeddings returns the embeddings of the destination descriptions and embeds the missing ones.
+func (s *EmbeddingRouteSelector) descriptionEmbeddings(ctx context.Context, destinations []RouterDestination) ([][]float32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	missing := []string{}
+
+	for _, d := range destinations {
+		if _, ok := s.embeddings[d.Description]; !ok && !util.Contains(missing, d.Description) {
+			missing = append(missing, d.Description)
+		}
+	}
+
+	if len(missing) > 0 {
+		embeddings, err := s.embedder.BatchEmbedText(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, description := range missing {
+			s.embeddings[description] = embeddings[i]
+		}
+	}
+
+	return util.Map(destinations, func(d RouterDestination, _ int) []float32 {
+		return s.embeddings[d.Description]
+	}), nil
+}
+
+// InputKeys returns the expected input keys.
+func (s *EmbeddingRouteSelector) InputKeys() []string {
+	return []string{s.opts.InputKey}
+}
+
+// appendUnique appends the values, which are not yet contained in the slice.
+func appendUnique(s []string, values ...string) []string {
+	for _, v := range values {
+		if !util.Contains(s, v) {
+			s = append(s, v)
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,166 @@
+package chain
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/model/llm"
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestRouter(t *testing.T) {
+	newDestinationChain := func(name string) *MockChain {
+		return &MockChain{
+			CallFunc: func(ctx context.Context, inputs schema.ChainValues) (schema.ChainValues, error) {
+				return schema.ChainValues{"text": name + ": " + inputs["input"].(string)}, nil
+			},
+			InputKeysFunc:  func() []string { return []string{"input"} },
+			OutputKeysFunc: func() []string { return []string{"text"} },
+		}
+	}
+
+	destinations := []RouterDestination{
+		{Name: "billing", Description: "Good for questions about invoices and payments", Chain: newDestinationChain("billing")},
+		{Name: "support", Description: "Good for technical problems with the app", Chain: newDestinationChain("support")},
+	}
+
+	t.Run("LLMRouteSelector", func(t *testing.T) {
+		fake := llm.NewFake(func(ctx context.Context, prompt string) (*schema.ModelResult, error) {
+			assert.Contains(t, prompt, "billing: Good for questions about invoices and payments\nsupport: Good for technical problems with the app")
+
+			text := "```json\n{\"destination\": \"DEFAULT\", \"next_inputs\": \"Hello\"}\n```"
+			if strings.Contains(prompt, "invoice?") {
+				text = "```json\n{\"destination\": \"billing\", \"next_inputs\": \"Where is my latest invoice?\"}\n```"
+			}
+
+			return &schema.ModelResult{
+				Generations: []schema.Generation{{Text: text}},
+			}, nil
+		})
+
+		router, err := NewRouter(NewLLMRouteSelector(fake), destinations, func(o *RouterOptions) {
+			o.DefaultChain = newDestinationChain("default")
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"input"}, router.InputKeys())
+		assert.Equal(t, []string{"text"}, router.OutputKeys())
+
+		handler := &routerCallbackHandler{}
+
+		outputs, err := golc.Call(context.Background(), router, schema.ChainValues{"input": "Where is my invoice?"}, func(o *golc.CallOptions) {
+			o.Callbacks = []schema.Callback{handler}
+		})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"text": "billing: Where is my latest invoice?"}, outputs)
+		assert.Equal(t, []string{"\nRouting to billing with inputs: map[input:Where is my latest invoice?]"}, handler.texts)
+
+		outputs, err = golc.Call(context.Background(), router, schema.ChainValues{"input": "Hi"})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"text": "default: Hello"}, outputs)
+	})
+
+	t.Run("UnknownDestination", func(t *testing.T) {
+		fake := llm.NewFake(func(ctx context.Context, prompt string) (*schema.ModelResult, error) {
+			return &schema.ModelResult{
+				Generations: []schema.Generation{{Text: "```json\n{\"destination\": \"sales\", \"next_inputs\": \"Hello\"}\n```"}},
+			}, nil
+		})
+
+		router, err := NewRouter(NewLLMRouteSelector(fake), destinations, func(o *RouterOptions) {
+			o.DefaultChain = newDestinationChain("default")
+		})
+		require.NoError(t, err)
+
+		handler := &routerCallbackHandler{}
+
+		outputs, err := golc.Call(context.Background(), router, schema.ChainValues{"input": "Hi"}, func(o *golc.CallOptions) {
+			o.Callbacks = []schema.Callback{handler}
+		})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"text": "default: Hello"}, outputs)
+		assert.Equal(t, []string{"\nUnknown destination sales, routing to DEFAULT with inputs: map[input:Hello]"}, handler.texts)
+
+		router, err = NewRouter(NewLLMRouteSelector(fake), destinations)
+		require.NoError(t, err)
+
+		_, err = golc.Call(context.Background(), router, schema.ChainValues{"input": "Hi"})
+		assert.EqualError(t, err, "unknown destination: sales")
+	})
+
+	t.Run("EmbeddingRouteSelector", func(t *testing.T) {
+		selector := NewEmbeddingRouteSelector(&keywordEmbedder{keywords: []string{"invoice", "app"}}, func(o *EmbeddingRouteSelectorOptions) {
+			o.ScoreThreshold = 0.5
+		})
+
+		router, err := NewRouter(selector, destinations)
+		require.NoError(t, err)
+
+		outputs, err := golc.Call(context.Background(), router, schema.ChainValues{"input": "The app crashes"})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"text": "support: The app crashes"}, outputs)
+
+		outputs, err = golc.Call(context.Background(), router, schema.ChainValues{"input": "Send me the invoice"})
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChainValues{"text": "billing: Send me the invoice"}, outputs)
+
+		_, err = golc.Call(context.Background(), router, schema.ChainValues{"input": "Hello"})
+		assert.EqualError(t, err, "no suitable destination and no default chain")
+	})
+
+	t.Run("InvalidDestinations", func(t *testing.T) {
+		selector := NewEmbeddingRouteSelector(&keywordEmbedder{})
+
+		_, err := NewRouter(selector, nil)
+		assert.Error(t, err)
+
+		_, err = NewRouter(selector, []RouterDestination{destinations[0], destinations[0]})
+		assert.Error(t, err)
+	})
+}
+
+type routerCallbackHandler struct {
+	callback.NoopHandler
+	texts []string
+}
+
+func (h *routerCallbackHandler) AlwaysVerbose() bool {
+	return true
+}
+
+func (h *routerCallbackHandler) OnText(ctx context.Context, input *schema.TextInput) error {
+	h.texts = append(h.texts, input.Text)
+	return nil
+}
+
+// keywordEmbedder embeds a text as vector, which counts the occurrences of the keywords plus a constant dimension.
+type keywordEmbedder struct {
+	keywords []string
+}
+
+func (e *keywordEmbedder) BatchEmbedText(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+
+	for i, text := range texts {
+		embeddings[i], _ = e.EmbedText(ctx, text)
+	}
+
+	return embeddings, nil
+}
+
+func (e *keywordEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	embedding := make([]float32, len(e.keywords)+1)
+	embedding[len(e.keywords)] = 0.1
+
+	for i, keyword := range e.keywords {
+		embedding[i] = float32(strings.Count(strings.ToLower(text), keyword))
+	}
+
+	return embedding, nil
+}
@@ -97,10 +97,7 @@ func (c *Sequential) Call(ctx context.Context, inputs schema.ChainValues, optFns
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
-			outputs, err := golc.Call(ctx, c, knownValues, func(co *golc.CallOptions) {
-				co.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-				co.ParentRunID = opts.CallbackManger.RunID()
-			})
+			outputs, err := golc.Call(ctx, c, knownValues, golc.ChildCallOptions(opts.CallbackManger))
 			if err != nil {
 				return nil, err
 			}
@@ -210,10 +207,7 @@ func (c *SimpleSequential) Call(ctx context.Context, inputs schema.ChainValues,
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
-			input, err := golc.SimpleCall(ctx, chain, input, func(co *golc.SimpleCallOptions) {
-				co.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-				co.ParentRunID = opts.CallbackManger.RunID()
-			})
+			input, err := golc.SimpleCall(ctx, chain, input, golc.ChildSimpleCallOptions(opts.CallbackManger))
 			if err != nil {
 				return nil, err
 			}
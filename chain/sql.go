@@ -175,9 +175,7 @@ func (c *SQL) Call(ctx context.Context, inputs schema.ChainValues, optFns ...fun
 		"input":     input,
 		"tableInfo": tableInfo,
 		"topK":      c.opts.TopK,
-	}, func(sco *golc.SimpleCallOptions) {
-		sco.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		sco.ParentRunID = opts.CallbackManger.RunID()
+	}, golc.ChildSimpleCallOptions(opts.CallbackManger), func(sco *golc.SimpleCallOptions) {
 		sco.Stop = []string{"\nSQLResult:"}
 	})
 	if err != nil {
@@ -224,10 +222,7 @@ func (c *SQL) Call(ctx context.Context, inputs schema.ChainValues, optFns ...fun
 		"input":     input,
 		"tableInfo": tableInfo,
 		"topK":      c.opts.TopK,
-	}, func(sco *golc.SimpleCallOptions) {
-		sco.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		sco.ParentRunID = opts.CallbackManger.RunID()
-	})
+	}, golc.ChildSimpleCallOptions(opts.CallbackManger))
 	if err != nil {
 		return nil, err
 	}
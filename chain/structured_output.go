@@ -111,9 +111,7 @@ func (c *StructuredOutput) Call(ctx context.Context, inputs schema.ChainValues,
 		fn(&opts)
 	}
 
-	output, err := golc.Call(ctx, c.chatModelChain, inputs, func(sco *golc.CallOptions) {
-		sco.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		sco.ParentRunID = opts.CallbackManger.RunID()
+	output, err := golc.Call(ctx, c.chatModelChain, inputs, golc.ChildCallOptions(opts.CallbackManger), func(sco *golc.CallOptions) {
 		sco.Stop = opts.Stop
 	})
 	if err != nil {
@@ -95,6 +95,20 @@ func TestRedis(t *testing.T) {
 			mockClient.AssertExpectations(t)
 		})
 
+		t.Run("Messages returns multimodal chat messages", func(t *testing.T) {
+			message := schema.NewHumanChatMessage("What is in the image?", schema.NewImageContentPart("image/png", []byte("foo")))
+			messageJSON, _ := json.Marshal(schema.ChatMessageToMap(message))
+
+			mockClient.Mock = mock.Mock{}
+			mockClient.On("LRange", mock.Anything, redisHistory.key(), int64(0), int64(-1)).
+				Return([]string{string(messageJSON)}, nil)
+
+			messages, err := redisHistory.Messages(context.TODO())
+			assert.NoError(t, err)
+			assert.Equal(t, schema.ChatMessages{message}, messages)
+			mockClient.AssertExpectations(t)
+		})
+
 		t.Run("Messages returns an empty slice if there are no messages", func(t *testing.T) {
 			mockClient.Mock = mock.Mock{}
 			mockClient.On("LRange", mock.Anything, redisHistory.key(), int64(0), int64(-1)).
@@ -3,7 +3,7 @@ package embedding
 import (
 	"context"
 
-	"cloud.google.com/go/ai/generativelanguage/apiv1/generativelanguagepb"
+	"cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
 	"github.com/googleapis/gax-go/v2"
 	"github.com/hupe1980/golc/schema"
 )
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/chain"
+	"github.com/hupe1980/golc/model/chatmodel"
+	"github.com/hupe1980/golc/schema"
+)
+
+func main() {
+	openai, err := chatmodel.NewOpenAI(os.Getenv("OPENAI_API_KEY"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	conversationChain, err := chain.NewConversation(openai)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for chunk := range golc.Stream(context.Background(), conversationChain, schema.ChainValues{"input": "Write me a song about sparkling water."}) {
+		switch chunk.Type {
+		case schema.StreamChunkTypeToken:
+			fmt.Print(chunk.Token)
+		case schema.StreamChunkTypeResult:
+			fmt.Println()
+		case schema.StreamChunkTypeError:
+			log.Fatal(chunk.Error)
+		}
+	}
+}
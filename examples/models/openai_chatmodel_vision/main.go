@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/hupe1980/golc/model"
+	"github.com/hupe1980/golc/model/chatmodel"
+	"github.com/hupe1980/golc/schema"
+)
+
+func main() {
+	openai, err := chatmodel.NewOpenAI(os.Getenv("OPENAI_API_KEY"), func(o *chatmodel.OpenAIOptions) {
+		o.ModelName = "gpt-4o"
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	image, err := os.ReadFile("screenshot.png")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	messages := schema.ChatMessages{
+		schema.NewHumanChatMessage("What is shown in this screenshot?", schema.NewImageContentPart("image/png", image)),
+	}
+
+	result, err := model.ChatModelGenerate(context.Background(), openai, messages)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(result.Generations[0].Text)
+}
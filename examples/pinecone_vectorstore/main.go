@@ -32,7 +32,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	if err = vs.AddDocuments(context.Background(), []schema.Document{
+	if _, err = vs.AddDocuments(context.Background(), []schema.Document{
 		{
 			PageContent: "Pizza is an Italian dish consisting of a flat, round base of dough topped with various ingredients, including tomato sauce, cheese, and various toppings.",
 			Metadata: map[string]any{
@@ -79,4 +79,14 @@ func main() {
 	}
 
 	fmt.Println(docs)
+
+	scoredDocs, err := vs.SimilaritySearchWithScore(context.Background(), "Raw fish", func(o *schema.VectorStoreSearchOptions) {
+		o.TopK = 2
+		o.Filter = schema.FilterEq("cousine", "Japanese")
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(scoredDocs)
 }
@@ -16,12 +16,27 @@ var (
 )
 
 type CallOptions struct {
-	Callbacks      []schema.Callback
-	ParentRunID    string
+	Callbacks   []schema.Callback
+	ParentRunID string
+	// Tags are passed to the callbacks of the chain run and inherited by its child runs.
+	Tags []string
+	// Metadata is passed to the callbacks of the chain run and inherited by its child runs.
+	Metadata       map[string]any
 	IncludeRunInfo bool
 	Stop           []string
 }
 
+// ChildCallOptions returns an option that runs the chain as a child of the run managed by cm.
+// The child run inherits the callbacks, tags and metadata of its parent.
+func ChildCallOptions(cm schema.CallbackManagerForParentRun) func(o *CallOptions) {
+	return func(o *CallOptions) {
+		o.Callbacks = cm.GetInheritableCallbacks()
+		o.ParentRunID = cm.RunID()
+		o.Tags = cm.GetInheritableTags()
+		o.Metadata = cm.GetInheritableMetadata()
+	}
+}
+
 // Call executes a chain with multiple inputs.
 // It returns the outputs of the chain or an error, if any.
 func Call(ctx context.Context, chain schema.Chain, inputs schema.ChainValues, optFns ...func(*CallOptions)) (schema.ChainValues, error) {
@@ -35,6 +50,8 @@ func Call(ctx context.Context, chain schema.Chain, inputs schema.ChainValues, op
 
 	cm := callback.NewManager(opts.Callbacks, chain.Callbacks(), chain.Verbose(), func(mo *callback.ManagerOptions) {
 		mo.ParentRunID = opts.ParentRunID
+		mo.Tags = opts.Tags
+		mo.Metadata = opts.Metadata
 	})
 
 	rm, err := cm.OnChainStart(ctx, &schema.ChainStartManagerInput{
@@ -88,7 +105,22 @@ func Call(ctx context.Context, chain schema.Chain, inputs schema.ChainValues, op
 type SimpleCallOptions struct {
 	Callbacks   []schema.Callback
 	ParentRunID string
-	Stop        []string
+	// Tags are passed to the callbacks of the chain run and inherited by its child runs.
+	Tags []string
+	// Metadata is passed to the callbacks of the chain run and inherited by its child runs.
+	Metadata map[string]any
+	Stop     []string
+}
+
+// ChildSimpleCallOptions returns an option that runs the chain as a child of the run managed by cm.
+// The child run inherits the callbacks, tags and metadata of its parent.
+func ChildSimpleCallOptions(cm schema.CallbackManagerForParentRun) func(o *SimpleCallOptions) {
+	return func(o *SimpleCallOptions) {
+		o.Callbacks = cm.GetInheritableCallbacks()
+		o.ParentRunID = cm.RunID()
+		o.Tags = cm.GetInheritableTags()
+		o.Metadata = cm.GetInheritableMetadata()
+	}
 }
 
 // SimpleCall executes a chain with a single input and a single output.
@@ -122,6 +154,8 @@ func SimpleCall(ctx context.Context, chain schema.Chain, input any, optFns ...fu
 	outputValues, err := Call(ctx, chain, cv, func(o *CallOptions) {
 		o.Callbacks = opts.Callbacks
 		o.ParentRunID = opts.ParentRunID
+		o.Tags = opts.Tags
+		o.Metadata = opts.Metadata
 		o.Stop = opts.Stop
 	})
 	if err != nil {
@@ -132,13 +166,28 @@ func SimpleCall(ctx context.Context, chain schema.Chain, input any, optFns ...fu
 }
 
 type BatchCallOptions struct {
-	Callbacks      []schema.Callback
-	ParentRunID    string
+	Callbacks   []schema.Callback
+	ParentRunID string
+	// Tags are passed to the callbacks of the chain run and inherited by its child runs.
+	Tags []string
+	// Metadata is passed to the callbacks of the chain run and inherited by its child runs.
+	Metadata       map[string]any
 	IncludeRunInfo bool
 	Stop           []string
 	MaxConcurrency int
 }
 
+// ChildBatchCallOptions returns an option that runs the chain calls as children of the run managed by cm.
+// Each child run inherits the callbacks, tags and metadata of its parent.
+func ChildBatchCallOptions(cm schema.CallbackManagerForParentRun) func(o *BatchCallOptions) {
+	return func(o *BatchCallOptions) {
+		o.Callbacks = cm.GetInheritableCallbacks()
+		o.ParentRunID = cm.RunID()
+		o.Tags = cm.GetInheritableTags()
+		o.Metadata = cm.GetInheritableMetadata()
+	}
+}
+
 // BatchCall executes multiple calls to the chain.Call function concurrently and collects
 // the results in the same order as the inputs. It utilizes the errgroup package to manage
 // the concurrent execution and handle any errors that may occur.
@@ -164,6 +213,8 @@ func BatchCall(ctx context.Context, chain schema.Chain, inputs []schema.ChainVal
 			vals, err := Call(errctx, chain, input, func(o *CallOptions) {
 				o.Callbacks = opts.Callbacks
 				o.ParentRunID = opts.ParentRunID
+				o.Tags = opts.Tags
+				o.Metadata = opts.Metadata
 				o.IncludeRunInfo = opts.IncludeRunInfo
 				o.Stop = opts.Stop
 			})
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/memory"
 	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/assert"
 )
@@ -121,6 +123,64 @@ func TestBatchCall(t *testing.T) {
 	}
 }
 
+func TestChildCallOptions(t *testing.T) {
+	callbacks := []schema.Callback{callback.NewTracer()}
+
+	rm := callback.NewManagerForChainRun("parent-run", callbacks, nil, false, func(mo *callback.ManagerOptions) {
+		mo.Tags = []string{"tag"}
+		mo.Metadata = map[string]any{"key": "value"}
+	})
+
+	opts := CallOptions{}
+	ChildCallOptions(rm)(&opts)
+
+	assert.Equal(t, callbacks, opts.Callbacks)
+	assert.Equal(t, "parent-run", opts.ParentRunID)
+	assert.Equal(t, []string{"tag"}, opts.Tags)
+	assert.Equal(t, map[string]any{"key": "value"}, opts.Metadata)
+}
+
+func TestCallAsyncCallback(t *testing.T) {
+	// Call adds the memory variables to the inputs and the run info to the outputs after the callbacks
+	// were invoked, so an asynchronous handler must not share the values with the caller.
+	tracer := callback.NewTracer()
+
+	async, err := callback.NewAsyncHandler(tracer, func(o *callback.AsyncHandlerOptions) {
+		o.Block = true
+	})
+	assert.NoError(t, err)
+
+	chain := mockChain{
+		CallFunc: func(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+			return schema.ChainValues{"output": "result"}, nil
+		},
+		MemoryFunc: func() schema.Memory {
+			return memory.NewConversationBuffer(func(o *memory.ConversationBufferOptions) {
+				o.InputKey = "input"
+				o.OutputKey = "output"
+			})
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+		_, err := Call(context.Background(), chain, schema.ChainValues{"input": "test"}, func(o *CallOptions) {
+			o.Callbacks = []schema.Callback{async}
+			o.IncludeRunInfo = true
+		})
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, async.Close(context.Background()))
+
+	runs := tracer.Runs()
+	assert.Len(t, runs, 10)
+
+	for _, r := range runs {
+		assert.Equal(t, map[string]any{"input": "test"}, r.Inputs)
+		assert.Equal(t, map[string]any{"output": "result"}, r.Outputs)
+	}
+}
+
 // mockChain is a mock implementation of the schema.Chain interface
 type mockChain struct {
 	CallFunc       func(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error)
@@ -135,7 +195,7 @@ type mockChain struct {
 // Call is the mock implementation of the Call method
 func (m mockChain) Call(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
 	if m.CallFunc != nil {
-		return m.CallFunc(ctx, inputs)
+		return m.CallFunc(ctx, inputs, optFns...)
 	}
 
 	return schema.ChainValues{}, nil
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 )
@@ -29,6 +30,9 @@ type Options struct {
 	// The version of the Anthropic API to use.
 	Version string
 
+	// The version of the Anthropic API to use for the Messages API.
+	MessagesVersion string
+
 	// The SDK identifier used in the API requests.
 	SDK string
 
@@ -45,10 +49,11 @@ type Client struct {
 // New creates a new instance of the Anthropic API client with the given API key and optional configuration options.
 func New(apiKey string, optFns ...func(o *Options)) *Client {
 	opts := Options{
-		APIUrl:     "https://api.anthropic.com",
-		Version:    "2023-01-01",
-		SDK:        "golc-anthrophic-sdk",
-		HTTPClient: http.DefaultClient,
+		APIUrl:          "https://api.anthropic.com",
+		Version:         "2023-01-01",
+		MessagesVersion: "2023-06-01",
+		SDK:             "golc-anthrophic-sdk",
+		HTTPClient:      http.DefaultClient,
 	}
 
 	for _, fn := range optFns {
@@ -137,3 +142,170 @@ func (c *Client) CreateCompletion(ctx context.Context, request *CompletionReques
 
 	return &response, nil
 }
+
+// Message represents a single turn in a conversation with the Anthropic Messages API.
+type Message struct {
+	// The role of the message author, either "user" or "assistant".
+	Role string `json:"role"`
+	// The content blocks of the message.
+	Content []ContentBlock `json:"content"`
+}
+
+// ContentBlock represents a content block of a message, e.g. text, an image, a tool use or a tool result.
+type ContentBlock struct {
+	// The type of the block ("text", "image", "document", "tool_use" or "tool_result").
+	Type string `json:"type"`
+	// The text of a text block.
+	Text string `json:"text,omitempty"`
+	// The source of an image or document block.
+	Source *Source `json:"source,omitempty"`
+	// The unique identifier of a tool use block.
+	ID string `json:"id,omitempty"`
+	// The name of the tool of a tool use block.
+	Name string `json:"name,omitempty"`
+	// The input of a tool use block as JSON object.
+	Input json.RawMessage `json:"input,omitempty"`
+	// The id of the tool use a tool result block belongs to.
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	// The content of a tool result block.
+	Content string `json:"content,omitempty"`
+	// Flag indicating that the tool result is an error.
+	IsError bool `json:"is_error,omitempty"`
+}
+
+// Source represents the source of an image or document block.
+type Source struct {
+	// The type of the source ("base64" or "url").
+	Type string `json:"type"`
+	// The media type of base64 encoded data, e.g. "image/png" or "application/pdf".
+	MediaType string `json:"media_type,omitempty"`
+	// The base64 encoded data.
+	Data string `json:"data,omitempty"`
+	// The URL of the image or document.
+	URL string `json:"url,omitempty"`
+}
+
+// Tool represents a tool definition the model may use.
+type Tool struct {
+	// The name of the tool.
+	Name string `json:"name"`
+	// The description of the tool.
+	Description string `json:"description,omitempty"`
+	// The JSON schema for the tool input.
+	InputSchema any `json:"input_schema"`
+}
+
+// ToolChoice controls how the model uses the provided tools.
+type ToolChoice struct {
+	// The type of the choice ("auto", "any" or "tool").
+	Type string `json:"type"`
+	// The name of the tool to use, if the type is "tool".
+	Name string `json:"name,omitempty"`
+}
+
+// MessageRequest represents a request to the Anthropic Messages API.
+type MessageRequest struct {
+	// The model to use.
+	Model string `json:"model,omitempty"`
+	// The API version, only required when the request is sent through Amazon Bedrock.
+	AnthropicVersion string `json:"anthropic_version,omitempty"`
+	// The input messages.
+	Messages []Message `json:"messages"`
+	// The system prompt.
+	System string `json:"system,omitempty"`
+	// The maximum number of tokens to generate.
+	MaxTokens int `json:"max_tokens"`
+	// The temperature for randomness in sampling.
+	Temperature float32 `json:"temperature,omitempty"`
+	// The number of highest probability tokens to use in sampling.
+	TopK int `json:"top_k,omitempty"`
+	// The cumulative probability for nucleus sampling.
+	TopP float32 `json:"top_p,omitempty"`
+	// List of strings to stop generation at.
+	StopSequences []string `json:"stop_sequences,omitempty"`
+	// Definitions of tools the model may use.
+	Tools []Tool `json:"tools,omitempty"`
+	// How the model should use the provided tools.
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+}
+
+// MessageUsage represents the token usage of a message request.
+type MessageUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// MessageResponse represents the response from the Anthropic Messages API.
+type MessageResponse struct {
+	// The unique identifier of the message.
+	ID string `json:"id"`
+	// The object type, always "message".
+	Type string `json:"type"`
+	// The role of the generated message, always "assistant".
+	Role string `json:"role"`
+	// The content blocks generated by the model.
+	Content []ContentBlock `json:"content"`
+	// The model that handled the request.
+	Model string `json:"model"`
+	// The reason for stopping generation.
+	StopReason string `json:"stop_reason"`
+	// The stop sequence that caused generation to stop.
+	StopSequence string `json:"stop_sequence"`
+	// The token usage of the request.
+	Usage MessageUsage `json:"usage"`
+}
+
+// ErrorResponse represents an error returned by the Anthropic API.
+type ErrorResponse struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// CreateMessage sends a request to the Anthropic Messages API and returns the response.
+func (c *Client) CreateMessage(ctx context.Context, request *MessageRequest) (*MessageResponse, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/messages", c.opts.APIUrl), bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Anthropic-SDK", c.opts.SDK)
+	req.Header.Set("Anthropic-Version", c.opts.MessagesVersion)
+	req.Header.Set("X-API-Key", c.apiKey)
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		errorResponse := ErrorResponse{}
+		if err := json.Unmarshal(body, &errorResponse); err != nil {
+			return nil, fmt.Errorf("anthropic api error: status code %d", resp.StatusCode)
+		}
+
+		return nil, fmt.Errorf("anthropic api error: %s: %s", errorResponse.Error.Type, errorResponse.Error.Message)
+	}
+
+	var response MessageResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
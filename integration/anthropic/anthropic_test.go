@@ -98,6 +98,82 @@ func TestClient(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, response)
 	})
+
+	t.Run("CreateMessage", func(t *testing.T) {
+		mockResponse := MessageResponse{
+			ID:   "msg_01",
+			Type: "message",
+			Role: "assistant",
+			Content: []ContentBlock{
+				{Type: "tool_use", ID: "toolu_01", Name: "get_weather", Input: json.RawMessage(`{"location":"Berlin"}`)},
+			},
+			StopReason: "tool_use",
+			Usage:      MessageUsage{InputTokens: 10, OutputTokens: 5},
+		}
+		mockPayload, err := json.Marshal(mockResponse)
+		assert.NoError(t, err)
+
+		mockClient := &mockHTTPClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "https://api.anthropic.com/v1/messages", req.URL.String())
+				assert.Equal(t, "2023-06-01", req.Header.Get("Anthropic-Version"))
+
+				body, bErr := io.ReadAll(req.Body)
+				assert.NoError(t, bErr)
+
+				defer req.Body.Close()
+
+				var request MessageRequest
+				err = json.Unmarshal(body, &request)
+				assert.NoError(t, err)
+
+				assert.Equal(t, "claude-3-haiku-20240307", request.Model)
+				assert.Len(t, request.Tools, 1)
+				assert.Equal(t, "get_weather", request.Tools[0].Name)
+				assert.Equal(t, &ToolChoice{Type: "tool", Name: "get_weather"}, request.ToolChoice)
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBuffer(mockPayload)),
+				}, nil
+			},
+		}
+
+		client := New("api-key", func(o *Options) {
+			o.HTTPClient = mockClient
+		})
+
+		response, err := client.CreateMessage(context.Background(), &MessageRequest{
+			Model: "claude-3-haiku-20240307",
+			Messages: []Message{{Role: "user", Content: []ContentBlock{
+				{Type: "text", Text: "What is the weather in Berlin?"},
+			}}},
+			MaxTokens:  256,
+			Tools:      []Tool{{Name: "get_weather", InputSchema: map[string]any{"type": "object"}}},
+			ToolChoice: &ToolChoice{Type: "tool", Name: "get_weather"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, &mockResponse, response)
+	})
+
+	t.Run("CreateMessage_APIError", func(t *testing.T) {
+		mockClient := &mockHTTPClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusBadRequest,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: field required"}}`)),
+				}, nil
+			},
+		}
+
+		client := New("api-key", func(o *Options) {
+			o.HTTPClient = mockClient
+		})
+
+		response, err := client.CreateMessage(context.Background(), &MessageRequest{})
+		assert.EqualError(t, err, "anthropic api error: invalid_request_error: max_tokens: field required")
+		assert.Nil(t, response)
+	})
 }
 
 // mockHTTPClient is a mock implementation of the HTTPClient interface.
@@ -66,30 +66,58 @@ func New(clientID, clientSecret string, optFns ...func(o *Options)) *Client {
 
 // Message represents a chat message with role and content.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role         string        `json:"role"`
+	Content      string        `json:"content"`
+	Name         string        `json:"name,omitempty"`
+	FunctionCall *FunctionCall `json:"function_call,omitempty"`
+}
+
+// Function represents a function the model may call.
+type Function struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Parameters  any    `json:"parameters"`
+}
+
+// FunctionCall represents a function call generated by the model.
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Thoughts  string `json:"thoughts,omitempty"`
+}
+
+// ToolChoice forces the model to call a specific function.
+type ToolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
 }
 
 // ChatCompletionRequest represents a request for chat completion.
 type ChatCompletionRequest struct {
-	Messages     []Message `json:"messages"`
-	Temperature  float64   `json:"temperature,omitempty"`
-	TopP         float64   `json:"top_p,omitempty"`
-	PenaltyScore float64   `json:"penalty_score,omitempty"`
-	Stream       bool      `json:"stream,omitempty"`
-	UserID       string    `json:"user_id,omitempty"`
+	Messages     []Message   `json:"messages"`
+	System       string      `json:"system,omitempty"`
+	Functions    []Function  `json:"functions,omitempty"`
+	ToolChoice   *ToolChoice `json:"tool_choice,omitempty"`
+	Temperature  float64     `json:"temperature,omitempty"`
+	TopP         float64     `json:"top_p,omitempty"`
+	PenaltyScore float64     `json:"penalty_score,omitempty"`
+	Stream       bool        `json:"stream,omitempty"`
+	UserID       string      `json:"user_id,omitempty"`
 }
 
 // ChatCompletionResponse represents the response from chat completion API.
 type ChatCompletionResponse struct {
-	ID               string `json:"id"`
-	Object           string `json:"object"`
-	Created          int    `json:"created"`
-	SentenceID       int    `json:"sentence_id"`
-	IsEnd            bool   `json:"is_end"`
-	IsTruncated      bool   `json:"is_truncated"`
-	Result           string `json:"result"`
-	NeedClearHistory bool   `json:"need_clear_history"`
+	ID               string        `json:"id"`
+	Object           string        `json:"object"`
+	Created          int           `json:"created"`
+	SentenceID       int           `json:"sentence_id"`
+	IsEnd            bool          `json:"is_end"`
+	IsTruncated      bool          `json:"is_truncated"`
+	Result           string        `json:"result"`
+	NeedClearHistory bool          `json:"need_clear_history"`
+	FunctionCall     *FunctionCall `json:"function_call,omitempty"`
 	Usage            struct {
 		PromptTokens     int `json:"prompt_tokens"`
 		CompletionTokens int `json:"completion_tokens"`
@@ -18,13 +18,64 @@ func ToOpenAIChatCompletionMessages(messages schema.ChatMessages) ([]openai.Chat
 			return nil, err
 		}
 
-		if functionMessage, ok := message.(*schema.FunctionChatMessage); ok {
+		switch m := message.(type) {
+		case *schema.HumanChatMessage:
+			openAIMessage := openai.ChatCompletionMessage{
+				Role: role,
+			}
+
+			if len(m.Parts()) > 0 {
+				multiContent, err := toOpenAIChatMessageParts(m.ContentParts())
+				if err != nil {
+					return nil, err
+				}
+
+				openAIMessage.MultiContent = multiContent
+			} else {
+				openAIMessage.Content = m.Content()
+			}
+
+			openAIMessages = append(openAIMessages, openAIMessage)
+		case *schema.FunctionChatMessage:
 			openAIMessages = append(openAIMessages, openai.ChatCompletionMessage{
 				Role:    role,
-				Content: functionMessage.Content(),
-				Name:    functionMessage.Name(),
+				Content: m.Content(),
+				Name:    m.Name(),
 			})
-		} else {
+		case *schema.ToolChatMessage:
+			openAIMessages = append(openAIMessages, openai.ChatCompletionMessage{
+				Role:       role,
+				Content:    m.Content(),
+				ToolCallID: m.ToolCallID(),
+			})
+		case *schema.AIChatMessage:
+			openAIMessage := openai.ChatCompletionMessage{
+				Role:    role,
+				Content: m.Content(),
+			}
+
+			ext := m.Extension()
+			if len(ext.ToolCalls) > 0 {
+				openAIMessage.ToolCalls = make([]openai.ToolCall, len(ext.ToolCalls))
+				for i, tc := range ext.ToolCalls {
+					openAIMessage.ToolCalls[i] = openai.ToolCall{
+						ID:   tc.ID,
+						Type: openai.ToolTypeFunction,
+						Function: openai.FunctionCall{
+							Name:      tc.Function.Name,
+							Arguments: tc.Function.Arguments,
+						},
+					}
+				}
+			} else if ext.FunctionCall != nil {
+				openAIMessage.FunctionCall = &openai.FunctionCall{
+					Name:      ext.FunctionCall.Name,
+					Arguments: ext.FunctionCall.Arguments,
+				}
+			}
+
+			openAIMessages = append(openAIMessages, openAIMessage)
+		default:
 			openAIMessages = append(openAIMessages, openai.ChatCompletionMessage{
 				Role:    role,
 				Content: message.Content(),
@@ -35,6 +86,34 @@ func ToOpenAIChatCompletionMessages(messages schema.ChatMessages) ([]openai.Chat
 	return openAIMessages, nil
 }
 
+// toOpenAIChatMessageParts converts content parts to the parts of a multimodal OpenAI message.
+// Inline images are sent as base64 encoded data URLs.
+func toOpenAIChatMessageParts(parts []schema.ContentPart) ([]openai.ChatMessagePart, error) {
+	openAIParts := make([]openai.ChatMessagePart, len(parts))
+
+	for i, p := range parts {
+		switch p.Type {
+		case schema.ContentPartTypeText:
+			openAIParts[i] = openai.ChatMessagePart{
+				Type: openai.ChatMessagePartTypeText,
+				Text: p.Text,
+			}
+		case schema.ContentPartTypeImage:
+			openAIParts[i] = openai.ChatMessagePart{
+				Type: openai.ChatMessagePartTypeImageURL,
+				ImageURL: &openai.ChatMessageImageURL{
+					URL:    p.DataURL(),
+					Detail: openai.ImageURLDetailAuto,
+				},
+			}
+		default:
+			return nil, fmt.Errorf("unsupported content part type: %s", p.Type)
+		}
+	}
+
+	return openAIParts, nil
+}
+
 // messageTypeToOpenAIRole converts a schema.ChatMessageType to the corresponding OpenAI role string.
 func messageTypeToOpenAIRole(mType schema.ChatMessageType) (string, error) {
 	switch mType { // nolint exhaustive
@@ -46,6 +125,8 @@ func messageTypeToOpenAIRole(mType schema.ChatMessageType) (string, error) {
 		return "user", nil
 	case schema.ChatMessageTypeFunction:
 		return "function", nil
+	case schema.ChatMessageTypeTool:
+		return "tool", nil
 	default:
 		return "", fmt.Errorf("unknown message type: %s", mType)
 	}
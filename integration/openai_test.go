@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/hupe1980/golc/schema"
+	"github.com/sashabaranov/go-openai"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,7 +25,67 @@ func TestToOpenAIChatCompletionMessages(t *testing.T) {
 	assert.Equal(t, "What is 1 times 1?", openAIMessages[1].Content)
 }
 
+func TestToOpenAIChatCompletionMessagesWithToolCalls(t *testing.T) {
+	messages := schema.ChatMessages{
+		schema.NewHumanChatMessage("What is the weather in Berlin and Paris?"),
+		schema.NewAIChatMessage("", func(o *schema.ChatMessageExtension) {
+			o.ToolCalls = []schema.ToolCall{
+				{ID: "call_1", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}},
+				{ID: "call_2", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Paris"}`}},
+			}
+		}),
+		schema.NewToolChatMessage("call_1", "sunny"),
+		schema.NewToolChatMessage("call_2", "rainy"),
+	}
+
+	openAIMessages, err := ToOpenAIChatCompletionMessages(messages)
+	assert.NoError(t, err)
+	assert.Len(t, openAIMessages, 4)
+
+	assert.Equal(t, "assistant", openAIMessages[1].Role)
+	assert.Len(t, openAIMessages[1].ToolCalls, 2)
+	assert.Equal(t, "call_2", openAIMessages[1].ToolCalls[1].ID)
+	assert.Equal(t, `{"location":"Paris"}`, openAIMessages[1].ToolCalls[1].Function.Arguments)
+
+	assert.Equal(t, "tool", openAIMessages[2].Role)
+	assert.Equal(t, "call_1", openAIMessages[2].ToolCallID)
+	assert.Equal(t, "sunny", openAIMessages[2].Content)
+	assert.Equal(t, "call_2", openAIMessages[3].ToolCallID)
+}
+
 // Test case for messageTypeToOpenAIRole function
+func TestToOpenAIChatCompletionMessagesWithContentParts(t *testing.T) {
+	t.Run("Images", func(t *testing.T) {
+		messages := schema.ChatMessages{
+			schema.NewHumanChatMessage("What is in the images?",
+				schema.NewImageContentPart("image/png", []byte("foo")),
+				schema.NewImageURLContentPart("", "https://example.com/image.png"),
+			),
+		}
+
+		openAIMessages, err := ToOpenAIChatCompletionMessages(messages)
+		assert.NoError(t, err)
+		assert.Len(t, openAIMessages, 1)
+
+		assert.Equal(t, "user", openAIMessages[0].Role)
+		assert.Empty(t, openAIMessages[0].Content)
+		assert.Equal(t, []openai.ChatMessagePart{
+			{Type: openai.ChatMessagePartTypeText, Text: "What is in the images?"},
+			{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: "data:image/png;base64,Zm9v", Detail: openai.ImageURLDetailAuto}},
+			{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: "https://example.com/image.png", Detail: openai.ImageURLDetailAuto}},
+		}, openAIMessages[0].MultiContent)
+	})
+
+	t.Run("UnsupportedFile", func(t *testing.T) {
+		messages := schema.ChatMessages{
+			schema.NewHumanChatMessage("Summarize", schema.NewFileContentPart("application/pdf", []byte("%PDF"))),
+		}
+
+		_, err := ToOpenAIChatCompletionMessages(messages)
+		assert.EqualError(t, err, "unsupported content part type: file")
+	})
+}
+
 func TestMessageTypeToOpenAIRole(t *testing.T) {
 	assertRole, assertErr := messageTypeToOpenAIRole(schema.ChatMessageTypeAI)
 	assert.Equal(t, "assistant", assertRole)
@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/tls"
 
-	"github.com/google/uuid"
 	pc "github.com/pinecone-io/go-pinecone/pinecone_grpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -55,7 +54,7 @@ func (p *GRPCClient) Upsert(ctx context.Context, req *UpsertRequest) (*UpsertRes
 		pineconeVectors = append(
 			pineconeVectors,
 			&pc.Vector{
-				Id:       uuid.New().String(),
+				Id:       req.Vectors[i].ID,
 				Values:   req.Vectors[i].Values,
 				Metadata: metadataStruct,
 			},
@@ -139,6 +138,20 @@ func (p *GRPCClient) Query(ctx context.Context, req *QueryRequest) (*QueryRespon
 	}, nil
 }
 
+func (p *GRPCClient) Delete(ctx context.Context, req *DeleteRequest) (*DeleteResponse, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "api-key", p.apiKey)
+
+	if _, err := p.client.Delete(ctx, &pc.DeleteRequest{
+		Ids:       req.IDs,
+		DeleteAll: req.DeleteAll,
+		Namespace: req.Namespace,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &DeleteResponse{}, nil
+}
+
 func (p *GRPCClient) Close() error {
 	return p.conn.Close()
 }
@@ -25,6 +25,7 @@ type Client interface {
 	Upsert(ctx context.Context, req *UpsertRequest) (*UpsertResponse, error)
 	Fetch(ctx context.Context, req *FetchRequest) (*FetchResponse, error)
 	Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error)
+	Delete(ctx context.Context, req *DeleteRequest) (*DeleteResponse, error)
 	Close() error
 }
 
@@ -31,10 +31,21 @@ type FetchResponse struct {
 	Namespace string             `json:"namespace"`
 }
 
+// DeleteRequest represents the parameters for a delete vectors request.
+// See https://docs.pinecone.io/reference/delete_post for more informations.
+type DeleteRequest struct {
+	IDs       []string `json:"ids,omitempty"`
+	DeleteAll bool     `json:"deleteAll,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+}
+
+// DeleteResponse represents the response from a delete vectors request.
+type DeleteResponse struct{}
+
 // QueryRequest represents the parameters for a query request.
 // See https://docs.pinecone.io/reference/query for more information.
 type QueryRequest struct {
-	Filter          map[string]any `json:"filter"`
+	Filter          map[string]any `json:"filter,omitempty"`
 	IncludeValues   bool           `json:"includeValues"`
 	IncludeMetadata bool           `json:"includeMetadata"`
 	Vector          []float32      `json:"vector"`
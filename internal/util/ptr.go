@@ -15,3 +15,13 @@ func AddrOrNil[T comparable](x T) *T {
 func PTR[T comparable](x T) *T {
 	return &x
 }
+
+// Deref returns the value x points to, or the zero value for T if x is nil.
+func Deref[T any](x *T) T {
+	if x == nil {
+		var z T
+		return z
+	}
+
+	return *x
+}
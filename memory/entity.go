@@ -0,0 +1,369 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hupe1980/golc/chatmessagehistory"
+	"github.com/hupe1980/golc/prompt"
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure Entity satisfies the Memory interface.
+var _ schema.Memory = (*Entity)(nil)
+
+const defaultEntityExtractionTemplate = `You are an AI assistant reading the transcript of a conversation between an AI and a human. Extract all of the proper nouns from the last line of conversation. As a guideline, a proper noun is generally capitalized. You should definitely extract all names, places, projects and systems.
+
+The conversation history is provided just in case of a coreference (e.g. "What do you know about him" where "him" is defined in a previous line) -- ignore items mentioned there that are not in the last line.
+
+Return the output as a single comma-separated list, or NONE if there is nothing of note to return (e.g. the user is just issuing a greeting or having a simple conversation).
+
+EXAMPLE
+Conversation history:
+Person #1: how's it going today?
+AI: "It's going great! How about you?"
+Person #1: good! busy working on Langchain. lots to do.
+AI: "That sounds like a lot of work! What kind of things are you doing to make Langchain better?"
+Last line:
+Person #1: i'm trying to improve Langchain's interfaces, the UX, its integrations with various products the user might want ... a lot of stuff. I'm working with Person #2.
+Output: Langchain, Person #2
+END OF EXAMPLE
+
+Conversation history (for reference only):
+{{.history}}
+Last line of conversation (for extraction):
+Human: {{.input}}
+
+Output:`
+
+const defaultEntitySummarizationTemplate = `You are an AI assistant helping a human keep track of facts about relevant people, places, projects and systems in their life. Update the summary of the provided entity in the "Entity" section based on the last line of your conversation with the human. If you are writing the summary for the first time, return a single sentence.
+The update should only include facts that are relayed in the last line of conversation about the provided entity, and should only contain facts about the provided entity.
+
+If there is no new information about the provided entity or the information is not worth noting (not an important or relevant fact to remember long-term), return the existing summary unchanged.
+
+Full conversation history (for context):
+{{.history}}
+
+Entity to summarize:
+{{.entity}}
+
+Existing summary of {{.entity}}:
+{{.summary}}
+
+Last line of conversation:
+Human: {{.input}}
+Updated summary:`
+
+// EntityOptions contains options for configuring the Entity memory type.
+type EntityOptions struct {
+	HumanPrefix        string
+	AIPrefix           string
+	InputKey           string
+	OutputKey          string
+	ReturnMessages     bool
+	ChatMessageHistory schema.ChatMessageHistory
+
+	// ChatHistoryKey is the memory key of the recent conversation.
+	ChatHistoryKey string
+
+	// EntitiesKey is the memory key of the entity summaries.
+	EntitiesKey string
+
+	// EntityNamesKey is the memory key of the names of the entities extracted from the input.
+	// SaveContext updates the summaries of these entities, so they are passed from LoadMemoryVariables
+	// to SaveContext with the inputs of the call instead of being kept in the memory.
+	EntityNamesKey string
+
+	// EntityStore stores the summaries of the entities. Default is an InMemoryEntityStore.
+	EntityStore EntityStore
+
+	// EntityExtractionPrompt is the prompt used to extract the entities of the input.
+	// It receives the variables "history" and "input".
+	EntityExtractionPrompt schema.PromptTemplate
+
+	// EntitySummarizationPrompt is the prompt used to update the summary of an entity.
+	// It receives the variables "history", "entity", "summary" and "input".
+	EntitySummarizationPrompt schema.PromptTemplate
+
+	// K is the number of recent interactions used as context and returned as chat history.
+	K uint
+}
+
+// Entity is a memory type that extracts the named entities of each turn with a model and keeps an updated summary
+// per entity. Only the entities mentioned in the current input are injected into the prompt.
+type Entity struct {
+	model schema.Model
+	opts  EntityOptions
+}
+
+// NewEntity creates a new instance of Entity memory type.
+func NewEntity(model schema.Model, optFns ...func(o *EntityOptions)) *Entity {
+	opts := EntityOptions{
+		HumanPrefix:    "Human",
+		AIPrefix:       "AI",
+		InputKey:       "",
+		OutputKey:      "",
+		ReturnMessages: false,
+		ChatHistoryKey: "history",
+		EntitiesKey:    "entities",
+		EntityNamesKey: "entityNames",
+		K:              3,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.ChatMessageHistory == nil {
+		opts.ChatMessageHistory = chatmessagehistory.NewInMemory()
+	}
+
+	if opts.EntityStore == nil {
+		opts.EntityStore = NewInMemoryEntityStore()
+	}
+
+	if opts.EntityExtractionPrompt == nil {
+		opts.EntityExtractionPrompt = prompt.NewTemplate(defaultEntityExtractionTemplate)
+	}
+
+	if opts.EntitySummarizationPrompt == nil {
+		opts.EntitySummarizationPrompt = prompt.NewTemplate(defaultEntitySummarizationTemplate)
+	}
+
+	return &Entity{
+		model: model,
+		opts:  opts,
+	}
+}
+
+// MemoryKeys returns the memory keys for Entity.
+func (m *Entity) MemoryKeys() []string {
+	return []string{m.opts.ChatHistoryKey, m.opts.EntitiesKey, m.opts.EntityNamesKey}
+}
+
+// LoadMemoryVariables extracts the entities of the input and returns their summaries together with the recent conversation.
+// The summaries are returned as "Entity: summary" lines or, if ReturnMessages is set, as a map of entity to summary.
+func (m *Entity) LoadMemoryVariables(ctx context.Context, inputs map[string]any) (map[string]any, error) {
+	input, err := m.getInput(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := m.recentMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := m.formatMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := m.extractEntities(ctx, history, input)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]string, len(entities))
+
+	for _, entity := range entities {
+		summary, err := m.opts.EntityStore.Get(ctx, entity)
+		if err != nil {
+			return nil, err
+		}
+
+		summaries[entity] = summary
+	}
+
+	if m.opts.ReturnMessages {
+		return map[string]any{
+			m.opts.ChatHistoryKey: messages,
+			m.opts.EntitiesKey:    summaries,
+			m.opts.EntityNamesKey: entities,
+		}, nil
+	}
+
+	return map[string]any{
+		m.opts.ChatHistoryKey: history,
+		m.opts.EntitiesKey:    formatEntities(summaries),
+		m.opts.EntityNamesKey: entities,
+	}, nil
+}
+
+// SaveContext saves the input and output messages to the chat message history and updates the summaries
+// of the entities extracted by LoadMemoryVariables, which are passed with the inputs. Without them,
+// the entities are extracted from the input again.
+func (m *Entity) SaveContext(ctx context.Context, inputs map[string]any, outputs map[string]any) error {
+	input, output, err := getInputOutput(inputs, outputs, m.opts.InputKey, m.opts.OutputKey, m.MemoryKeys())
+	if err != nil {
+		return err
+	}
+
+	entities, ok := inputs[m.opts.EntityNamesKey].([]string)
+	if !ok {
+		messages, err := m.recentMessages(ctx)
+		if err != nil {
+			return err
+		}
+
+		history, err := m.formatMessages(messages)
+		if err != nil {
+			return err
+		}
+
+		entities, err = m.extractEntities(ctx, history, input)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := m.opts.ChatMessageHistory.AddUserMessage(ctx, input); err != nil {
+		return err
+	}
+
+	if err := m.opts.ChatMessageHistory.AddAIMessage(ctx, output); err != nil {
+		return err
+	}
+
+	if len(entities) == 0 {
+		return nil
+	}
+
+	messages, err := m.recentMessages(ctx)
+	if err != nil {
+		return err
+	}
+
+	history, err := m.formatMessages(messages)
+	if err != nil {
+		return err
+	}
+
+	for _, entity := range entities {
+		summary, err := m.opts.EntityStore.Get(ctx, entity)
+		if err != nil {
+			return err
+		}
+
+		summary, err = predict(ctx, m.model, m.opts.EntitySummarizationPrompt, map[string]any{
+			"history": history,
+			"entity":  entity,
+			"summary": summary,
+			"input":   input,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := m.opts.EntityStore.Set(ctx, entity, summary); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Clear clears the chat message history and the entity store.
+func (m *Entity) Clear(ctx context.Context) error {
+	if err := m.opts.ChatMessageHistory.Clear(ctx); err != nil {
+		return err
+	}
+
+	return m.opts.EntityStore.Clear(ctx)
+}
+
+// extractEntities extracts the entities of the input with the model.
+func (m *Entity) extractEntities(ctx context.Context, history, input string) ([]string, error) {
+	output, err := predict(ctx, m.model, m.opts.EntityExtractionPrompt, map[string]any{
+		"history": history,
+		"input":   input,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseEntities(output), nil
+}
+
+func (m *Entity) getInput(inputs map[string]any) (string, error) {
+	inputKey := m.opts.InputKey
+	if inputKey == "" {
+		var err error
+
+		inputKey, err = getPromptInputKey(inputs, m.MemoryKeys())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	input, ok := inputs[inputKey].(string)
+	if !ok {
+		return "", fmt.Errorf("input %s is not a string", inputKey)
+	}
+
+	return input, nil
+}
+
+// recentMessages returns the messages of the last K interactions.
+func (m *Entity) recentMessages(ctx context.Context) (schema.ChatMessages, error) {
+	messages, err := m.opts.ChatMessageHistory.Messages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start := len(messages) - int(m.opts.K)*2
+	if start > 0 {
+		messages = messages[start:]
+	}
+
+	return messages, nil
+}
+
+func (m *Entity) formatMessages(messages schema.ChatMessages) (string, error) {
+	return messages.Format(func(o *schema.StringifyChatMessagesOptions) {
+		o.HumanPrefix = m.opts.HumanPrefix
+		o.AIPrefix = m.opts.AIPrefix
+	})
+}
+
+// parseEntities parses the comma-separated list of entities returned by the extraction prompt.
+func parseEntities(output string) []string {
+	output = strings.TrimSpace(output)
+	if output == "" || strings.EqualFold(output, "NONE") {
+		return []string{}
+	}
+
+	entities := make([]string, 0)
+	seen := make(map[string]struct{})
+
+	for _, entity := range strings.Split(output, ",") {
+		entity = strings.TrimSpace(entity)
+		if entity == "" {
+			continue
+		}
+
+		if _, ok := seen[entity]; ok {
+			continue
+		}
+
+		seen[entity] = struct{}{}
+		entities = append(entities, entity)
+	}
+
+	return entities
+}
+
+// formatEntities formats the summaries as "Entity: summary" lines sorted by entity.
+func formatEntities(summaries map[string]string) string {
+	lines := make([]string, 0, len(summaries))
+
+	for entity, summary := range summaries {
+		lines = append(lines, fmt.Sprintf("%s: %s", entity, summary))
+	}
+
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// EntityStore is a store for the summaries of entities.
+type EntityStore interface {
+	// Get returns the summary of the entity or an empty string if the entity is unknown.
+	Get(ctx context.Context, entity string) (string, error)
+	// Set stores the summary of the entity.
+	Set(ctx context.Context, entity string, summary string) error
+	// Delete removes the entity from the store.
+	Delete(ctx context.Context, entity string) error
+	// Clear removes all entities from the store.
+	Clear(ctx context.Context) error
+}
+
+// Compile time check to ensure InMemoryEntityStore satisfies the EntityStore interface.
+var _ EntityStore = (*InMemoryEntityStore)(nil)
+
+// InMemoryEntityStore is an entity store, which keeps the summaries in memory.
+type InMemoryEntityStore struct {
+	entities map[string]string
+	mu       sync.RWMutex
+}
+
+// NewInMemoryEntityStore creates a new instance of InMemoryEntityStore.
+func NewInMemoryEntityStore() *InMemoryEntityStore {
+	return &InMemoryEntityStore{
+		entities: make(map[string]string),
+	}
+}
+
+// Get returns the summary of the entity or an empty string if the entity is unknown.
+func (s *InMemoryEntityStore) Get(ctx context.Context, entity string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.entities[entity], nil
+}
+
+// Set stores the summary of the entity.
+func (s *InMemoryEntityStore) Set(ctx context.Context, entity string, summary string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entities[entity] = summary
+
+	return nil
+}
+
+// Delete removes the entity from the store.
+func (s *InMemoryEntityStore) Delete(ctx context.Context, entity string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entities, entity)
+
+	return nil
+}
+
+// Clear removes all entities from the store.
+func (s *InMemoryEntityStore) Clear(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entities = make(map[string]string)
+
+	return nil
+}
+
+// RedisEntityStoreClient is an interface representing the redis client used by the RedisEntityStore.
+type RedisEntityStoreClient interface {
+	HGet(ctx context.Context, key, field string) *redis.StringCmd
+	HSet(ctx context.Context, key string, values ...interface{}) *redis.IntCmd
+	HDel(ctx context.Context, key string, fields ...string) *redis.IntCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+}
+
+// Compile time check to ensure RedisEntityStore satisfies the EntityStore interface.
+var _ EntityStore = (*RedisEntityStore)(nil)
+
+// RedisEntityStoreOptions contains options for configuring the RedisEntityStore.
+type RedisEntityStoreOptions struct {
+	KeyPrefix string
+	TTL       *time.Duration
+}
+
+// RedisEntityStore is an entity store, which keeps the summaries of a session in a redis hash.
+type RedisEntityStore struct {
+	sessionID   string
+	redisClient RedisEntityStoreClient
+	opts        RedisEntityStoreOptions
+}
+
+// NewRedisEntityStore creates a new instance of RedisEntityStore.
+func NewRedisEntityStore(redisClient RedisEntityStoreClient, sessionID string, optFns ...func(o *RedisEntityStoreOptions)) *RedisEntityStore {
+	opts := RedisEntityStoreOptions{
+		KeyPrefix: "entity_store:",
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return &RedisEntityStore{
+		sessionID:   sessionID,
+		redisClient: redisClient,
+		opts:        opts,
+	}
+}
+
+// Get returns the summary of the entity or an empty string if the entity is unknown.
+func (s *RedisEntityStore) Get(ctx context.Context, entity string) (string, error) {
+	summary, err := s.redisClient.HGet(ctx, s.key(), entity).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	return summary, nil
+}
+
+// Set stores the summary of the entity.
+func (s *RedisEntityStore) Set(ctx context.Context, entity string, summary string) error {
+	if err := s.redisClient.HSet(ctx, s.key(), entity, summary).Err(); err != nil {
+		return err
+	}
+
+	if s.opts.TTL != nil {
+		if err := s.redisClient.Expire(ctx, s.key(), *s.opts.TTL).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete removes the entity from the store.
+func (s *RedisEntityStore) Delete(ctx context.Context, entity string) error {
+	return s.redisClient.HDel(ctx, s.key(), entity).Err()
+}
+
+// Clear removes all entities from the store.
+func (s *RedisEntityStore) Clear(ctx context.Context) error {
+	return s.redisClient.Del(ctx, s.key()).Err()
+}
+
+func (s *RedisEntityStore) key() string {
+	return s.opts.KeyPrefix + s.sessionID
+}
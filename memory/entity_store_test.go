@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryEntityStore(t *testing.T) {
+	store := NewInMemoryEntityStore()
+
+	require.NoError(t, store.Set(context.TODO(), "Alice", "Alice works on Apollo."))
+
+	summary, err := store.Get(context.TODO(), "Alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice works on Apollo.", summary)
+
+	require.NoError(t, store.Delete(context.TODO(), "Alice"))
+
+	summary, err = store.Get(context.TODO(), "Alice")
+	require.NoError(t, err)
+	assert.Empty(t, summary)
+}
+
+func TestRedisEntityStore(t *testing.T) {
+	ttl := time.Hour
+	client := &mockRedisEntityStoreClient{hash: map[string]map[string]string{}}
+
+	store := NewRedisEntityStore(client, "session", func(o *RedisEntityStoreOptions) {
+		o.TTL = &ttl
+	})
+
+	summary, err := store.Get(context.TODO(), "Alice")
+	require.NoError(t, err)
+	assert.Empty(t, summary)
+
+	require.NoError(t, store.Set(context.TODO(), "Alice", "Alice works on Apollo."))
+	assert.Equal(t, map[string]string{"Alice": "Alice works on Apollo."}, client.hash["entity_store:session"])
+	assert.Equal(t, ttl, client.ttl)
+
+	summary, err = store.Get(context.TODO(), "Alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice works on Apollo.", summary)
+
+	require.NoError(t, store.Delete(context.TODO(), "Alice"))
+	assert.Empty(t, client.hash["entity_store:session"])
+
+	require.NoError(t, store.Set(context.TODO(), "Bob", "Bob works on Apollo."))
+	require.NoError(t, store.Clear(context.TODO()))
+	assert.NotContains(t, client.hash, "entity_store:session")
+}
+
+// Compile time check to ensure mockRedisEntityStoreClient satisfies the RedisEntityStoreClient interface.
+var _ RedisEntityStoreClient = (*mockRedisEntityStoreClient)(nil)
+
+type mockRedisEntityStoreClient struct {
+	hash map[string]map[string]string
+	ttl  time.Duration
+}
+
+func (c *mockRedisEntityStoreClient) HGet(ctx context.Context, key, field string) *redis.StringCmd {
+	cmd := redis.NewStringCmd(ctx)
+
+	value, ok := c.hash[key][field]
+	if !ok {
+		cmd.SetErr(redis.Nil)
+		return cmd
+	}
+
+	cmd.SetVal(value)
+
+	return cmd
+}
+
+func (c *mockRedisEntityStoreClient) HSet(ctx context.Context, key string, values ...interface{}) *redis.IntCmd {
+	if c.hash[key] == nil {
+		c.hash[key] = map[string]string{}
+	}
+
+	for i := 0; i+1 < len(values); i += 2 {
+		c.hash[key][values[i].(string)] = values[i+1].(string)
+	}
+
+	return redis.NewIntCmd(ctx)
+}
+
+func (c *mockRedisEntityStoreClient) HDel(ctx context.Context, key string, fields ...string) *redis.IntCmd {
+	for _, field := range fields {
+		delete(c.hash[key], field)
+	}
+
+	return redis.NewIntCmd(ctx)
+}
+
+func (c *mockRedisEntityStoreClient) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	for _, key := range keys {
+		delete(c.hash, key)
+	}
+
+	return redis.NewIntCmd(ctx)
+}
+
+func (c *mockRedisEntityStoreClient) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	c.ttl = expiration
+	return redis.NewBoolCmd(ctx)
+}
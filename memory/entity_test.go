@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc/model/llm"
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestEntity(t *testing.T) {
+	fake := llm.NewFake(func(ctx context.Context, prompt string) (*schema.ModelResult, error) {
+		var text string
+
+		switch {
+		case strings.Contains(prompt, "Extract all of the proper nouns"):
+			text = "NONE"
+			if strings.Contains(prompt, "(for extraction):\nHuman: Alice and Bob work on Apollo") {
+				text = "Alice, Bob, Alice"
+			} else if strings.Contains(prompt, "(for extraction):\nHuman: What does Alice do?") {
+				text = "Alice"
+			}
+		case strings.Contains(prompt, "Entity to summarize:\nAlice"):
+			text = "Alice works on Apollo."
+		case strings.Contains(prompt, "Entity to summarize:\nBob"):
+			text = "Bob works on Apollo."
+		}
+
+		return &schema.ModelResult{
+			Generations: []schema.Generation{{Text: text}},
+		}, nil
+	})
+
+	store := NewInMemoryEntityStore()
+
+	m := NewEntity(fake, func(o *EntityOptions) {
+		o.EntityStore = store
+	})
+
+	t.Run("MemoryKeys", func(t *testing.T) {
+		assert.Equal(t, []string{"history", "entities", "entityNames"}, m.MemoryKeys())
+	})
+
+	t.Run("SaveContext", func(t *testing.T) {
+		vars, err := m.LoadMemoryVariables(context.TODO(), map[string]any{"input": "Alice and Bob work on Apollo"})
+		require.NoError(t, err)
+		assert.Equal(t, "Alice: \nBob: ", vars["entities"])
+		assert.Equal(t, []string{"Alice", "Bob"}, vars["entityNames"])
+
+		inputs := map[string]any{"input": "Alice and Bob work on Apollo"}
+		for k, v := range vars {
+			inputs[k] = v
+		}
+
+		require.NoError(t, m.SaveContext(context.TODO(), inputs, map[string]any{"output": "Got it"}))
+
+		summary, err := store.Get(context.TODO(), "Alice")
+		require.NoError(t, err)
+		assert.Equal(t, "Alice works on Apollo.", summary)
+
+		summary, err = store.Get(context.TODO(), "Bob")
+		require.NoError(t, err)
+		assert.Equal(t, "Bob works on Apollo.", summary)
+	})
+
+	t.Run("LoadMemoryVariables", func(t *testing.T) {
+		vars, err := m.LoadMemoryVariables(context.TODO(), map[string]any{"input": "What does Alice do?"})
+		require.NoError(t, err)
+		assert.Equal(t, "Human: Alice and Bob work on Apollo\nAI: Got it", vars["history"])
+		assert.Equal(t, "Alice: Alice works on Apollo.", vars["entities"])
+
+		m.opts.ReturnMessages = true
+
+		vars, err = m.LoadMemoryVariables(context.TODO(), map[string]any{"input": "Hi"})
+		require.NoError(t, err)
+		assert.Len(t, vars["history"], 2)
+		assert.Equal(t, map[string]string{}, vars["entities"])
+	})
+
+	t.Run("Combined", func(t *testing.T) {
+		combined, err := NewCombined(m, NewConversationBuffer(func(o *ConversationBufferOptions) {
+			o.MemoryKey = "buffer"
+		}))
+		require.NoError(t, err)
+
+		vars, err := combined.LoadMemoryVariables(context.TODO(), map[string]any{"input": "What does Alice do?"})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"history", "entities", "entityNames", "buffer"}, combined.MemoryKeys())
+		assert.Equal(t, map[string]string{"Alice": "Alice works on Apollo."}, vars["entities"])
+	})
+
+	t.Run("InterleavedCalls", func(t *testing.T) {
+		// The entities of a call are passed with its inputs, so concurrent calls don't update the
+		// summaries of each other's entities.
+		varsA, err := m.LoadMemoryVariables(context.TODO(), map[string]any{"input": "What does Alice do?"})
+		require.NoError(t, err)
+
+		varsB, err := m.LoadMemoryVariables(context.TODO(), map[string]any{"input": "Hi"})
+		require.NoError(t, err)
+		assert.Empty(t, varsB["entityNames"])
+
+		require.NoError(t, store.Set(context.TODO(), "Alice", "outdated"))
+
+		inputs := map[string]any{"input": "What does Alice do?"}
+		for k, v := range varsA {
+			inputs[k] = v
+		}
+
+		require.NoError(t, m.SaveContext(context.TODO(), inputs, map[string]any{"output": "She works on Apollo."}))
+
+		summary, err := store.Get(context.TODO(), "Alice")
+		require.NoError(t, err)
+		assert.Equal(t, "Alice works on Apollo.", summary)
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		require.NoError(t, m.Clear(context.TODO()))
+
+		summary, err := store.Get(context.TODO(), "Alice")
+		require.NoError(t, err)
+		assert.Empty(t, summary)
+	})
+}
+
+func TestParseEntities(t *testing.T) {
+	assert.Equal(t, []string{}, parseEntities(" NONE "))
+	assert.Equal(t, []string{"Alice", "Project X"}, parseEntities("Alice, Project X,, Alice"))
+}
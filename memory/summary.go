@@ -0,0 +1,451 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hupe1980/golc/chatmessagehistory"
+	"github.com/hupe1980/golc/model"
+	"github.com/hupe1980/golc/prompt"
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure ConversationSummary satisfies the Memory interface.
+var _ schema.Memory = (*ConversationSummary)(nil)
+
+// SummaryRole is the role of the generic chat message, which persists the running summary
+// as the first message of the chat message history.
+const SummaryRole = "summary"
+
+const defaultSummaryTemplate = `Progressively summarize the lines of conversation provided, adding onto the previous summary returning a new summary.
+
+EXAMPLE
+Current summary:
+The human asks what the AI thinks of artificial intelligence. The AI thinks artificial intelligence is a force for good.
+
+New lines of conversation:
+Human: Why do you think artificial intelligence is a force for good?
+AI: Because artificial intelligence will help humans reach their full potential.
+
+New summary:
+The human asks what the AI thinks of artificial intelligence. The AI thinks artificial intelligence is a force for good because it will help humans reach their full potential.
+END OF EXAMPLE
+
+Current summary:
+{{.summary}}
+
+New lines of conversation:
+{{.newLines}}
+
+New summary:`
+
+// ConversationSummaryOptions contains options for configuring the ConversationSummary memory type.
+type ConversationSummaryOptions struct {
+	HumanPrefix        string
+	AIPrefix           string
+	MemoryKey          string
+	InputKey           string
+	OutputKey          string
+	ReturnMessages     bool
+	ChatMessageHistory schema.ChatMessageHistory
+
+	// SummaryPrompt is the prompt used to extend the summary with new lines of conversation.
+	// It receives the variables "summary" and "newLines".
+	SummaryPrompt schema.PromptTemplate
+}
+
+// ConversationSummary is a memory type that progressively summarizes the conversation with a model.
+// The running summary is persisted in the chat message history.
+type ConversationSummary struct {
+	model schema.Model
+	opts  ConversationSummaryOptions
+}
+
+// NewConversationSummary creates a new instance of ConversationSummary memory type.
+func NewConversationSummary(model schema.Model, optFns ...func(o *ConversationSummaryOptions)) *ConversationSummary {
+	opts := ConversationSummaryOptions{
+		HumanPrefix:    "Human",
+		AIPrefix:       "AI",
+		MemoryKey:      "history",
+		InputKey:       "",
+		OutputKey:      "",
+		ReturnMessages: false,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.ChatMessageHistory == nil {
+		opts.ChatMessageHistory = chatmessagehistory.NewInMemory()
+	}
+
+	if opts.SummaryPrompt == nil {
+		opts.SummaryPrompt = prompt.NewTemplate(defaultSummaryTemplate)
+	}
+
+	return &ConversationSummary{
+		model: model,
+		opts:  opts,
+	}
+}
+
+// MemoryKeys returns the memory keys for ConversationSummary.
+func (m *ConversationSummary) MemoryKeys() []string {
+	return []string{m.opts.MemoryKey}
+}
+
+// LoadMemoryVariables returns key-value pairs given the text input to the chain.
+func (m *ConversationSummary) LoadMemoryVariables(ctx context.Context, inputs map[string]any) (map[string]any, error) {
+	messages, err := m.opts.ChatMessageHistory.Messages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, _ := splitSummary(messages)
+
+	if m.opts.ReturnMessages {
+		messages := schema.ChatMessages{}
+		if summary != "" {
+			messages = append(messages, schema.NewSystemChatMessage(summary))
+		}
+
+		return map[string]any{
+			m.opts.MemoryKey: messages,
+		}, nil
+	}
+
+	return map[string]any{
+		m.opts.MemoryKey: summary,
+	}, nil
+}
+
+// SaveContext extends the running summary with the input and output messages and persists it
+// in the chat message history.
+func (m *ConversationSummary) SaveContext(ctx context.Context, inputs map[string]any, outputs map[string]any) error {
+	input, output, err := getInputOutput(inputs, outputs, m.opts.InputKey, m.opts.OutputKey, m.MemoryKeys())
+	if err != nil {
+		return err
+	}
+
+	messages, err := m.opts.ChatMessageHistory.Messages(ctx)
+	if err != nil {
+		return err
+	}
+
+	summary, unsummarized := splitSummary(messages)
+
+	newLines := make(schema.ChatMessages, 0, len(unsummarized)+2)
+	newLines = append(newLines, unsummarized...)
+	newLines = append(newLines, schema.NewHumanChatMessage(input), schema.NewAIChatMessage(output))
+
+	summary, err = predictNewSummary(ctx, m.model, m.opts.SummaryPrompt, summary, newLines, m.opts.HumanPrefix, m.opts.AIPrefix)
+	if err != nil {
+		return err
+	}
+
+	return replaceHistory(ctx, m.opts.ChatMessageHistory, messages, summary, nil)
+}
+
+// Clear clears the chat message history including the summary.
+func (m *ConversationSummary) Clear(ctx context.Context) error {
+	return m.opts.ChatMessageHistory.Clear(ctx)
+}
+
+// ConversationSummaryBufferOptions contains options for configuring the ConversationSummaryBuffer memory type.
+type ConversationSummaryBufferOptions struct {
+	HumanPrefix        string
+	AIPrefix           string
+	SystemPrefix       string
+	MemoryKey          string
+	InputKey           string
+	OutputKey          string
+	ReturnMessages     bool
+	ChatMessageHistory schema.ChatMessageHistory
+
+	// SummaryPrompt is the prompt used to extend the summary with the evicted lines of conversation.
+	// It receives the variables "summary" and "newLines".
+	SummaryPrompt schema.PromptTemplate
+
+	// Tokenizer measures the size of the recent messages. Default is the model.
+	Tokenizer schema.Tokenizer
+
+	// MaxTokenLimit is the maximum number of tokens of the recent messages kept verbatim.
+	MaxTokenLimit uint
+}
+
+// Compile time check to ensure ConversationSummaryBuffer satisfies the Memory interface.
+var _ schema.Memory = (*ConversationSummaryBuffer)(nil)
+
+// ConversationSummaryBuffer is a memory type that keeps the recent messages verbatim up to a token limit
+// and progressively summarizes the evicted messages with a model.
+// The running summary is persisted in the chat message history.
+type ConversationSummaryBuffer struct {
+	model schema.Model
+	opts  ConversationSummaryBufferOptions
+}
+
+// NewConversationSummaryBuffer creates a new instance of ConversationSummaryBuffer memory type.
+func NewConversationSummaryBuffer(model schema.Model, optFns ...func(o *ConversationSummaryBufferOptions)) *ConversationSummaryBuffer {
+	opts := ConversationSummaryBufferOptions{
+		HumanPrefix:    "Human",
+		AIPrefix:       "AI",
+		SystemPrefix:   "System",
+		MemoryKey:      "history",
+		InputKey:       "",
+		OutputKey:      "",
+		ReturnMessages: false,
+		MaxTokenLimit:  2000,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.ChatMessageHistory == nil {
+		opts.ChatMessageHistory = chatmessagehistory.NewInMemory()
+	}
+
+	if opts.SummaryPrompt == nil {
+		opts.SummaryPrompt = prompt.NewTemplate(defaultSummaryTemplate)
+	}
+
+	if opts.Tokenizer == nil {
+		opts.Tokenizer = model
+	}
+
+	return &ConversationSummaryBuffer{
+		model: model,
+		opts:  opts,
+	}
+}
+
+// MemoryKeys returns the memory keys for ConversationSummaryBuffer.
+func (m *ConversationSummaryBuffer) MemoryKeys() []string {
+	return []string{m.opts.MemoryKey}
+}
+
+// LoadMemoryVariables returns key-value pairs given the text input to the chain.
+// The summary is returned as a system message followed by the recent messages.
+func (m *ConversationSummaryBuffer) LoadMemoryVariables(ctx context.Context, inputs map[string]any) (map[string]any, error) {
+	messages, err := m.opts.ChatMessageHistory.Messages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, buffer := splitSummary(messages)
+
+	if summary != "" {
+		buffer = append(schema.ChatMessages{schema.NewSystemChatMessage(summary)}, buffer...)
+	}
+
+	if m.opts.ReturnMessages {
+		return map[string]any{
+			m.opts.MemoryKey: buffer,
+		}, nil
+	}
+
+	text, err := buffer.Format(func(o *schema.StringifyChatMessagesOptions) {
+		o.HumanPrefix = m.opts.HumanPrefix
+		o.AIPrefix = m.opts.AIPrefix
+		o.SystemPrefix = m.opts.SystemPrefix
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		m.opts.MemoryKey: text,
+	}, nil
+}
+
+// SaveContext saves the input and output messages to the chat message history. If the recent messages
+// exceed the token limit, the oldest messages are evicted and summarized.
+func (m *ConversationSummaryBuffer) SaveContext(ctx context.Context, inputs map[string]any, outputs map[string]any) error {
+	input, output, err := getInputOutput(inputs, outputs, m.opts.InputKey, m.opts.OutputKey, m.MemoryKeys())
+	if err != nil {
+		return err
+	}
+
+	if err := m.opts.ChatMessageHistory.AddUserMessage(ctx, input); err != nil {
+		return err
+	}
+
+	if err := m.opts.ChatMessageHistory.AddAIMessage(ctx, output); err != nil {
+		return err
+	}
+
+	return m.prune(ctx)
+}
+
+// prune evicts the oldest messages until the recent messages fit into the token limit
+// and extends the summary with the evicted messages.
+func (m *ConversationSummaryBuffer) prune(ctx context.Context) error {
+	messages, err := m.opts.ChatMessageHistory.Messages(ctx)
+	if err != nil {
+		return err
+	}
+
+	summary, buffer := splitSummary(messages)
+
+	evicted := 0
+
+	for evicted < len(buffer) {
+		numTokens, err := m.getNumTokensForMessages(ctx, buffer[evicted:])
+		if err != nil {
+			return err
+		}
+
+		if numTokens <= m.opts.MaxTokenLimit {
+			break
+		}
+
+		evicted++
+	}
+
+	if evicted == 0 {
+		return nil
+	}
+
+	summary, err = predictNewSummary(ctx, m.model, m.opts.SummaryPrompt, summary, buffer[:evicted], m.opts.HumanPrefix, m.opts.AIPrefix)
+	if err != nil {
+		return err
+	}
+
+	return replaceHistory(ctx, m.opts.ChatMessageHistory, messages, summary, buffer[evicted:])
+}
+
+// Clear clears the chat message history including the summary.
+func (m *ConversationSummaryBuffer) Clear(ctx context.Context) error {
+	return m.opts.ChatMessageHistory.Clear(ctx)
+}
+
+func (m *ConversationSummaryBuffer) getNumTokensForMessages(ctx context.Context, messages schema.ChatMessages) (uint, error) {
+	buffer, err := messages.Format(func(o *schema.StringifyChatMessagesOptions) {
+		o.HumanPrefix = m.opts.HumanPrefix
+		o.AIPrefix = m.opts.AIPrefix
+		o.SystemPrefix = m.opts.SystemPrefix
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return m.opts.Tokenizer.GetNumTokens(ctx, buffer)
+}
+
+// predictNewSummary extends the summary with the new lines of conversation.
+func predictNewSummary(ctx context.Context, m schema.Model, summaryPrompt schema.PromptTemplate, summary string, newLines schema.ChatMessages, humanPrefix, aiPrefix string) (string, error) {
+	lines, err := newLines.Format(func(o *schema.StringifyChatMessagesOptions) {
+		o.HumanPrefix = humanPrefix
+		o.AIPrefix = aiPrefix
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return predict(ctx, m, summaryPrompt, map[string]any{
+		"summary":  summary,
+		"newLines": lines,
+	})
+}
+
+// predict formats the prompt with the values and returns the trimmed text generated by the model.
+func predict(ctx context.Context, m schema.Model, promptTemplate schema.PromptTemplate, values map[string]any) (string, error) {
+	promptValue, err := promptTemplate.FormatPrompt(values)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := model.GeneratePrompt(ctx, m, promptValue)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(result.Generations[0].Text), nil
+}
+
+// splitSummary splits the messages of a chat message history into the persisted summary and the remaining messages.
+func splitSummary(messages schema.ChatMessages) (string, schema.ChatMessages) {
+	if len(messages) > 0 {
+		if gm, ok := messages[0].(*schema.GenericChatMessage); ok && gm.Role() == SummaryRole {
+			return gm.Content(), messages[1:]
+		}
+	}
+
+	return "", messages
+}
+
+// replaceHistory replaces the old messages of the chat message history with the summary and the messages.
+// The new history is built before the history is changed. If it can't be written, the old messages are
+// restored, so that a failed write doesn't lose the conversation.
+func replaceHistory(ctx context.Context, history schema.ChatMessageHistory, oldMessages schema.ChatMessages, summary string, messages schema.ChatMessages) error {
+	newMessages := make(schema.ChatMessages, 0, len(messages)+1)
+
+	if summary != "" {
+		newMessages = append(newMessages, schema.NewGenericChatMessage(summary, SummaryRole))
+	}
+
+	newMessages = append(newMessages, messages...)
+
+	if err := setMessages(ctx, history, newMessages); err != nil {
+		if restoreErr := setMessages(ctx, history, oldMessages); restoreErr != nil {
+			return errors.Join(err, fmt.Errorf("restore chat message history: %w", restoreErr))
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// setMessages sets the messages of the chat message history.
+func setMessages(ctx context.Context, history schema.ChatMessageHistory, messages schema.ChatMessages) error {
+	if err := history.Clear(ctx); err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if err := history.AddMessage(ctx, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getInputOutput returns the input and output texts of a model run.
+func getInputOutput(inputs map[string]any, outputs map[string]any, inputKey, outputKey string, memoryKeys []string) (string, string, error) {
+	if inputKey == "" {
+		var err error
+
+		inputKey, err = getPromptInputKey(inputs, memoryKeys)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	input, ok := inputs[inputKey].(string)
+	if !ok {
+		return "", "", fmt.Errorf("input %s is not a string", inputKey)
+	}
+
+	if outputKey == "" {
+		if len(outputs) != 1 {
+			return "", "", fmt.Errorf("multiple output keys. Only one output key expected, got %d", len(outputs))
+		}
+
+		for key := range outputs {
+			outputKey = key
+			break
+		}
+	}
+
+	output, ok := outputs[outputKey].(string)
+	if !ok {
+		return "", "", errors.New("output is not a string")
+	}
+
+	return input, output, nil
+}
@@ -0,0 +1,178 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc/chatmessagehistory"
+	"github.com/hupe1980/golc/model/llm"
+	"github.com/hupe1980/golc/schema"
+	"github.com/hupe1980/golc/tokenizer"
+)
+
+// newFakeSummarizer returns a fake model, which appends the new lines of conversation to the current summary.
+func newFakeSummarizer(calls *int) *llm.Fake {
+	return llm.NewFake(func(ctx context.Context, prompt string) (*schema.ModelResult, error) {
+		*calls++
+
+		parts := strings.Split(prompt, "Current summary:\n")
+		current := parts[len(parts)-1]
+
+		summary, rest, _ := strings.Cut(current, "\n\nNew lines of conversation:\n")
+		newLines, _, _ := strings.Cut(rest, "\n\nNew summary:")
+
+		text := strings.TrimSpace(summary + " " + strings.ReplaceAll(newLines, "\n", " "))
+
+		return &schema.ModelResult{
+			Generations: []schema.Generation{{Text: text}},
+		}, nil
+	})
+}
+
+func TestConversationSummary(t *testing.T) {
+	calls := 0
+	history := chatmessagehistory.NewInMemory()
+
+	m := NewConversationSummary(newFakeSummarizer(&calls), func(o *ConversationSummaryOptions) {
+		o.ChatMessageHistory = history
+	})
+
+	t.Run("MemoryKeys", func(t *testing.T) {
+		assert.Equal(t, []string{"history"}, m.MemoryKeys())
+	})
+
+	t.Run("SaveContext", func(t *testing.T) {
+		require.NoError(t, m.SaveContext(context.TODO(), map[string]any{"input": "Hello"}, map[string]any{"output": "Hi there"}))
+		require.NoError(t, m.SaveContext(context.TODO(), map[string]any{"input": "Bye"}, map[string]any{"output": "Goodbye"}))
+
+		assert.Equal(t, 2, calls)
+
+		messages, err := history.Messages(context.TODO())
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Equal(t, schema.NewGenericChatMessage("Human: Hello AI: Hi there Human: Bye AI: Goodbye", SummaryRole), messages[0])
+	})
+
+	t.Run("LoadMemoryVariables", func(t *testing.T) {
+		vars, err := m.LoadMemoryVariables(context.TODO(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Human: Hello AI: Hi there Human: Bye AI: Goodbye", vars["history"])
+
+		m.opts.ReturnMessages = true
+
+		vars, err = m.LoadMemoryVariables(context.TODO(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChatMessages{schema.NewSystemChatMessage("Human: Hello AI: Hi there Human: Bye AI: Goodbye")}, vars["history"])
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		require.NoError(t, m.Clear(context.TODO()))
+
+		vars, err := m.LoadMemoryVariables(context.TODO(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChatMessages{}, vars["history"])
+	})
+}
+
+func TestConversationSummaryBuffer(t *testing.T) {
+	gpt2, err := tokenizer.NewGPT2()
+	require.NoError(t, err)
+
+	t.Run("NoPruning", func(t *testing.T) {
+		calls := 0
+
+		m := NewConversationSummaryBuffer(newFakeSummarizer(&calls), func(o *ConversationSummaryBufferOptions) {
+			o.Tokenizer = gpt2
+		})
+
+		require.NoError(t, m.SaveContext(context.TODO(), map[string]any{"input": "Hello"}, map[string]any{"output": "Hi there"}))
+
+		vars, err := m.LoadMemoryVariables(context.TODO(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Human: Hello\nAI: Hi there", vars["history"])
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("Pruning", func(t *testing.T) {
+		calls := 0
+		history := chatmessagehistory.NewInMemory()
+
+		m := NewConversationSummaryBuffer(newFakeSummarizer(&calls), func(o *ConversationSummaryBufferOptions) {
+			o.Tokenizer = gpt2
+			o.ChatMessageHistory = history
+			o.MaxTokenLimit = 10
+		})
+
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, m.SaveContext(context.TODO(),
+				map[string]any{"input": fmt.Sprintf("Hello%d", i)},
+				map[string]any{"output": fmt.Sprintf("Hi there%d", i)},
+			))
+		}
+
+		assert.Equal(t, 2, calls)
+
+		messages, err := history.Messages(context.TODO())
+		require.NoError(t, err)
+		require.Len(t, messages, 3)
+		assert.Equal(t, schema.NewGenericChatMessage("Human: Hello1 AI: Hi there1 Human: Hello2 AI: Hi there2", SummaryRole), messages[0])
+
+		vars, err := m.LoadMemoryVariables(context.TODO(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "System: Human: Hello1 AI: Hi there1 Human: Hello2 AI: Hi there2\nHuman: Hello3\nAI: Hi there3", vars["history"])
+
+		m.opts.ReturnMessages = true
+
+		vars, err = m.LoadMemoryVariables(context.TODO(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, schema.ChatMessages{
+			schema.NewSystemChatMessage("Human: Hello1 AI: Hi there1 Human: Hello2 AI: Hi there2"),
+			schema.NewHumanChatMessage("Hello3"),
+			schema.NewAIChatMessage("Hi there3"),
+		}, vars["history"])
+	})
+}
+
+func TestReplaceHistory(t *testing.T) {
+	oldMessages := schema.ChatMessages{
+		schema.NewHumanChatMessage("Hello"),
+		schema.NewAIChatMessage("Hi there"),
+	}
+
+	history := &failingChatMessageHistory{
+		ChatMessageHistory: chatmessagehistory.NewInMemory(),
+	}
+
+	for _, message := range oldMessages {
+		require.NoError(t, history.AddMessage(context.TODO(), message))
+	}
+
+	history.failSummary = true
+
+	err := replaceHistory(context.TODO(), history, oldMessages, "Human: Hello AI: Hi there", nil)
+	assert.EqualError(t, err, "write error")
+
+	messages, err := history.Messages(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, oldMessages, messages)
+}
+
+// failingChatMessageHistory is a chat message history, which fails to add summaries.
+type failingChatMessageHistory struct {
+	schema.ChatMessageHistory
+	failSummary bool
+}
+
+func (h *failingChatMessageHistory) AddMessage(ctx context.Context, message schema.ChatMessage) error {
+	if gm, ok := message.(*schema.GenericChatMessage); ok && h.failSummary && gm.Role() == SummaryRole {
+		return errors.New("write error")
+	}
+
+	return h.ChatMessageHistory.AddMessage(ctx, message)
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure VectorStore satisfies the Memory interface.
+var _ schema.Memory = (*VectorStore)(nil)
+
+// VectorStoreOptions contains options for configuring the VectorStore memory type.
+type VectorStoreOptions struct {
+	HumanPrefix string
+	AIPrefix    string
+	MemoryKey   string
+	InputKey    string
+	OutputKey   string
+
+	// ReturnDocs returns the retrieved exchanges as documents instead of a single string.
+	ReturnDocs bool
+
+	// TopK is the number of past exchanges retrieved for the current input. If zero, the default of the vector store is used.
+	TopK int
+
+	// Metadata is added to the metadata of every stored exchange, e.g. to separate the memories of different users.
+	Metadata map[string]any
+
+	// Filter restricts the retrieval to exchanges with matching metadata.
+	Filter *schema.MetadataFilter
+}
+
+// VectorStore is a memory type that stores every input/output exchange in a vector store and
+// recalls the past exchanges, which are semantically related to the current input.
+// It allows to remember facts across sessions without replaying the whole conversation.
+type VectorStore struct {
+	vectorStore schema.VectorStore
+	opts        VectorStoreOptions
+}
+
+// NewVectorStore creates a new instance of VectorStore memory type.
+func NewVectorStore(vectorStore schema.VectorStore, optFns ...func(o *VectorStoreOptions)) *VectorStore {
+	opts := VectorStoreOptions{
+		HumanPrefix: "Human",
+		AIPrefix:    "AI",
+		MemoryKey:   "history",
+		InputKey:    "",
+		OutputKey:   "",
+		ReturnDocs:  false,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return &VectorStore{
+		vectorStore: vectorStore,
+		opts:        opts,
+	}
+}
+
+// MemoryKeys returns the memory keys for VectorStore.
+func (m *VectorStore) MemoryKeys() []string {
+	return []string{m.opts.MemoryKey}
+}
+
+// LoadMemoryVariables returns the past exchanges related to the input of the chain.
+func (m *VectorStore) LoadMemoryVariables(ctx context.Context, inputs map[string]any) (map[string]any, error) {
+	inputKey := m.opts.InputKey
+	if inputKey == "" {
+		var err error
+
+		inputKey, err = getPromptInputKey(inputs, m.MemoryKeys())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	query, ok := inputs[inputKey].(string)
+	if !ok {
+		return nil, fmt.Errorf("input %s is not a string", inputKey)
+	}
+
+	docs, err := m.vectorStore.SimilaritySearch(ctx, query, func(o *schema.VectorStoreSearchOptions) {
+		o.TopK = m.opts.TopK
+		o.Filter = m.opts.Filter
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if m.opts.ReturnDocs {
+		return map[string]any{
+			m.opts.MemoryKey: docs,
+		}, nil
+	}
+
+	return map[string]any{
+		m.opts.MemoryKey: strings.Join(util.Map(docs, func(doc schema.Document, _ int) string {
+			return doc.PageContent
+		}), "\n"),
+	}, nil
+}
+
+// SaveContext stores the input/output exchange in the vector store.
+func (m *VectorStore) SaveContext(ctx context.Context, inputs map[string]any, outputs map[string]any) error {
+	input, output, err := getInputOutput(inputs, outputs, m.opts.InputKey, m.opts.OutputKey, m.MemoryKeys())
+	if err != nil {
+		return err
+	}
+
+	metadata := make(map[string]any, len(m.opts.Metadata)+1)
+	for k, v := range m.opts.Metadata {
+		metadata[k] = v
+	}
+
+	metadata["timestamp"] = time.Now().Unix()
+
+	_, err = m.vectorStore.AddDocuments(ctx, []schema.Document{{
+		PageContent: fmt.Sprintf("%s: %s\n%s: %s", m.opts.HumanPrefix, input, m.opts.AIPrefix, output),
+		Metadata:    metadata,
+	}})
+
+	return err
+}
+
+// Clear is a no-op, the exchanges remain in the vector store as long-term memory.
+// Use the Delete method of the vector store to remove them.
+func (m *VectorStore) Clear(ctx context.Context) error {
+	return nil
+}
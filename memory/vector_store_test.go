@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestVectorStore(t *testing.T) {
+	t.Run("SaveContext", func(t *testing.T) {
+		vs := &mockVectorStore{}
+
+		m := NewVectorStore(vs, func(o *VectorStoreOptions) {
+			o.Metadata = map[string]any{"user": "alice"}
+		})
+
+		err := m.SaveContext(context.TODO(), map[string]any{"input": "I prefer window seats"}, map[string]any{"output": "Noted!"})
+		require.NoError(t, err)
+
+		require.Len(t, vs.docs, 1)
+		assert.Equal(t, "Human: I prefer window seats\nAI: Noted!", vs.docs[0].PageContent)
+		assert.Equal(t, "alice", vs.docs[0].Metadata["user"])
+		assert.NotZero(t, vs.docs[0].Metadata["timestamp"])
+	})
+
+	t.Run("LoadMemoryVariables", func(t *testing.T) {
+		filter := schema.FilterEq("user", "alice")
+
+		vs := &mockVectorStore{
+			similaritySearchFn: func(ctx context.Context, query string, opts schema.VectorStoreSearchOptions) ([]schema.Document, error) {
+				assert.Equal(t, "Book a flight", query)
+				assert.Equal(t, schema.VectorStoreSearchOptions{TopK: 2, Filter: filter}, opts)
+
+				return []schema.Document{
+					{PageContent: "Human: I prefer window seats\nAI: Noted!"},
+					{PageContent: "Human: I live in Berlin\nAI: Nice!"},
+				}, nil
+			},
+		}
+
+		m := NewVectorStore(vs, func(o *VectorStoreOptions) {
+			o.TopK = 2
+			o.Filter = filter
+		})
+
+		vars, err := m.LoadMemoryVariables(context.TODO(), map[string]any{"input": "Book a flight"})
+		require.NoError(t, err)
+		assert.Equal(t, "Human: I prefer window seats\nAI: Noted!\nHuman: I live in Berlin\nAI: Nice!", vars["history"])
+
+		m.opts.ReturnDocs = true
+
+		vars, err = m.LoadMemoryVariables(context.TODO(), map[string]any{"input": "Book a flight"})
+		require.NoError(t, err)
+		assert.Len(t, vars["history"], 2)
+	})
+
+	t.Run("MultipleInputKeys", func(t *testing.T) {
+		m := NewVectorStore(&mockVectorStore{})
+
+		_, err := m.LoadMemoryVariables(context.TODO(), map[string]any{"input": "foo", "other": "bar"})
+		assert.Error(t, err)
+	})
+}
+
+// Compile time check to ensure mockVectorStore satisfies the VectorStore interface.
+var _ schema.VectorStore = (*mockVectorStore)(nil)
+
+type mockVectorStore struct {
+	docs               []schema.Document
+	similaritySearchFn func(ctx context.Context, query string, opts schema.VectorStoreSearchOptions) ([]schema.Document, error)
+}
+
+func (m *mockVectorStore) AddDocuments(ctx context.Context, docs []schema.Document) ([]string, error) {
+	m.docs = append(m.docs, docs...)
+	return make([]string, len(docs)), nil
+}
+
+func (m *mockVectorStore) Delete(ctx context.Context, ids []string) error {
+	return nil
+}
+
+func (m *mockVectorStore) SimilaritySearch(ctx context.Context, query string, optFns ...func(o *schema.VectorStoreSearchOptions)) ([]schema.Document, error) {
+	opts := schema.VectorStoreSearchOptions{}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return m.similaritySearchFn(ctx, query, opts)
+}
+
+func (m *mockVectorStore) SimilaritySearchWithScore(ctx context.Context, query string, optFns ...func(o *schema.VectorStoreSearchOptions)) ([]schema.ScoredDocument, error) {
+	return nil, nil
+}
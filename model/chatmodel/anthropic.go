@@ -2,6 +2,8 @@ package chatmodel
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -9,6 +11,7 @@ import (
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/integration/anthropic"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -24,6 +27,7 @@ var _ schema.ChatModel = (*Anthropic)(nil)
 // AnthropicClient is the interface for the Anthropic client.
 type AnthropicClient interface {
 	CreateCompletion(ctx context.Context, request *anthropic.CompletionRequest) (*anthropic.CompletionResponse, error)
+	CreateMessage(ctx context.Context, request *anthropic.MessageRequest) (*anthropic.MessageResponse, error)
 }
 
 // AnthropicOptions contains options for configuring the Anthropic chat model.
@@ -102,6 +106,11 @@ func (cm *Anthropic) Generate(ctx context.Context, messages schema.ChatMessages,
 		fn(&opts)
 	}
 
+	if len(opts.Functions) > 0 || hasContentParts(messages) {
+		// Tool use and multimodal content are only available through the messages api.
+		return cm.generateMessage(ctx, messages, opts)
+	}
+
 	prompt, err := convertMessagesToAnthropicPrompt(messages)
 	if err != nil {
 		return nil, err
@@ -120,9 +129,59 @@ func (cm *Anthropic) Generate(ctx context.Context, messages schema.ChatMessages,
 		return nil, err
 	}
 
-	return &schema.ModelResult{
+	result := &schema.ModelResult{
 		Generations: []schema.Generation{newChatGeneraton(res.Completion)},
 		LLMOutput:   map[string]any{},
+	}
+
+	// The completions api does not return the token usage, so the tokens are counted with the tokenizer.
+	// The token usage is best effort, so a failed count does not fail the generation.
+	if tokenUsage, err := tokenizerTokenUsage(ctx, cm.Tokenizer, messages, res.Completion); err == nil {
+		result.LLMOutput["TokenUsage"] = tokenUsage
+	}
+
+	return result, nil
+}
+
+// Stream streams the text generated for the provided chat messages.
+// Streaming is not supported natively, so the stream only has the final result chunk.
+func (cm *Anthropic) Stream(ctx context.Context, messages schema.ChatMessages, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.ChatModelStream(ctx, cm, messages, optFns...)
+}
+
+// generateMessage generates a message using the anthropic messages api, with the functions as tools.
+func (cm *Anthropic) generateMessage(ctx context.Context, messages schema.ChatMessages, opts schema.GenerateOptions) (*schema.ModelResult, error) {
+	system, anthropicMessages, err := convertMessagesToAnthropicMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := cm.client.CreateMessage(ctx, &anthropic.MessageRequest{
+		Model:         cm.opts.ModelName,
+		Messages:      anthropicMessages,
+		System:        system,
+		MaxTokens:     cm.opts.MaxTokens,
+		Temperature:   cm.opts.Temperature,
+		TopK:          cm.opts.TopK,
+		TopP:          cm.opts.TopP,
+		StopSequences: opts.Stop,
+		Tools:         convertFunctionsToAnthropicTools(opts.Functions),
+		ToolChoice:    anthropicToolChoice(opts.Functions, opts.ForceFunctionCall),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.ModelResult{
+		Generations: []schema.Generation{anthropicMessageResponseToGeneration(res)},
+		LLMOutput: map[string]any{
+			"ModelName": res.Model,
+			"TokenUsage": map[string]int{
+				"PromptTokens":     res.Usage.InputTokens,
+				"CompletionTokens": res.Usage.OutputTokens,
+				"TotalTokens":      res.Usage.InputTokens + res.Usage.OutputTokens,
+			},
+		},
 	}, nil
 }
 
@@ -171,3 +230,161 @@ func convertMessagesToAnthropicPrompt(messages schema.ChatMessages) (string, err
 
 	return strings.TrimRight(prompt, " "), nil
 }
+
+// convertMessagesToAnthropicMessages converts chat messages to the system prompt and the messages of the anthropic messages api.
+// Tool calls and tool results are mapped to tool_use and tool_result blocks with the ids of the tool calls.
+// Only function calls without id, e.g. of messages created before tool calls were supported, get a new id,
+// which function results refer to in the order of the calls.
+func convertMessagesToAnthropicMessages(messages schema.ChatMessages) (string, []anthropic.Message, error) {
+	var (
+		system  []string
+		result  []anthropic.Message
+		pending []string
+	)
+
+	appendBlock := func(role string, block anthropic.ContentBlock) {
+		// Consecutive turns of the same role are merged into one message.
+		if len(result) > 0 && result[len(result)-1].Role == role {
+			result[len(result)-1].Content = append(result[len(result)-1].Content, block)
+			return
+		}
+
+		result = append(result, anthropic.Message{Role: role, Content: []anthropic.ContentBlock{block}})
+	}
+
+	for _, message := range messages {
+		switch m := message.(type) {
+		case *schema.SystemChatMessage:
+			system = append(system, m.Content())
+		case *schema.HumanChatMessage:
+			if len(m.Parts()) == 0 {
+				appendBlock("user", anthropic.ContentBlock{Type: "text", Text: m.Content()})
+				continue
+			}
+
+			for _, p := range m.ContentParts() {
+				block, err := convertContentPartToAnthropicBlock(p)
+				if err != nil {
+					return "", nil, err
+				}
+
+				appendBlock("user", block)
+			}
+		case *schema.AIChatMessage:
+			if m.Content() != "" {
+				appendBlock("assistant", anthropic.ContentBlock{Type: "text", Text: m.Content()})
+			}
+
+			for _, tc := range toolCallsFromExtension(m.Extension()) {
+				id := tc.ID
+				if id == "" {
+					id = newToolCallID()
+				}
+
+				input := json.RawMessage(tc.Function.Arguments)
+				if len(input) == 0 {
+					input = json.RawMessage("{}")
+				}
+
+				pending = append(pending, id)
+
+				appendBlock("assistant", anthropic.ContentBlock{Type: "tool_use", ID: id, Name: tc.Function.Name, Input: input})
+			}
+		case *schema.FunctionChatMessage:
+			// Function messages carry no ID, so they answer the tool uses in order.
+			if len(pending) == 0 {
+				return "", nil, fmt.Errorf("function message %s without preceding function call", m.Name())
+			}
+
+			appendBlock("user", anthropic.ContentBlock{Type: "tool_result", ToolUseID: pending[0], Content: m.Content()})
+
+			pending = pending[1:]
+		case *schema.ToolChatMessage:
+			appendBlock("user", anthropic.ContentBlock{Type: "tool_result", ToolUseID: m.ToolCallID(), Content: m.Content()})
+
+			pending = util.Filter(pending, func(id string, _ int) bool { return id != m.ToolCallID() })
+		default:
+			return "", nil, fmt.Errorf("unsupported message type: %s", message.Type())
+		}
+	}
+
+	return strings.Join(system, "\n"), result, nil
+}
+
+// convertContentPartToAnthropicBlock converts a content part to a text, image or document block.
+// Inline data is sent base64 encoded, other parts refer to their data by URL.
+func convertContentPartToAnthropicBlock(p schema.ContentPart) (anthropic.ContentBlock, error) {
+	var blockType string
+
+	switch p.Type {
+	case schema.ContentPartTypeText:
+		return anthropic.ContentBlock{Type: "text", Text: p.Text}, nil
+	case schema.ContentPartTypeImage:
+		blockType = "image"
+	case schema.ContentPartTypeFile:
+		blockType = "document"
+	default:
+		return anthropic.ContentBlock{}, fmt.Errorf("unsupported content part type: %s", p.Type)
+	}
+
+	if !p.IsInline() {
+		return anthropic.ContentBlock{Type: blockType, Source: &anthropic.Source{Type: "url", URL: p.URL}}, nil
+	}
+
+	return anthropic.ContentBlock{Type: blockType, Source: &anthropic.Source{
+		Type:      "base64",
+		MediaType: p.MIMEType,
+		Data:      base64.StdEncoding.EncodeToString(p.Data),
+	}}, nil
+}
+
+// convertFunctionsToAnthropicTools converts function definitions to anthropic tools.
+func convertFunctionsToAnthropicTools(functions []schema.FunctionDefinition) []anthropic.Tool {
+	return util.Map(functions, func(fd schema.FunctionDefinition, _ int) anthropic.Tool {
+		return anthropic.Tool{
+			Name:        fd.Name,
+			Description: fd.Description,
+			InputSchema: fd.Parameters,
+		}
+	})
+}
+
+// anthropicToolChoice returns the tool choice forcing the model to use the single function, if requested.
+func anthropicToolChoice(functions []schema.FunctionDefinition, forceFunctionCall bool) *anthropic.ToolChoice {
+	if forceFunctionCall && len(functions) == 1 {
+		return &anthropic.ToolChoice{Type: "tool", Name: functions[0].Name}
+	}
+
+	return nil
+}
+
+// anthropicMessageResponseToGeneration converts a response of the anthropic messages api to a generation.
+func anthropicMessageResponseToGeneration(res *anthropic.MessageResponse) schema.Generation {
+	var (
+		texts     []string
+		toolCalls []schema.ToolCall
+	)
+
+	for _, block := range res.Content {
+		switch block.Type {
+		case "text":
+			texts = append(texts, block.Text)
+		case "tool_use":
+			toolCalls = append(toolCalls, schema.ToolCall{
+				ID: block.ID,
+				Function: schema.FunctionCall{
+					Name:      block.Name,
+					Arguments: string(block.Input),
+				},
+			})
+		}
+	}
+
+	generation := newChatGeneraton(strings.Join(texts, ""), withToolCalls(toolCalls))
+
+	generation.Info = map[string]any{
+		"FinishReason": res.StopReason,
+	}
+
+	return generation
+}
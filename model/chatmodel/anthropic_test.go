@@ -2,10 +2,13 @@ package chatmodel
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/hupe1980/golc/integration/anthropic"
+	"github.com/hupe1980/golc/integration/jsonschema"
 	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/assert"
 )
@@ -40,6 +43,17 @@ func TestAnthropic(t *testing.T) {
 			assert.NotNil(t, result, "Expected non-nil result")
 			assert.Len(t, result.Generations, 1, "Expected 1 generation")
 			assert.Equal(t, "Hello, how can I help you?", result.Generations[0].Text, "Generated text does not match")
+
+			// The completions api doesn't return the token usage, so it is counted with the tokenizer.
+			promptTokens, err := anthropicModel.GetNumTokensFromMessage(context.Background(), chatMessages)
+			assert.NoError(t, err)
+			completionTokens, err := anthropicModel.GetNumTokens(context.Background(), "Hello, how can I help you?")
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]int{
+				"PromptTokens":     int(promptTokens),
+				"CompletionTokens": int(completionTokens),
+				"TotalTokens":      int(promptTokens + completionTokens),
+			}, result.LLMOutput["TokenUsage"])
 		})
 
 		// Test case 2: Anthropic API error
@@ -60,6 +74,83 @@ func TestAnthropic(t *testing.T) {
 			assert.Error(t, err, "Expected an error")
 			assert.Nil(t, result, "Expected nil result")
 		})
+
+		// Test case 3: Tokenizer error
+		t.Run("Tokenizer error", func(t *testing.T) {
+			client.createCompletionFn = func(ctx context.Context, request *anthropic.CompletionRequest) (*anthropic.CompletionResponse, error) {
+				return &anthropic.CompletionResponse{
+					Completion: "Hello, how can I help you?",
+				}, nil
+			}
+
+			model, err := NewAnthropicFromClient(client, func(o *AnthropicOptions) {
+				o.Tokenizer = &mockTokenizer{err: errors.New("tokenizer error")}
+			})
+			assert.NoError(t, err)
+
+			// The token usage is omitted, but the generation succeeds.
+			result, err := model.Generate(context.Background(), schema.ChatMessages{schema.NewHumanChatMessage("Can you help me?")})
+			assert.NoError(t, err)
+			assert.Equal(t, "Hello, how can I help you?", result.Generations[0].Text)
+			assert.NotContains(t, result.LLMOutput, "TokenUsage")
+		})
+	})
+
+	t.Run("FunctionCalling", func(t *testing.T) {
+		client.createMessageFn = func(ctx context.Context, request *anthropic.MessageRequest) (*anthropic.MessageResponse, error) {
+			assert.Equal(t, "You are a helpful assistant.", request.System)
+			assert.Equal(t, []anthropic.Tool{{
+				Name:        "get_weather",
+				Description: "Get the current weather",
+				InputSchema: weatherFunction.Parameters,
+			}}, request.Tools)
+			assert.Equal(t, &anthropic.ToolChoice{Type: "tool", Name: "get_weather"}, request.ToolChoice)
+			assert.Len(t, request.Messages, 1)
+
+			return &anthropic.MessageResponse{
+				Content: []anthropic.ContentBlock{
+					{Type: "text", Text: "Let me check."},
+					{Type: "tool_use", ID: "toolu_abc", Name: "get_weather", Input: json.RawMessage(`{"location":"Berlin"}`)},
+				},
+				StopReason: "tool_use",
+				Usage:      anthropic.MessageUsage{InputTokens: 20, OutputTokens: 10},
+			}, nil
+		}
+
+		result, err := anthropicModel.Generate(context.Background(), schema.ChatMessages{
+			schema.NewSystemChatMessage("You are a helpful assistant."),
+			schema.NewHumanChatMessage("What is the weather in Berlin?"),
+		}, func(o *schema.GenerateOptions) {
+			o.Functions = []schema.FunctionDefinition{weatherFunction}
+			o.ForceFunctionCall = true
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Let me check.", result.Generations[0].Text)
+
+		aiMsg, ok := result.Generations[0].Message.(*schema.AIChatMessage)
+		assert.True(t, ok)
+		assert.Equal(t, &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}, aiMsg.Extension().FunctionCall)
+		assert.Equal(t, map[string]int{"PromptTokens": 20, "CompletionTokens": 10, "TotalTokens": 30}, result.LLMOutput["TokenUsage"])
+	})
+
+	t.Run("ContentParts", func(t *testing.T) {
+		client.createMessageFn = func(ctx context.Context, request *anthropic.MessageRequest) (*anthropic.MessageResponse, error) {
+			assert.Empty(t, request.Tools)
+			assert.Len(t, request.Messages, 1)
+			assert.Len(t, request.Messages[0].Content, 2)
+			assert.Equal(t, "image", request.Messages[0].Content[1].Type)
+
+			return &anthropic.MessageResponse{
+				Content:    []anthropic.ContentBlock{{Type: "text", Text: "A cat."}},
+				StopReason: "end_turn",
+			}, nil
+		}
+
+		result, err := anthropicModel.Generate(context.Background(), schema.ChatMessages{
+			schema.NewHumanChatMessage("What is in the image?", schema.NewImageContentPart("image/png", []byte("foo"))),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "A cat.", result.Generations[0].Text)
 	})
 
 	t.Run("Type", func(t *testing.T) {
@@ -87,12 +178,43 @@ func TestAnthropic(t *testing.T) {
 // mockAnthropicClient is a mock implementation of the AnthropicClient interface for testing.
 type mockAnthropicClient struct {
 	createCompletionFn func(ctx context.Context, request *anthropic.CompletionRequest) (*anthropic.CompletionResponse, error)
+	createMessageFn    func(ctx context.Context, request *anthropic.MessageRequest) (*anthropic.MessageResponse, error)
 }
 
 func (m *mockAnthropicClient) CreateCompletion(ctx context.Context, request *anthropic.CompletionRequest) (*anthropic.CompletionResponse, error) {
 	return m.createCompletionFn(ctx, request)
 }
 
+func (m *mockAnthropicClient) CreateMessage(ctx context.Context, request *anthropic.MessageRequest) (*anthropic.MessageResponse, error) {
+	return m.createMessageFn(ctx, request)
+}
+
+// mockTokenizer is a mock implementation of the schema.Tokenizer interface for testing.
+type mockTokenizer struct {
+	err error
+}
+
+func (m *mockTokenizer) GetNumTokens(ctx context.Context, text string) (uint, error) {
+	return 0, m.err
+}
+
+func (m *mockTokenizer) GetNumTokensFromMessage(ctx context.Context, messages schema.ChatMessages) (uint, error) {
+	return 0, m.err
+}
+
+// weatherFunction is a function definition used to test function calling.
+var weatherFunction = schema.FunctionDefinition{
+	Name:        "get_weather",
+	Description: "Get the current weather",
+	Parameters: schema.FunctionDefinitionParameters{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"location": {Type: "string", Description: "The city"},
+		},
+		Required: []string{"location"},
+	},
+}
+
 func TestConvertMessagesToAnthropicPrompt(t *testing.T) {
 	t.Run("Empty input messages", func(t *testing.T) {
 		emptyMessages := schema.ChatMessages{}
@@ -128,3 +250,79 @@ func TestConvertMessagesToAnthropicPrompt(t *testing.T) {
 		assert.Nil(t, humanErr)
 	})
 }
+
+func TestConvertMessagesToAnthropicMessages(t *testing.T) {
+	t.Run("Function call round trip", func(t *testing.T) {
+		system, messages, err := convertMessagesToAnthropicMessages(schema.ChatMessages{
+			schema.NewSystemChatMessage("System message"),
+			schema.NewHumanChatMessage("What is the weather in Berlin?"),
+			schema.NewAIChatMessage("", func(o *schema.ChatMessageExtension) {
+				o.FunctionCall = &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}
+			}),
+			schema.NewFunctionChatMessage("get_weather", "sunny"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "System message", system)
+
+		// The function call has no id, so a new id is generated, which the function result refers to.
+		id := messages[1].Content[0].ID
+		assert.NotEmpty(t, id)
+		assert.Equal(t, []anthropic.Message{
+			{Role: "user", Content: []anthropic.ContentBlock{{Type: "text", Text: "What is the weather in Berlin?"}}},
+			{Role: "assistant", Content: []anthropic.ContentBlock{{Type: "tool_use", ID: id, Name: "get_weather", Input: json.RawMessage(`{"location":"Berlin"}`)}}},
+			{Role: "user", Content: []anthropic.ContentBlock{{Type: "tool_result", ToolUseID: id, Content: "sunny"}}},
+		}, messages)
+	})
+
+	t.Run("Parallel tool calls", func(t *testing.T) {
+		_, messages, err := convertMessagesToAnthropicMessages(schema.ChatMessages{
+			schema.NewHumanChatMessage("What is the weather in Berlin and Paris?"),
+			schema.NewAIChatMessage("", func(o *schema.ChatMessageExtension) {
+				o.ToolCalls = []schema.ToolCall{
+					{ID: "toolu_a", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}},
+					{ID: "toolu_b", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Paris"}`}},
+				}
+			}),
+			schema.NewToolChatMessage("toolu_b", "rainy"),
+			schema.NewToolChatMessage("toolu_a", "sunny"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []anthropic.Message{
+			{Role: "user", Content: []anthropic.ContentBlock{{Type: "text", Text: "What is the weather in Berlin and Paris?"}}},
+			{Role: "assistant", Content: []anthropic.ContentBlock{
+				{Type: "tool_use", ID: "toolu_a", Name: "get_weather", Input: json.RawMessage(`{"location":"Berlin"}`)},
+				{Type: "tool_use", ID: "toolu_b", Name: "get_weather", Input: json.RawMessage(`{"location":"Paris"}`)},
+			}},
+			{Role: "user", Content: []anthropic.ContentBlock{
+				{Type: "tool_result", ToolUseID: "toolu_b", Content: "rainy"},
+				{Type: "tool_result", ToolUseID: "toolu_a", Content: "sunny"},
+			}},
+		}, messages)
+	})
+
+	t.Run("Content parts", func(t *testing.T) {
+		_, messages, err := convertMessagesToAnthropicMessages(schema.ChatMessages{
+			schema.NewHumanChatMessage("What is in the image?",
+				schema.NewImageContentPart("image/png", []byte("foo")),
+				schema.NewImageURLContentPart("", "https://example.com/image.png"),
+				schema.NewFileContentPart("application/pdf", []byte("bar")),
+			),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []anthropic.Message{
+			{Role: "user", Content: []anthropic.ContentBlock{
+				{Type: "text", Text: "What is in the image?"},
+				{Type: "image", Source: &anthropic.Source{Type: "base64", MediaType: "image/png", Data: "Zm9v"}},
+				{Type: "image", Source: &anthropic.Source{Type: "url", URL: "https://example.com/image.png"}},
+				{Type: "document", Source: &anthropic.Source{Type: "base64", MediaType: "application/pdf", Data: "YmFy"}},
+			}},
+		}, messages)
+	})
+
+	t.Run("Function message without function call", func(t *testing.T) {
+		_, _, err := convertMessagesToAnthropicMessages(schema.ChatMessages{
+			schema.NewFunctionChatMessage("get_weather", "sunny"),
+		})
+		assert.Error(t, err)
+	})
+}
@@ -4,14 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	bedrockruntimeTypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/integration/anthropic"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -19,6 +25,10 @@ import (
 // Compile time check to ensure Bedrock satisfies the ChatModel interface.
 var _ schema.ChatModel = (*Bedrock)(nil)
 
+// bedrockAnthropicDefaultMaxTokens is the maximum number of generated tokens of the "anthropic" provider,
+// if the model params don't set it.
+const bedrockAnthropicDefaultMaxTokens = 256
+
 // BedrockInputOutputAdapter is a helper struct for preparing input and handling output for Bedrock model.
 type BedrockInputOutputAdapter struct {
 	provider string
@@ -49,7 +59,7 @@ func (bioa *BedrockInputOutputAdapter) PrepareInput(messages schema.ChatMessages
 		}
 
 		if _, ok := body["max_tokens_to_sample"]; !ok {
-			body["max_tokens_to_sample"] = 256
+			body["max_tokens_to_sample"] = bedrockAnthropicDefaultMaxTokens
 		}
 	case "meta":
 		p, err := convertMessagesToMetaPrompt(messages)
@@ -316,6 +326,10 @@ func (cm *Bedrock) Generate(ctx context.Context, messages schema.ChatMessages, o
 		fn(&opts)
 	}
 
+	if len(opts.Functions) > 0 {
+		return cm.generateWithTools(ctx, messages, opts)
+	}
+
 	params := util.CopyMap(cm.opts.ModelParams)
 
 	bioa := NewBedrockInputOutputAdapter(cm.getProvider())
@@ -325,9 +339,12 @@ func (cm *Bedrock) Generate(ctx context.Context, messages schema.ChatMessages, o
 		return nil, err
 	}
 
-	var completion string
+	var (
+		completion string
+		metrics    bedrockInvocationMetrics
+	)
 
-	if cm.opts.Stream {
+	if cm.opts.Stream || opts.Stream {
 		res, err := cm.client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
 			ModelId:     aws.String(cm.modelID),
 			Body:        body,
@@ -359,6 +376,10 @@ func (cm *Bedrock) Generate(ctx context.Context, messages schema.ChatMessages, o
 				}
 
 				tokens = append(tokens, token)
+
+				if m, ok := bedrockStreamInvocationMetrics(v.Value.Bytes); ok {
+					metrics = m
+				}
 			}
 		}
 
@@ -380,11 +401,91 @@ func (cm *Bedrock) Generate(ctx context.Context, messages schema.ChatMessages, o
 		}
 
 		completion = output
+		metrics = bedrockResponseInvocationMetrics(res.ResultMetadata)
 	}
 
 	return &schema.ModelResult{
 		Generations: []schema.Generation{newChatGeneraton(completion)},
-		LLMOutput:   map[string]any{},
+		LLMOutput: map[string]any{
+			"TokenUsage": metrics.tokenUsage(),
+		},
+	}, nil
+}
+
+// Stream streams the text generated for the provided chat messages.
+func (cm *Bedrock) Stream(ctx context.Context, messages schema.ChatMessages, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.ChatModelStream(ctx, cm, messages, optFns...)
+}
+
+// generateWithTools generates a message with the functions as native tools of the provider.
+// Tool use is currently only supported for the "anthropic" provider and is never streamed.
+func (cm *Bedrock) generateWithTools(ctx context.Context, messages schema.ChatMessages, opts schema.GenerateOptions) (*schema.ModelResult, error) {
+	if provider := cm.getProvider(); provider != "anthropic" {
+		return nil, fmt.Errorf("function calling is not supported by provider: %s", provider)
+	}
+
+	system, anthropicMessages, err := convertMessagesToAnthropicMessages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	// The configured model params are passed to the messages api, which names the maximum number of
+	// tokens max_tokens instead of max_tokens_to_sample.
+	params := util.CopyMap(cm.opts.ModelParams)
+
+	if v, ok := params["max_tokens_to_sample"]; ok {
+		delete(params, "max_tokens_to_sample")
+		params["max_tokens"] = v
+	}
+
+	if _, ok := params["max_tokens"]; !ok {
+		params["max_tokens"] = bedrockAnthropicDefaultMaxTokens
+	}
+
+	params["anthropic_version"] = "bedrock-2023-05-31"
+	params["messages"] = anthropicMessages
+	params["tools"] = convertFunctionsToAnthropicTools(opts.Functions)
+
+	if system != "" {
+		params["system"] = system
+	}
+
+	if len(opts.Stop) > 0 {
+		params["stop_sequences"] = opts.Stop
+	}
+
+	if toolChoice := anthropicToolChoice(opts.Functions, opts.ForceFunctionCall); toolChoice != nil {
+		params["tool_choice"] = toolChoice
+	}
+
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := cm.client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+		ModelId:     aws.String(cm.modelID),
+		Body:        body,
+		Accept:      aws.String("application/json"),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := &anthropic.MessageResponse{}
+	if err := json.Unmarshal(res.Body, output); err != nil {
+		return nil, err
+	}
+
+	return &schema.ModelResult{
+		Generations: []schema.Generation{anthropicMessageResponseToGeneration(output)},
+		LLMOutput: map[string]any{
+			"TokenUsage": bedrockInvocationMetrics{
+				InputTokenCount:  output.Usage.InputTokens,
+				OutputTokenCount: output.Usage.OutputTokens,
+			}.tokenUsage(),
+		},
 	}, nil
 }
 
@@ -415,3 +516,46 @@ func (cm *Bedrock) InvocationParams() map[string]any {
 func (cm *Bedrock) getProvider() string {
 	return strings.Split(cm.modelID, ".")[0]
 }
+
+// bedrockInvocationMetrics holds the token counts of a bedrock model invocation.
+type bedrockInvocationMetrics struct {
+	InputTokenCount  int `json:"inputTokenCount"`
+	OutputTokenCount int `json:"outputTokenCount"`
+}
+
+// tokenUsage returns the token counts in the format of the LLMOutput.
+func (m bedrockInvocationMetrics) tokenUsage() map[string]int {
+	return map[string]int{
+		"PromptTokens":     m.InputTokenCount,
+		"CompletionTokens": m.OutputTokenCount,
+		"TotalTokens":      m.InputTokenCount + m.OutputTokenCount,
+	}
+}
+
+// bedrockStreamInvocationMetrics returns the invocation metrics, which bedrock adds to the last chunk of a response stream.
+func bedrockStreamInvocationMetrics(chunk []byte) (bedrockInvocationMetrics, bool) {
+	output := struct {
+		Metrics *bedrockInvocationMetrics `json:"amazon-bedrock-invocationMetrics"`
+	}{}
+
+	if err := json.Unmarshal(chunk, &output); err != nil || output.Metrics == nil {
+		return bedrockInvocationMetrics{}, false
+	}
+
+	return *output.Metrics, true
+}
+
+// bedrockResponseInvocationMetrics returns the invocation metrics from the headers of the raw bedrock response.
+func bedrockResponseInvocationMetrics(metadata middleware.Metadata) bedrockInvocationMetrics {
+	metrics := bedrockInvocationMetrics{}
+
+	res, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response)
+	if !ok {
+		return metrics
+	}
+
+	metrics.InputTokenCount, _ = strconv.Atoi(res.Header.Get("X-Amzn-Bedrock-Input-Token-Count"))
+	metrics.OutputTokenCount, _ = strconv.Atoi(res.Header.Get("X-Amzn-Bedrock-Output-Token-Count"))
+
+	return metrics
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/hupe1980/golc/integration/anthropic"
 	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/assert"
 )
@@ -216,6 +217,105 @@ func TestBedrock(t *testing.T) {
 		})
 	})
 
+	t.Run("AntrophicFunctionCalling", func(t *testing.T) {
+		bedrockModel, err := NewBedrockAntrophic(client, func(o *BedrockAnthropicOptions) {
+			o.ModelID = "anthropic.claude-3-sonnet-20240229-v1:0"
+		})
+		assert.NoError(t, err)
+
+		client.createInvokeModelFn = func(ctx context.Context, params *bedrockruntime.InvokeModelInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.InvokeModelOutput, error) {
+			request := &anthropic.MessageRequest{}
+			assert.NoError(t, json.Unmarshal(params.Body, request))
+			assert.Equal(t, "bedrock-2023-05-31", request.AnthropicVersion)
+			assert.Equal(t, 256, request.MaxTokens)
+			assert.Len(t, request.Tools, 1)
+			assert.Equal(t, "get_weather", request.Tools[0].Name)
+
+			b, err := json.Marshal(&anthropic.MessageResponse{
+				Content: []anthropic.ContentBlock{
+					{Type: "tool_use", ID: "toolu_abc", Name: "get_weather", Input: json.RawMessage(`{"location":"Berlin"}`)},
+				},
+			})
+			assert.NoError(t, err)
+
+			return &bedrockruntime.InvokeModelOutput{
+				Body: b,
+			}, nil
+		}
+
+		result, err := bedrockModel.Generate(context.Background(), schema.ChatMessages{
+			schema.NewHumanChatMessage("What is the weather in Berlin?"),
+		}, func(o *schema.GenerateOptions) {
+			o.Functions = []schema.FunctionDefinition{weatherFunction}
+		})
+		assert.NoError(t, err)
+
+		aiMsg, ok := result.Generations[0].Message.(*schema.AIChatMessage)
+		assert.True(t, ok)
+		assert.Equal(t, &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}, aiMsg.Extension().FunctionCall)
+	})
+
+	t.Run("AntrophicFunctionCallingModelParams", func(t *testing.T) {
+		bedrockModel, err := NewBedrockAntrophic(client, func(o *BedrockAnthropicOptions) {
+			o.ModelID = "anthropic.claude-3-sonnet-20240229-v1:0"
+			o.MaxTokensToSample = 1024
+			o.Temperature = 0.2
+		})
+		assert.NoError(t, err)
+
+		client.createInvokeModelFn = func(ctx context.Context, params *bedrockruntime.InvokeModelInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.InvokeModelOutput, error) {
+			request := &anthropic.MessageRequest{}
+			assert.NoError(t, json.Unmarshal(params.Body, request))
+			assert.Equal(t, 1024, request.MaxTokens)
+			assert.Equal(t, float32(0.2), request.Temperature)
+			assert.Equal(t, 250, request.TopK)
+
+			// The ids of the tool calls are passed to the tool use and tool result blocks.
+			assert.Equal(t, "toolu_abc", request.Messages[1].Content[0].ID)
+			assert.Equal(t, "toolu_abc", request.Messages[2].Content[0].ToolUseID)
+
+			b, err := json.Marshal(&anthropic.MessageResponse{
+				Content: []anthropic.ContentBlock{{Type: "text", Text: "It is sunny."}},
+				Usage:   anthropic.MessageUsage{InputTokens: 30, OutputTokens: 5},
+			})
+			assert.NoError(t, err)
+
+			return &bedrockruntime.InvokeModelOutput{
+				Body: b,
+			}, nil
+		}
+
+		result, err := bedrockModel.Generate(context.Background(), schema.ChatMessages{
+			schema.NewHumanChatMessage("What is the weather in Berlin?"),
+			schema.NewAIChatMessage("", func(o *schema.ChatMessageExtension) {
+				o.ToolCalls = []schema.ToolCall{{ID: "toolu_abc", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}}}
+			}),
+			schema.NewToolChatMessage("toolu_abc", "sunny"),
+		}, func(o *schema.GenerateOptions) {
+			o.Functions = []schema.FunctionDefinition{weatherFunction}
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "It is sunny.", result.Generations[0].Text)
+		assert.Equal(t, map[string]int{"PromptTokens": 30, "CompletionTokens": 5, "TotalTokens": 35}, result.LLMOutput["TokenUsage"])
+	})
+
+	t.Run("MetaFunctionCalling", func(t *testing.T) {
+		client.createInvokeModelFn = func(ctx context.Context, params *bedrockruntime.InvokeModelInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.InvokeModelOutput, error) {
+			t.Fatal("unexpected request for an unsupported provider")
+			return nil, nil
+		}
+
+		model, err := NewBedrockMeta(client)
+		assert.NoError(t, err)
+
+		_, err = model.Generate(context.Background(), schema.ChatMessages{
+			schema.NewHumanChatMessage("What is the weather in Berlin?"),
+		}, func(o *schema.GenerateOptions) {
+			o.Functions = []schema.FunctionDefinition{weatherFunction}
+		})
+		assert.EqualError(t, err, "function calling is not supported by provider: meta")
+	})
+
 	t.Run("Meta", func(t *testing.T) {
 		model, err := NewBedrockMeta(client)
 		assert.NoError(t, err)
@@ -1,7 +1,12 @@
 // Package chatmodel provides a framework for working with chat-based large language models (LLMs).
 package chatmodel
 
-import "github.com/hupe1980/golc/schema"
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hupe1980/golc/schema"
+)
 
 func newChatGeneraton(text string, extFns ...func(o *schema.ChatMessageExtension)) schema.Generation { // nolint uparam
 	return schema.Generation{
@@ -9,3 +14,78 @@ func newChatGeneraton(text string, extFns ...func(o *schema.ChatMessageExtension
 		Message: schema.NewAIChatMessage(text, extFns...),
 	}
 }
+
+// toolCallsFromExtension returns the tool calls of the extension. An extension that only
+// carries a function call is treated as a single tool call without ID.
+func toolCallsFromExtension(ext schema.ChatMessageExtension) []schema.ToolCall {
+	if len(ext.ToolCalls) > 0 {
+		return ext.ToolCalls
+	}
+
+	if ext.FunctionCall != nil {
+		return []schema.ToolCall{{Function: *ext.FunctionCall}}
+	}
+
+	return nil
+}
+
+// withToolCalls returns an extension function that sets the tool calls and uses the first one as function call.
+func withToolCalls(toolCalls []schema.ToolCall) func(o *schema.ChatMessageExtension) {
+	return func(o *schema.ChatMessageExtension) {
+		if len(toolCalls) == 0 {
+			return
+		}
+
+		o.FunctionCall = &schema.FunctionCall{
+			Name:      toolCalls[0].Function.Name,
+			Arguments: toolCalls[0].Function.Arguments,
+		}
+		o.ToolCalls = toolCalls
+	}
+}
+
+// newToolCallID returns a unique ID for tool calls of providers that do not assign IDs themselves.
+func newToolCallID() string {
+	return "call_" + uuid.New().String()
+}
+
+// notifyToolCall reports a tool call, which a model streams as a whole, as a single tool call fragment.
+func notifyToolCall(ctx context.Context, cm schema.CallbackManagerForModelRun, index int, tc schema.ToolCall) error {
+	return cm.OnModelNewToken(ctx, &schema.ModelNewTokenManagerInput{
+		ToolCallDelta: &schema.ToolCallDelta{
+			Index:    index,
+			ID:       tc.ID,
+			Function: tc.Function,
+		},
+	})
+}
+
+// hasContentParts returns true if one of the human messages contains additional content parts, e.g. images.
+func hasContentParts(messages schema.ChatMessages) bool {
+	for _, m := range messages {
+		if hm, ok := m.(*schema.HumanChatMessage); ok && len(hm.Parts()) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tokenizerTokenUsage returns the token usage counted with the tokenizer for providers that don't report it.
+func tokenizerTokenUsage(ctx context.Context, tokenizer schema.Tokenizer, messages schema.ChatMessages, completion string) (map[string]int, error) {
+	promptTokens, err := tokenizer.GetNumTokensFromMessage(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	completionTokens, err := tokenizer.GetNumTokens(ctx, completion)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]int{
+		"PromptTokens":     int(promptTokens),
+		"CompletionTokens": int(completionTokens),
+		"TotalTokens":      int(promptTokens + completionTokens),
+	}, nil
+}
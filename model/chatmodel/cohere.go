@@ -2,6 +2,7 @@ package chatmodel
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -13,7 +14,9 @@ import (
 	core "github.com/cohere-ai/cohere-go/v2/core"
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/integration/jsonschema"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -113,34 +116,31 @@ func (cm *Cohere) Generate(ctx context.Context, messages schema.ChatMessages, op
 		return nil, fmt.Errorf("at least one message must be passed")
 	}
 
-	chatMessages := make([]*cohere.ChatMessage, len(messages)-1)
-
-	for i, m := range messages[:len(messages)-1] {
-		var role cohere.ChatMessageRole
+	chat, err := convertMessagesToCohereChat(messages)
+	if err != nil {
+		return nil, err
+	}
 
-		switch m.Type() {
-		case schema.ChatMessageTypeAI:
-			role = cohere.ChatMessageRoleChatbot
-		case schema.ChatMessageTypeHuman:
-			role = cohere.ChatMessageRoleUser
-		default:
-			return nil, fmt.Errorf("unsupported chat message type: %s", m.Type())
-		}
+	tools := convertFunctionsToCohereTools(opts.Functions)
 
-		chatMessages[i] = &cohere.ChatMessage{
-			Role:    role,
-			Message: m.Content(),
-		}
-	}
+	var (
+		text      string
+		toolCalls []schema.ToolCall
+	)
 
-	var text string
+	// Cohere does not support forcing a tool call, so ForceFunctionCall is ignored.
 
-	if cm.opts.Stream {
+	if cm.opts.Stream || opts.Stream {
 		stream, err := cm.client.ChatStream(ctx, &cohere.ChatStreamRequest{
 			Model:       util.AddrOrNil(cm.opts.Model),
-			Message:     messages[len(messages)-1].Content(),
-			ChatHistory: chatMessages,
+			Message:     chat.message,
+			ChatHistory: chat.history,
+			Preamble:    util.AddrOrNil(chat.preamble),
 			Temperature: util.AddrOrNil(cm.opts.Temperature),
+			Tools:       tools,
+			ToolResults: util.Map(chat.toolResults, func(tr *cohere.ChatRequestToolResultsItem, _ int) *cohere.ChatStreamRequestToolResultsItem {
+				return &cohere.ChatStreamRequestToolResultsItem{Call: tr.Call, Outputs: tr.Outputs}
+			}),
 		})
 		if err != nil {
 			return nil, err
@@ -173,6 +173,19 @@ func (cm *Cohere) Generate(ctx context.Context, messages schema.ChatMessages, op
 
 					tokens = append(tokens, res.TextGeneration.Text)
 				}
+
+				if res.EventType == "tool-calls-generation" {
+					toolCalls, err = cohereToolCallsToToolCalls(res.ToolCallsGeneration.ToolCalls)
+					if err != nil {
+						return nil, err
+					}
+
+					for i, tc := range toolCalls {
+						if err := notifyToolCall(ctx, opts.CallbackManger, i, tc); err != nil {
+							return nil, err
+						}
+					}
+				}
 			}
 		}
 
@@ -180,21 +193,42 @@ func (cm *Cohere) Generate(ctx context.Context, messages schema.ChatMessages, op
 	} else {
 		res, err := cm.generateWithRetry(ctx, &cohere.ChatRequest{
 			Model:       util.AddrOrNil(cm.opts.Model),
-			Message:     messages[len(messages)-1].Content(),
-			ChatHistory: chatMessages,
+			Message:     chat.message,
+			ChatHistory: chat.history,
+			Preamble:    util.AddrOrNil(chat.preamble),
 			Temperature: util.AddrOrNil(cm.opts.Temperature),
+			Tools:       tools,
+			ToolResults: chat.toolResults,
 		})
 		if err != nil {
 			return nil, err
 		}
 
 		text = res.Text
+
+		toolCalls, err = cohereToolCallsToToolCalls(res.ToolCalls)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return &schema.ModelResult{
-		Generations: []schema.Generation{newChatGeneraton(text)},
+	result := &schema.ModelResult{
+		Generations: []schema.Generation{newChatGeneraton(text, withToolCalls(toolCalls))},
 		LLMOutput:   map[string]any{},
-	}, nil
+	}
+
+	// The chat api does not return the token usage, so the tokens are counted with the tokenizer.
+	// The token usage is best effort, so a failed count does not fail the generation.
+	if tokenUsage, err := tokenizerTokenUsage(ctx, cm.Tokenizer, messages, text); err == nil {
+		result.LLMOutput["TokenUsage"] = tokenUsage
+	}
+
+	return result, nil
+}
+
+// Stream streams the text generated for the provided chat messages.
+func (cm *Cohere) Stream(ctx context.Context, messages schema.ChatMessages, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.ChatModelStream(ctx, cm, messages, optFns...)
 }
 
 func (cm *Cohere) generateWithRetry(ctx context.Context, req *cohere.ChatRequest) (*cohere.NonStreamedChatResponse, error) {
@@ -254,3 +288,175 @@ func (cm *Cohere) Callbacks() []schema.Callback {
 func (cm *Cohere) InvocationParams() map[string]any {
 	return util.StructToMap(cm.opts)
 }
+
+// cohereChat holds the parts of a cohere chat request derived from chat messages.
+type cohereChat struct {
+	preamble    string
+	history     []*cohere.ChatMessage
+	message     string
+	toolResults []*cohere.ChatRequestToolResultsItem
+}
+
+// convertMessagesToCohereChat converts chat messages to a cohere chat request.
+// System messages become the preamble and the last message becomes the request message. If the
+// conversation ends with function or tool results, the last human message is sent again together with the
+// results of the tool calls following it, as cohere expects.
+func convertMessagesToCohereChat(messages schema.ChatMessages) (*cohereChat, error) {
+	split := len(messages) - 1
+
+	if t := messages[split].Type(); t == schema.ChatMessageTypeFunction || t == schema.ChatMessageTypeTool {
+		split = -1
+
+		for i := len(messages) - 1; i >= 0; i-- {
+			if messages[i].Type() == schema.ChatMessageTypeHuman {
+				split = i
+				break
+			}
+		}
+
+		if split == -1 {
+			return nil, fmt.Errorf("function results without human message")
+		}
+	}
+
+	chat := &cohereChat{
+		message: messages[split].Content(),
+	}
+
+	preamble := []string{}
+
+	for _, m := range messages[:split] {
+		switch m.Type() { // nolint exhaustive
+		case schema.ChatMessageTypeSystem:
+			preamble = append(preamble, m.Content())
+		case schema.ChatMessageTypeAI:
+			// Cohere has no representation of earlier tool calls in the chat history.
+			if m.Content() != "" {
+				chat.history = append(chat.history, &cohere.ChatMessage{Role: cohere.ChatMessageRoleChatbot, Message: m.Content()})
+			}
+		case schema.ChatMessageTypeHuman:
+			chat.history = append(chat.history, &cohere.ChatMessage{Role: cohere.ChatMessageRoleUser, Message: m.Content()})
+		case schema.ChatMessageTypeFunction, schema.ChatMessageTypeTool:
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported chat message type: %s", m.Type())
+		}
+	}
+
+	chat.preamble = strings.Join(preamble, "\n")
+
+	var (
+		// pending holds the calls not answered by a function message yet, in order.
+		pending   []*cohere.ToolCall
+		callsByID = map[string]*cohere.ToolCall{}
+	)
+
+	for _, m := range messages[split+1:] {
+		switch v := m.(type) {
+		case *schema.AIChatMessage:
+			for _, tc := range toolCallsFromExtension(v.Extension()) {
+				params := map[string]any{}
+				if tc.Function.Arguments != "" {
+					if err := json.Unmarshal([]byte(tc.Function.Arguments), &params); err != nil {
+						return nil, err
+					}
+				}
+
+				call := &cohere.ToolCall{Name: tc.Function.Name, Parameters: params}
+
+				pending = append(pending, call)
+
+				if tc.ID != "" {
+					callsByID[tc.ID] = call
+				}
+			}
+		case *schema.FunctionChatMessage:
+			if len(pending) == 0 {
+				return nil, fmt.Errorf("function message %s without preceding function call", v.Name())
+			}
+
+			chat.toolResults = append(chat.toolResults, &cohere.ChatRequestToolResultsItem{
+				Call:    pending[0],
+				Outputs: []map[string]any{{"output": v.Content()}},
+			})
+
+			pending = pending[1:]
+		case *schema.ToolChatMessage:
+			call, ok := callsByID[v.ToolCallID()]
+			if !ok {
+				return nil, fmt.Errorf("tool message %s without preceding tool call", v.ToolCallID())
+			}
+
+			chat.toolResults = append(chat.toolResults, &cohere.ChatRequestToolResultsItem{
+				Call:    call,
+				Outputs: []map[string]any{{"output": v.Content()}},
+			})
+		default:
+			return nil, fmt.Errorf("unsupported chat message type: %s", m.Type())
+		}
+	}
+
+	return chat, nil
+}
+
+// convertFunctionsToCohereTools converts function definitions to cohere tools.
+func convertFunctionsToCohereTools(functions []schema.FunctionDefinition) []*cohere.Tool {
+	return util.Map(functions, func(fd schema.FunctionDefinition, _ int) *cohere.Tool {
+		definitions := make(map[string]*cohere.ToolParameterDefinitionsValue, len(fd.Parameters.Properties))
+
+		for name, p := range fd.Parameters.Properties {
+			definitions[name] = &cohere.ToolParameterDefinitionsValue{
+				Description: p.Description,
+				Type:        jsonSchemaTypeToPythonType(p.Type),
+				Required:    util.PTR(util.Contains(fd.Parameters.Required, name)),
+			}
+		}
+
+		return &cohere.Tool{
+			Name:                 fd.Name,
+			Description:          fd.Description,
+			ParameterDefinitions: definitions,
+		}
+	})
+}
+
+// jsonSchemaTypeToPythonType maps a json schema type to the python type expected by cohere.
+func jsonSchemaTypeToPythonType(t string) string {
+	switch t {
+	case jsonschema.TypeString:
+		return "str"
+	case jsonschema.TypeInteger:
+		return "int"
+	case jsonschema.TypeNumber:
+		return "float"
+	case jsonschema.TypeBoolean:
+		return "bool"
+	case jsonschema.TypeArray:
+		return "list"
+	default:
+		return "dict"
+	}
+}
+
+// cohereToolCallsToToolCalls converts cohere tool calls to schema.ToolCalls.
+// Cohere does not assign IDs to tool calls, so unique IDs are generated.
+func cohereToolCallsToToolCalls(toolCalls []*cohere.ToolCall) ([]schema.ToolCall, error) {
+	result := make([]schema.ToolCall, 0, len(toolCalls))
+
+	for _, tc := range toolCalls {
+		args, err := json.Marshal(tc.Parameters)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, schema.ToolCall{
+			ID: newToolCallID(),
+			Function: schema.FunctionCall{
+				Name:      tc.Name,
+				Arguments: string(args),
+			},
+		})
+	}
+
+	return result, nil
+}
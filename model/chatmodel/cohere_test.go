@@ -6,6 +6,7 @@ import (
 
 	cohere "github.com/cohere-ai/cohere-go/v2"
 	"github.com/cohere-ai/cohere-go/v2/core"
+	"github.com/hupe1980/golc/internal/util"
 	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,14 +27,66 @@ func TestCohereGenerate(t *testing.T) {
 
 	t.Run("Generate", func(t *testing.T) {
 		// Call the Generate method with your test case inputs.
-		result, err := cohereModel.Generate(context.Background(), schema.ChatMessages{
+		messages := schema.ChatMessages{
 			schema.NewHumanChatMessage("hello"),
-		})
+		}
+
+		result, err := cohereModel.Generate(context.Background(), messages)
 		assert.NoError(t, err)
 
 		// Assert the expected result using testify assert.
 		assert.NotNil(t, result)
 		assert.Equal(t, "Mocked response", result.Generations[0].Text)
+
+		// The chat api doesn't return the token usage, so it is counted with the tokenizer.
+		promptTokens, err := cohereModel.GetNumTokensFromMessage(context.Background(), messages)
+		assert.NoError(t, err)
+		completionTokens, err := cohereModel.GetNumTokens(context.Background(), "Mocked response")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{
+			"PromptTokens":     int(promptTokens),
+			"CompletionTokens": int(completionTokens),
+			"TotalTokens":      int(promptTokens + completionTokens),
+		}, result.LLMOutput["TokenUsage"])
+	})
+
+	t.Run("FunctionCall", func(t *testing.T) {
+		mockClient.ChatFn = func(ctx context.Context, request *cohere.ChatRequest, opts ...core.RequestOption) (*cohere.NonStreamedChatResponse, error) {
+			assert.Equal(t, "You are a helpful assistant.", *request.Preamble)
+			assert.Equal(t, "What is the weather in Berlin?", request.Message)
+			assert.Empty(t, request.ChatHistory)
+			assert.Equal(t, []*cohere.Tool{{
+				Name:        "get_weather",
+				Description: "Get the current weather",
+				ParameterDefinitions: map[string]*cohere.ToolParameterDefinitionsValue{
+					"location": {Description: "The city", Type: "str", Required: util.PTR(true)},
+				},
+			}}, request.Tools)
+			assert.Equal(t, []*cohere.ChatRequestToolResultsItem{{
+				Call:    &cohere.ToolCall{Name: "get_weather", Parameters: map[string]any{"location": "Berlin"}},
+				Outputs: []map[string]any{{"output": "sunny"}},
+			}}, request.ToolResults)
+
+			return &cohere.NonStreamedChatResponse{
+				ToolCalls: []*cohere.ToolCall{{Name: "get_weather", Parameters: map[string]any{"location": "Paris"}}},
+			}, nil
+		}
+
+		result, err := cohereModel.Generate(context.Background(), schema.ChatMessages{
+			schema.NewSystemChatMessage("You are a helpful assistant."),
+			schema.NewHumanChatMessage("What is the weather in Berlin?"),
+			schema.NewAIChatMessage("", func(o *schema.ChatMessageExtension) {
+				o.FunctionCall = &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}
+			}),
+			schema.NewFunctionChatMessage("get_weather", "sunny"),
+		}, func(o *schema.GenerateOptions) {
+			o.Functions = []schema.FunctionDefinition{weatherFunction}
+		})
+		assert.NoError(t, err)
+
+		aiMsg, ok := result.Generations[0].Message.(*schema.AIChatMessage)
+		assert.True(t, ok)
+		assert.Equal(t, &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Paris"}`}, aiMsg.Extension().FunctionCall)
 	})
 
 	t.Run("no message", func(t *testing.T) {
@@ -3,11 +3,13 @@ package chatmodel
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/integration/ernie"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -94,38 +96,32 @@ func (cm *Ernie) Generate(ctx context.Context, messages schema.ChatMessages, opt
 		fn(&opts)
 	}
 
-	ernieMessages := make([]ernie.Message, len(messages))
-
-	for i, message := range messages {
-		switch message.Type() {
-		case schema.ChatMessageTypeAI:
-			ernieMessages[i] = ernie.Message{
-				Role:    "assistant",
-				Content: message.Content(),
-			}
-		case schema.ChatMessageTypeHuman:
-			ernieMessages[i] = ernie.Message{
-				Role:    "user",
-				Content: message.Content(),
-			}
-		case schema.ChatMessageTypeGeneric:
-			m, _ := message.(schema.GenericChatMessage)
-
-			ernieMessages[i] = ernie.Message{
-				Role:    m.Role(),
-				Content: m.Content(),
-			}
-		default:
-			return nil, fmt.Errorf("unsupported message type: %s", message.Type())
-		}
+	system, ernieMessages, err := convertMessagesToErnieMessages(messages)
+	if err != nil {
+		return nil, err
 	}
 
-	res, err := cm.client.CreateChatCompletion(ctx, cm.opts.ModelName, &ernie.ChatCompletionRequest{
+	request := &ernie.ChatCompletionRequest{
 		Messages:     ernieMessages,
+		System:       system,
 		Temperature:  cm.opts.Temperature,
 		TopP:         cm.opts.TopP,
 		PenaltyScore: cm.opts.PenaltyScore,
-	})
+		Functions: util.Map(opts.Functions, func(fd schema.FunctionDefinition, _ int) ernie.Function {
+			return ernie.Function{
+				Name:        fd.Name,
+				Description: fd.Description,
+				Parameters:  fd.Parameters,
+			}
+		}),
+	}
+
+	if opts.ForceFunctionCall && len(opts.Functions) == 1 {
+		request.ToolChoice = &ernie.ToolChoice{Type: "function"}
+		request.ToolChoice.Function.Name = opts.Functions[0].Name
+	}
+
+	res, err := cm.client.CreateChatCompletion(ctx, cm.opts.ModelName, request)
 	if err != nil {
 		return nil, err
 	}
@@ -134,11 +130,21 @@ func (cm *Ernie) Generate(ctx context.Context, messages schema.ChatMessages, opt
 		return nil, fmt.Errorf("ernie api error: %d", res.ErrorCode)
 	}
 
-	generation := schema.Generation{
-		Text:    res.Result,
-		Message: schema.NewAIChatMessage(res.Result),
+	var toolCalls []schema.ToolCall
+
+	if res.FunctionCall != nil {
+		// Ernie does not assign IDs to function calls, so a unique ID is generated.
+		toolCalls = []schema.ToolCall{{
+			ID: newToolCallID(),
+			Function: schema.FunctionCall{
+				Name:      res.FunctionCall.Name,
+				Arguments: res.FunctionCall.Arguments,
+			},
+		}}
 	}
 
+	generation := newChatGeneraton(res.Result, withToolCalls(toolCalls))
+
 	tokenUsage := map[string]int{
 		"PromptTokens":     res.Usage.PromptTokens,
 		"CompletionTokens": res.Usage.CompletionTokens,
@@ -153,6 +159,12 @@ func (cm *Ernie) Generate(ctx context.Context, messages schema.ChatMessages, opt
 	}, nil
 }
 
+// Stream streams the text generated for the provided chat messages.
+// Streaming is not supported natively, so the stream only has the final result chunk.
+func (cm *Ernie) Stream(ctx context.Context, messages schema.ChatMessages, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.ChatModelStream(ctx, cm, messages, optFns...)
+}
+
 // Type returns the type of the model.
 func (cm *Ernie) Type() string {
 	return "chatmodel.Ernie"
@@ -172,3 +184,73 @@ func (cm *Ernie) Callbacks() []schema.Callback {
 func (cm *Ernie) InvocationParams() map[string]any {
 	return util.StructToMap(cm.opts)
 }
+
+// convertMessagesToErnieMessages converts chat messages to the system prompt and ernie messages.
+func convertMessagesToErnieMessages(messages schema.ChatMessages) (string, []ernie.Message, error) {
+	var (
+		system        []string
+		ernieMessages []ernie.Message
+	)
+
+	// Ernie matches function results by name, so the names of the tool calls are tracked by ID.
+	toolCallNames := map[string]string{}
+
+	for _, message := range messages {
+		switch m := message.(type) {
+		case *schema.SystemChatMessage:
+			system = append(system, m.Content())
+		case *schema.AIChatMessage:
+			msg := ernie.Message{
+				Role:    "assistant",
+				Content: m.Content(),
+			}
+
+			// Ernie supports a single function call per message.
+			if toolCalls := toolCallsFromExtension(m.Extension()); len(toolCalls) > 0 {
+				msg.FunctionCall = &ernie.FunctionCall{
+					Name:      toolCalls[0].Function.Name,
+					Arguments: toolCalls[0].Function.Arguments,
+				}
+
+				for _, tc := range toolCalls {
+					if tc.ID != "" {
+						toolCallNames[tc.ID] = tc.Function.Name
+					}
+				}
+			}
+
+			ernieMessages = append(ernieMessages, msg)
+		case *schema.HumanChatMessage:
+			ernieMessages = append(ernieMessages, ernie.Message{
+				Role:    "user",
+				Content: m.Content(),
+			})
+		case *schema.FunctionChatMessage:
+			ernieMessages = append(ernieMessages, ernie.Message{
+				Role:    "function",
+				Name:    m.Name(),
+				Content: m.Content(),
+			})
+		case *schema.ToolChatMessage:
+			name, ok := toolCallNames[m.ToolCallID()]
+			if !ok {
+				return "", nil, fmt.Errorf("tool message %s without preceding tool call", m.ToolCallID())
+			}
+
+			ernieMessages = append(ernieMessages, ernie.Message{
+				Role:    "function",
+				Name:    name,
+				Content: m.Content(),
+			})
+		case *schema.GenericChatMessage:
+			ernieMessages = append(ernieMessages, ernie.Message{
+				Role:    m.Role(),
+				Content: m.Content(),
+			})
+		default:
+			return "", nil, fmt.Errorf("unsupported message type: %s", message.Type())
+		}
+	}
+
+	return strings.Join(system, "\n"), ernieMessages, nil
+}
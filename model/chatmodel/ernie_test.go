@@ -65,6 +65,44 @@ func TestErnie(t *testing.T) {
 		})
 	})
 
+	t.Run("FunctionCall", func(t *testing.T) {
+		client.createChatCompletionFn = func(ctx context.Context, model string, request *ernie.ChatCompletionRequest) (*ernie.ChatCompletionResponse, error) {
+			assert.Equal(t, "You are a helpful assistant.", request.System)
+			assert.Equal(t, []ernie.Function{{
+				Name:        "get_weather",
+				Description: "Get the current weather",
+				Parameters:  weatherFunction.Parameters,
+			}}, request.Functions)
+			assert.Equal(t, "get_weather", request.ToolChoice.Function.Name)
+			assert.Equal(t, []ernie.Message{
+				{Role: "user", Content: "What is the weather in Berlin?"},
+				{Role: "assistant", FunctionCall: &ernie.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}},
+				{Role: "function", Name: "get_weather", Content: "sunny"},
+			}, request.Messages)
+
+			return &ernie.ChatCompletionResponse{
+				FunctionCall: &ernie.FunctionCall{Name: "get_weather", Arguments: `{"location":"Paris"}`},
+			}, nil
+		}
+
+		result, err := ernieModel.Generate(context.Background(), schema.ChatMessages{
+			schema.NewSystemChatMessage("You are a helpful assistant."),
+			schema.NewHumanChatMessage("What is the weather in Berlin?"),
+			schema.NewAIChatMessage("", func(o *schema.ChatMessageExtension) {
+				o.FunctionCall = &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}
+			}),
+			schema.NewFunctionChatMessage("get_weather", "sunny"),
+		}, func(o *schema.GenerateOptions) {
+			o.Functions = []schema.FunctionDefinition{weatherFunction}
+			o.ForceFunctionCall = true
+		})
+		assert.NoError(t, err)
+
+		aiMsg, ok := result.Generations[0].Message.(*schema.AIChatMessage)
+		assert.True(t, ok)
+		assert.Equal(t, &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Paris"}`}, aiMsg.Extension().FunctionCall)
+	})
+
 	t.Run("Type", func(t *testing.T) {
 		assert.Equal(t, "chatmodel.Ernie", ernieModel.Type())
 	})
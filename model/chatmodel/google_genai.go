@@ -2,18 +2,22 @@ package chatmodel
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
 
-	"cloud.google.com/go/ai/generativelanguage/apiv1/generativelanguagepb"
+	"cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
 	"github.com/googleapis/gax-go/v2"
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/integration/jsonschema"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // Compile time check to ensure GoogleGenAI satisfies the ChatModel interface.
@@ -27,8 +31,9 @@ type GoogleGenAIClient interface {
 }
 
 const (
-	roleUser  = "user"
-	roleModel = "model"
+	roleUser     = "user"
+	roleModel    = "model"
+	roleFunction = "function"
 )
 
 type GoogleGenAIOptions struct {
@@ -50,6 +55,9 @@ type GoogleGenAIOptions struct {
 	TopK int32 `map:"top_k,omitempty"`
 	// Stream indicates whether to stream the results or not.
 	Stream bool `map:"stream,omitempty"`
+	// CountTokens enables the token usage, which is counted with additional calls of the CountTokens api.
+	// The token usage is omitted if counting fails.
+	CountTokens bool `map:"-"`
 }
 
 type GoogleGenAI struct {
@@ -98,21 +106,9 @@ func (cm *GoogleGenAI) Generate(ctx context.Context, messages schema.ChatMessage
 		fn(&opts)
 	}
 
-	contents := []*generativelanguagepb.Content{}
-
-	for _, message := range messages {
-		switch message.Type() {
-		case schema.ChatMessageTypeAI:
-			contents = append(contents, &generativelanguagepb.Content{Role: roleModel, Parts: []*generativelanguagepb.Part{{
-				Data: &generativelanguagepb.Part_Text{Text: message.Content()},
-			}}})
-		case schema.ChatMessageTypeHuman:
-			contents = append(contents, &generativelanguagepb.Content{Role: roleUser, Parts: []*generativelanguagepb.Part{{
-				Data: &generativelanguagepb.Part_Text{Text: message.Content()},
-			}}})
-		default:
-			return nil, fmt.Errorf("unsupported message type: %s", message.Type())
-		}
+	contents, err := convertMessagesToGoogleGenAIContents(messages)
+	if err != nil {
+		return nil, err
 	}
 
 	req := &generativelanguagepb.GenerateContentRequest{
@@ -128,15 +124,28 @@ func (cm *GoogleGenAI) Generate(ctx context.Context, messages schema.ChatMessage
 		},
 	}
 
+	// The api in this version does not support forcing a function call, so ForceFunctionCall is ignored.
+	if len(opts.Functions) > 0 {
+		tools, err := convertFunctionsToGoogleGenAITools(opts.Functions)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Tools = tools
+	}
+
 	generations := []schema.Generation{}
 
-	if cm.opts.Stream {
+	if cm.opts.Stream || opts.Stream {
 		stream, err := cm.client.StreamGenerateContent(ctx, req)
 		if err != nil {
 			return nil, err
 		}
 
-		tokens := []string{}
+		var (
+			tokens    []string
+			toolCalls []schema.ToolCall
+		)
 
 	streamProcessing:
 		for {
@@ -156,6 +165,19 @@ func (cm *GoogleGenAI) Generate(ctx context.Context, messages schema.ChatMessage
 				var b strings.Builder
 				for _, p := range res.Candidates[0].Content.Parts {
 					fmt.Fprintf(&b, "%s", p.GetText())
+
+					if fc := p.GetFunctionCall(); fc != nil {
+						tc, err := googleGenAIFunctionCallToToolCall(fc)
+						if err != nil {
+							return nil, err
+						}
+
+						if err := notifyToolCall(ctx, opts.CallbackManger, len(toolCalls), tc); err != nil {
+							return nil, err
+						}
+
+						toolCalls = append(toolCalls, tc)
+					}
 				}
 
 				token := b.String()
@@ -170,7 +192,7 @@ func (cm *GoogleGenAI) Generate(ctx context.Context, messages schema.ChatMessage
 			}
 		}
 
-		generations = append(generations, newChatGeneraton(strings.Join(tokens, "")))
+		generations = append(generations, newChatGeneraton(strings.Join(tokens, ""), withToolCalls(toolCalls)))
 	} else {
 		res, err := cm.client.GenerateContent(ctx, req)
 		if err != nil {
@@ -178,19 +200,46 @@ func (cm *GoogleGenAI) Generate(ctx context.Context, messages schema.ChatMessage
 		}
 
 		for _, c := range res.Candidates {
-			var b strings.Builder
+			var (
+				b         strings.Builder
+				toolCalls []schema.ToolCall
+			)
+
 			for _, p := range c.Content.Parts {
 				fmt.Fprintf(&b, "%s", p.GetText())
+
+				if fc := p.GetFunctionCall(); fc != nil {
+					tc, err := googleGenAIFunctionCallToToolCall(fc)
+					if err != nil {
+						return nil, err
+					}
+
+					toolCalls = append(toolCalls, tc)
+				}
 			}
 
-			generations = append(generations, newChatGeneraton(b.String()))
+			generations = append(generations, newChatGeneraton(b.String(), withToolCalls(toolCalls)))
 		}
 	}
 
-	return &schema.ModelResult{
+	result := &schema.ModelResult{
 		Generations: generations,
 		LLMOutput:   map[string]any{},
-	}, nil
+	}
+
+	// The token usage is best effort, so a failed count does not fail the generation.
+	if cm.opts.CountTokens {
+		if tokenUsage, err := googleGenAITokenUsage(ctx, cm.client, cm.opts.ModelName, contents, generations); err == nil {
+			result.LLMOutput["TokenUsage"] = tokenUsage
+		}
+	}
+
+	return result, nil
+}
+
+// Stream streams the text generated for the provided chat messages.
+func (cm *GoogleGenAI) Stream(ctx context.Context, messages schema.ChatMessages, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.ChatModelStream(ctx, cm, messages, optFns...)
 }
 
 // Type returns the type of the model.
@@ -212,3 +261,257 @@ func (cm *GoogleGenAI) Callbacks() []schema.Callback {
 func (cm *GoogleGenAI) InvocationParams() map[string]any {
 	return util.StructToMap(cm.opts)
 }
+
+// convertMessagesToGoogleGenAIContents converts chat messages to google genai contents.
+// System messages are sent as user content, and consecutive contents of the same role are merged,
+// because the api expects alternating turns.
+func convertMessagesToGoogleGenAIContents(messages schema.ChatMessages) ([]*generativelanguagepb.Content, error) {
+	contents := []*generativelanguagepb.Content{}
+
+	// The api matches function responses by name, so the names of the tool calls are tracked by ID.
+	toolCallNames := map[string]string{}
+
+	appendPart := func(role string, part *generativelanguagepb.Part) {
+		if len(contents) > 0 && contents[len(contents)-1].Role == role {
+			contents[len(contents)-1].Parts = append(contents[len(contents)-1].Parts, part)
+			return
+		}
+
+		contents = append(contents, &generativelanguagepb.Content{Role: role, Parts: []*generativelanguagepb.Part{part}})
+	}
+
+	for _, message := range messages {
+		switch m := message.(type) {
+		case *schema.SystemChatMessage:
+			appendPart(roleUser, &generativelanguagepb.Part{Data: &generativelanguagepb.Part_Text{Text: m.Content()}})
+		case *schema.HumanChatMessage:
+			if len(m.Parts()) == 0 {
+				appendPart(roleUser, &generativelanguagepb.Part{Data: &generativelanguagepb.Part_Text{Text: m.Content()}})
+				continue
+			}
+
+			for _, p := range m.ContentParts() {
+				part, err := convertContentPartToGoogleGenAIPart(p)
+				if err != nil {
+					return nil, err
+				}
+
+				appendPart(roleUser, part)
+			}
+		case *schema.AIChatMessage:
+			if m.Content() != "" {
+				appendPart(roleModel, &generativelanguagepb.Part{Data: &generativelanguagepb.Part_Text{Text: m.Content()}})
+			}
+
+			for _, tc := range toolCallsFromExtension(m.Extension()) {
+				args := map[string]any{}
+				if tc.Function.Arguments != "" {
+					if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+						return nil, err
+					}
+				}
+
+				argsStruct, err := structpb.NewStruct(args)
+				if err != nil {
+					return nil, err
+				}
+
+				if tc.ID != "" {
+					toolCallNames[tc.ID] = tc.Function.Name
+				}
+
+				appendPart(roleModel, &generativelanguagepb.Part{Data: &generativelanguagepb.Part_FunctionCall{
+					FunctionCall: &generativelanguagepb.FunctionCall{Name: tc.Function.Name, Args: argsStruct},
+				}})
+			}
+		case *schema.FunctionChatMessage:
+			part, err := googleGenAIFunctionResponsePart(m.Name(), m.Content())
+			if err != nil {
+				return nil, err
+			}
+
+			appendPart(roleFunction, part)
+		case *schema.ToolChatMessage:
+			name, ok := toolCallNames[m.ToolCallID()]
+			if !ok {
+				return nil, fmt.Errorf("tool message %s without preceding tool call", m.ToolCallID())
+			}
+
+			part, err := googleGenAIFunctionResponsePart(name, m.Content())
+			if err != nil {
+				return nil, err
+			}
+
+			appendPart(roleFunction, part)
+		default:
+			return nil, fmt.Errorf("unsupported message type: %s", message.Type())
+		}
+	}
+
+	return contents, nil
+}
+
+// convertContentPartToGoogleGenAIPart converts a content part to a google genai part.
+// Images and files are sent as inline data, because the api does not accept arbitrary URLs.
+func convertContentPartToGoogleGenAIPart(p schema.ContentPart) (*generativelanguagepb.Part, error) {
+	switch p.Type {
+	case schema.ContentPartTypeText:
+		return &generativelanguagepb.Part{Data: &generativelanguagepb.Part_Text{Text: p.Text}}, nil
+	case schema.ContentPartTypeImage, schema.ContentPartTypeFile:
+		if !p.IsInline() {
+			return nil, fmt.Errorf("content part with url %s is not supported, only inline data", p.URL)
+		}
+
+		return &generativelanguagepb.Part{Data: &generativelanguagepb.Part_InlineData{
+			InlineData: &generativelanguagepb.Blob{MimeType: p.MIMEType, Data: p.Data},
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported content part type: %s", p.Type)
+	}
+}
+
+// convertFunctionsToGoogleGenAITools converts function definitions to google genai tools.
+func convertFunctionsToGoogleGenAITools(functions []schema.FunctionDefinition) ([]*generativelanguagepb.Tool, error) {
+	declarations := make([]*generativelanguagepb.FunctionDeclaration, len(functions))
+
+	for i, fd := range functions {
+		parameters, err := convertJSONSchemaToGoogleGenAISchema(&jsonschema.Schema{
+			Type:       fd.Parameters.Type,
+			Properties: fd.Parameters.Properties,
+			Required:   fd.Parameters.Required,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		declarations[i] = &generativelanguagepb.FunctionDeclaration{
+			Name:        fd.Name,
+			Description: fd.Description,
+			Parameters:  parameters,
+		}
+	}
+
+	return []*generativelanguagepb.Tool{{FunctionDeclarations: declarations}}, nil
+}
+
+// convertJSONSchemaToGoogleGenAISchema converts a json schema to the openapi subset supported by google genai.
+func convertJSONSchemaToGoogleGenAISchema(s *jsonschema.Schema) (*generativelanguagepb.Schema, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	var t generativelanguagepb.Type
+
+	switch s.Type {
+	case jsonschema.TypeString:
+		t = generativelanguagepb.Type_STRING
+	case jsonschema.TypeNumber:
+		t = generativelanguagepb.Type_NUMBER
+	case jsonschema.TypeInteger:
+		t = generativelanguagepb.Type_INTEGER
+	case jsonschema.TypeBoolean:
+		t = generativelanguagepb.Type_BOOLEAN
+	case jsonschema.TypeArray:
+		t = generativelanguagepb.Type_ARRAY
+	case jsonschema.TypeObject:
+		t = generativelanguagepb.Type_OBJECT
+	default:
+		return nil, fmt.Errorf("unsupported schema type: %s", s.Type)
+	}
+
+	items, err := convertJSONSchemaToGoogleGenAISchema(s.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	var properties map[string]*generativelanguagepb.Schema
+
+	if len(s.Properties) > 0 {
+		properties = make(map[string]*generativelanguagepb.Schema, len(s.Properties))
+
+		for name, p := range s.Properties {
+			ps, err := convertJSONSchemaToGoogleGenAISchema(p)
+			if err != nil {
+				return nil, err
+			}
+
+			properties[name] = ps
+		}
+	}
+
+	enum := make([]string, 0, len(s.Enum))
+	for _, e := range s.Enum {
+		enum = append(enum, fmt.Sprint(e))
+	}
+
+	return &generativelanguagepb.Schema{
+		Type:        t,
+		Description: s.Description,
+		Nullable:    s.Nullable,
+		Enum:        enum,
+		Items:       items,
+		Properties:  properties,
+		Required:    s.Required,
+	}, nil
+}
+
+// googleGenAIFunctionResponsePart creates a part with the response of the named function.
+func googleGenAIFunctionResponsePart(name, content string) (*generativelanguagepb.Part, error) {
+	response, err := structpb.NewStruct(map[string]any{"content": content})
+	if err != nil {
+		return nil, err
+	}
+
+	return &generativelanguagepb.Part{Data: &generativelanguagepb.Part_FunctionResponse{
+		FunctionResponse: &generativelanguagepb.FunctionResponse{Name: name, Response: response},
+	}}, nil
+}
+
+// googleGenAIFunctionCallToToolCall converts a google genai function call to a schema.ToolCall.
+// The api does not assign IDs to function calls, so a unique ID is generated.
+func googleGenAIFunctionCallToToolCall(fc *generativelanguagepb.FunctionCall) (schema.ToolCall, error) {
+	args, err := json.Marshal(fc.GetArgs().AsMap())
+	if err != nil {
+		return schema.ToolCall{}, err
+	}
+
+	return schema.ToolCall{
+		ID: newToolCallID(),
+		Function: schema.FunctionCall{
+			Name:      fc.GetName(),
+			Arguments: string(args),
+		},
+	}, nil
+}
+
+// googleGenAITokenUsage counts the tokens of the prompt and of the generations with the CountTokens api,
+// because the api in this version does not return the token usage.
+func googleGenAITokenUsage(ctx context.Context, client GoogleGenAIClient, modelName string, contents []*generativelanguagepb.Content, generations []schema.Generation) (map[string]int, error) {
+	t := tokenizer.NewGoogleGenAI(client, modelName)
+
+	promptTokens, err := t.GetNumTokensFromContents(ctx, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	var completionTokens uint
+
+	for _, g := range generations {
+		if g.Text == "" {
+			continue
+		}
+
+		n, err := t.GetNumTokens(ctx, g.Text)
+		if err != nil {
+			return nil, err
+		}
+
+		completionTokens += n
+	}
+
+	return map[string]int{
+		"PromptTokens":     int(promptTokens),
+		"CompletionTokens": int(completionTokens),
+		"TotalTokens":      int(promptTokens + completionTokens),
+	}, nil
+}
@@ -4,17 +4,32 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
-	"cloud.google.com/go/ai/generativelanguage/apiv1/generativelanguagepb"
+	"cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
 	"github.com/googleapis/gax-go/v2"
 	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 func TestGoogleGenAI(t *testing.T) {
-	mockClient := &mockGoogleGenAIClient{}
-	model, err := NewGoogleGenAI(mockClient)
+	mockClient := &mockGoogleGenAIClient{
+		CountTokensFn: func(ctx context.Context, req *generativelanguagepb.CountTokensRequest, opts ...gax.CallOption) (*generativelanguagepb.CountTokensResponse, error) {
+			words := 0
+			for _, c := range req.Contents {
+				for _, p := range c.Parts {
+					words += len(strings.Fields(p.GetText()))
+				}
+			}
+
+			return &generativelanguagepb.CountTokensResponse{TotalTokens: int32(words)}, nil
+		},
+	}
+	model, err := NewGoogleGenAI(mockClient, func(o *GoogleGenAIOptions) {
+		o.CountTokens = true
+	})
 	assert.NoError(t, err)
 
 	t.Run("Generate_Success", func(t *testing.T) {
@@ -39,9 +54,40 @@ func TestGoogleGenAI(t *testing.T) {
 		result, err := model.Generate(context.Background(), chatMessages)
 		assert.NoError(t, err)
 		assert.Equal(t, "Generated text", result.Generations[0].Text)
+		assert.Equal(t, map[string]int{"PromptTokens": 4, "CompletionTokens": 2, "TotalTokens": 6}, result.LLMOutput["TokenUsage"])
 		assert.Equal(t, "Generated text", result.Generations[0].Message.Content())
 	})
 
+	t.Run("Generate_CountTokensError", func(t *testing.T) {
+		countTokensFn := mockClient.CountTokensFn
+		defer func() { mockClient.CountTokensFn = countTokensFn }()
+
+		mockClient.CountTokensFn = func(ctx context.Context, req *generativelanguagepb.CountTokensRequest, opts ...gax.CallOption) (*generativelanguagepb.CountTokensResponse, error) {
+			return nil, errors.New("quota exceeded")
+		}
+
+		result, err := model.Generate(context.Background(), []schema.ChatMessage{schema.NewHumanChatMessage("Can you help me?")})
+		assert.NoError(t, err)
+		assert.Equal(t, "Generated text", result.Generations[0].Text)
+		assert.NotContains(t, result.LLMOutput, "TokenUsage")
+	})
+
+	t.Run("Generate_WithoutCountTokens", func(t *testing.T) {
+		model, err := NewGoogleGenAI(&mockGoogleGenAIClient{
+			GenerateContentFn: mockClient.GenerateContentFn,
+			CountTokensFn: func(ctx context.Context, req *generativelanguagepb.CountTokensRequest, opts ...gax.CallOption) (*generativelanguagepb.CountTokensResponse, error) {
+				assert.Fail(t, "unexpected CountTokens call")
+				return nil, errors.New("unexpected call")
+			},
+		})
+		assert.NoError(t, err)
+
+		result, err := model.Generate(context.Background(), []schema.ChatMessage{schema.NewHumanChatMessage("Can you help me?")})
+		assert.NoError(t, err)
+		assert.Equal(t, "Generated text", result.Generations[0].Text)
+		assert.NotContains(t, result.LLMOutput, "TokenUsage")
+	})
+
 	t.Run("Generate_Error", func(t *testing.T) {
 		mockClient.GenerateContentFn = func(ctx context.Context, req *generativelanguagepb.GenerateContentRequest, opts ...gax.CallOption) (*generativelanguagepb.GenerateContentResponse, error) {
 			// Implement your custom behavior here, e.g., return a predefined response
@@ -57,7 +103,87 @@ func TestGoogleGenAI(t *testing.T) {
 		assert.ErrorContains(t, err, "google genai error")
 	})
 
+	t.Run("Generate_FunctionCall", func(t *testing.T) {
+		mockClient.GenerateContentFn = func(ctx context.Context, req *generativelanguagepb.GenerateContentRequest, opts ...gax.CallOption) (*generativelanguagepb.GenerateContentResponse, error) {
+			assert.Len(t, req.Tools, 1)
+			assert.Len(t, req.Tools[0].FunctionDeclarations, 1)
+
+			fd := req.Tools[0].FunctionDeclarations[0]
+			assert.Equal(t, "get_weather", fd.Name)
+			assert.Equal(t, generativelanguagepb.Type_OBJECT, fd.Parameters.Type)
+			assert.Equal(t, generativelanguagepb.Type_STRING, fd.Parameters.Properties["location"].Type)
+			assert.Equal(t, []string{"location"}, fd.Parameters.Required)
+
+			// The function call and the function response of the previous turn are passed as parts.
+			assert.Len(t, req.Contents, 3)
+			assert.Equal(t, "get_weather", req.Contents[1].Parts[0].GetFunctionCall().GetName())
+			assert.Equal(t, "function", req.Contents[2].Role)
+			assert.Equal(t, "sunny", req.Contents[2].Parts[0].GetFunctionResponse().GetResponse().AsMap()["content"])
+
+			args, err := structpb.NewStruct(map[string]any{"location": "Paris"})
+			assert.NoError(t, err)
+
+			return &generativelanguagepb.GenerateContentResponse{
+				Candidates: []*generativelanguagepb.Candidate{{
+					Content: &generativelanguagepb.Content{
+						Parts: []*generativelanguagepb.Part{{Data: &generativelanguagepb.Part_FunctionCall{
+							FunctionCall: &generativelanguagepb.FunctionCall{Name: "get_weather", Args: args},
+						}}},
+					},
+				}},
+			}, nil
+		}
+
+		chatMessages := schema.ChatMessages{
+			schema.NewHumanChatMessage("What is the weather in Berlin and Paris?"),
+			schema.NewAIChatMessage("", func(o *schema.ChatMessageExtension) {
+				o.FunctionCall = &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}
+			}),
+			schema.NewFunctionChatMessage("get_weather", "sunny"),
+		}
+
+		result, err := model.Generate(context.Background(), chatMessages, func(o *schema.GenerateOptions) {
+			o.Functions = []schema.FunctionDefinition{weatherFunction}
+		})
+		assert.NoError(t, err)
+
+		aiMsg, ok := result.Generations[0].Message.(*schema.AIChatMessage)
+		assert.True(t, ok)
+		assert.Equal(t, &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Paris"}`}, aiMsg.Extension().FunctionCall)
+	})
+
 	// Test the Type method
+	t.Run("Generate_ContentParts", func(t *testing.T) {
+		mockClient.GenerateContentFn = func(ctx context.Context, req *generativelanguagepb.GenerateContentRequest, opts ...gax.CallOption) (*generativelanguagepb.GenerateContentResponse, error) {
+			assert.Len(t, req.Contents, 1)
+			assert.Len(t, req.Contents[0].Parts, 2)
+			assert.Equal(t, "What is in the image?", req.Contents[0].Parts[0].GetText())
+			assert.Equal(t, "image/png", req.Contents[0].Parts[1].GetInlineData().GetMimeType())
+			assert.Equal(t, []byte("foo"), req.Contents[0].Parts[1].GetInlineData().GetData())
+
+			return &generativelanguagepb.GenerateContentResponse{
+				Candidates: []*generativelanguagepb.Candidate{{
+					Content: &generativelanguagepb.Content{
+						Parts: []*generativelanguagepb.Part{{Data: &generativelanguagepb.Part_Text{Text: "A cat."}}},
+					},
+				}},
+			}, nil
+		}
+
+		result, err := model.Generate(context.Background(), schema.ChatMessages{
+			schema.NewHumanChatMessage("What is in the image?", schema.NewImageContentPart("image/png", []byte("foo"))),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "A cat.", result.Generations[0].Text)
+	})
+
+	t.Run("Generate_ContentPartURL", func(t *testing.T) {
+		_, err := model.Generate(context.Background(), schema.ChatMessages{
+			schema.NewHumanChatMessage("What is in the image?", schema.NewImageURLContentPart("", "https://example.com/image.png")),
+		})
+		assert.ErrorContains(t, err, "only inline data")
+	})
+
 	t.Run("Type", func(t *testing.T) {
 		expectedType := "chatmodel.GoogleGenAI"
 		assert.Equal(t, expectedType, model.Type())
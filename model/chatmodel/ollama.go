@@ -2,6 +2,7 @@ package chatmodel
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -11,6 +12,7 @@ import (
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/integration/ollama"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -101,24 +103,15 @@ func (cm *Ollama) Generate(ctx context.Context, messages schema.ChatMessages, op
 		fn(&opts)
 	}
 
-	ollamaMessages := make([]ollama.Message, len(messages))
-
-	for i, m := range messages {
-		switch m.Type() { // nolint exhaustive
-		case schema.ChatMessageTypeSystem:
-			ollamaMessages[i] = ollama.Message{Role: "system", Content: m.Content()}
-		case schema.ChatMessageTypeAI:
-			ollamaMessages[i] = ollama.Message{Role: "assistant", Content: m.Content()}
-		case schema.ChatMessageTypeHuman:
-			ollamaMessages[i] = ollama.Message{Role: "user", Content: m.Content()}
-		default:
-			return nil, fmt.Errorf("unknown message type: %s", m.Type())
-		}
+	ollamaMessages, err := convertMessagesToOllamaMessages(messages)
+	if err != nil {
+		return nil, err
 	}
 
 	req := &ollama.ChatRequest{
 		Model:    cm.opts.ModelName,
 		Messages: ollamaMessages,
+		Tools:    convertFunctionsToOllamaTools(opts.Functions),
 		Stream:   util.AddrOrNil(false),
 		Options: ollama.Options{
 			Temperature:      cm.opts.Temperature,
@@ -131,9 +124,14 @@ func (cm *Ollama) Generate(ctx context.Context, messages schema.ChatMessages, op
 		},
 	}
 
-	content := ""
+	var (
+		content   string
+		toolCalls []schema.ToolCall
+		metrics   ollama.Metrics
+	)
 
-	if cm.opts.Stream {
+	// Ollama does not support forcing a tool call, so ForceFunctionCall is ignored.
+	if cm.opts.Stream || opts.Stream {
 		req.Stream = util.PTR(true)
 
 		stream, err := cm.client.CreateChatStream(ctx, req)
@@ -160,6 +158,21 @@ func (cm *Ollama) Generate(ctx context.Context, messages schema.ChatMessages, op
 					return nil, err
 				}
 
+				if res.Message != nil && len(res.Message.ToolCalls) > 0 {
+					tcs, err := ollamaToolCallsToToolCalls(res.Message.ToolCalls)
+					if err != nil {
+						return nil, err
+					}
+
+					for _, tc := range tcs {
+						if err := notifyToolCall(ctx, opts.CallbackManger, len(toolCalls), tc); err != nil {
+							return nil, err
+						}
+
+						toolCalls = append(toolCalls, tc)
+					}
+				}
+
 				if !res.Done {
 					if err := opts.CallbackManger.OnModelNewToken(ctx, &schema.ModelNewTokenManagerInput{
 						Token: res.Message.Content,
@@ -168,10 +181,9 @@ func (cm *Ollama) Generate(ctx context.Context, messages schema.ChatMessages, op
 					}
 
 					tokens = append(tokens, res.Message.Content)
+				} else {
+					metrics = res.Metrics
 				}
-				// else {
-				// 	// TODO Metrics, EvalCount, ... -> LLMOutput?
-				// }
 			}
 
 			content = strings.Join(tokens, "")
@@ -183,14 +195,31 @@ func (cm *Ollama) Generate(ctx context.Context, messages schema.ChatMessages, op
 		}
 
 		content = res.Message.Content
+		metrics = res.Metrics
+
+		toolCalls, err = ollamaToolCallsToToolCalls(res.Message.ToolCalls)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &schema.ModelResult{
-		Generations: []schema.Generation{newChatGeneraton(content)},
-		LLMOutput:   map[string]any{},
+		Generations: []schema.Generation{newChatGeneraton(content, withToolCalls(toolCalls))},
+		LLMOutput: map[string]any{
+			"TokenUsage": map[string]int{
+				"PromptTokens":     metrics.PromptEvalCount,
+				"CompletionTokens": metrics.EvalCount,
+				"TotalTokens":      metrics.PromptEvalCount + metrics.EvalCount,
+			},
+		},
 	}, nil
 }
 
+// Stream streams the text generated for the provided chat messages.
+func (cm *Ollama) Stream(ctx context.Context, messages schema.ChatMessages, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.ChatModelStream(ctx, cm, messages, optFns...)
+}
+
 // Type returns the type of the model.
 func (cm *Ollama) Type() string {
 	return "chatmodel.Ollama"
@@ -210,3 +239,94 @@ func (cm *Ollama) Callbacks() []schema.Callback {
 func (cm *Ollama) InvocationParams() map[string]any {
 	return util.StructToMap(cm.opts)
 }
+
+// convertMessagesToOllamaMessages converts chat messages to ollama messages.
+func convertMessagesToOllamaMessages(messages schema.ChatMessages) ([]ollama.Message, error) {
+	ollamaMessages := make([]ollama.Message, len(messages))
+
+	for i, m := range messages {
+		switch v := m.(type) {
+		case *schema.SystemChatMessage:
+			ollamaMessages[i] = ollama.Message{Role: "system", Content: v.Content()}
+		case *schema.AIChatMessage:
+			ollamaMessages[i] = ollama.Message{Role: "assistant", Content: v.Content()}
+
+			for _, tc := range toolCallsFromExtension(v.Extension()) {
+				args := map[string]any{}
+				if tc.Function.Arguments != "" {
+					if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+						return nil, err
+					}
+				}
+
+				ollamaMessages[i].ToolCalls = append(ollamaMessages[i].ToolCalls, ollama.ToolCall{
+					Function: ollama.ToolCallFunction{Name: tc.Function.Name, Arguments: args},
+				})
+			}
+		case *schema.HumanChatMessage:
+			ollamaMessages[i] = ollama.Message{Role: "user", Content: v.Content()}
+
+			// Ollama expects the text as content and the images as separate list.
+			for _, p := range v.Parts() {
+				switch {
+				case p.Type == schema.ContentPartTypeText && ollamaMessages[i].Content == "":
+					ollamaMessages[i].Content = p.Text
+				case p.Type == schema.ContentPartTypeText:
+					ollamaMessages[i].Content += "\n" + p.Text
+				case p.Type == schema.ContentPartTypeImage && p.IsInline():
+					ollamaMessages[i].Images = append(ollamaMessages[i].Images, ollama.ImageData(p.Data))
+				case p.Type == schema.ContentPartTypeImage:
+					return nil, fmt.Errorf("image with url %s is not supported, only inline data", p.URL)
+				default:
+					return nil, fmt.Errorf("unsupported content part type: %s", p.Type)
+				}
+			}
+		case *schema.FunctionChatMessage:
+			ollamaMessages[i] = ollama.Message{Role: "tool", Content: v.Content()}
+		case *schema.ToolChatMessage:
+			// Ollama matches tool results by order, so the tool call ID is not sent.
+			ollamaMessages[i] = ollama.Message{Role: "tool", Content: v.Content()}
+		default:
+			return nil, fmt.Errorf("unknown message type: %s", m.Type())
+		}
+	}
+
+	return ollamaMessages, nil
+}
+
+// convertFunctionsToOllamaTools converts function definitions to ollama tools.
+func convertFunctionsToOllamaTools(functions []schema.FunctionDefinition) []ollama.Tool {
+	return util.Map(functions, func(fd schema.FunctionDefinition, _ int) ollama.Tool {
+		return ollama.Tool{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        fd.Name,
+				Description: fd.Description,
+				Parameters:  fd.Parameters,
+			},
+		}
+	})
+}
+
+// ollamaToolCallsToToolCalls converts ollama tool calls to schema.ToolCalls.
+// Ollama does not assign IDs to tool calls, so unique IDs are generated.
+func ollamaToolCallsToToolCalls(toolCalls []ollama.ToolCall) ([]schema.ToolCall, error) {
+	result := make([]schema.ToolCall, 0, len(toolCalls))
+
+	for _, tc := range toolCalls {
+		args, err := json.Marshal(tc.Function.Arguments)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, schema.ToolCall{
+			ID: newToolCallID(),
+			Function: schema.FunctionCall{
+				Name:      tc.Function.Name,
+				Arguments: string(args),
+			},
+		})
+	}
+
+	return result, nil
+}
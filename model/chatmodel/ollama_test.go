@@ -34,6 +34,7 @@ func TestOllama(t *testing.T) {
 							Role:    "assistant",
 							Content: "I can help you with that.",
 						},
+						Metrics: ollama.Metrics{PromptEvalCount: 3, EvalCount: 7},
 					}, nil
 				},
 			}
@@ -54,6 +55,90 @@ func TestOllama(t *testing.T) {
 			// Check the result
 			assert.Len(t, result.Generations, 1)
 			assert.Equal(t, "I can help you with that.", result.Generations[0].Text)
+			assert.Equal(t, map[string]int{"PromptTokens": 3, "CompletionTokens": 7, "TotalTokens": 10}, result.LLMOutput["TokenUsage"])
+		})
+
+		t.Run("Images", func(t *testing.T) {
+			t.Parallel()
+
+			mockClient := &mockOllamaClient{
+				GenerateChatFunc: func(ctx context.Context, req *ollama.ChatRequest) (*ollama.ChatResponse, error) {
+					assert.Len(t, req.Messages, 1)
+					assert.Equal(t, "What is in the image?\nBe brief.", req.Messages[0].Content)
+					assert.Equal(t, []ollama.ImageData{ollama.ImageData("foo")}, req.Messages[0].Images)
+
+					return &ollama.ChatResponse{
+						Message: &ollama.Message{
+							Role:    "assistant",
+							Content: "A cat.",
+						},
+					}, nil
+				},
+			}
+
+			ollamaModel, err := NewOllama(mockClient)
+			assert.NoError(t, err)
+
+			result, err := ollamaModel.Generate(context.Background(), schema.ChatMessages{
+				schema.NewHumanChatMessage("What is in the image?",
+					schema.NewImageContentPart("image/png", []byte("foo")),
+					schema.NewTextContentPart("Be brief."),
+				),
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "A cat.", result.Generations[0].Text)
+		})
+
+		t.Run("FunctionCall", func(t *testing.T) {
+			t.Parallel()
+
+			mockClient := &mockOllamaClient{
+				GenerateChatFunc: func(ctx context.Context, req *ollama.ChatRequest) (*ollama.ChatResponse, error) {
+					assert.Equal(t, []ollama.Tool{{
+						Type: "function",
+						Function: ollama.ToolFunction{
+							Name:        "get_weather",
+							Description: "Get the current weather",
+							Parameters:  weatherFunction.Parameters,
+						},
+					}}, req.Tools)
+					assert.Len(t, req.Messages, 3)
+					assert.Equal(t, []ollama.ToolCall{{
+						Function: ollama.ToolCallFunction{Name: "get_weather", Arguments: map[string]any{"location": "Berlin"}},
+					}}, req.Messages[1].ToolCalls)
+					assert.Equal(t, "tool", req.Messages[2].Role)
+					assert.Equal(t, "sunny", req.Messages[2].Content)
+
+					return &ollama.ChatResponse{
+						Message: &ollama.Message{
+							Role: "assistant",
+							ToolCalls: []ollama.ToolCall{{
+								Function: ollama.ToolCallFunction{Name: "get_weather", Arguments: map[string]any{"location": "Paris"}},
+							}},
+						},
+					}, nil
+				},
+			}
+
+			ollamaModel, err := NewOllama(mockClient)
+			assert.NoError(t, err)
+
+			messages := schema.ChatMessages{
+				schema.NewHumanChatMessage("What is the weather in Berlin and Paris?"),
+				schema.NewAIChatMessage("", func(o *schema.ChatMessageExtension) {
+					o.FunctionCall = &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}
+				}),
+				schema.NewFunctionChatMessage("get_weather", "sunny"),
+			}
+
+			result, err := ollamaModel.Generate(context.Background(), messages, func(o *schema.GenerateOptions) {
+				o.Functions = []schema.FunctionDefinition{weatherFunction}
+			})
+			assert.NoError(t, err)
+
+			aiMsg, ok := result.Generations[0].Message.(*schema.AIChatMessage)
+			assert.True(t, ok)
+			assert.Equal(t, &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Paris"}`}, aiMsg.Extension().FunctionCall)
 		})
 
 		t.Run("Error", func(t *testing.T) {
@@ -11,6 +11,7 @@ import (
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/integration"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 	"github.com/sashabaranov/go-openai"
@@ -166,7 +167,7 @@ func (cm *OpenAI) Generate(ctx context.Context, messages schema.ChatMessages, op
 	choices := []openai.ChatCompletionChoice{}
 	tokenUsage := make(map[string]int)
 
-	if cm.opts.Stream {
+	if cm.opts.Stream || opts.Stream {
 		request.Stream = true
 
 		stream, err := cm.client.CreateChatCompletionStream(ctx, request)
@@ -179,7 +180,7 @@ func (cm *OpenAI) Generate(ctx context.Context, messages schema.ChatMessages, op
 		var (
 			role         string
 			tokens       []string
-			functionCall *openai.FunctionCall
+			toolCalls    []openai.ToolCall
 			finishReason openai.FinishReason
 		)
 
@@ -204,21 +205,38 @@ func (cm *OpenAI) Generate(ctx context.Context, messages schema.ChatMessages, op
 					return nil, err
 				}
 
-				role = res.Choices[0].Delta.Role
+				if res.Choices[0].Delta.Role != "" {
+					role = res.Choices[0].Delta.Role
+				}
+
 				tokens = append(tokens, res.Choices[0].Delta.Content)
 				finishReason = res.Choices[0].FinishReason
 
-				if len(res.Choices[0].Delta.ToolCalls) > 0 {
-					functionCall = &res.Choices[0].Delta.ToolCalls[0].Function
+				for _, delta := range res.Choices[0].Delta.ToolCalls {
+					var index int
+					toolCalls, index = mergeOpenAIToolCallDelta(toolCalls, delta)
+
+					if err := opts.CallbackManger.OnModelNewToken(ctx, &schema.ModelNewTokenManagerInput{
+						ToolCallDelta: &schema.ToolCallDelta{
+							Index: index,
+							ID:    delta.ID,
+							Function: schema.FunctionCall{
+								Name:      delta.Function.Name,
+								Arguments: delta.Function.Arguments,
+							},
+						},
+					}); err != nil {
+						return nil, err
+					}
 				}
 			}
 		}
 
 		choices = append(choices, openai.ChatCompletionChoice{
 			Message: openai.ChatCompletionMessage{
-				Role:         role,
-				Content:      strings.Join(tokens, ""),
-				FunctionCall: functionCall,
+				Role:      role,
+				Content:   strings.Join(tokens, ""),
+				ToolCalls: toolCalls,
 			},
 			FinishReason: finishReason,
 		})
@@ -254,6 +272,11 @@ func (cm *OpenAI) Generate(ctx context.Context, messages schema.ChatMessages, op
 	}, nil
 }
 
+// Stream streams the text generated for the provided chat messages.
+func (cm *OpenAI) Stream(ctx context.Context, messages schema.ChatMessages, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.ChatModelStream(ctx, cm, messages, optFns...)
+}
+
 func (cm *OpenAI) createChatCompletionWithRetry(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
 	retryOpts := []retry.Option{
 		retry.Attempts(cm.opts.MaxRetries),
@@ -319,20 +342,59 @@ func openAIResponseToChatMessage(msg openai.ChatCompletionMessage) schema.ChatMe
 		return schema.NewHumanChatMessage(msg.Content)
 	case "assistant":
 		if len(msg.ToolCalls) > 0 {
-			return schema.NewAIChatMessage(msg.Content, func(o *schema.ChatMessageExtension) {
-				o.FunctionCall = &schema.FunctionCall{
-					Name:      msg.ToolCalls[0].Function.Name,
-					Arguments: msg.ToolCalls[0].Function.Arguments,
+			return schema.NewAIChatMessage(msg.Content, withToolCalls(util.Map(msg.ToolCalls, func(tc openai.ToolCall, _ int) schema.ToolCall {
+				return schema.ToolCall{
+					ID: tc.ID,
+					Function: schema.FunctionCall{
+						Name:      tc.Function.Name,
+						Arguments: tc.Function.Arguments,
+					},
 				}
-			})
+			})))
 		}
 
 		return schema.NewAIChatMessage(msg.Content)
 	case "system":
 		return schema.NewSystemChatMessage(msg.Content)
 	case "function":
-		return schema.NewFunctionChatMessage(msg.Content, msg.Name)
+		return schema.NewFunctionChatMessage(msg.Name, msg.Content)
+	case "tool":
+		return schema.NewToolChatMessage(msg.ToolCallID, msg.Content)
 	}
 
 	return schema.NewGenericChatMessage(msg.Content, "unknown")
 }
+
+// mergeOpenAIToolCallDelta merges a streamed tool call delta into the tool calls and returns
+// the index of the tool call it belongs to. The first delta of a tool call carries its ID and name,
+// subsequent deltas carry fragments of the arguments and are matched by their index. A delta without
+// index belongs to the last tool call, unless it starts a new one.
+func mergeOpenAIToolCallDelta(toolCalls []openai.ToolCall, delta openai.ToolCall) ([]openai.ToolCall, int) {
+	index := len(toolCalls) - 1
+	if delta.Index != nil {
+		index = *delta.Index
+	} else if delta.ID != "" {
+		index = len(toolCalls)
+	}
+
+	if index < 0 {
+		index = 0
+	}
+
+	for len(toolCalls) <= index {
+		toolCalls = append(toolCalls, openai.ToolCall{Type: openai.ToolTypeFunction})
+	}
+
+	if delta.ID != "" {
+		toolCalls[index].ID = delta.ID
+	}
+
+	if delta.Type != "" {
+		toolCalls[index].Type = delta.Type
+	}
+
+	toolCalls[index].Function.Name += delta.Function.Name
+	toolCalls[index].Function.Arguments += delta.Function.Arguments
+
+	return toolCalls, index
+}
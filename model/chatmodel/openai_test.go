@@ -154,3 +154,58 @@ func TestOpenAIResponseToChatMessage(t *testing.T) {
 	assert.IsType(t, &schema.GenericChatMessage{}, unknownChatMessage)
 	assert.Equal(t, "Unknown message", unknownChatMessage.Content())
 }
+
+func TestOpenAIResponseToChatMessageWithToolCalls(t *testing.T) {
+	msg := openAIResponseToChatMessage(openai.ChatCompletionMessage{
+		Role: "assistant",
+		ToolCalls: []openai.ToolCall{
+			{ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}},
+			{ID: "call_2", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"location":"Paris"}`}},
+		},
+	})
+
+	aiMsg, ok := msg.(*schema.AIChatMessage)
+	assert.True(t, ok)
+
+	ext := aiMsg.Extension()
+	assert.Equal(t, &schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}, ext.FunctionCall)
+	assert.Equal(t, []schema.ToolCall{
+		{ID: "call_1", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Berlin"}`}},
+		{ID: "call_2", Function: schema.FunctionCall{Name: "get_weather", Arguments: `{"location":"Paris"}`}},
+	}, ext.ToolCalls)
+}
+
+func TestMergeOpenAIToolCallDelta(t *testing.T) {
+	index0, index1 := 0, 1
+
+	deltas := []openai.ToolCall{
+		{Index: &index0, ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "get_weather"}},
+		{Index: &index0, Function: openai.FunctionCall{Arguments: `{"location":`}},
+		{Index: &index0, Function: openai.FunctionCall{Arguments: `"Berlin"}`}},
+		{Index: &index1, ID: "call_2", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"location":`}},
+		// Deltas without index belong to the last tool call.
+		{Function: openai.FunctionCall{Arguments: `"Paris"}`}},
+		{ID: "call_3", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "get_time"}},
+		{Function: openai.FunctionCall{Arguments: `{}`}},
+	}
+
+	var (
+		toolCalls []openai.ToolCall
+		indexes   []int
+	)
+
+	for _, delta := range deltas {
+		var index int
+		toolCalls, index = mergeOpenAIToolCallDelta(toolCalls, delta)
+		indexes = append(indexes, index)
+	}
+
+	assert.Equal(t, []int{0, 0, 0, 1, 1, 2, 2}, indexes)
+	assert.Len(t, toolCalls, 3)
+	assert.Equal(t, "call_1", toolCalls[0].ID)
+	assert.Equal(t, `{"location":"Berlin"}`, toolCalls[0].Function.Arguments)
+	assert.Equal(t, "call_2", toolCalls[1].ID)
+	assert.Equal(t, `{"location":"Paris"}`, toolCalls[1].Function.Arguments)
+	assert.Equal(t, "call_3", toolCalls[2].ID)
+	assert.Equal(t, `{}`, toolCalls[2].Function.Arguments)
+}
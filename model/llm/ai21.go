@@ -7,6 +7,7 @@ import (
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/integration/ai21"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -146,6 +147,12 @@ func (l *AI21) Generate(ctx context.Context, prompt string, optFns ...func(o *sc
 	}, nil
 }
 
+// Stream streams the text generated for the provided prompt.
+// Streaming is not supported natively, so the stream only has the final result chunk.
+func (l *AI21) Stream(ctx context.Context, prompt string, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.LLMStream(ctx, l, prompt, optFns...)
+}
+
 // Type returns the type of the model.
 func (l *AI21) Type() string {
 	return "llm.AI21"
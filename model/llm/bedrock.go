@@ -4,15 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	bedrockruntimeTypes "github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/integration/ai21"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -724,9 +729,12 @@ func (l *Bedrock) Generate(ctx context.Context, prompt string, optFns ...func(o
 		return nil, err
 	}
 
-	var completion string
+	var (
+		completion string
+		metrics    bedrockInvocationMetrics
+	)
 
-	if l.opts.Stream {
+	if l.opts.Stream || opts.Stream {
 		res, err := l.client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
 			ModelId:     aws.String(l.modelID),
 			Body:        body,
@@ -758,6 +766,10 @@ func (l *Bedrock) Generate(ctx context.Context, prompt string, optFns ...func(o
 				}
 
 				tokens = append(tokens, token)
+
+				if m, ok := bedrockStreamInvocationMetrics(v.Value.Bytes); ok {
+					metrics = m
+				}
 			}
 		}
 
@@ -779,14 +791,22 @@ func (l *Bedrock) Generate(ctx context.Context, prompt string, optFns ...func(o
 		}
 
 		completion = output
+		metrics = bedrockResponseInvocationMetrics(res.ResultMetadata)
 	}
 
 	return &schema.ModelResult{
 		Generations: []schema.Generation{{Text: completion}},
-		LLMOutput:   map[string]any{},
+		LLMOutput: map[string]any{
+			"TokenUsage": metrics.tokenUsage(),
+		},
 	}, nil
 }
 
+// Stream streams the text generated for the provided prompt.
+func (l *Bedrock) Stream(ctx context.Context, prompt string, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.LLMStream(ctx, l, prompt, optFns...)
+}
+
 // Type returns the type of the model.
 func (l *Bedrock) Type() string {
 	return "llm.Bedrock"
@@ -814,3 +834,46 @@ func (l *Bedrock) InvocationParams() map[string]any {
 func (l *Bedrock) getProvider() string {
 	return strings.Split(l.modelID, ".")[0]
 }
+
+// bedrockInvocationMetrics holds the token counts of a bedrock model invocation.
+type bedrockInvocationMetrics struct {
+	InputTokenCount  int `json:"inputTokenCount"`
+	OutputTokenCount int `json:"outputTokenCount"`
+}
+
+// tokenUsage returns the token counts in the format of the LLMOutput.
+func (m bedrockInvocationMetrics) tokenUsage() map[string]int {
+	return map[string]int{
+		"PromptTokens":     m.InputTokenCount,
+		"CompletionTokens": m.OutputTokenCount,
+		"TotalTokens":      m.InputTokenCount + m.OutputTokenCount,
+	}
+}
+
+// bedrockStreamInvocationMetrics returns the invocation metrics, which bedrock adds to the last chunk of a response stream.
+func bedrockStreamInvocationMetrics(chunk []byte) (bedrockInvocationMetrics, bool) {
+	output := struct {
+		Metrics *bedrockInvocationMetrics `json:"amazon-bedrock-invocationMetrics"`
+	}{}
+
+	if err := json.Unmarshal(chunk, &output); err != nil || output.Metrics == nil {
+		return bedrockInvocationMetrics{}, false
+	}
+
+	return *output.Metrics, true
+}
+
+// bedrockResponseInvocationMetrics returns the invocation metrics from the headers of the raw bedrock response.
+func bedrockResponseInvocationMetrics(metadata middleware.Metadata) bedrockInvocationMetrics {
+	metrics := bedrockInvocationMetrics{}
+
+	res, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response)
+	if !ok {
+		return metrics
+	}
+
+	metrics.InputTokenCount, _ = strconv.Atoi(res.Header.Get("X-Amzn-Bedrock-Input-Token-Count"))
+	metrics.OutputTokenCount, _ = strconv.Atoi(res.Header.Get("X-Amzn-Bedrock-Output-Token-Count"))
+
+	return metrics
+}
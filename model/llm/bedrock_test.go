@@ -228,6 +228,19 @@ func TestBedrockInputOutputAdapter(t *testing.T) {
 	})
 }
 
+func TestBedrockStreamInvocationMetrics(t *testing.T) {
+	t.Run("LastChunk", func(t *testing.T) {
+		metrics, ok := bedrockStreamInvocationMetrics([]byte(`{"completion":"","amazon-bedrock-invocationMetrics":{"inputTokenCount":12,"outputTokenCount":34}}`))
+		assert.True(t, ok)
+		assert.Equal(t, map[string]int{"PromptTokens": 12, "CompletionTokens": 34, "TotalTokens": 46}, metrics.tokenUsage())
+	})
+
+	t.Run("Chunk", func(t *testing.T) {
+		_, ok := bedrockStreamInvocationMetrics([]byte(`{"completion":"Generated text"}`))
+		assert.False(t, ok)
+	})
+}
+
 func TestBedrock(t *testing.T) {
 	client := &mockBedrockClient{}
 
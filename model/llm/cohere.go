@@ -11,6 +11,7 @@ import (
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -150,15 +151,32 @@ func (l *Cohere) Generate(ctx context.Context, prompt string, optFns ...func(o *
 		return nil, err
 	}
 
+	var promptTokens, completionTokens int
+	if res.Meta != nil && res.Meta.BilledUnits != nil {
+		promptTokens = int(util.Deref(res.Meta.BilledUnits.InputTokens))
+		completionTokens = int(util.Deref(res.Meta.BilledUnits.OutputTokens))
+	}
+
 	return &schema.ModelResult{
 		Generations: []schema.Generation{{Text: res.Generations[0].Text}},
 		LLMOutput: map[string]any{
 			"likelihood":       res.Generations[0].Likelihood,
 			"tokenLikelihoods": res.Generations[0].TokenLikelihoods,
+			"TokenUsage": map[string]int{
+				"PromptTokens":     promptTokens,
+				"CompletionTokens": completionTokens,
+				"TotalTokens":      promptTokens + completionTokens,
+			},
 		},
 	}, nil
 }
 
+// Stream streams the text generated for the provided prompt.
+// Streaming is not supported natively, so the stream only has the final result chunk.
+func (l *Cohere) Stream(ctx context.Context, prompt string, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.LLMStream(ctx, l, prompt, optFns...)
+}
+
 func (l *Cohere) generateWithRetry(ctx context.Context, req *cohere.GenerateRequest) (*cohere.Generation, error) {
 	retryOpts := []retry.Option{
 		retry.Attempts(l.opts.MaxRetries),
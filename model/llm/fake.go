@@ -6,6 +6,7 @@ import (
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 )
 
@@ -68,7 +69,28 @@ func (l *Fake) Generate(ctx context.Context, prompt string, optFns ...func(o *sc
 		fn(&opts)
 	}
 
-	return l.fakeResultFunc(ctx, prompt)
+	result, err := l.fakeResultFunc(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	// When streaming, the text of each generation is reported as a single token.
+	if opts.Stream {
+		for _, g := range result.Generations {
+			if err := opts.CallbackManger.OnModelNewToken(ctx, &schema.ModelNewTokenManagerInput{
+				Token: g.Text,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Stream streams the text generated for the provided prompt.
+func (l *Fake) Stream(ctx context.Context, prompt string, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.LLMStream(ctx, l, prompt, optFns...)
 }
 
 // Type returns the type of the model.
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/assert"
 )
@@ -33,6 +34,38 @@ func TestFake(t *testing.T) {
 		assert.Equal(t, expectedText, result.Generations[0].Text)
 	})
 
+	// Test the Stream method
+	t.Run("Stream", func(t *testing.T) {
+		chunks := []schema.StreamChunk{}
+		for chunk := range fake.Stream(context.Background(), "Hello, world!") {
+			chunks = append(chunks, chunk)
+		}
+
+		assert.Len(t, chunks, 2)
+		assert.Equal(t, schema.StreamChunkTypeToken, chunks[0].Type)
+		assert.Equal(t, "Generated text based on prompt: Hello, world!", chunks[0].Token)
+		assert.Equal(t, schema.StreamChunkTypeResult, chunks[1].Type)
+		assert.Equal(t, "Generated text based on prompt: Hello, world!", chunks[1].Result.Generations[0].Text)
+	})
+
+	t.Run("Stream_Callbacks", func(t *testing.T) {
+		tracer := callback.NewTracer()
+
+		fake := NewFake(resultFunc, func(o *FakeOptions) {
+			o.Callbacks = []schema.Callback{tracer}
+		})
+
+		for range fake.Stream(context.Background(), "Hello, world!") {
+		}
+
+		runs := tracer.Runs()
+		assert.Len(t, runs, 1)
+		assert.Equal(t, callback.RunTypeLLM, runs[0].Type)
+		assert.Equal(t, map[string]any{"prompt": "Hello, world!"}, runs[0].Inputs)
+		assert.Equal(t, map[string]any{"generations": []any{"Generated text based on prompt: Hello, world!"}}, runs[0].Outputs)
+		assert.NotNil(t, runs[0].EndTime)
+	})
+
 	// Test the Type method
 	t.Run("Type", func(t *testing.T) {
 		expectedType := "llm.Fake"
@@ -7,11 +7,12 @@ import (
 	"io"
 	"strings"
 
-	"cloud.google.com/go/ai/generativelanguage/apiv1/generativelanguagepb"
+	"cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
 	"github.com/googleapis/gax-go/v2"
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -46,6 +47,9 @@ type GoogleGenAIOptions struct {
 	TopK int32 `map:"top_k,omitempty"`
 	// Stream indicates whether to stream the results or not.
 	Stream bool `map:"stream,omitempty"`
+	// CountTokens enables the token usage, which is counted with additional calls of the CountTokens api.
+	// The token usage is omitted if counting fails.
+	CountTokens bool `map:"-"`
 }
 
 // GoogleGenAI represents the GoogleGenAI Language Model.
@@ -113,7 +117,7 @@ func (l *GoogleGenAI) Generate(ctx context.Context, prompt string, optFns ...fun
 
 	generations := []schema.Generation{}
 
-	if l.opts.Stream {
+	if l.opts.Stream || opts.Stream {
 		stream, err := l.client.StreamGenerateContent(ctx, req)
 		if err != nil {
 			return nil, err
@@ -170,10 +174,24 @@ func (l *GoogleGenAI) Generate(ctx context.Context, prompt string, optFns ...fun
 		}
 	}
 
-	return &schema.ModelResult{
+	result := &schema.ModelResult{
 		Generations: generations,
 		LLMOutput:   map[string]any{},
-	}, nil
+	}
+
+	// The token usage is best effort, so a failed count does not fail the generation.
+	if l.opts.CountTokens {
+		if tokenUsage, err := googleGenAITokenUsage(ctx, l.client, l.opts.ModelName, req.Contents, generations); err == nil {
+			result.LLMOutput["TokenUsage"] = tokenUsage
+		}
+	}
+
+	return result, nil
+}
+
+// Stream streams the text generated for the provided prompt.
+func (l *GoogleGenAI) Stream(ctx context.Context, prompt string, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.LLMStream(ctx, l, prompt, optFns...)
 }
 
 // Type returns the type of the model.
@@ -195,3 +213,35 @@ func (l *GoogleGenAI) Callbacks() []schema.Callback {
 func (l *GoogleGenAI) InvocationParams() map[string]any {
 	return util.StructToMap(l.opts)
 }
+
+// googleGenAITokenUsage counts the tokens of the prompt and of the generations with the CountTokens api,
+// because the api in this version does not return the token usage.
+func googleGenAITokenUsage(ctx context.Context, client GoogleGenAIClient, modelName string, contents []*generativelanguagepb.Content, generations []schema.Generation) (map[string]int, error) {
+	t := tokenizer.NewGoogleGenAI(client, modelName)
+
+	promptTokens, err := t.GetNumTokensFromContents(ctx, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	var completionTokens uint
+
+	for _, g := range generations {
+		if g.Text == "" {
+			continue
+		}
+
+		n, err := t.GetNumTokens(ctx, g.Text)
+		if err != nil {
+			return nil, err
+		}
+
+		completionTokens += n
+	}
+
+	return map[string]int{
+		"PromptTokens":     int(promptTokens),
+		"CompletionTokens": int(completionTokens),
+		"TotalTokens":      int(promptTokens + completionTokens),
+	}, nil
+}
@@ -4,16 +4,30 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
-	"cloud.google.com/go/ai/generativelanguage/apiv1/generativelanguagepb"
+	"cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
 	"github.com/googleapis/gax-go/v2"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGoogleGenAI(t *testing.T) {
-	mockClient := &mockGoogleGenAIClient{}
-	model, err := NewGoogleGenAI(mockClient)
+	mockClient := &mockGoogleGenAIClient{
+		CountTokensFn: func(ctx context.Context, req *generativelanguagepb.CountTokensRequest, opts ...gax.CallOption) (*generativelanguagepb.CountTokensResponse, error) {
+			words := 0
+			for _, c := range req.Contents {
+				for _, p := range c.Parts {
+					words += len(strings.Fields(p.GetText()))
+				}
+			}
+
+			return &generativelanguagepb.CountTokensResponse{TotalTokens: int32(words)}, nil
+		},
+	}
+	model, err := NewGoogleGenAI(mockClient, func(o *GoogleGenAIOptions) {
+		o.CountTokens = true
+	})
 	assert.NoError(t, err)
 
 	t.Run("Generate_Success", func(t *testing.T) {
@@ -33,6 +47,37 @@ func TestGoogleGenAI(t *testing.T) {
 		result, err := model.Generate(context.Background(), "Test prompt")
 		assert.NoError(t, err)
 		assert.Equal(t, "Generated text", result.Generations[0].Text)
+		assert.Equal(t, map[string]int{"PromptTokens": 2, "CompletionTokens": 2, "TotalTokens": 4}, result.LLMOutput["TokenUsage"])
+	})
+
+	t.Run("Generate_CountTokensError", func(t *testing.T) {
+		countTokensFn := mockClient.CountTokensFn
+		defer func() { mockClient.CountTokensFn = countTokensFn }()
+
+		mockClient.CountTokensFn = func(ctx context.Context, req *generativelanguagepb.CountTokensRequest, opts ...gax.CallOption) (*generativelanguagepb.CountTokensResponse, error) {
+			return nil, errors.New("quota exceeded")
+		}
+
+		result, err := model.Generate(context.Background(), "Test prompt")
+		assert.NoError(t, err)
+		assert.Equal(t, "Generated text", result.Generations[0].Text)
+		assert.NotContains(t, result.LLMOutput, "TokenUsage")
+	})
+
+	t.Run("Generate_WithoutCountTokens", func(t *testing.T) {
+		model, err := NewGoogleGenAI(&mockGoogleGenAIClient{
+			GenerateContentFn: mockClient.GenerateContentFn,
+			CountTokensFn: func(ctx context.Context, req *generativelanguagepb.CountTokensRequest, opts ...gax.CallOption) (*generativelanguagepb.CountTokensResponse, error) {
+				assert.Fail(t, "unexpected CountTokens call")
+				return nil, errors.New("unexpected call")
+			},
+		})
+		assert.NoError(t, err)
+
+		result, err := model.Generate(context.Background(), "Test prompt")
+		assert.NoError(t, err)
+		assert.Equal(t, "Generated text", result.Generations[0].Text)
+		assert.NotContains(t, result.LLMOutput, "TokenUsage")
 	})
 
 	t.Run("Generate_Error", func(t *testing.T) {
@@ -8,6 +8,7 @@ import (
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -121,6 +122,12 @@ func (l *HuggingFaceHub) Generate(ctx context.Context, prompt string, optFns ...
 	}, nil
 }
 
+// Stream streams the text generated for the provided prompt.
+// Streaming is not supported natively, so the stream only has the final result chunk.
+func (l *HuggingFaceHub) Stream(ctx context.Context, prompt string, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.LLMStream(ctx, l, prompt, optFns...)
+}
+
 // textGeneration performs text generation based on the provided input using the Hugging Face Hub client.
 func (l *HuggingFaceHub) textGeneration(ctx context.Context, input string) (string, error) {
 	res, err := l.client.TextGeneration(ctx, &huggingface.TextGenerationRequest{
@@ -10,6 +10,7 @@ import (
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/integration/ollama"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -114,9 +115,12 @@ func (l *Ollama) Generate(ctx context.Context, prompt string, optFns ...func(o *
 		},
 	}
 
-	var text string
+	var (
+		text    string
+		metrics ollama.Metrics
+	)
 
-	if l.opts.Stream {
+	if l.opts.Stream || opts.Stream {
 		req.Stream = util.PTR(true)
 
 		stream, err := l.client.CreateGenerationStream(ctx, req)
@@ -151,10 +155,9 @@ func (l *Ollama) Generate(ctx context.Context, prompt string, optFns ...func(o *
 					}
 
 					tokens = append(tokens, res.Response)
+				} else {
+					metrics = res.Metrics
 				}
-				// else {
-				// 	// TODO Metrics, EvalCount, ... -> LLMOutput?
-				// }
 			}
 
 			text = strings.Join(tokens, "")
@@ -166,16 +169,26 @@ func (l *Ollama) Generate(ctx context.Context, prompt string, optFns ...func(o *
 		}
 
 		text = res.Response
+		metrics = res.Metrics
 	}
 
 	return &schema.ModelResult{
 		Generations: []schema.Generation{{Text: text}},
-		LLMOutput:   map[string]any{
-			//"Done": res.Done,
+		LLMOutput: map[string]any{
+			"TokenUsage": map[string]int{
+				"PromptTokens":     metrics.PromptEvalCount,
+				"CompletionTokens": metrics.EvalCount,
+				"TotalTokens":      metrics.PromptEvalCount + metrics.EvalCount,
+			},
 		},
 	}, nil
 }
 
+// Stream streams the text generated for the provided prompt.
+func (l *Ollama) Stream(ctx context.Context, prompt string, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.LLMStream(ctx, l, prompt, optFns...)
+}
+
 // Type returns the type of the model.
 func (l *Ollama) Type() string {
 	return "llm.Ollama"
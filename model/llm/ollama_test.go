@@ -26,6 +26,7 @@ func TestOllama(t *testing.T) {
 
 					return &ollama.GenerationResponse{
 						Response: "I can help you with that.",
+						Metrics:  ollama.Metrics{PromptEvalCount: 3, EvalCount: 7},
 					}, nil
 				},
 			}
@@ -40,6 +41,7 @@ func TestOllama(t *testing.T) {
 			// Check the result
 			assert.Len(t, result.Generations, 1)
 			assert.Equal(t, "I can help you with that.", result.Generations[0].Text)
+			assert.Equal(t, map[string]int{"PromptTokens": 3, "CompletionTokens": 7, "TotalTokens": 10}, result.LLMOutput["TokenUsage"])
 		})
 
 		t.Run("Error", func(t *testing.T) {
@@ -10,6 +10,7 @@ import (
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 	"github.com/sashabaranov/go-openai"
@@ -152,7 +153,7 @@ func (l *OpenAI) Generate(ctx context.Context, prompt string, optFns ...func(o *
 		Stop:             opts.Stop,
 	}
 
-	if l.opts.Stream {
+	if l.opts.Stream || opts.Stream {
 		completionRequest.Stream = true
 
 		stream, err := l.client.CreateCompletionStream(ctx, completionRequest)
@@ -224,6 +225,11 @@ func (l *OpenAI) Generate(ctx context.Context, prompt string, optFns ...func(o *
 	}, nil
 }
 
+// Stream streams the text generated for the provided prompt.
+func (l *OpenAI) Stream(ctx context.Context, prompt string, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.LLMStream(ctx, l, prompt, optFns...)
+}
+
 func (l *OpenAI) createCompletionWithRetry(ctx context.Context, request openai.CompletionRequest) (openai.CompletionResponse, error) {
 	retryOpts := []retry.Option{
 		retry.Attempts(l.opts.MaxRetries),
@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sagemakerruntime"
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 )
@@ -157,6 +158,12 @@ func (l *SagemakerEndpoint) Generate(ctx context.Context, prompt string, optFns
 	}, nil
 }
 
+// Stream streams the text generated for the provided prompt.
+// Streaming is not supported natively, so the stream only has the final result chunk.
+func (l *SagemakerEndpoint) Stream(ctx context.Context, prompt string, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.LLMStream(ctx, l, prompt, optFns...)
+}
+
 // Type returns the type of the model.
 func (l *SagemakerEndpoint) Type() string {
 	return "llm.SagemakerEndpoint"
@@ -8,6 +8,7 @@ import (
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/internal/util"
+	"github.com/hupe1980/golc/model"
 	"github.com/hupe1980/golc/schema"
 	"github.com/hupe1980/golc/tokenizer"
 	"google.golang.org/protobuf/types/known/structpb"
@@ -139,6 +140,12 @@ func (l *VertexAI) Generate(ctx context.Context, prompt string, optFns ...func(o
 	}, nil
 }
 
+// Stream streams the text generated for the provided prompt.
+// Streaming is not supported natively, so the stream only has the final result chunk.
+func (l *VertexAI) Stream(ctx context.Context, prompt string, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return model.LLMStream(ctx, l, prompt, optFns...)
+}
+
 // Type returns the type of the model.
 func (l *VertexAI) Type() string {
 	return "llm.VertexAI"
@@ -9,11 +9,28 @@ import (
 )
 
 type Options struct {
-	Stop              []string
-	Callbacks         []schema.Callback
-	ParentRunID       string
+	Stop        []string
+	Callbacks   []schema.Callback
+	ParentRunID string
+	// Tags are passed to the callbacks of the model run.
+	Tags []string
+	// Metadata is passed to the callbacks of the model run.
+	Metadata          map[string]any
 	Functions         []schema.FunctionDefinition
 	ForceFunctionCall bool
+	// Stream enables streaming. It is enabled automatically if one of the callbacks requires it.
+	Stream bool
+}
+
+// ChildOptions returns an option that runs the model as a child of the run managed by cm.
+// The child run inherits the callbacks, tags and metadata of its parent.
+func ChildOptions(cm schema.CallbackManagerForParentRun) func(o *Options) {
+	return func(o *Options) {
+		o.Callbacks = cm.GetInheritableCallbacks()
+		o.ParentRunID = cm.RunID()
+		o.Tags = cm.GetInheritableTags()
+		o.Metadata = cm.GetInheritableMetadata()
+	}
 }
 
 func GeneratePrompt(ctx context.Context, model schema.Model, promptValue schema.PromptValue, optFns ...func(o *Options)) (*schema.ModelResult, error) {
@@ -38,6 +55,8 @@ func LLMGenerate(ctx context.Context, model schema.LLM, prompt string, optFns ..
 
 	cm := callback.NewManager(opts.Callbacks, model.Callbacks(), model.Verbose(), func(mo *callback.ManagerOptions) {
 		mo.ParentRunID = opts.ParentRunID
+		mo.Tags = opts.Tags
+		mo.Metadata = opts.Metadata
 	})
 
 	rm, err := cm.OnLLMStart(ctx, &schema.LLMStartManagerInput{
@@ -52,6 +71,7 @@ func LLMGenerate(ctx context.Context, model schema.LLM, prompt string, optFns ..
 	result, err := model.Generate(ctx, prompt, func(o *schema.GenerateOptions) {
 		o.CallbackManger = rm
 		o.Stop = opts.Stop
+		o.Stream = opts.Stream || requiresStream(opts.Callbacks, model.Callbacks())
 	})
 	if err != nil {
 		if cbErr := rm.OnModelError(ctx, &schema.ModelErrorManagerInput{
@@ -80,9 +100,9 @@ func ChatModelGenerate(ctx context.Context, model schema.ChatModel, messages sch
 	}
 
 	cm := callback.NewManager(opts.Callbacks, model.Callbacks(), model.Verbose(), func(mo *callback.ManagerOptions) {
-		if opts.ParentRunID != "" {
-			mo.ParentRunID = opts.ParentRunID
-		}
+		mo.ParentRunID = opts.ParentRunID
+		mo.Tags = opts.Tags
+		mo.Metadata = opts.Metadata
 	})
 
 	rm, err := cm.OnChatModelStart(ctx, &schema.ChatModelStartManagerInput{
@@ -99,6 +119,7 @@ func ChatModelGenerate(ctx context.Context, model schema.ChatModel, messages sch
 		o.Stop = opts.Stop
 		o.Functions = opts.Functions
 		o.ForceFunctionCall = opts.ForceFunctionCall
+		o.Stream = opts.Stream || requiresStream(opts.Callbacks, model.Callbacks())
 	})
 	if err != nil {
 		if cbErr := rm.OnModelError(ctx, &schema.ModelErrorManagerInput{
@@ -118,3 +139,16 @@ func ChatModelGenerate(ctx context.Context, model schema.ChatModel, messages sch
 
 	return result, nil
 }
+
+// requiresStream returns true if one of the callbacks requires the model to stream its output.
+func requiresStream(callbackLists ...[]schema.Callback) bool {
+	for _, callbacks := range callbackLists {
+		for _, c := range callbacks {
+			if sc, ok := c.(schema.StreamCallback); ok && sc.RequiresStream() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
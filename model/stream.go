@@ -0,0 +1,154 @@
+package model
+
+import (
+	"context"
+
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/schema"
+)
+
+// LLMStream streams the text generated by the llm for the provided prompt. It is used by the llms to
+// implement their Stream method.
+// Without callback manager in the options, the stream is a run of its own with the callbacks of the llm,
+// like a run started by LLMGenerate. Otherwise the run is managed by the caller.
+// Llms without native streaming don't report new tokens, so their stream only has the final chunk.
+func LLMStream(ctx context.Context, llm schema.LLM, prompt string, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return streamGenerate(ctx, func(ctx context.Context) (schema.CallbackManagerForModelRun, error) {
+		cm := callback.NewManager(nil, llm.Callbacks(), llm.Verbose())
+
+		return cm.OnLLMStart(ctx, &schema.LLMStartManagerInput{
+			LLMType:          llm.Type(),
+			Prompt:           prompt,
+			InvocationParams: llm.InvocationParams(),
+		})
+	}, func(ctx context.Context, optFns ...func(o *schema.GenerateOptions)) (*schema.ModelResult, error) {
+		return llm.Generate(ctx, prompt, optFns...)
+	}, optFns...)
+}
+
+// ChatModelStream streams the text generated by the chat model for the provided messages. It is used by
+// the chat models to implement their Stream method.
+// Without callback manager in the options, the stream is a run of its own with the callbacks of the chat
+// model, like a run started by ChatModelGenerate. Otherwise the run is managed by the caller.
+// Chat models without native streaming don't report new tokens, so their stream only has the final chunk.
+func ChatModelStream(ctx context.Context, chatModel schema.ChatModel, messages schema.ChatMessages, optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	return streamGenerate(ctx, func(ctx context.Context) (schema.CallbackManagerForModelRun, error) {
+		cm := callback.NewManager(nil, chatModel.Callbacks(), chatModel.Verbose())
+
+		return cm.OnChatModelStart(ctx, &schema.ChatModelStartManagerInput{
+			ChatModelType:    chatModel.Type(),
+			Messages:         messages,
+			InvocationParams: chatModel.InvocationParams(),
+		})
+	}, func(ctx context.Context, optFns ...func(o *schema.GenerateOptions)) (*schema.ModelResult, error) {
+		return chatModel.Generate(ctx, messages, optFns...)
+	}, optFns...)
+}
+
+// streamGenerate runs the generate function with streaming enabled and returns a channel with
+// the tokens and tool call fragments reported by the model, followed by a final result or error chunk.
+// If the options have no callback manager, the run is started with startRun and ended by streamGenerate.
+func streamGenerate(ctx context.Context, startRun func(ctx context.Context) (schema.CallbackManagerForModelRun, error), generate func(ctx context.Context, optFns ...func(o *schema.GenerateOptions)) (*schema.ModelResult, error), optFns ...func(o *schema.GenerateOptions)) <-chan schema.StreamChunk {
+	opts := schema.GenerateOptions{}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	ch := make(chan schema.StreamChunk)
+
+	go func() {
+		defer close(ch)
+
+		rm, ownRun := opts.CallbackManger, opts.CallbackManger == nil
+		if ownRun {
+			var err error
+
+			rm, err = startRun(ctx)
+			if err != nil {
+				sendChunk(ctx, ch, schema.StreamChunk{Type: schema.StreamChunkTypeError, Error: err})
+				return
+			}
+		}
+
+		result, err := generate(ctx, func(o *schema.GenerateOptions) {
+			*o = opts
+			o.Stream = true
+			o.CallbackManger = &streamManager{
+				CallbackManagerForModelRun: rm,
+				ch:                         ch,
+			}
+		})
+
+		if ownRun {
+			err = endRun(ctx, rm, result, err)
+		}
+
+		if err != nil {
+			sendChunk(ctx, ch, schema.StreamChunk{Type: schema.StreamChunkTypeError, Error: err})
+			return
+		}
+
+		sendChunk(ctx, ch, schema.StreamChunk{Type: schema.StreamChunkTypeResult, Result: result})
+	}()
+
+	return ch
+}
+
+// endRun reports the result or the error of a run started by streamGenerate to the callbacks.
+// It returns the error of the run or of the callbacks.
+func endRun(ctx context.Context, rm schema.CallbackManagerForModelRun, result *schema.ModelResult, err error) error {
+	if err != nil {
+		if cbErr := rm.OnModelError(ctx, &schema.ModelErrorManagerInput{
+			Error: err,
+		}); cbErr != nil {
+			return cbErr
+		}
+
+		return err
+	}
+
+	return rm.OnModelEnd(ctx, &schema.ModelEndManagerInput{
+		Result: result,
+	})
+}
+
+// sendChunk sends the chunk, unless the context is done.
+func sendChunk(ctx context.Context, ch chan<- schema.StreamChunk, chunk schema.StreamChunk) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case ch <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// streamManager forwards the new tokens reported by a model to a stream, in addition to the wrapped callback manager.
+type streamManager struct {
+	schema.CallbackManagerForModelRun
+	ch chan<- schema.StreamChunk
+}
+
+// OnModelNewToken forwards the token or tool call fragment to the stream.
+func (m *streamManager) OnModelNewToken(ctx context.Context, input *schema.ModelNewTokenManagerInput) error {
+	if err := m.CallbackManagerForModelRun.OnModelNewToken(ctx, input); err != nil {
+		return err
+	}
+
+	chunk := schema.StreamChunk{Type: schema.StreamChunkTypeToken, Token: input.Token}
+	if input.ToolCallDelta != nil {
+		chunk = schema.StreamChunk{Type: schema.StreamChunkTypeFunctionCall, ToolCallDelta: input.ToolCallDelta}
+	} else if input.Token == "" {
+		return nil
+	}
+
+	if !sendChunk(ctx, m.ch, chunk) {
+		return ctx.Err()
+	}
+
+	return nil
+}
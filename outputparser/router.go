@@ -0,0 +1,123 @@
+package outputparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure Router satisfies the OutputParser interface.
+var _ schema.OutputParser[any] = (*Router)(nil)
+
+// RouterOutput is the routing decision parsed by the Router parser.
+type RouterOutput struct {
+	// Destination is the name of the selected destination. It's empty if the default destination was selected.
+	Destination string
+	// NextInputs are the inputs for the selected destination.
+	NextInputs map[string]any
+}
+
+// RouterOptions contains options for the Router parser.
+type RouterOptions struct {
+	// DefaultDestination is the name, which selects the default destination. Default is "DEFAULT".
+	DefaultDestination string
+	// NextInputsKey is the input key used, if the next inputs are returned as a single string. Default is "input".
+	NextInputsKey string
+}
+
+// Router represents a parser for the JSON routing decision of a LLM, e.g.:
+//
+//	{"destination": "billing", "next_inputs": "Where is my invoice?"}
+type Router struct {
+	opts RouterOptions
+}
+
+// NewRouter creates a new instance of the Router parser.
+func NewRouter(optFns ...func(o *RouterOptions)) *Router {
+	opts := RouterOptions{
+		DefaultDestination: "DEFAULT",
+		NextInputsKey:      "input",
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return &Router{
+		opts: opts,
+	}
+}
+
+// ParseResult parses the result of generation and returns the routing decision as *RouterOutput.
+func (p *Router) ParseResult(result schema.Generation) (any, error) {
+	return p.Parse(result.Text)
+}
+
+var jsonMarkdownPattern = regexp.MustCompile("(?s)```(?:json)?(.*?)```")
+
+// Parse parses the JSON routing decision, which may be enclosed in a markdown code block, and returns it as *RouterOutput.
+func (p *Router) Parse(text string) (any, error) {
+	jsonText := text
+	if match := jsonMarkdownPattern.FindStringSubmatch(text); match != nil {
+		jsonText = match[1]
+	}
+
+	var output struct {
+		Destination string `json:"destination"`
+		NextInputs  any    `json:"next_inputs"`
+	}
+
+	if err := json.Unmarshal([]byte(strings.TrimSpace(jsonText)), &output); err != nil {
+		return nil, fmt.Errorf("cannot parse output: %s: %w", text, err)
+	}
+
+	if output.Destination == "" {
+		return nil, fmt.Errorf("cannot parse output: %s: missing destination", text)
+	}
+
+	destination := strings.TrimSpace(output.Destination)
+	if strings.EqualFold(destination, p.opts.DefaultDestination) {
+		destination = ""
+	}
+
+	var nextInputs map[string]any
+
+	switch v := output.NextInputs.(type) {
+	case nil:
+	case string:
+		nextInputs = map[string]any{p.opts.NextInputsKey: v}
+	case map[string]any:
+		nextInputs = v
+	default:
+		return nil, fmt.Errorf("cannot parse output: %s: unexpected next inputs of type %T", text, v)
+	}
+
+	return &RouterOutput{
+		Destination: destination,
+		NextInputs:  nextInputs,
+	}, nil
+}
+
+// ParseWithPrompt is not used for this parser, so it simply calls Parse.
+func (p *Router) ParseWithPrompt(text string, prompt schema.PromptValue) (any, error) {
+	return p.Parse(text)
+}
+
+// GetFormatInstructions returns a formatted string describing the expected format of the output.
+func (p *Router) GetFormatInstructions() string {
+	return fmt.Sprintf(`Return a markdown code snippet with a JSON object formatted to look like:
+`+"```json"+`
+{
+    "destination": string \ name of the destination to use or "%s"
+    "next_inputs": string \ a potentially modified version of the original input
+}
+`+"```", p.opts.DefaultDestination)
+}
+
+// Type returns the type identifier of the parser, which is "router".
+func (p *Router) Type() string {
+	return "router"
+}
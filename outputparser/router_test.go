@@ -0,0 +1,41 @@
+package outputparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter(t *testing.T) {
+	parser := NewRouter()
+
+	t.Run("Parse", func(t *testing.T) {
+		output, err := parser.Parse("```json\n{\"destination\": \"billing\", \"next_inputs\": \"Where is my invoice?\"}\n```")
+		assert.NoError(t, err)
+		assert.Equal(t, &RouterOutput{Destination: "billing", NextInputs: map[string]any{"input": "Where is my invoice?"}}, output)
+	})
+
+	t.Run("ParseObjectInputs", func(t *testing.T) {
+		output, err := parser.Parse("{\"destination\": \"billing\", \"next_inputs\": {\"question\": \"foo\"}}")
+		assert.NoError(t, err)
+		assert.Equal(t, &RouterOutput{Destination: "billing", NextInputs: map[string]any{"question": "foo"}}, output)
+	})
+
+	t.Run("ParseDefault", func(t *testing.T) {
+		output, err := parser.Parse("```json\n{\"destination\": \"DEFAULT\"}\n```")
+		assert.NoError(t, err)
+		assert.Equal(t, &RouterOutput{}, output)
+	})
+
+	t.Run("ParseInvalid", func(t *testing.T) {
+		_, err := parser.Parse("billing")
+		assert.Error(t, err)
+
+		_, err = parser.Parse("{\"next_inputs\": \"foo\"}")
+		assert.Error(t, err)
+	})
+
+	t.Run("Type", func(t *testing.T) {
+		assert.Equal(t, "router", parser.Type())
+	})
+}
@@ -115,10 +115,7 @@ func (c *ConversationalRetrievalQA) Call(ctx context.Context, inputs schema.Chai
 
 	retrievalOutput, err := golc.Call(ctx, c.retrievalQAChain, schema.ChainValues{
 		c.retrievalQAChain.InputKeys()[0]: generatedQuestion,
-	}, func(co *golc.CallOptions) {
-		co.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		co.ParentRunID = opts.CallbackManger.RunID()
-	})
+	}, golc.ChildCallOptions(opts.CallbackManger))
 	if err != nil {
 		return nil, err
 	}
@@ -148,10 +145,7 @@ func (c *ConversationalRetrievalQA) generateQuestion(ctx context.Context, inputs
 		return inputs.GetString(c.opts.InputKey)
 	}
 
-	output, err := golc.Call(ctx, c.condenseQuestionChain, inputs, func(co *golc.CallOptions) {
-		co.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		co.ParentRunID = opts.CallbackManger.RunID()
-	})
+	output, err := golc.Call(ctx, c.condenseQuestionChain, inputs, golc.ChildCallOptions(opts.CallbackManger))
 	if err != nil {
 		return "", err
 	}
@@ -71,10 +71,7 @@ func (c *MapReduceDocuments) Call(ctx context.Context, inputs schema.ChainValues
 		batchInputs[i] = batchInput
 	}
 
-	mapResults, err := golc.BatchCall(ctx, c.mapChain, batchInputs, func(co *golc.BatchCallOptions) {
-		co.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		co.ParentRunID = opts.CallbackManger.RunID()
-	})
+	mapResults, err := golc.BatchCall(ctx, c.mapChain, batchInputs, golc.ChildBatchCallOptions(opts.CallbackManger))
 	if err != nil {
 		return nil, err
 	}
@@ -96,10 +93,7 @@ func (c *MapReduceDocuments) Call(ctx context.Context, inputs schema.ChainValues
 	combineInputs := rest.Clone()
 	combineInputs[c.combineChain.InputKeys()[0]] = combineDocs
 
-	return golc.Call(ctx, c.combineChain, combineInputs, func(co *golc.CallOptions) {
-		co.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		co.ParentRunID = opts.CallbackManger.RunID()
-	})
+	return golc.Call(ctx, c.combineChain, combineInputs, golc.ChildCallOptions(opts.CallbackManger))
 }
 
 // Memory returns the memory associated with the chain.
@@ -79,10 +79,7 @@ func (c *RefineDocuments) Call(ctx context.Context, values schema.ChainValues, o
 		return nil, err
 	}
 
-	res, err := golc.SimpleCall(ctx, c.llmChain, initialInputs, func(sco *golc.SimpleCallOptions) {
-		sco.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		sco.ParentRunID = opts.CallbackManger.RunID()
-	})
+	res, err := golc.SimpleCall(ctx, c.llmChain, initialInputs, golc.ChildSimpleCallOptions(opts.CallbackManger))
 	if err != nil {
 		return nil, err
 	}
@@ -93,10 +90,7 @@ func (c *RefineDocuments) Call(ctx context.Context, values schema.ChainValues, o
 			return nil, err
 		}
 
-		res, err = golc.SimpleCall(ctx, c.refineLLMChain, refineInputs, func(sco *golc.SimpleCallOptions) {
-			sco.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-			sco.ParentRunID = opts.CallbackManger.RunID()
-		})
+		res, err = golc.SimpleCall(ctx, c.refineLLMChain, refineInputs, golc.ChildSimpleCallOptions(opts.CallbackManger))
 		if err != nil {
 			return nil, err
 		}
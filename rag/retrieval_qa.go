@@ -99,10 +99,7 @@ func (c *RetrievalQA) Call(ctx context.Context, values schema.ChainValues, optFn
 	result, err := golc.Call(ctx, c.stuffDocumentsChain, schema.ChainValues{
 		"question":                           question,
 		c.stuffDocumentsChain.InputKeys()[0]: docs,
-	}, func(co *golc.CallOptions) {
-		co.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		co.ParentRunID = opts.CallbackManger.RunID()
-	})
+	}, golc.ChildCallOptions(opts.CallbackManger))
 	if err != nil {
 		return nil, err
 	}
@@ -115,10 +112,7 @@ func (c *RetrievalQA) Call(ctx context.Context, values schema.ChainValues, optFn
 }
 
 func (c *RetrievalQA) getDocuments(ctx context.Context, query string, opts schema.CallOptions) ([]schema.Document, error) {
-	docs, err := retriever.Run(ctx, c.retriever, query, func(o *retriever.Options) {
-		o.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		o.ParentRunID = opts.CallbackManger.RunID()
-	})
+	docs, err := retriever.Run(ctx, c.retriever, query, retriever.ChildOptions(opts.CallbackManger))
 	if err != nil {
 		return nil, err
 	}
@@ -71,10 +71,7 @@ func (c *StuffDocuments) Call(ctx context.Context, inputs schema.ChainValues, op
 
 	rest[c.opts.DocumentVariableName] = strings.Join(contents, c.opts.DocumentSeparator)
 
-	output, err := golc.SimpleCall(ctx, c.llmChain, rest, func(co *golc.SimpleCallOptions) {
-		co.Callbacks = opts.CallbackManger.GetInheritableCallbacks()
-		co.ParentRunID = opts.CallbackManger.RunID()
-	})
+	output, err := golc.SimpleCall(ctx, c.llmChain, rest, golc.ChildSimpleCallOptions(opts.CallbackManger))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,122 @@
+package retriever
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Analyzer converts a text into the terms used by lexical retrievers.
+type Analyzer interface {
+	// Analyze returns the terms of the text.
+	Analyze(text string) []string
+}
+
+// Stemmer reduces a term to its stem.
+type Stemmer func(term string) string
+
+// EnglishStopwords is a list of common English words, which carry little meaning for retrieval.
+var EnglishStopwords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "if", "in", "into", "is", "it",
+	"no", "not", "of", "on", "or", "such", "that", "the", "their", "then", "there", "these",
+	"they", "this", "to", "was", "will", "with",
+}
+
+// StandardAnalyzerOptions represents the options for configuring the StandardAnalyzer.
+type StandardAnalyzerOptions struct {
+	// Lowercase converts all terms to lower case. Default is true.
+	Lowercase bool
+	// Stopwords are the terms removed from the analyzed text. Default is EnglishStopwords.
+	Stopwords []string
+	// Stemmer reduces the terms to their stems. Default is nil, which keeps the terms unchanged.
+	Stemmer Stemmer
+	// MinTermLength is the minimum number of characters of a term. Default is 1.
+	MinTermLength int
+}
+
+// StandardAnalyzer splits a text at whitespace and punctuation, normalizes the terms and removes stopwords.
+// Hyphens, underscores and dots between letters or digits are kept, so that identifiers like
+// part numbers, error codes or versions remain a single term.
+type StandardAnalyzer struct {
+	stopwords map[string]struct{}
+	opts      StandardAnalyzerOptions
+}
+
+// NewStandardAnalyzer creates a new StandardAnalyzer with the specified options.
+func NewStandardAnalyzer(optFns ...func(o *StandardAnalyzerOptions)) *StandardAnalyzer {
+	opts := StandardAnalyzerOptions{
+		Lowercase:     true,
+		Stopwords:     EnglishStopwords,
+		MinTermLength: 1,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	stopwords := make(map[string]struct{}, len(opts.Stopwords))
+
+	for _, s := range opts.Stopwords {
+		if opts.Lowercase {
+			s = strings.ToLower(s)
+		}
+
+		stopwords[s] = struct{}{}
+	}
+
+	return &StandardAnalyzer{
+		stopwords: stopwords,
+		opts:      opts,
+	}
+}
+
+// Analyze returns the terms of the text.
+func (a *StandardAnalyzer) Analyze(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !isTermConnector(r)
+	})
+
+	terms := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		term := strings.TrimFunc(field, isTermConnector)
+
+		if a.opts.Lowercase {
+			term = strings.ToLower(term)
+		}
+
+		if _, ok := a.stopwords[term]; ok {
+			continue
+		}
+
+		if a.opts.Stemmer != nil {
+			term = a.opts.Stemmer(term)
+		}
+
+		if term == "" || len([]rune(term)) < a.opts.MinTermLength {
+			continue
+		}
+
+		terms = append(terms, term)
+	}
+
+	return terms
+}
+
+// isTermConnector reports whether the rune may connect letters or digits within a term.
+func isTermConnector(r rune) bool {
+	return r == '-' || r == '_' || r == '.'
+}
+
+// EnglishSStemmer is a conservative English stemmer, which only reduces plural forms (Harman's S-stemmer).
+func EnglishSStemmer(term string) string {
+	switch {
+	case strings.HasSuffix(term, "ies") && !strings.HasSuffix(term, "eies") && !strings.HasSuffix(term, "aies"):
+		return strings.TrimSuffix(term, "ies") + "y"
+	case strings.HasSuffix(term, "es") && !strings.HasSuffix(term, "aes") && !strings.HasSuffix(term, "ees") && !strings.HasSuffix(term, "oes"):
+		return strings.TrimSuffix(term, "s")
+	case strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "us") && !strings.HasSuffix(term, "ss"):
+		return strings.TrimSuffix(term, "s")
+	default:
+		return term
+	}
+}
@@ -0,0 +1,43 @@
+package retriever
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandardAnalyzer(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		analyzer := NewStandardAnalyzer()
+
+		terms := analyzer.Analyze("The pump P-4711.B fails with error E_42, see v1.2...")
+		assert.Equal(t, []string{"pump", "p-4711.b", "fails", "error", "e_42", "see", "v1.2"}, terms)
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		analyzer := NewStandardAnalyzer(func(o *StandardAnalyzerOptions) {
+			o.Lowercase = false
+			o.Stopwords = []string{"with"}
+			o.Stemmer = EnglishSStemmer
+			o.MinTermLength = 3
+		})
+
+		terms := analyzer.Analyze("The pumps fail with errors")
+		assert.Equal(t, []string{"The", "pump", "fail", "error"}, terms)
+	})
+}
+
+func TestEnglishSStemmer(t *testing.T) {
+	tests := map[string]string{
+		"queries": "query",
+		"horses":  "horse",
+		"pumps":   "pump",
+		"status":  "status",
+		"glass":   "glass",
+		"error":   "error",
+	}
+
+	for term, expected := range tests {
+		assert.Equal(t, expected, EnglishSStemmer(term), term)
+	}
+}
@@ -0,0 +1,219 @@
+package retriever
+
+import (
+	"context"
+	"encoding/gob"
+	"io"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure BM25 satisfies the Retriever interface.
+var _ schema.Retriever = (*BM25)(nil)
+
+// BM25Options represents the options for configuring the BM25 retriever.
+type BM25Options struct {
+	*schema.CallbackOptions
+
+	// TopK is the maximum number of retrieved documents. Default is 4.
+	TopK int
+
+	// K1 controls the saturation of the term frequency. Default is 1.5.
+	K1 float64
+
+	// B controls the normalization by document length. Default is 0.75.
+	B float64
+
+	// Analyzer converts the documents and queries into terms. Default is a StandardAnalyzer.
+	Analyzer Analyzer
+}
+
+// bm25Posting represents the occurrences of a term in a document.
+type bm25Posting struct {
+	doc  int
+	freq int
+}
+
+// BM25 is an in-process lexical retriever, which ranks documents with the Okapi BM25 function.
+// It finds exact terms like part numbers or error codes, which are often missed by embedding search.
+type BM25 struct {
+	docs      []schema.Document
+	docLens   []int
+	postings  map[string][]bm25Posting
+	avgDocLen float64
+	opts      BM25Options
+	mu        sync.RWMutex
+}
+
+// NewBM25 creates a new BM25 retriever, which indexes the given documents.
+func NewBM25(docs []schema.Document, optFns ...func(o *BM25Options)) *BM25 {
+	opts := BM25Options{
+		CallbackOptions: &schema.CallbackOptions{
+			Verbose: golc.Verbose,
+		},
+		TopK:     4,
+		K1:       1.5,
+		B:        0.75,
+		Analyzer: NewStandardAnalyzer(),
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	r := &BM25{
+		opts: opts,
+	}
+
+	r.index(docs)
+
+	return r
+}
+
+// index replaces the indexed documents.
+func (r *BM25) index(docs []schema.Document) {
+	r.docs = docs
+	r.docLens = make([]int, len(docs))
+	r.postings = make(map[string][]bm25Posting)
+
+	totalLen := 0
+
+	for i, doc := range docs {
+		terms := r.opts.Analyzer.Analyze(doc.PageContent)
+
+		freqs := make(map[string]int, len(terms))
+		for _, term := range terms {
+			freqs[term]++
+		}
+
+		for term, freq := range freqs {
+			r.postings[term] = append(r.postings[term], bm25Posting{doc: i, freq: freq})
+		}
+
+		r.docLens[i] = len(terms)
+		totalLen += len(terms)
+	}
+
+	r.avgDocLen = 0
+	if len(docs) > 0 {
+		r.avgDocLen = float64(totalLen) / float64(len(docs))
+	}
+}
+
+// GetRelevantDocuments returns the TopK documents with the highest BM25 scores for the query.
+// Documents without any query term are not returned.
+func (r *BM25) GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scores := r.scores(query)
+
+	ranked := make([]int, 0, len(scores))
+	for doc := range scores {
+		ranked = append(ranked, doc)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] == scores[ranked[j]] {
+			return ranked[i] < ranked[j]
+		}
+
+		return scores[ranked[i]] > scores[ranked[j]]
+	})
+
+	if r.opts.TopK > 0 && len(ranked) > r.opts.TopK {
+		ranked = ranked[:r.opts.TopK]
+	}
+
+	docs := make([]schema.Document, len(ranked))
+	for i, doc := range ranked {
+		docs[i] = r.docs[doc]
+	}
+
+	return docs, nil
+}
+
+// scores returns the BM25 scores of the documents containing at least one query term.
+func (r *BM25) scores(query string) map[int]float64 {
+	scores := make(map[int]float64)
+	n := float64(len(r.docs))
+
+	seen := make(map[string]struct{})
+
+	for _, term := range r.opts.Analyzer.Analyze(query) {
+		if _, ok := seen[term]; ok {
+			continue
+		}
+
+		seen[term] = struct{}{}
+
+		postings := r.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+
+		df := float64(len(postings))
+		idf := math.Log((n-df+0.5)/(df+0.5) + 1)
+
+		for _, p := range postings {
+			freq := float64(p.freq)
+			norm := 1 - r.opts.B + r.opts.B*float64(r.docLens[p.doc])/r.avgDocLen
+
+			scores[p.doc] += idf * freq * (r.opts.K1 + 1) / (freq + r.opts.K1*norm)
+		}
+	}
+
+	return scores
+}
+
+// Documents returns the indexed documents.
+func (r *BM25) Documents() []schema.Document {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.docs
+}
+
+// Load loads the documents from an io.Reader and indexes them with the analyzer of the retriever.
+func (r *BM25) Load(rd io.Reader) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var docs []schema.Document
+
+	decoder := gob.NewDecoder(rd)
+
+	// Decode the documents
+	if err := decoder.Decode(&docs); err != nil {
+		return err
+	}
+
+	r.index(docs)
+
+	return nil
+}
+
+// Save saves the documents to an io.Writer.
+func (r *BM25) Save(w io.Writer) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	encoder := gob.NewEncoder(w)
+
+	// Encode the documents
+	return encoder.Encode(r.docs)
+}
+
+// Verbose returns the verbosity setting of the retriever.
+func (r *BM25) Verbose() bool {
+	return r.opts.CallbackOptions.Verbose
+}
+
+// Callbacks returns the registered callbacks of the retriever.
+func (r *BM25) Callbacks() []schema.Callback {
+	return r.opts.CallbackOptions.Callbacks
+}
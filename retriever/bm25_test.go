@@ -0,0 +1,82 @@
+package retriever
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestBM25(t *testing.T) {
+	docs := []schema.Document{
+		{ID: "1", PageContent: "Replace the filter of pump P-4711 every six months."},
+		{ID: "2", PageContent: "Error E-42 indicates a blocked filter."},
+		{ID: "3", PageContent: "The pump manual describes the pump, the filter and the motor."},
+		{ID: "4", PageContent: "Motor maintenance instructions."},
+	}
+
+	t.Run("GetRelevantDocuments", func(t *testing.T) {
+		r := NewBM25(docs)
+
+		result, err := r.GetRelevantDocuments(context.Background(), "error E-42")
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "2", result[0].ID)
+
+		result, err = r.GetRelevantDocuments(context.Background(), "pump")
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "3", result[0].ID)
+		assert.Equal(t, "1", result[1].ID)
+
+		result, err = r.GetRelevantDocuments(context.Background(), "unknown")
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("TopK", func(t *testing.T) {
+		r := NewBM25(docs, func(o *BM25Options) {
+			o.TopK = 2
+		})
+
+		result, err := r.GetRelevantDocuments(context.Background(), "filter motor")
+		require.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, "3", result[0].ID)
+	})
+
+	t.Run("Analyzer", func(t *testing.T) {
+		r := NewBM25(docs, func(o *BM25Options) {
+			o.Analyzer = NewStandardAnalyzer(func(o *StandardAnalyzerOptions) {
+				o.Stemmer = EnglishSStemmer
+			})
+		})
+
+		result, err := r.GetRelevantDocuments(context.Background(), "instruction")
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "4", result[0].ID)
+	})
+
+	t.Run("SaveAndLoad", func(t *testing.T) {
+		r := NewBM25(docs)
+
+		var buf bytes.Buffer
+		require.NoError(t, r.Save(&buf))
+
+		loaded := NewBM25(nil)
+		require.NoError(t, loaded.Load(&buf))
+		assert.Equal(t, docs, loaded.Documents())
+
+		expected, err := r.GetRelevantDocuments(context.Background(), "pump filter")
+		require.NoError(t, err)
+
+		actual, err := loaded.GetRelevantDocuments(context.Background(), "pump filter")
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+}
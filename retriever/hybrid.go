@@ -0,0 +1,79 @@
+package retriever
+
+import (
+	"context"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure Hybrid satisfies the Retriever interface.
+var _ schema.Retriever = (*Hybrid)(nil)
+
+// HybridOptions represents the options for configuring the Hybrid retriever.
+type HybridOptions struct {
+	*schema.CallbackOptions
+
+	// LexicalWeight is the weight of the lexical ranking. Default is 0.5.
+	LexicalWeight float64
+
+	// VectorWeight is the weight of the vector ranking. Default is 0.5.
+	VectorWeight float64
+
+	// RRFConstant is the constant of the Reciprocal Rank Fusion. Default is 60.
+	RRFConstant int
+
+	// TopK is the maximum number of returned documents. A value of 0 returns all documents.
+	TopK int
+}
+
+// Hybrid is a retriever, which combines a lexical retriever like BM25 with a vector retriever like VectorStore.
+// Both rankings are fused with weighted Reciprocal Rank Fusion and deduplicated.
+type Hybrid struct {
+	merger *Merger
+	opts   HybridOptions
+}
+
+// NewHybrid creates a new Hybrid retriever with the specified lexical and vector retrievers and options.
+func NewHybrid(lexical, vector schema.Retriever, optFns ...func(o *HybridOptions)) *Hybrid {
+	opts := HybridOptions{
+		CallbackOptions: &schema.CallbackOptions{
+			Verbose: golc.Verbose,
+		},
+		LexicalWeight: 0.5,
+		VectorWeight:  0.5,
+		RRFConstant:   DefaultRRFConstant,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	merger := NewMerger([]schema.Retriever{lexical, vector}, func(o *MergerOptions) {
+		o.CallbackOptions = opts.CallbackOptions
+		o.FusionMode = MergerFusionModeRRF
+		o.Weights = []float64{opts.LexicalWeight, opts.VectorWeight}
+		o.RRFConstant = opts.RRFConstant
+		o.TopK = opts.TopK
+	})
+
+	return &Hybrid{
+		merger: merger,
+		opts:   opts,
+	}
+}
+
+// GetRelevantDocuments returns the fused ranking of the lexical and vector retrievers.
+func (r *Hybrid) GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error) {
+	return r.merger.GetRelevantDocuments(ctx, query)
+}
+
+// Verbose returns the verbosity setting of the retriever.
+func (r *Hybrid) Verbose() bool {
+	return r.opts.CallbackOptions.Verbose
+}
+
+// Callbacks returns the registered callbacks of the retriever.
+func (r *Hybrid) Callbacks() []schema.Callback {
+	return r.opts.CallbackOptions.Callbacks
+}
@@ -0,0 +1,56 @@
+package retriever
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestHybrid(t *testing.T) {
+	lexical := NewBM25([]schema.Document{
+		{ID: "1", PageContent: "Error E-42 indicates a blocked filter."},
+		{ID: "2", PageContent: "Clean the filter regularly."},
+	})
+
+	vector := NewVectorStore(&mockVectorStore{
+		similaritySearchWithScoreFn: func(ctx context.Context, query string, opts schema.VectorStoreSearchOptions) ([]schema.ScoredDocument, error) {
+			return []schema.ScoredDocument{
+				{Document: schema.Document{ID: "3", PageContent: "The pump stops when the filter is clogged."}, Score: 0.9},
+				{Document: schema.Document{ID: "1", PageContent: "Error E-42 indicates a blocked filter."}, Score: 0.8},
+			}, nil
+		},
+	})
+
+	t.Run("Fusion", func(t *testing.T) {
+		r := NewHybrid(lexical, vector)
+
+		docs, err := r.GetRelevantDocuments(context.Background(), "E-42 filter")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "3", "2"}, ids(docs))
+	})
+
+	t.Run("Weights", func(t *testing.T) {
+		r := NewHybrid(lexical, vector, func(o *HybridOptions) {
+			o.LexicalWeight = 0.9
+			o.VectorWeight = 0.1
+			o.TopK = 2
+		})
+
+		docs, err := r.GetRelevantDocuments(context.Background(), "E-42 filter")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "2"}, ids(docs))
+	})
+}
+
+func ids(docs []schema.Document) []string {
+	result := make([]string, len(docs))
+	for i, doc := range docs {
+		result[i] = doc.ID
+	}
+
+	return result
+}
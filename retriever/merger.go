@@ -2,28 +2,84 @@ package retriever
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
 
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/schema"
 )
 
-// Compile time check to ensure Merger satisfies the Retriever interface.
-var _ schema.Retriever = (*Merger)(nil)
+// Compile time check to ensure Merger satisfies the RetrieverWithRunOptions interface.
+var _ schema.RetrieverWithRunOptions = (*Merger)(nil)
+
+// MergerFusionMode represents the method used to fuse the rankings of the retrievers.
+type MergerFusionMode string
+
+const (
+	// MergerFusionModeRRF fuses the rankings with weighted Reciprocal Rank Fusion,
+	// where each document scores weight / (RRFConstant + rank) per retriever.
+	MergerFusionModeRRF MergerFusionMode = "rrf"
+	// MergerFusionModeWeightedScore fuses the rankings with the weighted sum of the min-max normalized scores
+	// found in the document metadata under ScoreKey. Rankings without scores are scored by rank.
+	MergerFusionModeWeightedScore MergerFusionMode = "weighted_score"
+)
+
+const (
+	// DefaultRRFConstant is the default constant of the Reciprocal Rank Fusion, which dampens the impact of top ranks.
+	DefaultRRFConstant = 60
+)
 
+// MergerOptions represents the options for configuring the Merger.
 type MergerOptions struct {
 	*schema.CallbackOptions
+
+	// FusionMode is the method used to fuse the rankings of the retrievers. Default is MergerFusionModeRRF.
+	FusionMode MergerFusionMode
+
+	// Weights are the weights of the retrievers in the order of the retrievers. Missing weights default to 1.
+	Weights []float64
+
+	// RRFConstant is the constant of the Reciprocal Rank Fusion. Default is 60.
+	RRFConstant int
+
+	// ScoreKey is the metadata key of the document scores used by MergerFusionModeWeightedScore. Default is "score".
+	ScoreKey string
+
+	// TopK is the maximum number of returned documents. A value of 0 returns all documents.
+	TopK int
+
+	// KeyFunc returns the key used to deduplicate the documents. Default is DocumentKey.
+	// Documents with an empty key are never deduplicated.
+	KeyFunc func(doc schema.Document) string
+
+	// FailFast returns an error as soon as one retriever fails. By default, failing retrievers are skipped
+	// and an error is only returned if all retrievers fail. The errors of skipped retrievers are reported
+	// to the callbacks of their retriever runs.
+	FailFast bool
 }
 
+// Merger is an ensemble retriever, which queries multiple retrievers in parallel and
+// merges their deduplicated results into a single ranking. The retrievers are run as child
+// runs of the merger run, if the merger is run with Run, so that their callbacks are invoked.
 type Merger struct {
 	retrievers []schema.Retriever
 	opts       MergerOptions
 }
 
+// NewMerger creates a new Merger with the specified retrievers and options.
 func NewMerger(retrievers []schema.Retriever, optFns ...func(o *MergerOptions)) *Merger {
 	opts := MergerOptions{
 		CallbackOptions: &schema.CallbackOptions{
 			Verbose: golc.Verbose,
 		},
+		FusionMode:  MergerFusionModeRRF,
+		RRFConstant: DefaultRRFConstant,
+		ScoreKey:    "score",
+		KeyFunc:     DocumentKey,
 	}
 
 	for _, fn := range optFns {
@@ -32,42 +88,185 @@ func NewMerger(retrievers []schema.Retriever, optFns ...func(o *MergerOptions))
 
 	return &Merger{
 		retrievers: retrievers,
+		opts:       opts,
 	}
 }
 
+// GetRelevantDocuments queries all retrievers in parallel and returns the fused ranking of their documents.
 func (r *Merger) GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error) {
-	var err error
+	return r.GetRelevantDocumentsWithOptions(ctx, query)
+}
+
+// GetRelevantDocumentsWithOptions queries all retrievers in parallel and returns the fused ranking of their
+// documents. The retrievers are run as child runs of the merger run.
+func (r *Merger) GetRelevantDocumentsWithOptions(ctx context.Context, query string, optFns ...func(o *schema.RetrieverRunOptions)) ([]schema.Document, error) {
+	opts := schema.RetrieverRunOptions{}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
 
 	// Get the results of all retrievers.
 	retrieverDocs := make([][]schema.Document, len(r.retrievers))
+	retrieverErrs := make([]error, len(r.retrievers))
+
+	errs, errctx := errgroup.WithContext(ctx)
+	runOpts := r.runOptions(opts.CallbackManger)
 
 	for i, retriever := range r.retrievers {
-		retrieverDocs[i], err = retriever.GetRelevantDocuments(ctx, query)
-		if err != nil {
-			return nil, err
-		}
+		i, retriever := i, retriever
+
+		errs.Go(func() error {
+			docs, err := Run(errctx, retriever, query, runOpts)
+			if err != nil {
+				if r.opts.FailFast {
+					return err
+				}
+
+				retrieverErrs[i] = err
+
+				return nil
+			}
+
+			retrieverDocs[i] = docs
+
+			return nil
+		})
+	}
+
+	if err := errs.Wait(); err != nil {
+		return nil, err
+	}
+
+	if len(r.retrievers) > 0 && allFailed(retrieverErrs) {
+		return nil, errors.Join(retrieverErrs...)
 	}
 
 	// Merge the results of the retrievers.
-	mergedDocuments := make([]schema.Document, 0)
+	return r.fuse(retrieverDocs), nil
+}
 
-	maxDocs := 0
+// runOptions returns the options to run the retrievers as child runs of the merger run. Without merger
+// run, the retrievers inherit the callbacks of the merger.
+func (r *Merger) runOptions(rm schema.CallbackManagerForRetrieverRun) func(o *Options) {
+	if rm != nil {
+		return ChildOptions(rm)
+	}
 
-	for _, docs := range retrieverDocs {
-		if len(docs) > maxDocs {
-			maxDocs = len(docs)
-		}
+	return func(o *Options) {
+		o.Callbacks = r.opts.Callbacks
 	}
+}
+
+// fuse merges the rankings of the retrievers into a single ranking of deduplicated documents.
+func (r *Merger) fuse(retrieverDocs [][]schema.Document) []schema.Document {
+	type fusedDocument struct {
+		doc   schema.Document
+		score float64
+	}
+
+	fused := make([]*fusedDocument, 0)
+	byKey := make(map[string]*fusedDocument)
+
+	for i, docs := range retrieverDocs {
+		weight := 1.0
+		if i < len(r.opts.Weights) {
+			weight = r.opts.Weights[i]
+		}
+
+		scores := r.scores(docs)
+
+		for rank, doc := range docs {
+			score := weight * scores[rank]
+
+			key := r.opts.KeyFunc(doc)
+			if key != "" {
+				if fd, ok := byKey[key]; ok {
+					fd.score += score
+					continue
+				}
+			}
+
+			fd := &fusedDocument{doc: doc, score: score}
+			fused = append(fused, fd)
 
-	for i := 0; i < maxDocs; i++ {
-		for j := range r.retrievers {
-			if i < len(retrieverDocs[j]) {
-				mergedDocuments = append(mergedDocuments, retrieverDocs[j][i])
+			if key != "" {
+				byKey[key] = fd
 			}
 		}
 	}
 
-	return mergedDocuments, nil
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].score > fused[j].score
+	})
+
+	if r.opts.TopK > 0 && len(fused) > r.opts.TopK {
+		fused = fused[:r.opts.TopK]
+	}
+
+	documents := make([]schema.Document, len(fused))
+	for i, fd := range fused {
+		documents[i] = fd.doc
+	}
+
+	return documents
+}
+
+// scores returns the unweighted scores of a ranking according to the fusion mode.
+func (r *Merger) scores(docs []schema.Document) []float64 {
+	scores := make([]float64, len(docs))
+
+	if r.opts.FusionMode == MergerFusionModeWeightedScore {
+		if ok := r.normalizedScores(docs, scores); ok {
+			return scores
+		}
+
+		for rank := range docs {
+			scores[rank] = 1 - float64(rank)/float64(len(docs))
+		}
+
+		return scores
+	}
+
+	for rank := range docs {
+		scores[rank] = 1 / float64(r.opts.RRFConstant+rank+1)
+	}
+
+	return scores
+}
+
+// normalizedScores writes the min-max normalized scores of the documents to scores and
+// reports whether all documents carry a numeric score.
+func (r *Merger) normalizedScores(docs []schema.Document, scores []float64) bool {
+	if len(docs) == 0 {
+		return true
+	}
+
+	for i, doc := range docs {
+		score, ok := schema.FilterValueToFloat64(doc.Metadata[r.opts.ScoreKey])
+		if !ok {
+			return false
+		}
+
+		scores[i] = score
+	}
+
+	minScore, maxScore := scores[0], scores[0]
+
+	for _, score := range scores {
+		minScore = min(minScore, score)
+		maxScore = max(maxScore, score)
+	}
+
+	for i := range scores {
+		if maxScore == minScore {
+			scores[i] = 1
+		} else {
+			scores[i] = (scores[i] - minScore) / (maxScore - minScore)
+		}
+	}
+
+	return true
 }
 
 // Verbose returns the verbosity setting of the retriever.
@@ -79,3 +278,29 @@ func (r *Merger) Verbose() bool {
 func (r *Merger) Callbacks() []schema.Callback {
 	return r.opts.CallbackOptions.Callbacks
 }
+
+// DocumentKey returns the ID of the document or, if the document has no ID, the hash of its content.
+func DocumentKey(doc schema.Document) string {
+	if doc.ID != "" {
+		return doc.ID
+	}
+
+	return ContentHash(doc)
+}
+
+// ContentHash returns the SHA-256 hash of the content of the document.
+func ContentHash(doc schema.Document) string {
+	hash := sha256.Sum256([]byte(doc.PageContent))
+	return hex.EncodeToString(hash[:])
+}
+
+// allFailed reports whether all errors are non-nil.
+func allFailed(errs []error) bool {
+	for _, err := range errs {
+		if err == nil {
+			return false
+		}
+	}
+
+	return true
+}
@@ -2,10 +2,14 @@ package retriever
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 
+	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMergeDocuments(t *testing.T) {
@@ -82,3 +86,244 @@ func TestMergeDocuments(t *testing.T) {
 		assert.Equal(t, expectedDocuments, mergedDocuments)
 	})
 }
+
+func TestMergerFusion(t *testing.T) {
+	docs := func(contents ...string) []schema.Document {
+		result := make([]schema.Document, len(contents))
+		for i, c := range contents {
+			result[i] = schema.Document{PageContent: c}
+		}
+
+		return result
+	}
+
+	retrieverA := &retrieverMock{
+		GetRelevantDocumentsFunc: func(ctx context.Context, query string) ([]schema.Document, error) {
+			return docs("a", "b", "c"), nil
+		},
+	}
+
+	retrieverB := &retrieverMock{
+		GetRelevantDocumentsFunc: func(ctx context.Context, query string) ([]schema.Document, error) {
+			return docs("c", "d", "b"), nil
+		},
+	}
+
+	failing := &retrieverMock{
+		GetRelevantDocumentsFunc: func(ctx context.Context, query string) ([]schema.Document, error) {
+			return nil, errors.New("backend unavailable")
+		},
+	}
+
+	t.Run("RRF", func(t *testing.T) {
+		merger := NewMerger([]schema.Retriever{retrieverA, retrieverB})
+
+		mergedDocuments, err := merger.GetRelevantDocuments(context.TODO(), "query")
+		assert.NoError(t, err)
+		assert.Equal(t, docs("c", "b", "a", "d"), mergedDocuments)
+	})
+
+	t.Run("Weights", func(t *testing.T) {
+		merger := NewMerger([]schema.Retriever{retrieverA, retrieverB}, func(o *MergerOptions) {
+			o.Weights = []float64{1, 3}
+			o.TopK = 2
+		})
+
+		mergedDocuments, err := merger.GetRelevantDocuments(context.TODO(), "query")
+		assert.NoError(t, err)
+		assert.Equal(t, docs("c", "b"), mergedDocuments)
+	})
+
+	t.Run("WeightedScore", func(t *testing.T) {
+		scored := &retrieverMock{
+			GetRelevantDocumentsFunc: func(ctx context.Context, query string) ([]schema.Document, error) {
+				return []schema.Document{
+					{PageContent: "d", Metadata: map[string]any{"score": 0.9}},
+					{PageContent: "a", Metadata: map[string]any{"score": 0.8}},
+					{PageContent: "e", Metadata: map[string]any{"score": 0.1}},
+				}, nil
+			},
+		}
+
+		merger := NewMerger([]schema.Retriever{retrieverA, scored}, func(o *MergerOptions) {
+			o.FusionMode = MergerFusionModeWeightedScore
+		})
+
+		mergedDocuments, err := merger.GetRelevantDocuments(context.TODO(), "query")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "d", "b", "c", "e"}, contents(mergedDocuments))
+	})
+
+	t.Run("DeduplicateByID", func(t *testing.T) {
+		withIDs := &retrieverMock{
+			GetRelevantDocumentsFunc: func(ctx context.Context, query string) ([]schema.Document, error) {
+				return []schema.Document{{ID: "1", PageContent: "a v1"}, {ID: "2", PageContent: "b"}}, nil
+			},
+		}
+
+		withUpdatedIDs := &retrieverMock{
+			GetRelevantDocumentsFunc: func(ctx context.Context, query string) ([]schema.Document, error) {
+				return []schema.Document{{ID: "1", PageContent: "a v2"}}, nil
+			},
+		}
+
+		merger := NewMerger([]schema.Retriever{withIDs, withUpdatedIDs})
+
+		mergedDocuments, err := merger.GetRelevantDocuments(context.TODO(), "query")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a v1", "b"}, contents(mergedDocuments))
+	})
+
+	t.Run("WeightedScoreVectorStore", func(t *testing.T) {
+		// The vector store retriever returns the scores of the similarity search in the metadata.
+		vector := NewVectorStore(&mockVectorStore{
+			similaritySearchWithScoreFn: func(ctx context.Context, query string, opts schema.VectorStoreSearchOptions) ([]schema.ScoredDocument, error) {
+				return []schema.ScoredDocument{
+					{Document: schema.Document{PageContent: "d"}, Score: 0.9},
+					{Document: schema.Document{PageContent: "a"}, Score: 0.8},
+					{Document: schema.Document{PageContent: "e"}, Score: 0.1},
+				}, nil
+			},
+		})
+
+		merger := NewMerger([]schema.Retriever{retrieverA, vector}, func(o *MergerOptions) {
+			o.FusionMode = MergerFusionModeWeightedScore
+		})
+
+		mergedDocuments, err := merger.GetRelevantDocuments(context.TODO(), "query")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "d", "b", "c", "e"}, contents(mergedDocuments))
+	})
+
+	t.Run("WeightedScoreFallback", func(t *testing.T) {
+		// Rankings without scores are scored by rank.
+		merger := NewMerger([]schema.Retriever{retrieverA, retrieverB}, func(o *MergerOptions) {
+			o.FusionMode = MergerFusionModeWeightedScore
+		})
+
+		mergedDocuments, err := merger.GetRelevantDocuments(context.TODO(), "query")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"c", "a", "b", "d"}, contents(mergedDocuments))
+	})
+
+	t.Run("PartialFailure", func(t *testing.T) {
+		handler := &retrieverRecordingHandler{}
+
+		merger := NewMerger([]schema.Retriever{failing, retrieverA}, func(o *MergerOptions) {
+			o.Callbacks = []schema.Callback{handler}
+		})
+
+		mergedDocuments, err := merger.GetRelevantDocuments(context.TODO(), "query")
+		assert.NoError(t, err)
+		assert.Equal(t, docs("a", "b", "c"), mergedDocuments)
+
+		// The error of the skipped retriever is reported to the callbacks.
+		assert.Len(t, handler.errors, 1)
+		assert.EqualError(t, handler.errors[0], "backend unavailable")
+	})
+
+	t.Run("ChildRuns", func(t *testing.T) {
+		handler := &retrieverRecordingHandler{}
+
+		merger := NewMerger([]schema.Retriever{retrieverA, retrieverB})
+
+		_, err := Run(context.TODO(), merger, "query", func(o *Options) {
+			o.Callbacks = []schema.Callback{handler}
+			o.Tags = []string{"merger"}
+		})
+		assert.NoError(t, err)
+
+		require.Len(t, handler.starts, 3)
+
+		mergerRun := handler.starts[0]
+		assert.Empty(t, mergerRun.ParentRunID)
+		assert.Equal(t, "retriever.Merger", mergerRun.RetrieverType)
+
+		for _, child := range handler.starts[1:] {
+			assert.Equal(t, mergerRun.RunID, child.ParentRunID)
+			assert.Equal(t, []string{"merger"}, child.Tags)
+		}
+	})
+
+	t.Run("ChildRunsWithOptions", func(t *testing.T) {
+		handler := &retrieverRecordingHandler{}
+
+		rm := callback.NewManagerForRetrieverRun("parent", []schema.Callback{handler}, nil, false)
+
+		merger := NewMerger([]schema.Retriever{retrieverA, retrieverB})
+
+		// The retrievers are children of the passed run, independent of the context.
+		_, err := merger.GetRelevantDocumentsWithOptions(context.Background(), "query", func(o *schema.RetrieverRunOptions) {
+			o.CallbackManger = rm
+		})
+		assert.NoError(t, err)
+
+		require.Len(t, handler.starts, 2)
+
+		for _, child := range handler.starts {
+			assert.Equal(t, "parent", child.ParentRunID)
+		}
+	})
+
+	t.Run("FailFast", func(t *testing.T) {
+		merger := NewMerger([]schema.Retriever{failing, retrieverA}, func(o *MergerOptions) {
+			o.FailFast = true
+		})
+
+		_, err := merger.GetRelevantDocuments(context.TODO(), "query")
+		assert.ErrorContains(t, err, "backend unavailable")
+	})
+
+	t.Run("AllFailed", func(t *testing.T) {
+		merger := NewMerger([]schema.Retriever{failing, failing})
+
+		_, err := merger.GetRelevantDocuments(context.TODO(), "query")
+		assert.ErrorContains(t, err, "backend unavailable")
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		merger := NewMerger([]schema.Retriever{retrieverA}, func(o *MergerOptions) {
+			o.Verbose = true
+		})
+
+		assert.True(t, merger.Verbose())
+	})
+}
+
+func contents(docs []schema.Document) []string {
+	result := make([]string, len(docs))
+	for i, doc := range docs {
+		result[i] = doc.PageContent
+	}
+
+	return result
+}
+
+type retrieverRecordingHandler struct {
+	callback.NoopHandler
+	starts []*schema.RetrieverStartInput
+	errors []error
+	mu     sync.Mutex
+}
+
+func (h *retrieverRecordingHandler) AlwaysVerbose() bool {
+	return true
+}
+
+func (h *retrieverRecordingHandler) OnRetrieverStart(ctx context.Context, input *schema.RetrieverStartInput) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.starts = append(h.starts, input)
+
+	return nil
+}
+
+func (h *retrieverRecordingHandler) OnRetrieverError(ctx context.Context, input *schema.RetrieverErrorInput) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.errors = append(h.errors, input.Error)
+
+	return nil
+}
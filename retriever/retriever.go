@@ -3,7 +3,9 @@ package retriever
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/schema"
@@ -16,6 +18,21 @@ type HTTPClient interface {
 type Options struct {
 	Callbacks   []schema.Callback
 	ParentRunID string
+	// Tags are passed to the callbacks of the retriever run.
+	Tags []string
+	// Metadata is passed to the callbacks of the retriever run.
+	Metadata map[string]any
+}
+
+// ChildOptions returns an option that runs the retriever as a child of the run managed by cm.
+// The child run inherits the callbacks, tags and metadata of its parent.
+func ChildOptions(cm schema.CallbackManagerForParentRun) func(o *Options) {
+	return func(o *Options) {
+		o.Callbacks = cm.GetInheritableCallbacks()
+		o.ParentRunID = cm.RunID()
+		o.Tags = cm.GetInheritableTags()
+		o.Metadata = cm.GetInheritableMetadata()
+	}
 }
 
 func Run(ctx context.Context, retriever schema.Retriever, query string, optFns ...func(*Options)) ([]schema.Document, error) {
@@ -27,16 +44,28 @@ func Run(ctx context.Context, retriever schema.Retriever, query string, optFns .
 
 	cm := callback.NewManager(opts.Callbacks, retriever.Callbacks(), retriever.Verbose(), func(mo *callback.ManagerOptions) {
 		mo.ParentRunID = opts.ParentRunID
+		mo.Tags = opts.Tags
+		mo.Metadata = opts.Metadata
 	})
 
 	rm, err := cm.OnRetrieverStart(ctx, &schema.RetrieverStartManagerInput{
-		Query: query,
+		RetrieverType: retrieverType(retriever),
+		Query:         query,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	docs, err := retriever.GetRelevantDocuments(ctx, query)
+	var docs []schema.Document
+
+	if rr, ok := retriever.(schema.RetrieverWithRunOptions); ok {
+		docs, err = rr.GetRelevantDocumentsWithOptions(ctx, query, func(o *schema.RetrieverRunOptions) {
+			o.CallbackManger = rm
+		})
+	} else {
+		docs, err = retriever.GetRelevantDocuments(ctx, query)
+	}
+
 	if err != nil {
 		if cbErr := rm.OnRetrieverError(ctx, &schema.RetrieverErrorManagerInput{
 			Error: err,
@@ -55,3 +84,13 @@ func Run(ctx context.Context, retriever schema.Retriever, query string, optFns .
 
 	return docs, nil
 }
+
+// retrieverType returns the type of the retriever, e.g. "retriever.VectorStore". A retriever can
+// report another type with a Type method.
+func retrieverType(retriever schema.Retriever) string {
+	if t, ok := retriever.(interface{ Type() string }); ok {
+		return t.Type()
+	}
+
+	return strings.TrimPrefix(fmt.Sprintf("%T", retriever), "*")
+}
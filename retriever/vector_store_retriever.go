@@ -2,6 +2,7 @@ package retriever
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/hupe1980/golc"
 	"github.com/hupe1980/golc/schema"
@@ -14,11 +15,27 @@ type VectorStoreSearchType string
 
 const (
 	VectorStoreSearchTypeSimilarity VectorStoreSearchType = "similarity"
+	// VectorStoreSearchTypeMMR selects documents by maximal marginal relevance, which are relevant but diverse.
+	// The vector store must implement the schema.MaxMarginalRelevanceSearcher interface.
+	VectorStoreSearchTypeMMR VectorStoreSearchType = "mmr"
 )
 
 type VectorStoreOptions struct {
 	*schema.CallbackOptions
 	SearchType VectorStoreSearchType
+	// TopK is the number of documents to retrieve. If zero, the default of the vector store is used.
+	TopK int
+	// ScoreThreshold is the minimum score of the retrieved documents. If zero, no threshold is applied.
+	ScoreThreshold float32
+	// Filter restricts the retrieval to documents with matching metadata.
+	Filter *schema.MetadataFilter
+	// FetchK is the number of candidates fetched by a mmr search, from which TopK documents are selected.
+	FetchK int
+	// Lambda controls the trade-off between relevance (1) and diversity (0) of a mmr search.
+	Lambda float32
+	// ScoreKey is the metadata key, under which the scores of a similarity search are returned, e.g. to fuse
+	// the rankings with MergerFusionModeWeightedScore. Default is "score". Documents of a mmr search have no score.
+	ScoreKey string
 }
 
 type VectorStore struct {
@@ -29,6 +46,9 @@ type VectorStore struct {
 func NewVectorStore(vectorStore schema.VectorStore, optFns ...func(o *VectorStoreOptions)) *VectorStore {
 	opts := VectorStoreOptions{
 		SearchType: VectorStoreSearchTypeSimilarity,
+		FetchK:     20,
+		Lambda:     0.5,
+		ScoreKey:   "score",
 		CallbackOptions: &schema.CallbackOptions{
 			Verbose: golc.Verbose,
 		},
@@ -46,7 +66,48 @@ func NewVectorStore(vectorStore schema.VectorStore, optFns ...func(o *VectorStor
 
 // GetRelevantDocuments returns documents using the vector store.
 func (r *VectorStore) GetRelevantDocuments(ctx context.Context, query string) ([]schema.Document, error) {
-	return r.v.SimilaritySearch(ctx, query)
+	switch r.opts.SearchType {
+	case VectorStoreSearchTypeSimilarity:
+		scoredDocs, err := r.v.SimilaritySearchWithScore(ctx, query, func(o *schema.VectorStoreSearchOptions) {
+			o.TopK = r.opts.TopK
+			o.ScoreThreshold = r.opts.ScoreThreshold
+			o.Filter = r.opts.Filter
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		docs := make([]schema.Document, len(scoredDocs))
+
+		for i, sd := range scoredDocs {
+			docs[i] = sd.Document
+
+			// Copy the metadata, so that the documents of the vector store are not changed.
+			docs[i].Metadata = make(map[string]any, len(sd.Metadata)+1)
+			for k, v := range sd.Metadata {
+				docs[i].Metadata[k] = v
+			}
+
+			docs[i].Metadata[r.opts.ScoreKey] = sd.Score
+		}
+
+		return docs, nil
+	case VectorStoreSearchTypeMMR:
+		searcher, ok := r.v.(schema.MaxMarginalRelevanceSearcher)
+		if !ok {
+			return nil, fmt.Errorf("vector store %T does not support mmr search", r.v)
+		}
+
+		return searcher.MaxMarginalRelevanceSearch(ctx, query, func(o *schema.MaxMarginalRelevanceSearchOptions) {
+			o.TopK = r.opts.TopK
+			o.ScoreThreshold = r.opts.ScoreThreshold
+			o.Filter = r.opts.Filter
+			o.FetchK = r.opts.FetchK
+			o.Lambda = r.opts.Lambda
+		})
+	default:
+		return nil, fmt.Errorf("unsupported search type: %s", r.opts.SearchType)
+	}
 }
 
 // Verbose returns the verbosity setting of the retriever.
@@ -0,0 +1,136 @@
+package retriever
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hupe1980/golc/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVectorStore(t *testing.T) {
+	filter := schema.FilterEq("source", "wiki")
+
+	t.Run("Similarity", func(t *testing.T) {
+		metadata := map[string]any{"source": "wiki"}
+
+		vs := &mockVectorStore{
+			similaritySearchWithScoreFn: func(ctx context.Context, query string, opts schema.VectorStoreSearchOptions) ([]schema.ScoredDocument, error) {
+				assert.Equal(t, "query", query)
+				assert.Equal(t, schema.VectorStoreSearchOptions{TopK: 2, ScoreThreshold: 0.5, Filter: filter}, opts)
+
+				return []schema.ScoredDocument{
+					{Document: schema.Document{PageContent: "foo", Metadata: metadata}, Score: 0.9},
+					{Document: schema.Document{PageContent: "bar"}, Score: 0.7},
+				}, nil
+			},
+		}
+
+		r := NewVectorStore(vs, func(o *VectorStoreOptions) {
+			o.TopK = 2
+			o.ScoreThreshold = 0.5
+			o.Filter = filter
+		})
+
+		docs, err := r.GetRelevantDocuments(context.Background(), "query")
+		assert.NoError(t, err)
+		assert.Equal(t, []schema.Document{
+			{PageContent: "foo", Metadata: map[string]any{"source": "wiki", "score": float32(0.9)}},
+			{PageContent: "bar", Metadata: map[string]any{"score": float32(0.7)}},
+		}, docs)
+
+		// The metadata of the vector store documents is not changed.
+		assert.Equal(t, map[string]any{"source": "wiki"}, metadata)
+	})
+
+	t.Run("MMR", func(t *testing.T) {
+		vs := &mockMMRVectorStore{
+			mmrSearchFn: func(ctx context.Context, query string, opts schema.MaxMarginalRelevanceSearchOptions) ([]schema.Document, error) {
+				assert.Equal(t, schema.MaxMarginalRelevanceSearchOptions{
+					VectorStoreSearchOptions: schema.VectorStoreSearchOptions{TopK: 3, Filter: filter},
+					FetchK:                   10,
+					Lambda:                   0.5,
+				}, opts)
+
+				return []schema.Document{{PageContent: "foo"}, {PageContent: "bar"}}, nil
+			},
+		}
+
+		r := NewVectorStore(vs, func(o *VectorStoreOptions) {
+			o.SearchType = VectorStoreSearchTypeMMR
+			o.TopK = 3
+			o.FetchK = 10
+			o.Filter = filter
+		})
+
+		docs, err := r.GetRelevantDocuments(context.Background(), "query")
+		assert.NoError(t, err)
+		assert.Len(t, docs, 2)
+	})
+
+	t.Run("MMRNotSupported", func(t *testing.T) {
+		r := NewVectorStore(&mockVectorStore{}, func(o *VectorStoreOptions) {
+			o.SearchType = VectorStoreSearchTypeMMR
+		})
+
+		_, err := r.GetRelevantDocuments(context.Background(), "query")
+		assert.ErrorContains(t, err, "does not support mmr search")
+	})
+}
+
+// Compile time check to ensure mockVectorStore satisfies the VectorStore interface.
+var _ schema.VectorStore = (*mockVectorStore)(nil)
+
+type mockVectorStore struct {
+	similaritySearchWithScoreFn func(ctx context.Context, query string, opts schema.VectorStoreSearchOptions) ([]schema.ScoredDocument, error)
+}
+
+func (m *mockVectorStore) AddDocuments(ctx context.Context, docs []schema.Document) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockVectorStore) Delete(ctx context.Context, ids []string) error {
+	return nil
+}
+
+func (m *mockVectorStore) SimilaritySearch(ctx context.Context, query string, optFns ...func(o *schema.VectorStoreSearchOptions)) ([]schema.Document, error) {
+	scoredDocs, err := m.SimilaritySearchWithScore(ctx, query, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]schema.Document, len(scoredDocs))
+	for i, sd := range scoredDocs {
+		docs[i] = sd.Document
+	}
+
+	return docs, nil
+}
+
+func (m *mockVectorStore) SimilaritySearchWithScore(ctx context.Context, query string, optFns ...func(o *schema.VectorStoreSearchOptions)) ([]schema.ScoredDocument, error) {
+	opts := schema.VectorStoreSearchOptions{}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return m.similaritySearchWithScoreFn(ctx, query, opts)
+}
+
+// Compile time check to ensure mockMMRVectorStore satisfies the MaxMarginalRelevanceSearcher interface.
+var _ schema.MaxMarginalRelevanceSearcher = (*mockMMRVectorStore)(nil)
+
+type mockMMRVectorStore struct {
+	mockVectorStore
+	mmrSearchFn func(ctx context.Context, query string, opts schema.MaxMarginalRelevanceSearchOptions) ([]schema.Document, error)
+}
+
+func (m *mockMMRVectorStore) MaxMarginalRelevanceSearch(ctx context.Context, query string, optFns ...func(o *schema.MaxMarginalRelevanceSearchOptions)) ([]schema.Document, error) {
+	opts := schema.MaxMarginalRelevanceSearchOptions{}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return m.mmrSearchFn(ctx, query, opts)
+}
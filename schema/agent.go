@@ -86,6 +86,8 @@ type AgentAction struct {
 	Log string
 	// Message log associated with the action.
 	MessageLog ChatMessages
+	// ID of the tool call the action was created from, if any.
+	ToolCallID string
 }
 
 // AgentStep represents a step in the agent's action plan.
@@ -103,10 +105,15 @@ type AgentFinish struct {
 	Log          string
 }
 
+// AgentPlanOptions contains options for planning the next step of an agent.
+type AgentPlanOptions struct {
+	CallbackManger CallbackManagerForChainRun
+}
+
 // Agent is an interface that defines the behavior of an agent.
 type Agent interface {
 	// Plan plans the agent's action given the intermediate steps and inputs.
-	Plan(ctx context.Context, intermediateSteps []AgentStep, inputs ChainValues) ([]*AgentAction, *AgentFinish, error)
+	Plan(ctx context.Context, intermediateSteps []AgentStep, inputs ChainValues, optFns ...func(o *AgentPlanOptions)) ([]*AgentAction, *AgentFinish, error)
 	// InputKeys returns the keys for expected input values for the agent.
 	InputKeys() []string
 	// OutputKeys returns the keys for the agent's output values.
@@ -128,3 +135,18 @@ type Tool interface {
 	// Callbacks returns the registered callbacks of the tool.
 	Callbacks() []Callback
 }
+
+// ToolRunOptions contains options for running a tool.
+type ToolRunOptions struct {
+	// CallbackManger is the callback manager of the tool run.
+	CallbackManger CallbackManagerForToolRun
+}
+
+// ToolWithRunOptions is implemented by tools, which call chains, models or other tools themselves.
+// The callback manager of the tool run is passed to the tool, so that the nested runs inherit
+// the callbacks and are children of the tool run.
+type ToolWithRunOptions interface {
+	Tool
+	// RunWithOptions executes the tool with the given input and run options and returns the output.
+	RunWithOptions(ctx context.Context, input any, optFns ...func(o *ToolRunOptions)) (string, error)
+}
@@ -11,6 +11,12 @@ type LLMStartManagerInput struct {
 type LLMStartInput struct {
 	*LLMStartManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type ChatModelStartManagerInput struct {
@@ -22,15 +28,29 @@ type ChatModelStartManagerInput struct {
 type ChatModelStartInput struct {
 	*ChatModelStartManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type ModelNewTokenManagerInput struct {
 	Token string
+	// ToolCallDelta holds the fragment of a tool call, if the model streams a tool call instead of a token.
+	ToolCallDelta *ToolCallDelta
 }
 
 type ModelNewTokenInput struct {
 	*ModelNewTokenManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type ModelEndManagerInput struct {
@@ -40,6 +60,12 @@ type ModelEndManagerInput struct {
 type ModelEndInput struct {
 	*ModelEndManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type ModelErrorManagerInput struct {
@@ -49,6 +75,12 @@ type ModelErrorManagerInput struct {
 type ModelErrorInput struct {
 	*ModelErrorManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type ChainStartManagerInput struct {
@@ -59,6 +91,12 @@ type ChainStartManagerInput struct {
 type ChainStartInput struct {
 	*ChainStartManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type ChainEndManagerInput struct {
@@ -68,6 +106,12 @@ type ChainEndManagerInput struct {
 type ChainEndInput struct {
 	*ChainEndManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type ChainErrorManagerInput struct {
@@ -77,6 +121,12 @@ type ChainErrorManagerInput struct {
 type ChainErrorInput struct {
 	*ChainErrorManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type AgentActionManagerInput struct {
@@ -86,6 +136,12 @@ type AgentActionManagerInput struct {
 type AgentActionInput struct {
 	*AgentActionManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type AgentFinishManagerInput struct {
@@ -95,6 +151,12 @@ type AgentFinishManagerInput struct {
 type AgentFinishInput struct {
 	*AgentFinishManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type ToolStartManagerInput struct {
@@ -105,6 +167,12 @@ type ToolStartManagerInput struct {
 type ToolStartInput struct {
 	*ToolStartManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type ToolEndManagerInput struct {
@@ -114,6 +182,12 @@ type ToolEndManagerInput struct {
 type ToolEndInput struct {
 	*ToolEndManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type ToolErrorManagerInput struct {
@@ -123,6 +197,12 @@ type ToolErrorManagerInput struct {
 type ToolErrorInput struct {
 	*ToolErrorManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type TextManagerInput struct {
@@ -132,15 +212,29 @@ type TextManagerInput struct {
 type TextInput struct {
 	*TextManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type RetrieverStartManagerInput struct {
-	Query string
+	// RetrieverType is the type of the retriever, e.g. "retriever.VectorStore".
+	RetrieverType string
+	Query         string
 }
 
 type RetrieverStartInput struct {
 	*RetrieverStartManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type RetrieverEndManagerInput struct {
@@ -150,6 +244,12 @@ type RetrieverEndManagerInput struct {
 type RetrieverEndInput struct {
 	*RetrieverEndManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type RetrieverErrorManagerInput struct {
@@ -159,6 +259,12 @@ type RetrieverErrorManagerInput struct {
 type RetrieverErrorInput struct {
 	*RetrieverErrorManagerInput
 	RunID string
+	// ParentRunID is the run id of the parent run, if any.
+	ParentRunID string
+	// Tags are the user-supplied tags of the run, which are inherited by its child runs.
+	Tags []string
+	// Metadata is the user-supplied metadata of the run, which is inherited by its child runs.
+	Metadata map[string]any
 }
 
 type Callback interface {
@@ -192,14 +298,22 @@ type CallbackManager interface {
 	RunID() string
 }
 
+// CallbackManagerForParentRun is implemented by the callback managers of runs that start child runs.
+// It provides what a child run inherits from its parent.
+type CallbackManagerForParentRun interface {
+	GetInheritableCallbacks() []Callback
+	GetInheritableTags() []string
+	GetInheritableMetadata() map[string]any
+	RunID() string
+}
+
 type CallbackManagerForChainRun interface {
 	OnChainEnd(ctx context.Context, input *ChainEndManagerInput) error
 	OnChainError(ctx context.Context, input *ChainErrorManagerInput) error
 	OnAgentAction(ctx context.Context, input *AgentActionManagerInput) error
 	OnAgentFinish(ctx context.Context, input *AgentFinishManagerInput) error
 	OnText(ctx context.Context, input *TextManagerInput) error
-	GetInheritableCallbacks() []Callback
-	RunID() string
+	CallbackManagerForParentRun
 }
 
 type CallbackManagerForModelRun interface {
@@ -207,19 +321,20 @@ type CallbackManagerForModelRun interface {
 	OnModelEnd(ctx context.Context, input *ModelEndManagerInput) error
 	OnModelError(ctx context.Context, input *ModelErrorManagerInput) error
 	OnText(ctx context.Context, input *TextManagerInput) error
-	GetInheritableCallbacks() []Callback
-	RunID() string
+	CallbackManagerForParentRun
 }
 
 type CallbackManagerForToolRun interface {
 	OnToolEnd(ctx context.Context, input *ToolEndManagerInput) error
 	OnToolError(ctx context.Context, input *ToolErrorManagerInput) error
 	OnText(ctx context.Context, input *TextManagerInput) error
+	CallbackManagerForParentRun
 }
 
 type CallbackManagerForRetrieverRun interface {
 	OnRetrieverEnd(ctx context.Context, input *RetrieverEndManagerInput) error
 	OnRetrieverError(ctx context.Context, input *RetrieverErrorManagerInput) error
+	CallbackManagerForParentRun
 }
 
 type CallbackOptions struct {
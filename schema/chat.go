@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -12,6 +14,74 @@ type FunctionCall struct {
 	Arguments string `json:"arguments,omitempty"`
 }
 
+// ToolCall represents a call of a tool requested by the model.
+type ToolCall struct {
+	// ID of the tool call. It is used to match the call with its ToolChatMessage.
+	ID string `json:"id,omitempty"`
+	// Function to be called.
+	Function FunctionCall `json:"function"`
+}
+
+// ContentPartType represents the type of a content part.
+type ContentPartType string
+
+const (
+	ContentPartTypeText  ContentPartType = "text"
+	ContentPartTypeImage ContentPartType = "image"
+	ContentPartTypeFile  ContentPartType = "file"
+)
+
+// ContentPart represents a part of a multimodal chat message, e.g. a text or an image.
+// Images and files are provided either inline as data or by URL.
+type ContentPart struct {
+	// Type of the content part.
+	Type ContentPartType `json:"type"`
+	// Text of a text part.
+	Text string `json:"text,omitempty"`
+	// MIMEType of the data, e.g. image/png or application/pdf.
+	MIMEType string `json:"mimeType,omitempty"`
+	// Data holds the raw bytes of an inline image or file.
+	Data []byte `json:"data,omitempty"`
+	// URL of an image or file that is not provided inline.
+	URL string `json:"url,omitempty"`
+}
+
+// NewTextContentPart creates a new text content part.
+func NewTextContentPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartTypeText, Text: text}
+}
+
+// NewImageContentPart creates a new image content part with inline data.
+func NewImageContentPart(mimeType string, data []byte) ContentPart {
+	return ContentPart{Type: ContentPartTypeImage, MIMEType: mimeType, Data: data}
+}
+
+// NewImageURLContentPart creates a new image content part that refers to the image by URL.
+// The MIME type is optional.
+func NewImageURLContentPart(mimeType string, url string) ContentPart {
+	return ContentPart{Type: ContentPartTypeImage, MIMEType: mimeType, URL: url}
+}
+
+// NewFileContentPart creates a new file content part with inline data, e.g. a scanned PDF document.
+func NewFileContentPart(mimeType string, data []byte) ContentPart {
+	return ContentPart{Type: ContentPartTypeFile, MIMEType: mimeType, Data: data}
+}
+
+// IsInline returns true if the data of the content part is provided inline.
+func (p ContentPart) IsInline() bool {
+	return len(p.Data) > 0
+}
+
+// DataURL returns the inline data of the content part as base64 encoded data URL.
+// If the data is not provided inline, the URL of the part is returned.
+func (p ContentPart) DataURL() string {
+	if !p.IsInline() {
+		return p.URL
+	}
+
+	return fmt.Sprintf("data:%s;base64,%s", p.MIMEType, base64.StdEncoding.EncodeToString(p.Data))
+}
+
 // ChatMessageType represents the type of a chat message.
 type ChatMessageType string
 
@@ -21,11 +91,15 @@ const (
 	ChatMessageTypeSystem   ChatMessageType = "system"
 	ChatMessageTypeGeneric  ChatMessageType = "generic"
 	ChatMessageTypeFunction ChatMessageType = "function"
+	ChatMessageTypeTool     ChatMessageType = "tool"
 )
 
 // ChatMessageExtension represents additional data associated with a chat message.
 type ChatMessageExtension struct {
+	// FunctionCall is the first function call requested by the model.
 	FunctionCall *FunctionCall `json:"functionCall,omitempty"`
+	// ToolCalls holds all tool calls requested by the model, which can be executed in parallel.
+	ToolCalls []ToolCall `json:"toolCalls,omitempty"`
 }
 
 // ChatMessage is an interface for different types of chat messages.
@@ -43,10 +117,25 @@ func ChatMessageToMap(cm ChatMessage) map[string]string {
 		"content": cm.Content(),
 	}
 
-	if fm, ok := cm.(*FunctionChatMessage); ok {
-		m["name"] = fm.Name()
-	} else if gm, ok := cm.(*GenericChatMessage); ok {
-		m["role"] = gm.Role()
+	switch t := cm.(type) {
+	case *FunctionChatMessage:
+		m["name"] = t.Name()
+	case *GenericChatMessage:
+		m["role"] = t.Role()
+	case *ToolChatMessage:
+		m["toolCallID"] = t.ToolCallID()
+	case *HumanChatMessage:
+		if len(t.parts) > 0 {
+			// The parts only contain strings and bytes, so marshaling cannot fail.
+			b, _ := json.Marshal(t.parts)
+			m["parts"] = string(b)
+		}
+	case *AIChatMessage:
+		if t.ext.FunctionCall != nil || len(t.ext.ToolCalls) > 0 {
+			// The extension only contains strings, so marshaling cannot fail.
+			b, _ := json.Marshal(t.ext)
+			m["extension"] = string(b)
+		}
 	}
 
 	return m
@@ -56,15 +145,33 @@ func ChatMessageToMap(cm ChatMessage) map[string]string {
 func MapToChatMessage(m map[string]string) (ChatMessage, error) {
 	switch ChatMessageType(m["type"]) {
 	case ChatMessageTypeHuman:
-		return NewHumanChatMessage(m["content"]), nil
+		var parts []ContentPart
+		if m["parts"] != "" {
+			if err := json.Unmarshal([]byte(m["parts"]), &parts); err != nil {
+				return nil, err
+			}
+		}
+
+		return NewHumanChatMessage(m["content"], parts...), nil
 	case ChatMessageTypeAI:
-		return NewAIChatMessage(m["content"]), nil
+		ext := ChatMessageExtension{}
+		if m["extension"] != "" {
+			if err := json.Unmarshal([]byte(m["extension"]), &ext); err != nil {
+				return nil, err
+			}
+		}
+
+		return NewAIChatMessage(m["content"], func(o *ChatMessageExtension) {
+			*o = ext
+		}), nil
 	case ChatMessageTypeSystem:
 		return NewSystemChatMessage(m["content"]), nil
 	case ChatMessageTypeGeneric:
 		return NewGenericChatMessage(m["content"], m["role"]), nil
 	case ChatMessageTypeFunction:
-		return NewFunctionChatMessage(m["content"], m["name"]), nil
+		return NewFunctionChatMessage(m["name"], m["content"]), nil
+	case ChatMessageTypeTool:
+		return NewToolChatMessage(m["toolCallID"], m["content"]), nil
 	default:
 		return nil, fmt.Errorf("unknown chat message type: %s", m["type"])
 	}
@@ -73,12 +180,15 @@ func MapToChatMessage(m map[string]string) (ChatMessage, error) {
 // HumanChatMessage represents a chat message from a human.
 type HumanChatMessage struct {
 	content string
+	parts   []ContentPart
 }
 
 // NewHumanChatMessage creates a new HumanChatMessage instance.
-func NewHumanChatMessage(content string) *HumanChatMessage {
+// Optional content parts, e.g. images, are sent to the model after the text content.
+func NewHumanChatMessage(content string, parts ...ContentPart) *HumanChatMessage {
 	return &HumanChatMessage{
 		content: content,
+		parts:   parts,
 	}
 }
 
@@ -88,6 +198,18 @@ func (m HumanChatMessage) Type() ChatMessageType { return ChatMessageTypeHuman }
 // Content returns the content of the chat message.
 func (m HumanChatMessage) Content() string { return m.content }
 
+// Parts returns the additional content parts of the chat message.
+func (m HumanChatMessage) Parts() []ContentPart { return m.parts }
+
+// ContentParts returns the text content followed by the additional content parts of the chat message.
+func (m HumanChatMessage) ContentParts() []ContentPart {
+	if m.content == "" {
+		return m.parts
+	}
+
+	return append([]ContentPart{NewTextContentPart(m.content)}, m.parts...)
+}
+
 // AIChatMessage represents a chat message from an AI.
 type AIChatMessage struct {
 	content string
@@ -181,6 +303,29 @@ func (m FunctionChatMessage) Content() string { return m.content }
 // Name returns the name of the function associated with the chat message.
 func (m FunctionChatMessage) Name() string { return m.name }
 
+// ToolChatMessage represents a chat message with the result of a tool call.
+type ToolChatMessage struct {
+	toolCallID string
+	content    string
+}
+
+// NewToolChatMessage creates a new ToolChatMessage instance.
+func NewToolChatMessage(toolCallID, content string) *ToolChatMessage {
+	return &ToolChatMessage{
+		toolCallID: toolCallID,
+		content:    content,
+	}
+}
+
+// Type returns the type of the chat message.
+func (m ToolChatMessage) Type() ChatMessageType { return ChatMessageTypeTool }
+
+// Content returns the content of the chat message.
+func (m ToolChatMessage) Content() string { return m.content }
+
+// ToolCallID returns the ID of the tool call associated with the chat message.
+func (m ToolChatMessage) ToolCallID() string { return m.toolCallID }
+
 // ChatMessages represents a slice of ChatMessage.
 type ChatMessages []ChatMessage
 
@@ -190,6 +335,7 @@ type StringifyChatMessagesOptions struct {
 	AIPrefix       string
 	SystemPrefix   string
 	FunctionPrefix string
+	ToolPrefix     string
 }
 
 // Format formats the ChatMessages into a single string representation.
@@ -199,6 +345,7 @@ func (cm ChatMessages) Format(optFns ...func(o *StringifyChatMessagesOptions)) (
 		AIPrefix:       "AI",
 		SystemPrefix:   "System",
 		FunctionPrefix: "Function",
+		ToolPrefix:     "Tool",
 	}
 
 	for _, fn := range optFns {
@@ -221,6 +368,8 @@ func (cm ChatMessages) Format(optFns ...func(o *StringifyChatMessagesOptions)) (
 			role = message.(*GenericChatMessage).Role()
 		case ChatMessageTypeFunction:
 			role = opts.FunctionPrefix
+		case ChatMessageTypeTool:
+			role = opts.ToolPrefix
 		default:
 			return "", fmt.Errorf("unknown chat message type: %s", message.Type())
 		}
@@ -47,6 +47,89 @@ func TestMapToChatMessage(t *testing.T) {
 	require.Equal(t, "Hello, I am an AI.", aiMsg.Content())
 }
 
+func TestChatMessageMapRoundTrip(t *testing.T) {
+	t.Run("ToolCalls", func(t *testing.T) {
+		aiMsg := NewAIChatMessage("", func(o *ChatMessageExtension) {
+			o.ToolCalls = []ToolCall{
+				{ID: "call_1", Function: FunctionCall{Name: "foo", Arguments: `{"a":1}`}},
+				{ID: "call_2", Function: FunctionCall{Name: "bar", Arguments: `{"b":2}`}},
+			}
+		})
+
+		msg, err := MapToChatMessage(ChatMessageToMap(aiMsg))
+		require.NoError(t, err)
+		require.Equal(t, aiMsg, msg)
+	})
+
+	t.Run("ToolChatMessage", func(t *testing.T) {
+		toolMsg := NewToolChatMessage("call_1", "result")
+
+		m := ChatMessageToMap(toolMsg)
+		require.Equal(t, "tool", m["type"])
+		require.Equal(t, "call_1", m["toolCallID"])
+
+		msg, err := MapToChatMessage(m)
+		require.NoError(t, err)
+		require.Equal(t, toolMsg, msg)
+	})
+
+	t.Run("FunctionChatMessage", func(t *testing.T) {
+		funcMsg := NewFunctionChatMessage("foo", "bar")
+
+		msg, err := MapToChatMessage(ChatMessageToMap(funcMsg))
+		require.NoError(t, err)
+		require.Equal(t, funcMsg, msg)
+	})
+
+	t.Run("HumanChatMessageWithParts", func(t *testing.T) {
+		humanMsg := NewHumanChatMessage("What is in the image?",
+			NewImageContentPart("image/png", []byte{0x89, 0x50, 0x4e, 0x47}),
+			NewImageURLContentPart("image/jpeg", "https://example.com/image.jpg"),
+			NewFileContentPart("application/pdf", []byte("%PDF-1.7")),
+		)
+
+		m := ChatMessageToMap(humanMsg)
+		require.Equal(t, "What is in the image?", m["content"])
+		require.NotEmpty(t, m["parts"])
+
+		msg, err := MapToChatMessage(m)
+		require.NoError(t, err)
+		require.Equal(t, humanMsg, msg)
+	})
+
+	t.Run("HumanChatMessageWithoutParts", func(t *testing.T) {
+		humanMsg := NewHumanChatMessage("Hello")
+
+		m := ChatMessageToMap(humanMsg)
+		require.NotContains(t, m, "parts")
+
+		msg, err := MapToChatMessage(m)
+		require.NoError(t, err)
+		require.Equal(t, humanMsg, msg)
+	})
+}
+
+func TestContentPart(t *testing.T) {
+	t.Run("DataURL", func(t *testing.T) {
+		part := NewImageContentPart("image/png", []byte("foo"))
+		require.True(t, part.IsInline())
+		require.Equal(t, "data:image/png;base64,Zm9v", part.DataURL())
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		part := NewImageURLContentPart("", "https://example.com/image.png")
+		require.False(t, part.IsInline())
+		require.Equal(t, "https://example.com/image.png", part.DataURL())
+	})
+
+	t.Run("ContentParts", func(t *testing.T) {
+		image := NewImageContentPart("image/png", []byte("foo"))
+
+		require.Equal(t, []ContentPart{NewTextContentPart("Describe"), image}, NewHumanChatMessage("Describe", image).ContentParts())
+		require.Equal(t, []ContentPart{image}, NewHumanChatMessage("", image).ContentParts())
+	})
+}
+
 func TestStringifyChatMessages(t *testing.T) {
 	chatMessages := ChatMessages{
 		NewHumanChatMessage("Hello, I am a human."),
@@ -54,6 +137,7 @@ func TestStringifyChatMessages(t *testing.T) {
 		NewSystemChatMessage("System message."),
 		NewGenericChatMessage("Generic message.", "role"),
 		NewFunctionChatMessage("function", "Function call message."),
+		NewToolChatMessage("call_1", "Tool call message."),
 	}
 
 	formatted, err := chatMessages.Format()
@@ -63,4 +147,5 @@ func TestStringifyChatMessages(t *testing.T) {
 	require.Contains(t, formatted, "System: System message.")
 	require.Contains(t, formatted, "role: Generic message.")
 	require.Contains(t, formatted, "Function: Function call message.")
+	require.Contains(t, formatted, "Tool: Tool call message.")
 }
@@ -3,6 +3,9 @@ package schema
 import "context"
 
 type Document struct {
+	// ID is an optional stable identifier of the document.
+	// Vector stores replace existing documents with the same ID.
+	ID          string
 	PageContent string
 	Metadata    map[string]any
 }
@@ -29,11 +32,21 @@ type Retriever interface {
 	Callbacks() []Callback
 }
 
-type TextSplitter interface {
-	SplitDocuments(docs []Document) ([]Document, error)
+// RetrieverRunOptions contains options for running a retriever.
+type RetrieverRunOptions struct {
+	// CallbackManger is the callback manager of the retriever run.
+	CallbackManger CallbackManagerForRetrieverRun
+}
+
+// RetrieverWithRunOptions is implemented by retrievers, which query other retrievers themselves.
+// The callback manager of the retriever run is passed to the retriever, so that the nested runs
+// inherit the callbacks and are children of the retriever run.
+type RetrieverWithRunOptions interface {
+	Retriever
+	// GetRelevantDocumentsWithOptions returns the documents relevant to the query using the given run options.
+	GetRelevantDocumentsWithOptions(ctx context.Context, query string, optFns ...func(o *RetrieverRunOptions)) ([]Document, error)
 }
 
-type VectorStore interface {
-	AddDocuments(ctx context.Context, docs []Document) error
-	SimilaritySearch(ctx context.Context, query string) ([]Document, error)
+type TextSplitter interface {
+	SplitDocuments(docs []Document) ([]Document, error)
 }
@@ -0,0 +1,200 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FilterOperator represents the operator of a metadata filter.
+type FilterOperator string
+
+const (
+	FilterOperatorEq  FilterOperator = "eq"
+	FilterOperatorNe  FilterOperator = "ne"
+	FilterOperatorGt  FilterOperator = "gt"
+	FilterOperatorGte FilterOperator = "gte"
+	FilterOperatorLt  FilterOperator = "lt"
+	FilterOperatorLte FilterOperator = "lte"
+	FilterOperatorIn  FilterOperator = "in"
+	FilterOperatorAnd FilterOperator = "and"
+	FilterOperatorOr  FilterOperator = "or"
+)
+
+// MetadataFilter is a portable filter expression on the metadata of documents.
+// Vector stores translate it to their native filter language.
+type MetadataFilter struct {
+	// Operator of the filter.
+	Operator FilterOperator
+	// Key of the metadata field compared by a comparison filter.
+	Key string
+	// Value compared by a comparison filter. Supported are strings, numbers and booleans.
+	Value any
+	// Values of an in filter.
+	Values []any
+	// Filters combined by an and or or filter.
+	Filters []*MetadataFilter
+}
+
+// FilterEq creates a filter that matches documents whose metadata field equals the value.
+func FilterEq(key string, value any) *MetadataFilter {
+	return &MetadataFilter{Operator: FilterOperatorEq, Key: key, Value: value}
+}
+
+// FilterNe creates a filter that matches documents whose metadata field does not equal the value.
+func FilterNe(key string, value any) *MetadataFilter {
+	return &MetadataFilter{Operator: FilterOperatorNe, Key: key, Value: value}
+}
+
+// FilterGt creates a filter that matches documents whose metadata field is greater than the value.
+func FilterGt(key string, value any) *MetadataFilter {
+	return &MetadataFilter{Operator: FilterOperatorGt, Key: key, Value: value}
+}
+
+// FilterGte creates a filter that matches documents whose metadata field is greater than or equal to the value.
+func FilterGte(key string, value any) *MetadataFilter {
+	return &MetadataFilter{Operator: FilterOperatorGte, Key: key, Value: value}
+}
+
+// FilterLt creates a filter that matches documents whose metadata field is less than the value.
+func FilterLt(key string, value any) *MetadataFilter {
+	return &MetadataFilter{Operator: FilterOperatorLt, Key: key, Value: value}
+}
+
+// FilterLte creates a filter that matches documents whose metadata field is less than or equal to the value.
+func FilterLte(key string, value any) *MetadataFilter {
+	return &MetadataFilter{Operator: FilterOperatorLte, Key: key, Value: value}
+}
+
+// FilterIn creates a filter that matches documents whose metadata field equals one of the values.
+func FilterIn(key string, values ...any) *MetadataFilter {
+	return &MetadataFilter{Operator: FilterOperatorIn, Key: key, Values: values}
+}
+
+// FilterAnd creates a filter that matches documents matching all of the filters.
+func FilterAnd(filters ...*MetadataFilter) *MetadataFilter {
+	return &MetadataFilter{Operator: FilterOperatorAnd, Filters: filters}
+}
+
+// FilterOr creates a filter that matches documents matching at least one of the filters.
+func FilterOr(filters ...*MetadataFilter) *MetadataFilter {
+	return &MetadataFilter{Operator: FilterOperatorOr, Filters: filters}
+}
+
+// Match evaluates the filter against the metadata of a document.
+// Numbers of different types are compared by value. A missing field only matches a ne filter.
+func (f *MetadataFilter) Match(metadata map[string]any) bool {
+	switch f.Operator {
+	case FilterOperatorAnd:
+		for _, sub := range f.Filters {
+			if !sub.Match(metadata) {
+				return false
+			}
+		}
+
+		return true
+	case FilterOperatorOr:
+		for _, sub := range f.Filters {
+			if sub.Match(metadata) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	value, ok := metadata[f.Key]
+	if !ok {
+		return f.Operator == FilterOperatorNe
+	}
+
+	switch f.Operator {
+	case FilterOperatorEq:
+		return filterValuesEqual(value, f.Value)
+	case FilterOperatorNe:
+		return !filterValuesEqual(value, f.Value)
+	case FilterOperatorIn:
+		for _, v := range f.Values {
+			if filterValuesEqual(value, v) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	cmp, ok := compareFilterValues(value, f.Value)
+	if !ok {
+		return false
+	}
+
+	switch f.Operator {
+	case FilterOperatorGt:
+		return cmp > 0
+	case FilterOperatorGte:
+		return cmp >= 0
+	case FilterOperatorLt:
+		return cmp < 0
+	case FilterOperatorLte:
+		return cmp <= 0
+	default:
+		return false
+	}
+}
+
+// filterValuesEqual reports whether the values are equal, comparing numbers by value.
+func filterValuesEqual(a, b any) bool {
+	if cmp, ok := compareFilterValues(a, b); ok {
+		return cmp == 0
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// compareFilterValues compares two numbers or two strings.
+// The boolean result is false, if the values are not comparable.
+func compareFilterValues(a, b any) (int, bool) {
+	if fa, ok := FilterValueToFloat64(a); ok {
+		fb, ok := FilterValueToFloat64(b)
+		if !ok {
+			return 0, false
+		}
+
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	sa, ok := a.(string)
+	if !ok {
+		return 0, false
+	}
+
+	sb, ok := b.(string)
+	if !ok {
+		return 0, false
+	}
+
+	return strings.Compare(sa, sb), true
+}
+
+// FilterValueToFloat64 converts a numeric filter value to float64.
+// The boolean result is false, if the value is not a number.
+func FilterValueToFloat64(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
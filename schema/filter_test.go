@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataFilter(t *testing.T) {
+	metadata := map[string]any{
+		"source": "wiki",
+		"year":   2021,
+		"score":  float32(0.5),
+		"draft":  false,
+	}
+
+	tests := []struct {
+		name     string
+		filter   *MetadataFilter
+		expected bool
+	}{
+		{"Eq string", FilterEq("source", "wiki"), true},
+		{"Eq number of different type", FilterEq("year", float64(2021)), true},
+		{"Eq bool", FilterEq("draft", false), true},
+		{"Eq missing key", FilterEq("author", "john"), false},
+		{"Ne", FilterNe("source", "blog"), true},
+		{"Ne missing key", FilterNe("author", "john"), true},
+		{"Gt", FilterGt("year", 2020), true},
+		{"Gte", FilterGte("year", 2021), true},
+		{"Lt", FilterLt("score", 0.4), false},
+		{"Lte", FilterLte("score", 0.5), true},
+		{"Gt string", FilterGt("source", "blog"), true},
+		{"Gt incomparable", FilterGt("source", 1), false},
+		{"In", FilterIn("source", "blog", "wiki"), true},
+		{"In no match", FilterIn("year", 2019, 2020), false},
+		{"And", FilterAnd(FilterEq("source", "wiki"), FilterGte("year", 2021)), true},
+		{"And no match", FilterAnd(FilterEq("source", "wiki"), FilterGt("year", 2021)), false},
+		{"Or", FilterOr(FilterEq("source", "blog"), FilterEq("draft", false)), true},
+		{"Or no match", FilterOr(FilterEq("source", "blog"), FilterEq("draft", true)), false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.filter.Match(metadata))
+		})
+	}
+}
@@ -68,6 +68,8 @@ type GenerateOptions struct {
 	Stop              []string
 	Functions         []FunctionDefinition
 	ForceFunctionCall bool
+	// Stream enables streaming, even if it is not enabled in the options of the model.
+	Stream bool
 }
 
 // LLM is the interface for language models.
@@ -75,6 +77,10 @@ type LLM interface {
 	Model
 	// Generate generates text based on the provided prompt and options.
 	Generate(ctx context.Context, prompt string, optFns ...func(o *GenerateOptions)) (*ModelResult, error)
+	// Stream streams the text generated for the provided prompt. The channel is closed
+	// after the final result or error chunk. It must be drained or the context canceled.
+	// Models without native streaming send only the final chunk.
+	Stream(ctx context.Context, prompt string, optFns ...func(o *GenerateOptions)) <-chan StreamChunk
 }
 
 // ChatModel is the interface for chat models.
@@ -82,6 +88,10 @@ type ChatModel interface {
 	Model
 	// Generate generates text based on the provided chat messages and options.
 	Generate(ctx context.Context, messages ChatMessages, optFns ...func(o *GenerateOptions)) (*ModelResult, error)
+	// Stream streams the text generated for the provided chat messages. The channel is closed
+	// after the final result or error chunk. It must be drained or the context canceled.
+	// Models without native streaming send only the final chunk.
+	Stream(ctx context.Context, messages ChatMessages, optFns ...func(o *GenerateOptions)) <-chan StreamChunk
 }
 
 // Model is the interface for language models and chat models.
@@ -0,0 +1,50 @@
+package schema
+
+// StreamChunkType represents the type of a stream chunk.
+type StreamChunkType string
+
+const (
+	// StreamChunkTypeToken is the type of a chunk with a new token generated by a model.
+	StreamChunkTypeToken StreamChunkType = "token"
+	// StreamChunkTypeFunctionCall is the type of a chunk with a fragment of a function call generated by a model.
+	StreamChunkTypeFunctionCall StreamChunkType = "functionCall"
+	// StreamChunkTypeResult is the type of the final chunk with the result.
+	StreamChunkTypeResult StreamChunkType = "result"
+	// StreamChunkTypeError is the type of the final chunk with the error that terminated the stream.
+	StreamChunkTypeError StreamChunkType = "error"
+)
+
+// ToolCallDelta represents a fragment of a tool call streamed by a model.
+// Fragments with the same index belong to the same tool call.
+type ToolCallDelta struct {
+	// Index of the tool call within the generation.
+	Index int
+	// ID of the tool call. It is only set on the first fragment.
+	ID string
+	// Function holds the fragments of the function name and arguments.
+	Function FunctionCall
+}
+
+// StreamChunk represents a chunk of a stream returned by models and chains.
+type StreamChunk struct {
+	// Type of the chunk.
+	Type StreamChunkType
+	// Token holds the new token of a StreamChunkTypeToken chunk.
+	Token string
+	// ToolCallDelta holds the fragment of a StreamChunkTypeFunctionCall chunk.
+	ToolCallDelta *ToolCallDelta
+	// Result holds the model result of a StreamChunkTypeResult chunk of a model stream.
+	Result *ModelResult
+	// Outputs holds the chain outputs of a StreamChunkTypeResult chunk of a chain stream.
+	Outputs ChainValues
+	// Error holds the error of a StreamChunkTypeError chunk.
+	Error error
+}
+
+// StreamCallback is an optional interface for callbacks that consume the tokens of models.
+// Models generating with such a callback stream their output, even if streaming is not enabled in their options.
+type StreamCallback interface {
+	Callback
+	// RequiresStream returns true if the models should stream their output.
+	RequiresStream() bool
+}
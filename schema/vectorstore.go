@@ -0,0 +1,49 @@
+package schema
+
+import "context"
+
+// ScoredDocument represents a document with the relevance score of a similarity search.
+// Higher scores indicate more similar documents.
+type ScoredDocument struct {
+	Document
+	Score float32
+}
+
+// VectorStoreSearchOptions holds the options of a similarity search.
+type VectorStoreSearchOptions struct {
+	// TopK is the number of documents to return. If zero, the default of the vector store is used.
+	TopK int
+	// ScoreThreshold is the minimum score of the returned documents. If zero, no threshold is applied.
+	ScoreThreshold float32
+	// Filter restricts the search to documents with matching metadata.
+	Filter *MetadataFilter
+}
+
+// VectorStore is the interface for storing documents with their embeddings and searching them by similarity.
+type VectorStore interface {
+	// AddDocuments embeds and adds the documents to the vector store and returns their IDs.
+	// Documents without ID get a generated ID, documents with an existing ID are replaced.
+	AddDocuments(ctx context.Context, docs []Document) ([]string, error)
+	// Delete removes the documents with the given IDs from the vector store.
+	Delete(ctx context.Context, ids []string) error
+	// SimilaritySearch returns the documents most similar to the query.
+	SimilaritySearch(ctx context.Context, query string, optFns ...func(o *VectorStoreSearchOptions)) ([]Document, error)
+	// SimilaritySearchWithScore returns the documents most similar to the query together with their scores.
+	SimilaritySearchWithScore(ctx context.Context, query string, optFns ...func(o *VectorStoreSearchOptions)) ([]ScoredDocument, error)
+}
+
+// MaxMarginalRelevanceSearchOptions holds the options of a maximal marginal relevance search.
+type MaxMarginalRelevanceSearchOptions struct {
+	VectorStoreSearchOptions
+	// FetchK is the number of candidates fetched by similarity, from which TopK documents are selected.
+	FetchK int
+	// Lambda controls the trade-off between relevance and diversity of the selected documents,
+	// from 0 for maximum diversity to 1 for maximum relevance.
+	Lambda float32
+}
+
+// MaxMarginalRelevanceSearcher is an optional interface for vector stores supporting maximal marginal relevance search.
+type MaxMarginalRelevanceSearcher interface {
+	// MaxMarginalRelevanceSearch returns documents that are relevant to the query but diverse among each other.
+	MaxMarginalRelevanceSearch(ctx context.Context, query string, optFns ...func(o *MaxMarginalRelevanceSearchOptions)) ([]Document, error)
+}
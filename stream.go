@@ -0,0 +1,87 @@
+package golc
+
+import (
+	"context"
+
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/schema"
+)
+
+// Stream executes a chain like Call, but returns a channel with the tokens and tool call fragments
+// generated by the models of the chain, followed by a final chunk with the outputs or the error.
+// The models of the chain stream their output, even if streaming is not enabled in their options.
+// The channel is closed after the final chunk. It must be drained or the context canceled.
+func Stream(ctx context.Context, chain schema.Chain, inputs schema.ChainValues, optFns ...func(*CallOptions)) <-chan schema.StreamChunk {
+	ch := make(chan schema.StreamChunk)
+
+	go func() {
+		defer close(ch)
+
+		handler := &streamHandler{ch: ch}
+
+		callFns := append(append([]func(*CallOptions){}, optFns...), func(o *CallOptions) {
+			o.Callbacks = append(append([]schema.Callback{}, o.Callbacks...), handler)
+		})
+
+		outputs, err := Call(ctx, chain, inputs, callFns...)
+		if err != nil {
+			_ = handler.send(ctx, schema.StreamChunk{Type: schema.StreamChunkTypeError, Error: err})
+			return
+		}
+
+		_ = handler.send(ctx, schema.StreamChunk{Type: schema.StreamChunkTypeResult, Outputs: outputs})
+	}()
+
+	return ch
+}
+
+// Compile time check to ensure streamHandler satisfies the StreamCallback interface.
+var _ schema.StreamCallback = (*streamHandler)(nil)
+
+// streamHandler is a callback handler that forwards the tokens of the models to a stream.
+type streamHandler struct {
+	callback.NoopHandler
+	ch chan<- schema.StreamChunk
+}
+
+// AlwaysVerbose returns true, because the handler must receive the tokens regardless of the verbosity.
+func (h *streamHandler) AlwaysVerbose() bool {
+	return true
+}
+
+// RaiseError returns true, so that the chain stops if the context of the stream is done.
+func (h *streamHandler) RaiseError() bool {
+	return true
+}
+
+// RequiresStream returns true, so that the models stream their output.
+func (h *streamHandler) RequiresStream() bool {
+	return true
+}
+
+// OnModelNewToken forwards the token or tool call fragment to the stream.
+func (h *streamHandler) OnModelNewToken(ctx context.Context, input *schema.ModelNewTokenInput) error {
+	if input.ToolCallDelta != nil {
+		return h.send(ctx, schema.StreamChunk{Type: schema.StreamChunkTypeFunctionCall, ToolCallDelta: input.ToolCallDelta})
+	}
+
+	if input.Token == "" {
+		return nil
+	}
+
+	return h.send(ctx, schema.StreamChunk{Type: schema.StreamChunkTypeToken, Token: input.Token})
+}
+
+// send sends the chunk, unless the context is done.
+func (h *streamHandler) send(ctx context.Context, chunk schema.StreamChunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case h.ch <- chunk:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,104 @@
+package golc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hupe1980/golc/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	t.Run("Tokens", func(t *testing.T) {
+		chain := mockChain{
+			CallFunc: func(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+				opts := schema.CallOptions{}
+				for _, fn := range optFns {
+					fn(&opts)
+				}
+
+				callbacks := opts.CallbackManger.GetInheritableCallbacks()
+				assert.Len(t, callbacks, 1)
+
+				sc, ok := callbacks[0].(schema.StreamCallback)
+				assert.True(t, ok)
+				assert.True(t, sc.RequiresStream())
+
+				for _, token := range []string{"Hello", " ", "World"} {
+					if err := sc.OnModelNewToken(ctx, &schema.ModelNewTokenInput{
+						ModelNewTokenManagerInput: &schema.ModelNewTokenManagerInput{Token: token},
+					}); err != nil {
+						return nil, err
+					}
+				}
+
+				if err := sc.OnModelNewToken(ctx, &schema.ModelNewTokenInput{
+					ModelNewTokenManagerInput: &schema.ModelNewTokenManagerInput{
+						ToolCallDelta: &schema.ToolCallDelta{ID: "call_1", Function: schema.FunctionCall{Name: "foo"}},
+					},
+				}); err != nil {
+					return nil, err
+				}
+
+				return schema.ChainValues{"output": "Hello World"}, nil
+			},
+		}
+
+		chunks := []schema.StreamChunk{}
+		for chunk := range Stream(context.Background(), chain, schema.ChainValues{"input": "test"}) {
+			chunks = append(chunks, chunk)
+		}
+
+		assert.Equal(t, []schema.StreamChunk{
+			{Type: schema.StreamChunkTypeToken, Token: "Hello"},
+			{Type: schema.StreamChunkTypeToken, Token: " "},
+			{Type: schema.StreamChunkTypeToken, Token: "World"},
+			{Type: schema.StreamChunkTypeFunctionCall, ToolCallDelta: &schema.ToolCallDelta{ID: "call_1", Function: schema.FunctionCall{Name: "foo"}}},
+			{Type: schema.StreamChunkTypeResult, Outputs: schema.ChainValues{"output": "Hello World"}},
+		}, chunks)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		chain := mockChain{
+			CallFunc: func(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+				return nil, errors.New("chain error")
+			},
+		}
+
+		chunks := []schema.StreamChunk{}
+		for chunk := range Stream(context.Background(), chain, schema.ChainValues{"input": "test"}) {
+			chunks = append(chunks, chunk)
+		}
+
+		assert.Len(t, chunks, 1)
+		assert.Equal(t, schema.StreamChunkTypeError, chunks[0].Type)
+		assert.EqualError(t, chunks[0].Error, "chain error")
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		chain := mockChain{
+			CallFunc: func(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+				opts := schema.CallOptions{}
+				for _, fn := range optFns {
+					fn(&opts)
+				}
+
+				cancel()
+
+				err := opts.CallbackManger.GetInheritableCallbacks()[0].OnModelNewToken(ctx, &schema.ModelNewTokenInput{
+					ModelNewTokenManagerInput: &schema.ModelNewTokenManagerInput{Token: "Hello"},
+				})
+				assert.ErrorIs(t, err, context.Canceled)
+
+				return nil, err
+			},
+		}
+
+		for range Stream(ctx, chain, schema.ChainValues{"input": "test"}) {
+			// drain the stream
+		}
+	})
+}
@@ -3,7 +3,7 @@ package tokenizer
 import (
 	"context"
 
-	"cloud.google.com/go/ai/generativelanguage/apiv1/generativelanguagepb"
+	"cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
 	"github.com/googleapis/gax-go/v2"
 	"github.com/hupe1980/golc/schema"
 )
@@ -52,3 +52,16 @@ func (t *GoogleGenAI) GetNumTokensFromMessage(ctx context.Context, messages sche
 
 	return t.GetNumTokens(ctx, text)
 }
+
+// GetNumTokensFromContents returns the number of tokens in the provided contents.
+func (t *GoogleGenAI) GetNumTokensFromContents(ctx context.Context, contents []*generativelanguagepb.Content) (uint, error) {
+	res, err := t.client.CountTokens(ctx, &generativelanguagepb.CountTokensRequest{
+		Model:    t.model,
+		Contents: contents,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(res.TotalTokens), nil
+}
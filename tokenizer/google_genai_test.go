@@ -4,7 +4,7 @@ import (
 	"context"
 	"testing"
 
-	"cloud.google.com/go/ai/generativelanguage/apiv1/generativelanguagepb"
+	"cloud.google.com/go/ai/generativelanguage/apiv1beta/generativelanguagepb"
 	"github.com/googleapis/gax-go/v2"
 	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/require"
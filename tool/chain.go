@@ -0,0 +1,161 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/schema"
+)
+
+// Compile time check to ensure Chain satisfies the ToolWithRunOptions interface.
+var _ schema.ToolWithRunOptions = (*Chain[string])(nil)
+
+// ChainOptions contains options for configuring the Chain tool.
+type ChainOptions[T any] struct {
+	*schema.CallbackOptions
+	// Inputs maps the arguments of the tool to the inputs of the chain. By default, a string argument is passed
+	// as the single input of the chain and the json fields of a struct argument are passed as inputs.
+	Inputs func(args T) (schema.ChainValues, error)
+	// OutputKey is the output of the chain, which is returned by the tool. Default is the first output key of the chain.
+	OutputKey string
+}
+
+// Chain is a tool that calls a chain, e.g. an agent executor, with the typed arguments T.
+// The chain run is a child of the tool run, so that the callbacks trace the nested run.
+type Chain[T any] struct {
+	chain       schema.Chain
+	name        string
+	description string
+	opts        ChainOptions[T]
+}
+
+// NewChain creates a new Chain tool using the provided chain, name, and description, along with optional configuration options.
+func NewChain[T any](chain schema.Chain, name, description string, optFns ...func(o *ChainOptions[T])) *Chain[T] {
+	opts := ChainOptions[T]{
+		CallbackOptions: &schema.CallbackOptions{
+			Verbose: golc.Verbose,
+		},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.Inputs == nil {
+		opts.Inputs = func(args T) (schema.ChainValues, error) {
+			return defaultChainInputs(chain, args)
+		}
+	}
+
+	if opts.OutputKey == "" && len(chain.OutputKeys()) > 0 {
+		opts.OutputKey = chain.OutputKeys()[0]
+	}
+
+	return &Chain[T]{
+		chain:       chain,
+		name:        name,
+		description: description,
+		opts:        opts,
+	}
+}
+
+// Name returns the name of the tool.
+func (t *Chain[T]) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool.
+func (t *Chain[T]) Description() string {
+	return t.description
+}
+
+// ArgsType returns the type of the input argument expected by the tool.
+func (t *Chain[T]) ArgsType() reflect.Type {
+	return reflect.TypeOf(*new(T))
+}
+
+// Run executes the tool with the given input and returns the output.
+func (t *Chain[T]) Run(ctx context.Context, input any) (string, error) {
+	return t.RunWithOptions(ctx, input)
+}
+
+// RunWithOptions executes the chain with the given input and returns the output. The chain
+// inherits the callbacks of the tool run.
+func (t *Chain[T]) RunWithOptions(ctx context.Context, input any, optFns ...func(o *schema.ToolRunOptions)) (string, error) {
+	opts := schema.ToolRunOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	args, ok := input.(T)
+	if !ok {
+		return "", fmt.Errorf("illegal input type: %T", input)
+	}
+
+	inputs, err := t.opts.Inputs(args)
+	if err != nil {
+		return "", err
+	}
+
+	outputs, err := golc.Call(ctx, t.chain, inputs, golc.ChildCallOptions(opts.CallbackManger))
+	if err != nil {
+		return "", err
+	}
+
+	output, ok := outputs[t.opts.OutputKey]
+	if !ok {
+		return "", fmt.Errorf("chain did not return output %s", t.opts.OutputKey)
+	}
+
+	if s, ok := output.(string); ok {
+		return s, nil
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// Verbose returns the verbosity setting of the tool.
+func (t *Chain[T]) Verbose() bool {
+	return t.opts.Verbose
+}
+
+// Callbacks returns the registered callbacks of the tool.
+func (t *Chain[T]) Callbacks() []schema.Callback {
+	return t.opts.Callbacks
+}
+
+// defaultChainInputs passes a string argument as the single input of the chain and the
+// json fields of other arguments as inputs.
+func defaultChainInputs(chain schema.Chain, args any) (schema.ChainValues, error) {
+	if s, ok := args.(string); ok {
+		if len(chain.InputKeys()) != 1 {
+			return nil, fmt.Errorf("invalid arguments: number of input keys must be 1, got %d", len(chain.InputKeys()))
+		}
+
+		return schema.ChainValues{chain.InputKeys()[0]: s}, nil
+	}
+
+	b, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := schema.ChainValues{}
+	if err := json.Unmarshal(b, &inputs); err != nil {
+		return nil, err
+	}
+
+	return inputs, nil
+}
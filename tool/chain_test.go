@@ -0,0 +1,127 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc/callback"
+	"github.com/hupe1980/golc/chain"
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	type searchArgs struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+
+	t.Run("StructArgs", func(t *testing.T) {
+		t.Parallel()
+
+		search, err := chain.NewTransform([]string{"query", "limit"}, []string{"results"}, func(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+			return schema.ChainValues{"results": []string{fmt.Sprintf("%s:%v", inputs["query"], inputs["limit"])}}, nil
+		})
+		require.NoError(t, err)
+
+		tool := NewChain[searchArgs](search, "Search", "Searches the docs.")
+		assert.Equal(t, "Search", tool.Name())
+		assert.Equal(t, "Searches the docs.", tool.Description())
+		assert.Equal(t, reflect.TypeOf(searchArgs{}), tool.ArgsType())
+
+		output, err := Run(context.Background(), tool, schema.NewToolInputFromArguments(`{"query": "golc", "limit": 3}`))
+		require.NoError(t, err)
+		assert.Equal(t, `["golc:3"]`, output)
+	})
+
+	t.Run("StringArg", func(t *testing.T) {
+		t.Parallel()
+
+		echo, err := chain.NewTransform([]string{"input"}, []string{"output"}, func(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+			return schema.ChainValues{"output": "echo: " + inputs["input"].(string)}, nil
+		})
+		require.NoError(t, err)
+
+		output, err := Run(context.Background(), NewChain[string](echo, "Echo", "Echos the input."), schema.NewToolInputFromString("hello"))
+		require.NoError(t, err)
+		assert.Equal(t, "echo: hello", output)
+	})
+
+	t.Run("NestedRun", func(t *testing.T) {
+		t.Parallel()
+
+		handler := &chainToolCallbackHandler{}
+
+		child, err := chain.NewTransform([]string{"input"}, []string{"output"}, func(ctx context.Context, inputs schema.ChainValues, optFns ...func(o *schema.CallOptions)) (schema.ChainValues, error) {
+			opts := schema.CallOptions{}
+			for _, fn := range optFns {
+				fn(&opts)
+			}
+
+			assert.Equal(t, []schema.Callback{handler}, opts.CallbackManger.GetInheritableCallbacks())
+
+			return schema.ChainValues{"output": "done"}, nil
+		})
+		require.NoError(t, err)
+
+		output, err := Run(context.Background(), NewChain[string](child, "Child", "Child chain."), schema.NewToolInputFromString("task"), func(o *Options) {
+			o.Callbacks = []schema.Callback{handler}
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "done", output)
+		assert.Equal(t, []string{"ToolStart", "ChainStart", "ChainEnd", "ToolEnd"}, handler.events)
+	})
+
+	t.Run("IllegalInput", func(t *testing.T) {
+		t.Parallel()
+
+		tool := NewChain[searchArgs](&chain.Transform{}, "Search", "Searches the docs.")
+
+		_, err := tool.Run(context.Background(), "golc")
+		assert.ErrorContains(t, err, "illegal input type: string")
+	})
+}
+
+type chainToolCallbackHandler struct {
+	callback.NoopHandler
+	events []string
+	mu     sync.Mutex
+}
+
+func (h *chainToolCallbackHandler) AlwaysVerbose() bool {
+	return true
+}
+
+func (h *chainToolCallbackHandler) record(event string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.events = append(h.events, event)
+}
+
+func (h *chainToolCallbackHandler) OnToolStart(ctx context.Context, input *schema.ToolStartInput) error {
+	h.record("ToolStart")
+	return nil
+}
+
+func (h *chainToolCallbackHandler) OnToolEnd(ctx context.Context, input *schema.ToolEndInput) error {
+	h.record("ToolEnd")
+	return nil
+}
+
+func (h *chainToolCallbackHandler) OnChainStart(ctx context.Context, input *schema.ChainStartInput) error {
+	h.record("ChainStart")
+	return nil
+}
+
+func (h *chainToolCallbackHandler) OnChainEnd(ctx context.Context, input *schema.ChainEndInput) error {
+	h.record("ChainEnd")
+	return nil
+}
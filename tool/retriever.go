@@ -7,12 +7,13 @@ import (
 	"strings"
 
 	"github.com/hupe1980/golc"
+	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/retriever"
 	"github.com/hupe1980/golc/schema"
 )
 
-// Compile time check to ensure Retriever satisfies the Tool interface.
-var _ schema.Tool = (*Retriever)(nil)
+// Compile time check to ensure Retriever satisfies the ToolWithRunOptions interface.
+var _ schema.ToolWithRunOptions = (*Retriever)(nil)
 
 // RetrieverOptions contains options for configuring the Retriever tool.
 type RetrieverOptions struct {
@@ -66,12 +67,26 @@ func (t *Retriever) ArgsType() reflect.Type {
 
 // Run executes the tool with the given input and returns the output.
 func (t *Retriever) Run(ctx context.Context, input any) (string, error) {
+	return t.RunWithOptions(ctx, input)
+}
+
+// RunWithOptions executes the tool with the given input and returns the output. The retriever
+// run is a child of the tool run.
+func (t *Retriever) RunWithOptions(ctx context.Context, input any, optFns ...func(o *schema.ToolRunOptions)) (string, error) {
+	opts := schema.ToolRunOptions{
+		CallbackManger: &callback.NoopManager{},
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
 	query, ok := input.(string)
 	if !ok {
 		return "", errors.New("illegal input type")
 	}
 
-	docs, err := retriever.Run(ctx, t.retriever, query)
+	docs, err := retriever.Run(ctx, t.retriever, query, retriever.ChildOptions(opts.CallbackManger))
 	if err != nil {
 		return "", err
 	}
@@ -6,8 +6,10 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/hupe1980/golc/callback"
 	"github.com/hupe1980/golc/schema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRetriever(t *testing.T) {
@@ -41,6 +43,24 @@ func TestRetriever(t *testing.T) {
 		})
 	})
 
+	t.Run("NestedRun", func(t *testing.T) {
+		t.Parallel()
+
+		handler := &retrieverToolCallbackHandler{}
+		retrieverTool := NewRetriever(&mockRetriever{}, "Retriever", "A tool to retrieve documents")
+
+		_, err := Run(context.Background(), retrieverTool, schema.NewToolInputFromString("query"), func(o *Options) {
+			o.Callbacks = []schema.Callback{handler}
+			o.Tags = []string{"test"}
+		})
+		require.NoError(t, err)
+
+		require.NotNil(t, handler.toolStart)
+		require.NotNil(t, handler.retrieverStart)
+		assert.Equal(t, handler.toolStart.RunID, handler.retrieverStart.ParentRunID)
+		assert.Equal(t, []string{"test"}, handler.retrieverStart.Tags)
+	})
+
 	t.Run("Getter", func(t *testing.T) {
 		t.Parallel()
 
@@ -69,6 +89,26 @@ func TestRetriever(t *testing.T) {
 	})
 }
 
+type retrieverToolCallbackHandler struct {
+	callback.NoopHandler
+	toolStart      *schema.ToolStartInput
+	retrieverStart *schema.RetrieverStartInput
+}
+
+func (h *retrieverToolCallbackHandler) AlwaysVerbose() bool {
+	return true
+}
+
+func (h *retrieverToolCallbackHandler) OnToolStart(ctx context.Context, input *schema.ToolStartInput) error {
+	h.toolStart = input
+	return nil
+}
+
+func (h *retrieverToolCallbackHandler) OnRetrieverStart(ctx context.Context, input *schema.RetrieverStartInput) error {
+	h.retrieverStart = input
+	return nil
+}
+
 // mockRetriever is a mock implementation of the schema.Retriever interface.
 type mockRetriever struct {
 	docsResp  []schema.Document
@@ -13,6 +13,21 @@ import (
 type Options struct {
 	Callbacks   []schema.Callback
 	ParentRunID string
+	// Tags are passed to the callbacks of the tool run.
+	Tags []string
+	// Metadata is passed to the callbacks of the tool run.
+	Metadata map[string]any
+}
+
+// ChildOptions returns an option that runs the tool as a child of the run managed by cm.
+// The child run inherits the callbacks, tags and metadata of its parent.
+func ChildOptions(cm schema.CallbackManagerForParentRun) func(o *Options) {
+	return func(o *Options) {
+		o.Callbacks = cm.GetInheritableCallbacks()
+		o.ParentRunID = cm.RunID()
+		o.Tags = cm.GetInheritableTags()
+		o.Metadata = cm.GetInheritableMetadata()
+	}
 }
 
 func Run(ctx context.Context, t schema.Tool, input *schema.ToolInput, optFns ...func(o *Options)) (string, error) {
@@ -22,7 +37,11 @@ func Run(ctx context.Context, t schema.Tool, input *schema.ToolInput, optFns ...
 		fn(&opts)
 	}
 
-	cm := callback.NewManager(opts.Callbacks, t.Callbacks(), t.Verbose())
+	cm := callback.NewManager(opts.Callbacks, t.Callbacks(), t.Verbose(), func(mo *callback.ManagerOptions) {
+		mo.ParentRunID = opts.ParentRunID
+		mo.Tags = opts.Tags
+		mo.Metadata = opts.Metadata
+	})
 
 	rm, err := cm.OnToolStart(ctx, &schema.ToolStartManagerInput{
 		ToolName: t.Name(),
@@ -47,7 +66,16 @@ func Run(ctx context.Context, t schema.Tool, input *schema.ToolInput, optFns ...
 		inputValue, _ = input.GetString()
 	}
 
-	output, err := t.Run(ctx, inputValue)
+	var output string
+
+	if rt, ok := t.(schema.ToolWithRunOptions); ok {
+		output, err = rt.RunWithOptions(ctx, inputValue, func(o *schema.ToolRunOptions) {
+			o.CallbackManger = rm
+		})
+	} else {
+		output, err = t.Run(ctx, inputValue)
+	}
+
 	if err != nil {
 		if cbErr := rm.OnToolError(ctx, &schema.ToolErrorManagerInput{
 			Error: err,
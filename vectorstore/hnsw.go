@@ -0,0 +1,587 @@
+package vectorstore
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultHNSWM is the default maximum number of connections per node and layer of a HNSW index.
+	DefaultHNSWM = 16
+	// DefaultHNSWEfConstruction is the default size of the candidate list used while inserting into a HNSW index.
+	DefaultHNSWEfConstruction = 200
+	// DefaultHNSWEfSearch is the default size of the candidate list used while searching a HNSW index.
+	DefaultHNSWEfSearch = 50
+)
+
+// HNSWOptions represents options for a hierarchical navigable small world (HNSW) index.
+// Zero values are replaced by the defaults.
+type HNSWOptions struct {
+	// M is the maximum number of connections per node and layer. Layer 0 allows 2*M connections.
+	M int
+	// EfConstruction is the size of the dynamic candidate list used while inserting.
+	// Higher values improve the quality of the graph at the cost of slower inserts.
+	EfConstruction int
+	// EfSearch is the size of the dynamic candidate list used while searching.
+	// Higher values improve the recall at the cost of slower searches. Values below k are raised to k.
+	EfSearch int
+}
+
+// withDefaults returns a copy of the options with zero values replaced by the defaults.
+func (o HNSWOptions) withDefaults() HNSWOptions {
+	if o.M <= 1 {
+		o.M = DefaultHNSWM
+	}
+
+	if o.EfConstruction <= 0 {
+		o.EfConstruction = DefaultHNSWEfConstruction
+	}
+
+	if o.EfSearch <= 0 {
+		o.EfSearch = DefaultHNSWEfSearch
+	}
+
+	return o
+}
+
+// hnswNode represents a node of the HNSW graph.
+type hnswNode struct {
+	id        string
+	vector    []float32
+	neighbors [][]int // neighbors per layer, the level of the node is len(neighbors)-1
+	deleted   bool
+}
+
+// hnswCandidate represents a node together with its distance to a query vector.
+type hnswCandidate struct {
+	node     int
+	distance float32
+}
+
+// hnswResult represents an item found by a HNSW search.
+type hnswResult struct {
+	id       string
+	distance float32
+}
+
+// hnswHeap is a binary heap of candidates with the nearest candidate on top,
+// or the farthest candidate if farthestFirst is set.
+type hnswHeap struct {
+	items         []hnswCandidate
+	farthestFirst bool
+}
+
+// Len returns the number of candidates in the heap.
+func (h *hnswHeap) Len() int { return len(h.items) }
+
+// Top returns the candidate on top of the heap.
+func (h *hnswHeap) Top() hnswCandidate { return h.items[0] }
+
+// Push adds a candidate to the heap.
+func (h *hnswHeap) Push(c hnswCandidate) {
+	h.items = append(h.items, c)
+
+	for i := len(h.items) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if !h.less(i, parent) {
+			break
+		}
+
+		h.items[i], h.items[parent] = h.items[parent], h.items[i]
+		i = parent
+	}
+}
+
+// Pop removes and returns the candidate on top of the heap.
+func (h *hnswHeap) Pop() hnswCandidate {
+	top := h.items[0]
+	n := len(h.items) - 1
+	h.items[0] = h.items[n]
+	h.items = h.items[:n]
+
+	for i := 0; ; {
+		first, left, right := i, 2*i+1, 2*i+2
+		if left < n && h.less(left, first) {
+			first = left
+		}
+
+		if right < n && h.less(right, first) {
+			first = right
+		}
+
+		if first == i {
+			break
+		}
+
+		h.items[i], h.items[first] = h.items[first], h.items[i]
+		i = first
+	}
+
+	return top
+}
+
+// less reports whether the candidate with index i should be closer to the top than the candidate with index j.
+func (h *hnswHeap) less(i, j int) bool {
+	if h.farthestFirst {
+		return h.items[i].distance > h.items[j].distance
+	}
+
+	return h.items[i].distance < h.items[j].distance
+}
+
+// hnswVisited is a bitset of the visited nodes.
+type hnswVisited []uint64
+
+// visit marks the node as visited and reports whether it has been visited before.
+func (v hnswVisited) visit(node int) bool {
+	word, bit := node/64, uint64(1)<<(node%64)
+	if v[word]&bit != 0 {
+		return true
+	}
+
+	v[word] |= bit
+
+	return false
+}
+
+// hnswIndex is a hierarchical navigable small world graph for approximate nearest neighbour search.
+// Deleted nodes are kept as tombstones to preserve the connectivity of the graph until
+// they outnumber the live nodes and the graph is rebuilt.
+// The index is not safe for concurrent use, the caller is responsible for synchronization.
+type hnswIndex struct {
+	opts       HNSWOptions
+	distance   DistanceFunc
+	levelMult  float64
+	rng        *rand.Rand
+	nodes      []*hnswNode
+	nodeIDs    map[string]int
+	entryPoint int
+	maxLevel   int
+}
+
+// newHNSWIndex creates a new empty HNSW index.
+func newHNSWIndex(distance DistanceFunc, opts HNSWOptions) *hnswIndex {
+	opts = opts.withDefaults()
+
+	return &hnswIndex{
+		opts:       opts,
+		distance:   distance,
+		levelMult:  1 / math.Log(float64(opts.M)),
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())), // nolint gosec
+		nodeIDs:    make(map[string]int),
+		entryPoint: -1,
+	}
+}
+
+// Len returns the number of live nodes in the index.
+func (h *hnswIndex) Len() int {
+	return len(h.nodeIDs)
+}
+
+// insert adds a vector with the given ID to the index. An existing node with the same ID is replaced.
+func (h *hnswIndex) insert(id string, vector []float32) error {
+	if h.entryPoint >= 0 && len(vector) != len(h.nodes[h.entryPoint].vector) {
+		return errors.New("vector sizes do not match")
+	}
+
+	if err := h.delete(id); err != nil {
+		return err
+	}
+
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+
+	n := len(h.nodes)
+	node := &hnswNode{
+		id:        id,
+		vector:    vector,
+		neighbors: make([][]int, level+1),
+	}
+
+	h.nodes = append(h.nodes, node)
+	h.nodeIDs[id] = n
+
+	if h.entryPoint < 0 {
+		h.entryPoint, h.maxLevel = n, level
+		return nil
+	}
+
+	entryPoints, err := h.descend(vector, level)
+	if err != nil {
+		return err
+	}
+
+	for layer := min(level, h.maxLevel); layer >= 0; layer-- {
+		candidates, err := h.searchLayer(vector, entryPoints, h.opts.EfConstruction, layer, nil)
+		if err != nil {
+			return err
+		}
+
+		neighbors, err := h.selectNeighbors(candidates, h.opts.M)
+		if err != nil {
+			return err
+		}
+
+		node.neighbors[layer] = make([]int, len(neighbors))
+
+		for i, neighbor := range neighbors {
+			node.neighbors[layer][i] = neighbor.node
+
+			if err := h.connect(neighbor.node, n, layer); err != nil {
+				return err
+			}
+		}
+
+		entryPoints = candidates
+	}
+
+	if level > h.maxLevel {
+		h.entryPoint, h.maxLevel = n, level
+	}
+
+	return nil
+}
+
+// delete removes the node with the given ID from the index.
+func (h *hnswIndex) delete(id string) error {
+	n, ok := h.nodeIDs[id]
+	if !ok {
+		return nil
+	}
+
+	h.nodes[n].deleted = true
+	delete(h.nodeIDs, id)
+
+	if len(h.nodeIDs) == 0 {
+		h.reset()
+		return nil
+	}
+
+	if len(h.nodes)-len(h.nodeIDs) > len(h.nodeIDs) {
+		return h.compact()
+	}
+
+	return nil
+}
+
+// search returns the k nearest live nodes to the query vector, which are accepted by the accept func,
+// sorted by distance. A nil accept func accepts all nodes.
+func (h *hnswIndex) search(query []float32, k, ef int, accept func(id string) bool) ([]hnswResult, error) {
+	if h.entryPoint < 0 || k <= 0 {
+		return []hnswResult{}, nil
+	}
+
+	entryPoints, err := h.descend(query, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := h.searchLayer(query, entryPoints, max(ef, k), 0, func(node *hnswNode) bool {
+		return !node.deleted && (accept == nil || accept(node.id))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	results := make([]hnswResult, len(candidates))
+
+	for i, c := range candidates {
+		results[i] = hnswResult{
+			id:       h.nodes[c.node].id,
+			distance: c.distance,
+		}
+	}
+
+	return results, nil
+}
+
+// descend greedily traverses the layers above the target layer and returns the entry points for the target layer.
+func (h *hnswIndex) descend(query []float32, target int) ([]hnswCandidate, error) {
+	distance, err := h.distance(query, h.nodes[h.entryPoint].vector)
+	if err != nil {
+		return nil, err
+	}
+
+	entryPoints := []hnswCandidate{{node: h.entryPoint, distance: distance}}
+
+	for layer := h.maxLevel; layer > target; layer-- {
+		entryPoints, err = h.searchLayer(query, entryPoints, 1, layer, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entryPoints, nil
+}
+
+// searchLayer returns up to ef nodes of the layer nearest to the query vector, which are accepted by the accept func,
+// sorted by distance. Rejected nodes are still traversed. A nil accept func accepts all nodes.
+func (h *hnswIndex) searchLayer(query []float32, entryPoints []hnswCandidate, ef, layer int, accept func(node *hnswNode) bool) ([]hnswCandidate, error) {
+	visited := make(hnswVisited, (len(h.nodes)+63)/64)
+	candidates := &hnswHeap{items: make([]hnswCandidate, 0, ef)}
+	results := &hnswHeap{items: make([]hnswCandidate, 0, ef+1), farthestFirst: true}
+
+	for _, ep := range entryPoints {
+		visited.visit(ep.node)
+		candidates.Push(ep)
+
+		if accept == nil || accept(h.nodes[ep.node]) {
+			results.Push(ep)
+		}
+	}
+
+	for results.Len() > ef {
+		results.Pop()
+	}
+
+	for candidates.Len() > 0 {
+		c := candidates.Pop()
+
+		if results.Len() >= ef && c.distance > results.Top().distance {
+			break
+		}
+
+		for _, neighbor := range h.nodes[c.node].neighbors[layer] {
+			if visited.visit(neighbor) {
+				continue
+			}
+
+			distance, err := h.distance(query, h.nodes[neighbor].vector)
+			if err != nil {
+				return nil, err
+			}
+
+			if results.Len() >= ef && distance >= results.Top().distance {
+				continue
+			}
+
+			candidates.Push(hnswCandidate{node: neighbor, distance: distance})
+
+			if accept == nil || accept(h.nodes[neighbor]) {
+				results.Push(hnswCandidate{node: neighbor, distance: distance})
+
+				if results.Len() > ef {
+					results.Pop()
+				}
+			}
+		}
+	}
+
+	sorted := results.items
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].distance < sorted[j].distance })
+
+	return sorted, nil
+}
+
+// selectNeighbors selects up to m of the candidates, which are sorted by distance, with the neighbor selection heuristic.
+// Candidates closer to an already selected neighbor than to the base node are skipped in favour of diversity,
+// but fill up the remaining connections if necessary.
+func (h *hnswIndex) selectNeighbors(candidates []hnswCandidate, m int) ([]hnswCandidate, error) {
+	if len(candidates) <= m {
+		return candidates, nil
+	}
+
+	selected := make([]hnswCandidate, 0, m)
+	pruned := make([]hnswCandidate, 0, len(candidates))
+
+	for _, c := range candidates {
+		if len(selected) >= m {
+			break
+		}
+
+		diverse := true
+
+		for _, s := range selected {
+			distance, err := h.distance(h.nodes[c.node].vector, h.nodes[s.node].vector)
+			if err != nil {
+				return nil, err
+			}
+
+			if distance < c.distance {
+				diverse = false
+				break
+			}
+		}
+
+		if diverse {
+			selected = append(selected, c)
+		} else {
+			pruned = append(pruned, c)
+		}
+	}
+
+	for _, c := range pruned {
+		if len(selected) >= m {
+			break
+		}
+
+		selected = append(selected, c)
+	}
+
+	return selected, nil
+}
+
+// connect adds a connection from node to neighbor on the given layer and shrinks the connections
+// of node if they exceed the maximum.
+func (h *hnswIndex) connect(node, neighbor, layer int) error {
+	n := h.nodes[node]
+	n.neighbors[layer] = append(n.neighbors[layer], neighbor)
+
+	maxConnections := h.opts.M
+	if layer == 0 {
+		maxConnections = 2 * h.opts.M
+	}
+
+	if len(n.neighbors[layer]) <= maxConnections {
+		return nil
+	}
+
+	candidates := make([]hnswCandidate, len(n.neighbors[layer]))
+
+	for i, c := range n.neighbors[layer] {
+		distance, err := h.distance(n.vector, h.nodes[c].vector)
+		if err != nil {
+			return err
+		}
+
+		candidates[i] = hnswCandidate{node: c, distance: distance}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+
+	selected, err := h.selectNeighbors(candidates, maxConnections)
+	if err != nil {
+		return err
+	}
+
+	n.neighbors[layer] = n.neighbors[layer][:0]
+	for _, c := range selected {
+		n.neighbors[layer] = append(n.neighbors[layer], c.node)
+	}
+
+	return nil
+}
+
+// compact rebuilds the graph from the live nodes to get rid of the tombstones.
+func (h *hnswIndex) compact() error {
+	live := make([]*hnswNode, 0, len(h.nodeIDs))
+
+	for _, node := range h.nodes {
+		if !node.deleted {
+			live = append(live, node)
+		}
+	}
+
+	h.reset()
+
+	for _, node := range live {
+		if err := h.insert(node.id, node.vector); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reset removes all nodes from the index.
+func (h *hnswIndex) reset() {
+	h.nodes = nil
+	h.nodeIDs = make(map[string]int)
+	h.entryPoint = -1
+	h.maxLevel = 0
+}
+
+// hnswSnapshot represents the serializable state of a HNSW index.
+type hnswSnapshot struct {
+	Nodes      []hnswNodeSnapshot
+	EntryPoint int
+	MaxLevel   int
+}
+
+// hnswNodeSnapshot represents the serializable state of a HNSW node.
+// Only deleted nodes carry their vector, the vectors of live nodes are stored with the items.
+type hnswNodeSnapshot struct {
+	ID        string
+	Neighbors [][]int
+	Deleted   bool
+	Vector    []float32
+}
+
+// snapshot returns the serializable state of the index.
+func (h *hnswIndex) snapshot() hnswSnapshot {
+	nodes := make([]hnswNodeSnapshot, len(h.nodes))
+
+	for i, node := range h.nodes {
+		nodes[i] = hnswNodeSnapshot{
+			ID:        node.id,
+			Neighbors: node.neighbors,
+			Deleted:   node.deleted,
+		}
+
+		if node.deleted {
+			nodes[i].Vector = node.vector
+		}
+	}
+
+	return hnswSnapshot{
+		Nodes:      nodes,
+		EntryPoint: h.entryPoint,
+		MaxLevel:   h.maxLevel,
+	}
+}
+
+// restore replaces the state of the index with the snapshot. The vectors of the live nodes are looked up by ID.
+func (h *hnswIndex) restore(s hnswSnapshot, vectors func(id string) ([]float32, bool)) error {
+	if s.EntryPoint >= len(s.Nodes) {
+		return errors.New("invalid hnsw entry point")
+	}
+
+	nodes := make([]*hnswNode, len(s.Nodes))
+	nodeIDs := make(map[string]int, len(s.Nodes))
+
+	for i, ns := range s.Nodes {
+		vector := ns.Vector
+
+		if !ns.Deleted {
+			v, ok := vectors(ns.ID)
+			if !ok {
+				return errors.New("missing vector of hnsw node")
+			}
+
+			vector = v
+			nodeIDs[ns.ID] = i
+		}
+
+		for layer, neighbors := range ns.Neighbors {
+			for _, neighbor := range neighbors {
+				if neighbor < 0 || neighbor >= len(s.Nodes) || layer >= len(s.Nodes[neighbor].Neighbors) {
+					return errors.New("invalid hnsw neighbor")
+				}
+			}
+		}
+
+		nodes[i] = &hnswNode{
+			id:        ns.ID,
+			vector:    vector,
+			neighbors: ns.Neighbors,
+			deleted:   ns.Deleted,
+		}
+	}
+
+	h.nodes = nodes
+	h.nodeIDs = nodeIDs
+	h.entryPoint = s.EntryPoint
+	h.maxLevel = s.MaxLevel
+
+	if len(nodes) == 0 {
+		h.entryPoint = -1
+	}
+
+	return nil
+}
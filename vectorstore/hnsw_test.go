@@ -0,0 +1,300 @@
+package vectorstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hupe1980/golc/metric"
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestHNSWIndex(t *testing.T) {
+	rng := rand.New(rand.NewSource(42)) // nolint gosec
+	vectors := randomVectors(rng, 1000, 16)
+
+	index := newHNSWIndex(metric.SquaredL2, HNSWOptions{})
+
+	for i, v := range vectors {
+		require.NoError(t, index.insert(fmt.Sprint(i), v))
+	}
+
+	assert.Equal(t, 1000, index.Len())
+
+	t.Run("Recall", func(t *testing.T) {
+		var hits, total int
+
+		for _, query := range randomVectors(rng, 20, 16) {
+			expected := bruteForceSearch(t, vectors, query, 10)
+
+			results, err := index.search(query, 10, DefaultHNSWEfSearch, nil)
+			require.NoError(t, err)
+			require.Len(t, results, 10)
+
+			for _, r := range results {
+				if expected[r.id] {
+					hits++
+				}
+			}
+
+			total += len(expected)
+		}
+
+		assert.GreaterOrEqual(t, float64(hits)/float64(total), 0.9)
+	})
+
+	t.Run("Accept", func(t *testing.T) {
+		results, err := index.search(vectors[0], 5, DefaultHNSWEfSearch, func(id string) bool {
+			return id != "0"
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 5)
+
+		for _, r := range results {
+			assert.NotEqual(t, "0", r.id)
+		}
+	})
+
+	t.Run("DimensionMismatch", func(t *testing.T) {
+		assert.Error(t, index.insert("invalid", []float32{1, 2}))
+	})
+
+	t.Run("DeleteAndCompact", func(t *testing.T) {
+		require.NoError(t, index.delete("0"))
+
+		results, err := index.search(vectors[0], 1, DefaultHNSWEfSearch, nil)
+		require.NoError(t, err)
+		assert.NotEqual(t, "0", results[0].id)
+		assert.Equal(t, 999, index.Len())
+
+		for i := 1; i < 600; i++ {
+			require.NoError(t, index.delete(fmt.Sprint(i)))
+		}
+
+		assert.Equal(t, 400, index.Len())
+		assert.LessOrEqual(t, len(index.nodes), 2*index.Len())
+
+		results, err = index.search(vectors[999], 1, DefaultHNSWEfSearch, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "999", results[0].id)
+
+		for i := 600; i < 1000; i++ {
+			require.NoError(t, index.delete(fmt.Sprint(i)))
+		}
+
+		assert.Equal(t, 0, index.Len())
+
+		results, err = index.search(vectors[999], 1, DefaultHNSWEfSearch, nil)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
+
+func TestInMemoryHNSW(t *testing.T) {
+	newVectorStore := func() *InMemory {
+		return NewInMemory(&mockEmbedder{}, func(o *InMemoryOptions) {
+			o.HNSW = &HNSWOptions{M: 8}
+		})
+	}
+
+	t.Run("Options", func(t *testing.T) {
+		vs := newVectorStore()
+		assert.Equal(t, &HNSWOptions{M: 8, EfConstruction: DefaultHNSWEfConstruction, EfSearch: DefaultHNSWEfSearch}, vs.opts.HNSW)
+	})
+
+	t.Run("SimilaritySearch", func(t *testing.T) {
+		vs := newVectorStore()
+
+		_, err := vs.AddDocuments(context.Background(), []schema.Document{
+			{ID: "a", PageContent: "document1", Metadata: map[string]any{"source": "wiki"}},
+			{ID: "b", PageContent: "document2", Metadata: map[string]any{"source": "blog"}},
+			{ID: "c", PageContent: "document3", Metadata: map[string]any{"source": "wiki"}},
+		})
+		require.NoError(t, err)
+
+		docs, err := vs.SimilaritySearchWithScore(context.Background(), "query", func(o *schema.VectorStoreSearchOptions) {
+			o.TopK = 2
+		})
+		require.NoError(t, err)
+		require.Len(t, docs, 2)
+		assert.Equal(t, "a", docs[0].ID)
+		assert.Equal(t, float32(1), docs[0].Score)
+		assert.Equal(t, "b", docs[1].ID)
+
+		docs, err = vs.SimilaritySearchWithScore(context.Background(), "query", func(o *schema.VectorStoreSearchOptions) {
+			o.TopK = 2
+			o.Filter = schema.FilterEq("source", "wiki")
+		})
+		require.NoError(t, err)
+		require.Len(t, docs, 2)
+		assert.Equal(t, "a", docs[0].ID)
+		assert.Equal(t, "c", docs[1].ID)
+
+		require.NoError(t, vs.Delete(context.Background(), []string{"a"}))
+
+		docs, err = vs.SimilaritySearchWithScore(context.Background(), "query")
+		require.NoError(t, err)
+		require.Len(t, docs, 2)
+		assert.Equal(t, "b", docs[0].ID)
+	})
+
+	t.Run("Upsert", func(t *testing.T) {
+		vs := newVectorStore()
+
+		require.NoError(t, vs.InsertItem(InMemoryItem{ID: "a", Content: "item1", Vector: []float32{1, 2, 3}}))
+		require.NoError(t, vs.InsertItem(InMemoryItem{ID: "a", Content: "item1 v2", Vector: []float32{9, 9, 9}}))
+		require.NoError(t, vs.InsertItem(InMemoryItem{ID: "b", Content: "item2", Vector: []float32{2, 3, 4}}))
+
+		assert.Len(t, vs.Data(), 2)
+		assert.Equal(t, 2, vs.index.Len())
+
+		docs, err := vs.SimilaritySearch(context.Background(), "query", func(o *schema.VectorStoreSearchOptions) {
+			o.TopK = 1
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "item2", docs[0].PageContent)
+	})
+
+	t.Run("InvalidItem", func(t *testing.T) {
+		vs := newVectorStore()
+
+		require.NoError(t, vs.InsertItem(InMemoryItem{ID: "a", Vector: []float32{1, 2, 3}}))
+		assert.Error(t, vs.InsertItem(InMemoryItem{ID: "b", Vector: []float32{1, 2}}))
+
+		vs.AddItem(InMemoryItem{ID: "c", Vector: []float32{1, 2}})
+
+		assert.Len(t, vs.Data(), 1)
+		assert.Equal(t, 1, vs.index.Len())
+	})
+
+	t.Run("SaveAndLoad", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(42)) // nolint gosec
+
+		vs := newVectorStore()
+
+		for i, v := range randomVectors(rng, 200, 8) {
+			require.NoError(t, vs.InsertItem(InMemoryItem{ID: fmt.Sprint(i), Vector: v}))
+		}
+
+		require.NoError(t, vs.Delete(context.Background(), []string{"0", "1"}))
+
+		var buf bytes.Buffer
+		require.NoError(t, vs.Save(&buf))
+
+		vsLoaded := newVectorStore()
+		require.NoError(t, vsLoaded.Load(&buf))
+
+		assert.Equal(t, vs.Data(), vsLoaded.Data())
+		assert.Equal(t, vs.index.snapshot(), vsLoaded.index.snapshot())
+
+		for _, query := range randomVectors(rng, 5, 8) {
+			expected, err := vs.searchCandidates(query, 5, nil)
+			require.NoError(t, err)
+
+			actual, err := vsLoaded.searchCandidates(query, 5, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, actual)
+		}
+	})
+
+	t.Run("LoadWithoutIndex", func(t *testing.T) {
+		vsOriginal := &InMemory{data: []InMemoryItem{
+			{ID: "1", Content: "item1", Vector: []float32{1, 2, 3}},
+			{Content: "item2", Vector: []float32{4, 5, 6}},
+		}}
+
+		var buf bytes.Buffer
+		require.NoError(t, vsOriginal.Save(&buf))
+
+		vsLoaded := newVectorStore()
+		require.NoError(t, vsLoaded.Load(&buf))
+
+		assert.Equal(t, 2, vsLoaded.index.Len())
+		assert.NotEmpty(t, vsLoaded.Data()[1].ID)
+
+		docs, err := vsLoaded.SimilaritySearch(context.Background(), "query", func(o *schema.VectorStoreSearchOptions) {
+			o.TopK = 1
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "item1", docs[0].PageContent)
+	})
+}
+
+func BenchmarkInMemorySearch(b *testing.B) {
+	const (
+		size       = 10000
+		dimensions = 128
+		k          = 10
+	)
+
+	rng := rand.New(rand.NewSource(42)) // nolint gosec
+	vectors := randomVectors(rng, size, dimensions)
+	queries := randomVectors(rng, 100, dimensions)
+
+	for _, bc := range []struct {
+		name string
+		hnsw *HNSWOptions
+	}{
+		{name: "BruteForce"},
+		{name: "HNSW", hnsw: &HNSWOptions{}},
+	} {
+		vs := NewInMemory(&mockEmbedder{}, func(o *InMemoryOptions) {
+			o.HNSW = bc.hnsw
+		})
+
+		for i, v := range vectors {
+			if err := vs.InsertItem(InMemoryItem{ID: fmt.Sprint(i), Vector: v}); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		b.Run(bc.name, func(b *testing.B) {
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := vs.searchCandidates(queries[i%len(queries)], k, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func randomVectors(rng *rand.Rand, n, dimensions int) [][]float32 {
+	vectors := make([][]float32, n)
+
+	for i := range vectors {
+		vectors[i] = make([]float32, dimensions)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.Float32()
+		}
+	}
+
+	return vectors
+}
+
+func bruteForceSearch(t *testing.T, vectors [][]float32, query []float32, k int) map[string]bool {
+	t.Helper()
+
+	index := &InMemory{opts: InMemoryOptions{DistanceFunc: metric.SquaredL2}}
+
+	for i, v := range vectors {
+		require.NoError(t, index.InsertItem(InMemoryItem{ID: fmt.Sprint(i), Vector: v}))
+	}
+
+	candidates, err := index.searchCandidates(query, k, nil)
+	require.NoError(t, err)
+
+	ids := make(map[string]bool, len(candidates))
+	for _, c := range candidates {
+		ids[c.Data.ID] = true
+	}
+
+	return ids
+}
@@ -4,8 +4,14 @@ import (
 	"container/heap"
 	"context"
 	"encoding/gob"
+	"errors"
 	"io"
+	"maps"
+	"sync"
 
+	"github.com/google/uuid"
+
+	"github.com/hupe1980/golc/internal/deepcopy"
 	"github.com/hupe1980/golc/internal/util"
 	"github.com/hupe1980/golc/metric"
 	"github.com/hupe1980/golc/schema"
@@ -14,13 +20,27 @@ import (
 // Compile time check to ensure InMemory satisfies the VectorStore interface.
 var _ schema.VectorStore = (*InMemory)(nil)
 
+// Compile time check to ensure InMemory satisfies the MaxMarginalRelevanceSearcher interface.
+var _ schema.MaxMarginalRelevanceSearcher = (*InMemory)(nil)
+
 // InMemoryItem represents an item stored in memory with its content, vector, and metadata.
 type InMemoryItem struct {
+	ID       string         `json:"id"`
 	Content  string         `json:"content"`
 	Vector   []float32      `json:"vector"`
 	Metadata map[string]any `json:"metadata"`
 }
 
+// toDocument converts the item to a document. The metadata is copied, so that changes to the
+// document don't affect the store.
+func (item InMemoryItem) toDocument() schema.Document {
+	return schema.Document{
+		ID:          item.ID,
+		PageContent: item.Content,
+		Metadata:    maps.Clone(item.Metadata),
+	}
+}
+
 // priorityQueueItem represents an item in the priority queue.
 type priorityQueueItem struct {
 	Data     InMemoryItem // Data associated with the item
@@ -72,17 +92,27 @@ func (pq *priorityQueue) Top() any {
 // DistanceFunc represents a function for calculating the distance between two vectors
 type DistanceFunc func(v1, v2 []float32) (float32, error)
 
+// RelevanceScoreFunc represents a function for converting a distance to a relevance score,
+// where higher scores indicate more similar vectors.
+type RelevanceScoreFunc func(distance float32) float32
+
 // InMemoryOptions represents options for the in-memory vector store.
 type InMemoryOptions struct {
-	TopK         int
-	DistanceFunc DistanceFunc
+	TopK               int
+	DistanceFunc       DistanceFunc
+	RelevanceScoreFunc RelevanceScoreFunc
+	// HNSW enables an approximate nearest neighbour index. If nil, searches compare the query with every item.
+	HNSW *HNSWOptions
 }
 
 // InMemory represents an in-memory vector store.
 type InMemory struct {
-	embedder schema.Embedder
-	data     []InMemoryItem
-	opts     InMemoryOptions
+	embedder  schema.Embedder
+	data      []InMemoryItem
+	positions map[string]int
+	index     *hnswIndex
+	opts      InMemoryOptions
+	mu        sync.RWMutex
 }
 
 // NewInMemory creates a new instance of the in-memory vector store.
@@ -90,21 +120,34 @@ func NewInMemory(embedder schema.Embedder, optFns ...func(*InMemoryOptions)) *In
 	opts := InMemoryOptions{
 		TopK:         3,
 		DistanceFunc: metric.SquaredL2,
+		RelevanceScoreFunc: func(distance float32) float32 {
+			return 1 / (1 + distance)
+		},
 	}
 
 	for _, fn := range optFns {
 		fn(&opts)
 	}
 
-	return &InMemory{
-		data:     make([]InMemoryItem, 0),
-		embedder: embedder,
-		opts:     opts,
+	vs := &InMemory{
+		data:      make([]InMemoryItem, 0),
+		positions: make(map[string]int),
+		embedder:  embedder,
+		opts:      opts,
+	}
+
+	if opts.HNSW != nil {
+		hnswOpts := opts.HNSW.withDefaults()
+		vs.opts.HNSW = &hnswOpts
+		vs.index = newHNSWIndex(opts.DistanceFunc, hnswOpts)
 	}
+
+	return vs
 }
 
 // AddDocuments adds a batch of documents to the InMemory vector store.
-func (vs *InMemory) AddDocuments(ctx context.Context, docs []schema.Document) error {
+// Documents with the ID of an existing item replace this item.
+func (vs *InMemory) AddDocuments(ctx context.Context, docs []schema.Document) ([]string, error) {
 	texts := make([]string, len(docs))
 	for i, doc := range docs {
 		texts[i] = doc.PageContent
@@ -112,57 +155,237 @@ func (vs *InMemory) AddDocuments(ctx context.Context, docs []schema.Document) er
 
 	vectors, err := vs.embedder.BatchEmbedText(ctx, texts)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	ids := make([]string, len(docs))
+
 	for i, doc := range docs {
-		vs.data = append(vs.data, InMemoryItem{
+		ids[i] = doc.ID
+		if ids[i] == "" {
+			ids[i] = uuid.New().String()
+		}
+
+		if err := vs.InsertItem(InMemoryItem{
+			ID:       ids[i],
 			Content:  doc.PageContent,
 			Vector:   vectors[i],
 			Metadata: doc.Metadata,
-		})
+		}); err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	return ids, nil
 }
 
 // AddItem adds a single item to the InMemory vector store.
+// An item with the ID of an existing item replaces this item.
+// Items without an ID are assigned a generated one. Items, which are
+// rejected by the HNSW index, e.g. because of a vector of a different
+// size, are not added. Use InsertItem to get the error.
 func (vs *InMemory) AddItem(item InMemoryItem) {
+	_ = vs.InsertItem(item)
+}
+
+// InsertItem adds a single item to the InMemory vector store like AddItem,
+// but returns the error, if the HNSW index rejects the item.
+func (vs *InMemory) InsertItem(item InMemoryItem) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	if item.ID == "" {
+		item.ID = uuid.New().String()
+	}
+
+	if vs.index != nil {
+		if err := vs.index.insert(item.ID, item.Vector); err != nil {
+			return err
+		}
+	}
+
+	if vs.positions == nil {
+		vs.updatePositions()
+	}
+
+	if i, ok := vs.positions[item.ID]; ok {
+		vs.data[i] = item
+		return nil
+	}
+
+	vs.positions[item.ID] = len(vs.data)
 	vs.data = append(vs.data, item)
+
+	return nil
 }
 
-// Data returns the underlying data stored in the InMemory vector store.
+// Delete removes the items with the given IDs from the InMemory vector store.
+func (vs *InMemory) Delete(ctx context.Context, ids []string) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	remove := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		remove[id] = struct{}{}
+	}
+
+	data := vs.data[:0]
+
+	for _, item := range vs.data {
+		if _, ok := remove[item.ID]; !ok {
+			data = append(data, item)
+		}
+	}
+
+	vs.data = data
+	vs.updatePositions()
+
+	if vs.index != nil {
+		for id := range remove {
+			if err := vs.index.delete(id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// updatePositions rebuilds the mapping of item IDs to their positions in the data.
+func (vs *InMemory) updatePositions() {
+	vs.positions = make(map[string]int, len(vs.data))
+
+	for i, item := range vs.data {
+		if item.ID != "" {
+			vs.positions[item.ID] = i
+		}
+	}
+}
+
+// Data returns a deep copy of the data stored in the InMemory vector store.
+// Changes to the returned items don't affect the store.
 func (vs *InMemory) Data() []InMemoryItem {
-	return vs.data
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	return deepcopy.Copy(vs.data).([]InMemoryItem)
 }
 
 // SimilaritySearch performs a similarity search with the given query in the InMemory vector store.
-func (vs *InMemory) SimilaritySearch(ctx context.Context, query string) ([]schema.Document, error) {
+func (vs *InMemory) SimilaritySearch(ctx context.Context, query string, optFns ...func(o *schema.VectorStoreSearchOptions)) ([]schema.Document, error) {
+	scoredDocs, err := vs.SimilaritySearchWithScore(ctx, query, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(scoredDocs, func(sd schema.ScoredDocument, _ int) schema.Document {
+		return sd.Document
+	}), nil
+}
+
+// SimilaritySearchWithScore performs a similarity search with the given query in the InMemory vector store
+// and returns the documents with their relevance scores.
+func (vs *InMemory) SimilaritySearchWithScore(ctx context.Context, query string, optFns ...func(o *schema.VectorStoreSearchOptions)) ([]schema.ScoredDocument, error) {
+	opts := schema.VectorStoreSearchOptions{
+		TopK: vs.opts.TopK,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.TopK <= 0 {
+		opts.TopK = vs.opts.TopK
+	}
+
 	queryVector, err := vs.embedder.EmbedText(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
-	topCandidates := &priorityQueue{}
-	heap.Init(topCandidates)
+	candidates, err := vs.searchCandidates(queryVector, opts.TopK, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make([]schema.ScoredDocument, len(candidates))
 
-	type searchResult struct {
-		Item       InMemoryItem
-		Similarity float32
+	for i, c := range candidates {
+		documents[i] = schema.ScoredDocument{
+			Document: c.Data.toDocument(),
+			Score:    vs.opts.RelevanceScoreFunc(c.Distance),
+		}
 	}
 
-	results := make([]searchResult, len(vs.data))
+	if opts.ScoreThreshold != 0 {
+		documents = util.Filter(documents, func(sd schema.ScoredDocument, _ int) bool {
+			return sd.Score >= opts.ScoreThreshold
+		})
+	}
+
+	return documents, nil
+}
+
+// MaxMarginalRelevanceSearch performs a maximal marginal relevance search with the given query in the InMemory vector store.
+func (vs *InMemory) MaxMarginalRelevanceSearch(ctx context.Context, query string, optFns ...func(o *schema.MaxMarginalRelevanceSearchOptions)) ([]schema.Document, error) {
+	opts := newMaxMarginalRelevanceSearchOptions(vs.opts.TopK, optFns...)
+
+	queryVector, err := vs.embedder.EmbedText(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := vs.searchCandidates(queryVector, opts.FetchK, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ScoreThreshold != 0 {
+		candidates = util.Filter(candidates, func(c *priorityQueueItem, _ int) bool {
+			return vs.opts.RelevanceScoreFunc(c.Distance) >= opts.ScoreThreshold
+		})
+	}
+
+	vectors := util.Map(candidates, func(c *priorityQueueItem, _ int) []float32 {
+		return c.Data.Vector
+	})
+
+	selected, err := maximalMarginalRelevance(queryVector, vectors, opts.TopK, opts.Lambda)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(selected, func(i int, _ int) schema.Document {
+		return candidates[i].Data.toDocument()
+	}), nil
+}
+
+// searchCandidates returns the k items nearest to the query vector that match the filter, sorted by distance.
+func (vs *InMemory) searchCandidates(queryVector []float32, k int, filter *schema.MetadataFilter) ([]*priorityQueueItem, error) {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	if vs.index != nil {
+		return vs.searchIndex(queryVector, k, filter)
+	}
+
+	topCandidates := &priorityQueue{}
+	heap.Init(topCandidates)
 
 	for _, item := range vs.data {
-		similarity, err := vs.opts.DistanceFunc(queryVector, item.Vector)
+		if filter != nil && !filter.Match(item.Metadata) {
+			continue
+		}
+
+		distance, err := vs.opts.DistanceFunc(queryVector, item.Vector)
 		if err != nil {
 			return nil, err
 		}
 
-		if topCandidates.Len() < vs.opts.TopK {
+		if topCandidates.Len() < k {
 			heap.Push(topCandidates, &priorityQueueItem{
 				Data:     item,
-				Distance: similarity,
+				Distance: distance,
 			})
 
 			continue
@@ -170,33 +393,74 @@ func (vs *InMemory) SimilaritySearch(ctx context.Context, query string) ([]schem
 
 		largestDist, _ := topCandidates.Top().(*priorityQueueItem)
 
-		if similarity < largestDist.Distance {
+		if distance < largestDist.Distance {
 			_ = heap.Pop(topCandidates)
 
 			heap.Push(topCandidates, &priorityQueueItem{
 				Data:     item,
-				Distance: similarity,
+				Distance: distance,
 			})
 		}
 	}
 
-	docLen := util.Min(len(results), vs.opts.TopK)
-
-	// Extract documents from sorted results
-	documents := make([]schema.Document, docLen)
+	// Extract candidates from sorted results
+	candidates := make([]*priorityQueueItem, topCandidates.Len())
 
 	for i := topCandidates.Len() - 1; i >= 0; i-- {
-		item, _ := heap.Pop(topCandidates).(*priorityQueueItem)
-		documents[i] = schema.Document{
-			PageContent: item.Data.Content,
-			Metadata:    item.Data.Metadata,
+		candidates[i], _ = heap.Pop(topCandidates).(*priorityQueueItem)
+	}
+
+	return candidates, nil
+}
+
+// searchIndex returns the k items nearest to the query vector that match the filter
+// using the HNSW index, sorted by distance.
+func (vs *InMemory) searchIndex(queryVector []float32, k int, filter *schema.MetadataFilter) ([]*priorityQueueItem, error) {
+	var accept func(id string) bool
+	if filter != nil {
+		accept = func(id string) bool {
+			return filter.Match(vs.data[vs.positions[id]].Metadata)
 		}
 	}
 
-	return documents, nil
+	results, err := vs.index.search(queryVector, k, vs.opts.HNSW.EfSearch, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(results, func(r hnswResult, _ int) *priorityQueueItem {
+		return &priorityQueueItem{
+			Data:     vs.data[vs.positions[r.id]],
+			Distance: r.distance,
+		}
+	}), nil
+}
+
+// rebuildIndex inserts all items into a new HNSW index. Items without an ID are assigned a generated one.
+func (vs *InMemory) rebuildIndex() error {
+	vs.index = newHNSWIndex(vs.opts.DistanceFunc, *vs.opts.HNSW)
+
+	for i := range vs.data {
+		if vs.data[i].ID == "" {
+			vs.data[i].ID = uuid.New().String()
+		}
+
+		if err := vs.index.insert(vs.data[i].ID, vs.data[i].Vector); err != nil {
+			return err
+		}
+	}
+
+	vs.updatePositions()
+
+	return nil
 }
 
+// Load loads the data from an io.Reader.
+// If the HNSW index is enabled, the persisted graph is restored or, if missing, rebuilt from the data.
 func (vs *InMemory) Load(r io.Reader) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
 	decoder := gob.NewDecoder(r)
 
 	// Decode the data
@@ -204,11 +468,42 @@ func (vs *InMemory) Load(r io.Reader) error {
 		return err
 	}
 
+	vs.updatePositions()
+
+	if vs.index == nil {
+		return nil
+	}
+
+	// Decode the index
+	var snapshot hnswSnapshot
+	if err := decoder.Decode(&snapshot); err != nil {
+		if errors.Is(err, io.EOF) {
+			return vs.rebuildIndex()
+		}
+
+		return err
+	}
+
+	if err := vs.index.restore(snapshot, func(id string) ([]float32, bool) {
+		i, ok := vs.positions[id]
+		if !ok {
+			return nil, false
+		}
+
+		return vs.data[i].Vector, true
+	}); err != nil || vs.index.Len() != len(vs.data) {
+		return vs.rebuildIndex()
+	}
+
 	return nil
 }
 
 // Save saves the data to an io.Writer.
+// If the HNSW index is enabled, the graph is saved after the data.
 func (vs *InMemory) Save(w io.Writer) error {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
 	encoder := gob.NewEncoder(w)
 
 	// Encode the data
@@ -216,5 +511,10 @@ func (vs *InMemory) Save(w io.Writer) error {
 		return err
 	}
 
-	return nil
+	if vs.index == nil {
+		return nil
+	}
+
+	// Encode the index
+	return encoder.Encode(vs.index.snapshot())
 }
@@ -26,10 +26,11 @@ func TestInMemory(t *testing.T) {
 		}
 
 		// When
-		err := vs.AddDocuments(context.Background(), documents)
+		ids, err := vs.AddDocuments(context.Background(), documents)
 
 		// Then
 		assert.NoError(t, err)
+		assert.Len(t, ids, 3)
 		assert.Len(t, vs.Data(), 3)
 	})
 
@@ -55,9 +56,94 @@ func TestInMemory(t *testing.T) {
 		}
 	})
 
+	t.Run("SimilaritySearchWithScore", func(t *testing.T) {
+		docs, err := vs.SimilaritySearchWithScore(context.Background(), "query", func(o *schema.VectorStoreSearchOptions) {
+			o.TopK = 2
+		})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 2)
+		assert.Equal(t, "document1", docs[0].PageContent)
+		assert.Equal(t, float32(1), docs[0].Score)
+		assert.Equal(t, float32(0.25), docs[1].Score)
+
+		docs, err = vs.SimilaritySearchWithScore(context.Background(), "query", func(o *schema.VectorStoreSearchOptions) {
+			o.ScoreThreshold = 0.5
+		})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+	})
+
+	t.Run("UpsertAndDelete", func(t *testing.T) {
+		vs := NewInMemory(embedder)
+
+		ids, err := vs.AddDocuments(context.Background(), []schema.Document{
+			{ID: "a", PageContent: "document1", Metadata: map[string]any{"source": "wiki", "year": 2020}},
+			{ID: "b", PageContent: "document2", Metadata: map[string]any{"source": "blog", "year": 2021}},
+			{PageContent: "document3", Metadata: map[string]any{"source": "wiki", "year": 2022}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "a", ids[0])
+		assert.Equal(t, "b", ids[1])
+		assert.NotEmpty(t, ids[2])
+
+		// Replace document a
+		_, err = vs.AddDocuments(context.Background(), []schema.Document{
+			{ID: "a", PageContent: "document1 v2", Metadata: map[string]any{"source": "wiki", "year": 2023}},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, vs.Data(), 3)
+
+		docs, err := vs.SimilaritySearch(context.Background(), "query", func(o *schema.VectorStoreSearchOptions) {
+			o.Filter = schema.FilterAnd(schema.FilterEq("source", "wiki"), schema.FilterGt("year", 2022))
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []schema.Document{
+			{ID: "a", PageContent: "document1 v2", Metadata: map[string]any{"source": "wiki", "year": 2023}},
+		}, docs)
+
+		err = vs.Delete(context.Background(), []string{"a", ids[2]})
+		assert.NoError(t, err)
+		assert.Len(t, vs.Data(), 1)
+		assert.Equal(t, "b", vs.Data()[0].ID)
+
+		// Data returns a copy, which doesn't change the store.
+		data := vs.Data()
+		data[0].ID = "changed"
+		data[0].Vector[0] = 42
+		data[0].Metadata["source"] = "changed"
+
+		assert.Equal(t, "b", vs.Data()[0].ID)
+		assert.NotEqual(t, float32(42), vs.Data()[0].Vector[0])
+		assert.Equal(t, "blog", vs.Data()[0].Metadata["source"])
+	})
+
+	t.Run("MaxMarginalRelevanceSearch", func(t *testing.T) {
+		vs := NewInMemory(embedder)
+		require.NoError(t, vs.InsertItem(InMemoryItem{ID: "a", Content: "document1", Vector: []float32{1, 2, 3}}))
+		require.NoError(t, vs.InsertItem(InMemoryItem{ID: "b", Content: "document1 duplicate", Vector: []float32{1, 2, 3.01}}))
+		require.NoError(t, vs.InsertItem(InMemoryItem{ID: "c", Content: "document2", Vector: []float32{3, 2, 1}}))
+
+		docs, err := vs.MaxMarginalRelevanceSearch(context.Background(), "query", func(o *schema.MaxMarginalRelevanceSearchOptions) {
+			o.TopK = 2
+			o.Lambda = 0.3
+		})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 2)
+		assert.Equal(t, "a", docs[0].ID)
+		assert.Equal(t, "c", docs[1].ID)
+
+		docs, err = vs.MaxMarginalRelevanceSearch(context.Background(), "query", func(o *schema.MaxMarginalRelevanceSearchOptions) {
+			o.TopK = 2
+			o.Lambda = 1
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "a", docs[0].ID)
+		assert.Equal(t, "b", docs[1].ID)
+	})
+
 	t.Run("SaveAndLoad", func(t *testing.T) {
 		originalData := []InMemoryItem{
-			{Content: "item1", Vector: []float32{1.0, 2.0, 3.0}, Metadata: map[string]any{"key1": "value1"}},
+			{ID: "1", Content: "item1", Vector: []float32{1.0, 2.0, 3.0}, Metadata: map[string]any{"key1": "value1"}},
 			{Content: "item2", Vector: []float32{4.0, 5.0, 6.0}, Metadata: map[string]any{"key2": "value2"}},
 		}
 
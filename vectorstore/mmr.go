@@ -0,0 +1,112 @@
+package vectorstore
+
+import (
+	"math"
+
+	"github.com/hupe1980/golc/metric"
+	"github.com/hupe1980/golc/schema"
+)
+
+const (
+	// DefaultFetchK is the default number of candidates fetched for a maximal marginal relevance search.
+	DefaultFetchK = 20
+	// DefaultLambda is the default trade-off between relevance and diversity of a maximal marginal relevance search.
+	DefaultLambda = 0.5
+)
+
+// newMaxMarginalRelevanceSearchOptions returns the options of a maximal marginal relevance search.
+func newMaxMarginalRelevanceSearchOptions(topK int, optFns ...func(o *schema.MaxMarginalRelevanceSearchOptions)) schema.MaxMarginalRelevanceSearchOptions {
+	opts := schema.MaxMarginalRelevanceSearchOptions{
+		VectorStoreSearchOptions: schema.VectorStoreSearchOptions{
+			TopK: topK,
+		},
+		FetchK: DefaultFetchK,
+		Lambda: DefaultLambda,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.TopK <= 0 {
+		opts.TopK = topK
+	}
+
+	if opts.FetchK < opts.TopK {
+		opts.FetchK = opts.TopK
+	}
+
+	return opts
+}
+
+// maximalMarginalRelevance selects k of the candidate vectors, which are relevant to the query vector
+// but diverse among each other, and returns their indices in the order of selection.
+// Lambda controls the trade-off between relevance (1) and diversity (0).
+func maximalMarginalRelevance(queryVector []float32, candidates [][]float32, k int, lambda float32) ([]int, error) {
+	if k > len(candidates) {
+		k = len(candidates)
+	}
+
+	if k <= 0 {
+		return []int{}, nil
+	}
+
+	querySimilarities := make([]float32, len(candidates))
+
+	for i, c := range candidates {
+		similarity, err := metric.CosineSimilarity(queryVector, c)
+		if err != nil {
+			return nil, err
+		}
+
+		querySimilarities[i] = similarity
+	}
+
+	// maxRedundancy holds the highest similarity of each candidate to the already selected candidates.
+	maxRedundancy := make([]float32, len(candidates))
+	for i := range maxRedundancy {
+		maxRedundancy[i] = -math.MaxFloat32
+	}
+
+	selected := make([]int, 0, k)
+	isSelected := make([]bool, len(candidates))
+
+	for len(selected) < k {
+		best, bestScore := -1, float32(-math.MaxFloat32)
+
+		for i := range candidates {
+			if isSelected[i] {
+				continue
+			}
+
+			score := querySimilarities[i]
+			if len(selected) > 0 {
+				score = lambda*querySimilarities[i] - (1-lambda)*maxRedundancy[i]
+			}
+
+			if best == -1 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		selected = append(selected, best)
+		isSelected[best] = true
+
+		for i, c := range candidates {
+			if isSelected[i] {
+				continue
+			}
+
+			similarity, err := metric.CosineSimilarity(candidates[best], c)
+			if err != nil {
+				return nil, err
+			}
+
+			if similarity > maxRedundancy[i] {
+				maxRedundancy[i] = similarity
+			}
+		}
+	}
+
+	return selected, nil
+}
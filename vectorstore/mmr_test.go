@@ -0,0 +1,47 @@
+package vectorstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaximalMarginalRelevance(t *testing.T) {
+	queryVector := []float32{1, 1}
+
+	candidates := [][]float32{
+		{1, 0.9},  // most relevant
+		{1, 0.89}, // near duplicate of the first candidate
+		{0.7, 1},  // relevant
+		{0, 1},    // less relevant, but different
+	}
+
+	t.Run("Diversity", func(t *testing.T) {
+		selected, err := maximalMarginalRelevance(queryVector, candidates, 2, 0.5)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{0, 3}, selected)
+	})
+
+	t.Run("Relevance", func(t *testing.T) {
+		selected, err := maximalMarginalRelevance(queryVector, candidates, 2, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{0, 1}, selected)
+	})
+
+	t.Run("KGreaterThanCandidates", func(t *testing.T) {
+		selected, err := maximalMarginalRelevance(queryVector, candidates, 10, 0.5)
+		assert.NoError(t, err)
+		assert.Len(t, selected, 4)
+	})
+
+	t.Run("NoCandidates", func(t *testing.T) {
+		selected, err := maximalMarginalRelevance(queryVector, nil, 2, 0.5)
+		assert.NoError(t, err)
+		assert.Empty(t, selected)
+	})
+
+	t.Run("VectorSizeMismatch", func(t *testing.T) {
+		_, err := maximalMarginalRelevance(queryVector, [][]float32{{1, 0, 0}}, 1, 0.5)
+		assert.Error(t, err)
+	})
+}
@@ -4,18 +4,25 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/google/uuid"
 	"github.com/hupe1980/golc/integration/pinecone"
+	"github.com/hupe1980/golc/internal/util"
 	"github.com/hupe1980/golc/schema"
 )
 
 // Compile time check to ensure Pinecone satisfies the VectorStore interface.
 var _ schema.VectorStore = (*Pinecone)(nil)
 
+// Compile time check to ensure Pinecone satisfies the MaxMarginalRelevanceSearcher interface.
+var _ schema.MaxMarginalRelevanceSearcher = (*Pinecone)(nil)
+
+// PineconeOptions contains options for configuring the Pinecone vector store.
 type PineconeOptions struct {
 	Namespace string
 	TopK      int64
 }
 
+// Pinecone represents a Pinecone vector store.
 type Pinecone struct {
 	client   pinecone.Client
 	embedder schema.Embedder
@@ -23,6 +30,7 @@ type Pinecone struct {
 	opts     PineconeOptions
 }
 
+// NewPinecone creates a new Pinecone vector store with the given client, embedder and the metadata key of the text content.
 func NewPinecone(client pinecone.Client, embedder schema.Embedder, textKey string, optFns ...func(*PineconeOptions)) (*Pinecone, error) {
 	opts := PineconeOptions{
 		TopK: 4,
@@ -40,7 +48,9 @@ func NewPinecone(client pinecone.Client, embedder schema.Embedder, textKey strin
 	}, nil
 }
 
-func (vs *Pinecone) AddDocuments(ctx context.Context, docs []schema.Document) error {
+// AddDocuments adds a batch of documents to the Pinecone vector store.
+// Documents with the ID of an existing vector replace this vector.
+func (vs *Pinecone) AddDocuments(ctx context.Context, docs []schema.Document) ([]string, error) {
 	texts := make([]string, len(docs))
 	for i, doc := range docs {
 		texts[i] = doc.PageContent
@@ -48,10 +58,11 @@ func (vs *Pinecone) AddDocuments(ctx context.Context, docs []schema.Document) er
 
 	vectors, err := vs.embedder.BatchEmbedText(ctx, texts)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	metadata := make([]map[string]any, 0, len(docs))
+	ids := make([]string, len(docs))
+	pineconeVectors := make([]*pinecone.Vector, 0, len(docs))
 
 	for i := 0; i < len(docs); i++ {
 		m := make(map[string]any, len(docs[i].Metadata))
@@ -61,12 +72,16 @@ func (vs *Pinecone) AddDocuments(ctx context.Context, docs []schema.Document) er
 
 		m[vs.textKey] = texts[i]
 
-		metadata = append(metadata, m)
-	}
+		ids[i] = docs[i].ID
+		if ids[i] == "" {
+			ids[i] = uuid.New().String()
+		}
 
-	pineconeVectors, err := pinecone.ToPineconeVectors(vectors, metadata)
-	if err != nil {
-		return err
+		pineconeVectors = append(pineconeVectors, &pinecone.Vector{
+			ID:       ids[i],
+			Values:   vectors[i],
+			Metadata: m,
+		})
 	}
 
 	req := &pinecone.UpsertRequest{
@@ -77,20 +92,139 @@ func (vs *Pinecone) AddDocuments(ctx context.Context, docs []schema.Document) er
 		req.Namespace = vs.opts.Namespace
 	}
 
-	_, err = vs.client.Upsert(ctx, req)
+	if _, err := vs.client.Upsert(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Delete removes the vectors with the given IDs from the Pinecone vector store.
+func (vs *Pinecone) Delete(ctx context.Context, ids []string) error {
+	_, err := vs.client.Delete(ctx, &pinecone.DeleteRequest{
+		IDs:       ids,
+		Namespace: vs.opts.Namespace,
+	})
 
 	return err
 }
 
-func (vs *Pinecone) SimilaritySearch(ctx context.Context, query string) ([]schema.Document, error) {
+// SimilaritySearch performs a similarity search with the given query in the Pinecone vector store.
+func (vs *Pinecone) SimilaritySearch(ctx context.Context, query string, optFns ...func(o *schema.VectorStoreSearchOptions)) ([]schema.Document, error) {
+	scoredDocs, err := vs.SimilaritySearchWithScore(ctx, query, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(scoredDocs, func(sd schema.ScoredDocument, _ int) schema.Document {
+		return sd.Document
+	}), nil
+}
+
+// SimilaritySearchWithScore performs a similarity search with the given query in the Pinecone vector store
+// and returns the documents with the scores of the index metric.
+func (vs *Pinecone) SimilaritySearchWithScore(ctx context.Context, query string, optFns ...func(o *schema.VectorStoreSearchOptions)) ([]schema.ScoredDocument, error) {
+	opts := schema.VectorStoreSearchOptions{}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	topK := vs.opts.TopK
+	if opts.TopK > 0 {
+		topK = int64(opts.TopK)
+	}
+
 	vector, err := vs.embedder.EmbedText(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
+	matches, err := vs.query(ctx, vector, topK, opts.Filter, false)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]schema.ScoredDocument, 0, len(matches))
+
+	for _, match := range matches {
+		if opts.ScoreThreshold != 0 && float32(match.Score) < opts.ScoreThreshold {
+			continue
+		}
+
+		doc, err := vs.matchToDocument(match)
+		if err != nil {
+			return nil, err
+		}
+
+		docs = append(docs, schema.ScoredDocument{
+			Document: doc,
+			Score:    float32(match.Score),
+		})
+	}
+
+	return docs, nil
+}
+
+// MaxMarginalRelevanceSearch performs a maximal marginal relevance search with the given query in the Pinecone vector store.
+func (vs *Pinecone) MaxMarginalRelevanceSearch(ctx context.Context, query string, optFns ...func(o *schema.MaxMarginalRelevanceSearchOptions)) ([]schema.Document, error) {
+	opts := newMaxMarginalRelevanceSearchOptions(int(vs.opts.TopK), optFns...)
+
+	vector, err := vs.embedder.EmbedText(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := vs.query(ctx, vector, int64(opts.FetchK), opts.Filter, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ScoreThreshold != 0 {
+		matches = util.Filter(matches, func(m *pinecone.Match, _ int) bool {
+			return float32(m.Score) >= opts.ScoreThreshold
+		})
+	}
+
+	selected, err := maximalMarginalRelevance(vector, util.Map(matches, func(m *pinecone.Match, _ int) []float32 {
+		return m.Values
+	}), opts.TopK, opts.Lambda)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]schema.Document, len(selected))
+
+	for i, index := range selected {
+		doc, err := vs.matchToDocument(matches[index])
+		if err != nil {
+			return nil, err
+		}
+
+		docs[i] = doc
+	}
+
+	return docs, nil
+}
+
+// query queries the topK vectors nearest to the vector, which match the filter.
+func (vs *Pinecone) query(ctx context.Context, vector []float32, topK int64, filter *schema.MetadataFilter, includeValues bool) ([]*pinecone.Match, error) {
+	var pineconeFilter map[string]any
+
+	if filter != nil {
+		var err error
+
+		pineconeFilter, err = toPineconeFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	res, err := vs.client.Query(ctx, &pinecone.QueryRequest{
 		Namespace:       vs.opts.Namespace,
-		TopK:            vs.opts.TopK,
+		TopK:            topK,
+		Filter:          pineconeFilter,
+		IncludeValues:   includeValues,
 		IncludeMetadata: true,
 		Vector:          vector,
 	})
@@ -98,23 +232,67 @@ func (vs *Pinecone) SimilaritySearch(ctx context.Context, query string) ([]schem
 		return nil, err
 	}
 
-	docs := make([]schema.Document, 0, len(res.Matches))
+	return res.Matches, nil
+}
+
+// matchToDocument converts a match to a document, taking the page content from the metadata.
+func (vs *Pinecone) matchToDocument(match *pinecone.Match) (schema.Document, error) {
+	pageContent, ok := match.Metadata[vs.textKey].(string)
+	if !ok {
+		return schema.Document{}, fmt.Errorf("no content for textKey %s", vs.textKey)
+	}
+
+	metadata := make(map[string]any, len(match.Metadata))
 
-	for _, match := range res.Matches {
-		pageContent, ok := match.Metadata[vs.textKey].(string)
-		if !ok {
-			return nil, fmt.Errorf("no content for textKey %s", vs.textKey)
+	for key, value := range match.Metadata {
+		if key != vs.textKey {
+			metadata[key] = value
 		}
+	}
+
+	return schema.Document{
+		ID:          match.ID,
+		PageContent: pageContent,
+		Metadata:    metadata,
+	}, nil
+}
+
+// pineconeFilterOperators maps the comparison operators to the operators of the pinecone filter language.
+var pineconeFilterOperators = map[schema.FilterOperator]string{
+	schema.FilterOperatorEq:  "$eq",
+	schema.FilterOperatorNe:  "$ne",
+	schema.FilterOperatorGt:  "$gt",
+	schema.FilterOperatorGte: "$gte",
+	schema.FilterOperatorLt:  "$lt",
+	schema.FilterOperatorLte: "$lte",
+	schema.FilterOperatorIn:  "$in",
+}
+
+// toPineconeFilter translates a metadata filter to the filter language of pinecone.
+// See https://docs.pinecone.io/guides/data/filter-with-metadata for more informations.
+func toPineconeFilter(f *schema.MetadataFilter) (map[string]any, error) {
+	switch f.Operator {
+	case schema.FilterOperatorAnd, schema.FilterOperatorOr:
+		filters := make([]any, len(f.Filters))
 
-		delete(match.Metadata, vs.textKey)
+		for i, sub := range f.Filters {
+			filter, err := toPineconeFilter(sub)
+			if err != nil {
+				return nil, err
+			}
 
-		doc := schema.Document{
-			PageContent: pageContent,
-			Metadata:    match.Metadata,
+			filters[i] = filter
 		}
 
-		docs = append(docs, doc)
+		return map[string]any{"$" + string(f.Operator): filters}, nil
+	case schema.FilterOperatorIn:
+		return map[string]any{f.Key: map[string]any{"$in": f.Values}}, nil
 	}
 
-	return docs, nil
+	op, ok := pineconeFilterOperators[f.Operator]
+	if !ok {
+		return nil, fmt.Errorf("unsupported filter operator: %s", f.Operator)
+	}
+
+	return map[string]any{f.Key: map[string]any{op: f.Value}}, nil
 }
@@ -0,0 +1,149 @@
+package vectorstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hupe1980/golc/integration/pinecone"
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestPinecone(t *testing.T) {
+	t.Run("AddDocuments", func(t *testing.T) {
+		client := &mockPineconeClient{
+			upsertFn: func(ctx context.Context, req *pinecone.UpsertRequest) (*pinecone.UpsertResponse, error) {
+				assert.Equal(t, "ns", req.Namespace)
+				assert.Len(t, req.Vectors, 2)
+				assert.Equal(t, "a", req.Vectors[0].ID)
+				assert.NotEmpty(t, req.Vectors[1].ID)
+				assert.Equal(t, map[string]any{"text": "document1", "source": "wiki"}, req.Vectors[0].Metadata)
+
+				return &pinecone.UpsertResponse{UpsertedCount: 2}, nil
+			},
+		}
+
+		vs, err := NewPinecone(client, &mockEmbedder{}, "text", func(o *PineconeOptions) {
+			o.Namespace = "ns"
+		})
+		assert.NoError(t, err)
+
+		ids, err := vs.AddDocuments(context.Background(), []schema.Document{
+			{ID: "a", PageContent: "document1", Metadata: map[string]any{"source": "wiki"}},
+			{PageContent: "document2"},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, ids, 2)
+		assert.Equal(t, "a", ids[0])
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		client := &mockPineconeClient{
+			deleteFn: func(ctx context.Context, req *pinecone.DeleteRequest) (*pinecone.DeleteResponse, error) {
+				assert.Equal(t, []string{"a", "b"}, req.IDs)
+				return &pinecone.DeleteResponse{}, nil
+			},
+		}
+
+		vs, err := NewPinecone(client, &mockEmbedder{}, "text")
+		assert.NoError(t, err)
+
+		assert.NoError(t, vs.Delete(context.Background(), []string{"a", "b"}))
+	})
+
+	t.Run("SimilaritySearchWithScore", func(t *testing.T) {
+		client := &mockPineconeClient{
+			queryFn: func(ctx context.Context, req *pinecone.QueryRequest) (*pinecone.QueryResponse, error) {
+				assert.Equal(t, int64(2), req.TopK)
+				assert.Equal(t, map[string]any{
+					"$and": []any{
+						map[string]any{"source": map[string]any{"$in": []any{"wiki", "blog"}}},
+						map[string]any{"year": map[string]any{"$gte": 2021}},
+					},
+				}, req.Filter)
+
+				return &pinecone.QueryResponse{
+					Matches: []*pinecone.Match{
+						{ID: "a", Score: 0.9, Metadata: map[string]any{"text": "document1", "source": "wiki"}},
+						{ID: "b", Score: 0.4, Metadata: map[string]any{"text": "document2", "source": "blog"}},
+					},
+				}, nil
+			},
+		}
+
+		vs, err := NewPinecone(client, &mockEmbedder{}, "text")
+		assert.NoError(t, err)
+
+		docs, err := vs.SimilaritySearchWithScore(context.Background(), "query", func(o *schema.VectorStoreSearchOptions) {
+			o.TopK = 2
+			o.ScoreThreshold = 0.5
+			o.Filter = schema.FilterAnd(schema.FilterIn("source", "wiki", "blog"), schema.FilterGte("year", 2021))
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []schema.ScoredDocument{{
+			Document: schema.Document{ID: "a", PageContent: "document1", Metadata: map[string]any{"source": "wiki"}},
+			Score:    0.9,
+		}}, docs)
+	})
+
+	t.Run("MaxMarginalRelevanceSearch", func(t *testing.T) {
+		client := &mockPineconeClient{
+			queryFn: func(ctx context.Context, req *pinecone.QueryRequest) (*pinecone.QueryResponse, error) {
+				assert.Equal(t, int64(DefaultFetchK), req.TopK)
+				assert.True(t, req.IncludeValues)
+
+				return &pinecone.QueryResponse{
+					Matches: []*pinecone.Match{
+						{ID: "a", Score: 1, Values: []float32{1, 2, 3}, Metadata: map[string]any{"text": "document1"}},
+						{ID: "b", Score: 0.99, Values: []float32{1, 2, 3.01}, Metadata: map[string]any{"text": "document1 duplicate"}},
+						{ID: "c", Score: 0.7, Values: []float32{3, 2, 1}, Metadata: map[string]any{"text": "document2"}},
+					},
+				}, nil
+			},
+		}
+
+		vs, err := NewPinecone(client, &mockEmbedder{}, "text", func(o *PineconeOptions) {
+			o.TopK = 2
+		})
+		assert.NoError(t, err)
+
+		docs, err := vs.MaxMarginalRelevanceSearch(context.Background(), "query", func(o *schema.MaxMarginalRelevanceSearchOptions) {
+			o.Lambda = 0.3
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []schema.Document{
+			{ID: "a", PageContent: "document1", Metadata: map[string]any{}},
+			{ID: "c", PageContent: "document2", Metadata: map[string]any{}},
+		}, docs)
+	})
+}
+
+// Compile time check to ensure mockPineconeClient satisfies the pinecone client interface.
+var _ pinecone.Client = (*mockPineconeClient)(nil)
+
+type mockPineconeClient struct {
+	upsertFn func(ctx context.Context, req *pinecone.UpsertRequest) (*pinecone.UpsertResponse, error)
+	queryFn  func(ctx context.Context, req *pinecone.QueryRequest) (*pinecone.QueryResponse, error)
+	deleteFn func(ctx context.Context, req *pinecone.DeleteRequest) (*pinecone.DeleteResponse, error)
+}
+
+func (m *mockPineconeClient) Upsert(ctx context.Context, req *pinecone.UpsertRequest) (*pinecone.UpsertResponse, error) {
+	return m.upsertFn(ctx, req)
+}
+
+func (m *mockPineconeClient) Fetch(ctx context.Context, req *pinecone.FetchRequest) (*pinecone.FetchResponse, error) {
+	return &pinecone.FetchResponse{}, nil
+}
+
+func (m *mockPineconeClient) Query(ctx context.Context, req *pinecone.QueryRequest) (*pinecone.QueryResponse, error) {
+	return m.queryFn(ctx, req)
+}
+
+func (m *mockPineconeClient) Delete(ctx context.Context, req *pinecone.DeleteRequest) (*pinecone.DeleteResponse, error) {
+	return m.deleteFn(ctx, req)
+}
+
+func (m *mockPineconeClient) Close() error {
+	return nil
+}
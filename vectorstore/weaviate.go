@@ -7,8 +7,10 @@ import (
 
 	"github.com/go-openapi/strfmt"
 	"github.com/google/uuid"
+	"github.com/hupe1980/golc/internal/util"
 	"github.com/hupe1980/golc/schema"
 	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/filters"
 	"github.com/weaviate/weaviate-go-client/v4/weaviate/graphql"
 	"github.com/weaviate/weaviate/entities/models"
 )
@@ -16,11 +18,31 @@ import (
 // Compile time check to ensure Weaviate satisfies the VectorStore interface.
 var _ schema.VectorStore = (*Weaviate)(nil)
 
+// Compile time check to ensure Weaviate satisfies the MaxMarginalRelevanceSearcher interface.
+var _ schema.MaxMarginalRelevanceSearcher = (*Weaviate)(nil)
+
+// WeaviateDistance represents a distance metric of the vector index of a Weaviate class.
+type WeaviateDistance string
+
+const (
+	WeaviateDistanceCosine    WeaviateDistance = "cosine"
+	WeaviateDistanceDot       WeaviateDistance = "dot"
+	WeaviateDistanceL2Squared WeaviateDistance = "l2-squared"
+	WeaviateDistanceManhattan WeaviateDistance = "manhattan"
+	WeaviateDistanceHamming   WeaviateDistance = "hamming"
+)
+
 // WeaviateOptions contains options for configuring the Weaviate vector store.
 type WeaviateOptions struct {
 	// TextKey is the name of the property in the Weaviate objects where the text content is stored.
 	TextKey string
 
+	// IDKey is the name of the property in the Weaviate objects where the document ID is stored.
+	IDKey string
+
+	// Distance is the distance metric of the vector index. It must match the distance metric of an existing class.
+	Distance WeaviateDistance
+
 	// TopK is the number of documents to retrieve in similarity search.
 	TopK int
 
@@ -42,6 +64,8 @@ type Weaviate struct {
 func NewWeaviate(client *weaviate.Client, embedder schema.Embedder, optFns ...func(*WeaviateOptions)) *Weaviate {
 	opts := WeaviateOptions{
 		TextKey:   "text",
+		IDKey:     "docID",
+		Distance:  WeaviateDistanceCosine,
 		TopK:      4,
 		IndexName: fmt.Sprintf("GoLC_%s", uuid.New().String()),
 	}
@@ -72,6 +96,13 @@ func (vs *Weaviate) CreateClassIfNotExist(ctx context.Context) error {
 					Name:     vs.opts.TextKey,
 					DataType: []string{"text"},
 				},
+				{
+					Name:     vs.opts.IDKey,
+					DataType: []string{"text"},
+				},
+			},
+			VectorIndexConfig: map[string]any{
+				"distance": string(vs.opts.Distance),
 			},
 		}).Do(ctx); ccErr != nil {
 			return ccErr
@@ -82,7 +113,10 @@ func (vs *Weaviate) CreateClassIfNotExist(ctx context.Context) error {
 }
 
 // AddDocuments adds a batch of documents to the Weaviate vector store.
-func (vs *Weaviate) AddDocuments(ctx context.Context, docs []schema.Document) error {
+// Weaviate requires UUIDs as object IDs, so document IDs that are no UUIDs are mapped to name based UUIDs.
+// The document ID is stored as property and returned by the searches. Documents with the ID of an existing
+// object replace this object. Documents without an ID get a random UUID.
+func (vs *Weaviate) AddDocuments(ctx context.Context, docs []schema.Document) ([]string, error) {
 	texts := make([]string, len(docs))
 	for i, doc := range docs {
 		texts[i] = doc.PageContent
@@ -90,9 +124,10 @@ func (vs *Weaviate) AddDocuments(ctx context.Context, docs []schema.Document) er
 
 	vectors, err := vs.embedder.BatchEmbedText(ctx, texts)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	ids := make([]string, len(docs))
 	objects := make([]*models.Object, 0, len(docs))
 
 	for i, doc := range docs {
@@ -101,34 +136,130 @@ func (vs *Weaviate) AddDocuments(ctx context.Context, docs []schema.Document) er
 			metadata[key] = value
 		}
 
+		objectID := weaviateObjectID(doc.ID)
+
+		ids[i] = doc.ID
+		if ids[i] == "" {
+			ids[i] = objectID
+		}
+
 		metadata[vs.opts.TextKey] = doc.PageContent
+		metadata[vs.opts.IDKey] = ids[i]
 
 		objects = append(objects, &models.Object{
 			Class:      vs.opts.IndexName,
-			ID:         strfmt.UUID(uuid.New().String()),
+			ID:         strfmt.UUID(objectID),
 			Vector:     vectors[i],
 			Properties: metadata,
 		})
 	}
 
 	if _, err := vs.client.Batch().ObjectsBatcher().WithObjects(objects...).Do(ctx); err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return ids, nil
 }
 
 // SimilaritySearch performs a similarity search with the given query in the Weaviate vector store.
-func (vs *Weaviate) SimilaritySearch(ctx context.Context, query string) ([]schema.Document, error) {
+func (vs *Weaviate) SimilaritySearch(ctx context.Context, query string, optFns ...func(o *schema.VectorStoreSearchOptions)) ([]schema.Document, error) {
+	scoredDocs, err := vs.SimilaritySearchWithScore(ctx, query, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(scoredDocs, func(sd schema.ScoredDocument, _ int) schema.Document {
+		return sd.Document
+	}), nil
+}
+
+// SimilaritySearchWithScore performs a similarity search with the given query in the Weaviate vector store
+// and returns the documents with their scores. The score is the cosine similarity for the cosine distance,
+// the dot product for the dot distance and 1 / (1 + distance) for the other distance metrics.
+func (vs *Weaviate) SimilaritySearchWithScore(ctx context.Context, query string, optFns ...func(o *schema.VectorStoreSearchOptions)) ([]schema.ScoredDocument, error) {
+	opts := schema.VectorStoreSearchOptions{
+		TopK: vs.opts.TopK,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.TopK <= 0 {
+		opts.TopK = vs.opts.TopK
+	}
+
 	vector, err := vs.embedder.EmbedText(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
+	results, err := vs.search(ctx, vector, opts.TopK, opts, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(results, func(r weaviateSearchResult, _ int) schema.ScoredDocument {
+		return r.ScoredDocument
+	}), nil
+}
+
+// MaxMarginalRelevanceSearch performs a maximal marginal relevance search with the given query in the Weaviate vector store.
+func (vs *Weaviate) MaxMarginalRelevanceSearch(ctx context.Context, query string, optFns ...func(o *schema.MaxMarginalRelevanceSearchOptions)) ([]schema.Document, error) {
+	opts := newMaxMarginalRelevanceSearchOptions(vs.opts.TopK, optFns...)
+
+	vector, err := vs.embedder.EmbedText(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := vs.search(ctx, vector, opts.FetchK, opts.VectorStoreSearchOptions, true)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := maximalMarginalRelevance(vector, util.Map(results, func(r weaviateSearchResult, _ int) []float32 {
+		return r.Vector
+	}), opts.TopK, opts.Lambda)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.Map(selected, func(i int, _ int) schema.Document {
+		return results[i].Document
+	}), nil
+}
+
+// weaviateSearchResult represents a document found by a search with its score and optionally its vector.
+type weaviateSearchResult struct {
+	schema.ScoredDocument
+	Vector []float32
+}
+
+// search searches the objects nearest to the vector, which match the filter and the score threshold of the options.
+func (vs *Weaviate) search(ctx context.Context, vector []float32, limit int, opts schema.VectorStoreSearchOptions, withVector bool) ([]weaviateSearchResult, error) {
 	nearVector := vs.client.GraphQL().NearVectorArgBuilder().WithVector(vector)
 
+	if opts.ScoreThreshold != 0 {
+		distance, ok, err := vs.maxDistance(opts.ScoreThreshold)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			nearVector = nearVector.WithDistance(distance)
+		}
+	}
+
+	additionalFields := []graphql.Field{{Name: "id"}, {Name: "distance"}}
+	if withVector {
+		additionalFields = append(additionalFields, graphql.Field{Name: "vector"})
+	}
+
 	fields := []graphql.Field{
 		{Name: vs.opts.TextKey},
+		{Name: vs.opts.IDKey},
+		{Name: "_additional", Fields: additionalFields},
 	}
 
 	for _, fieldName := range vs.opts.AdditionalFields {
@@ -137,13 +268,23 @@ func (vs *Weaviate) SimilaritySearch(ctx context.Context, query string) ([]schem
 		})
 	}
 
-	res, err := vs.client.GraphQL().
+	get := vs.client.GraphQL().
 		Get().
 		WithNearVector(nearVector).
 		WithClassName(vs.opts.IndexName).
 		WithFields(fields...).
-		WithLimit(vs.opts.TopK).
-		Do(ctx)
+		WithLimit(limit)
+
+	if opts.Filter != nil {
+		where, err := toWeaviateWhere(opts.Filter)
+		if err != nil {
+			return nil, err
+		}
+
+		get = get.WithWhere(where)
+	}
+
+	res, err := get.Do(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -163,26 +304,179 @@ func (vs *Weaviate) SimilaritySearch(ctx context.Context, query string) ([]schem
 	}
 
 	items, _ := data.([]any)
-	docs := make([]schema.Document, len(items))
 
-	for i, item := range items {
-		metadata, _ := item.(map[string]any)
+	return util.Map(items, func(item any, _ int) weaviateSearchResult {
+		return vs.toSearchResult(item)
+	}), nil
+}
+
+// toSearchResult converts an object of a graphql response to a search result.
+func (vs *Weaviate) toSearchResult(item any) weaviateSearchResult {
+	metadata, _ := item.(map[string]any)
+
+	pageContent, _ := metadata[vs.opts.TextKey].(string)
+
+	result := weaviateSearchResult{
+		ScoredDocument: schema.ScoredDocument{
+			Document: schema.Document{
+				PageContent: pageContent,
+				Metadata:    map[string]any{},
+			},
+		},
+	}
+
+	if additional, ok := metadata["_additional"].(map[string]any); ok {
+		result.ID, _ = additional["id"].(string)
 
-		docs[i] = schema.Document{
-			PageContent: metadata[vs.opts.TextKey].(string),
+		if distance, ok := additional["distance"].(float64); ok {
+			result.Score = vs.score(float32(distance))
 		}
 
-		for _, field := range vs.opts.AdditionalFields {
-			if v, ok := metadata[field]; ok {
-				docs[i].Metadata[field] = v
+		if values, ok := additional["vector"].([]any); ok {
+			result.Vector = make([]float32, len(values))
+
+			for i, v := range values {
+				f, _ := v.(float64)
+				result.Vector[i] = float32(f)
 			}
 		}
 	}
 
-	return docs, nil
+	// Objects stored without the document ID property keep their object ID.
+	if id, ok := metadata[vs.opts.IDKey].(string); ok && id != "" {
+		result.ID = id
+	}
+
+	for _, field := range vs.opts.AdditionalFields {
+		if v, ok := metadata[field]; ok {
+			result.Metadata[field] = v
+		}
+	}
+
+	return result
+}
+
+// score converts a distance of the distance metric to a relevance score, where higher scores
+// indicate more similar vectors. Weaviate reports the negative dot product as dot distance.
+func (vs *Weaviate) score(distance float32) float32 {
+	switch vs.opts.Distance {
+	case WeaviateDistanceCosine:
+		return 1 - distance
+	case WeaviateDistanceDot:
+		return -distance
+	default:
+		return 1 / (1 + distance)
+	}
+}
+
+// maxDistance converts a score threshold to the maximum distance of the distance metric.
+// It returns false if every distance satisfies the threshold.
+func (vs *Weaviate) maxDistance(scoreThreshold float32) (float32, bool, error) {
+	switch vs.opts.Distance {
+	case WeaviateDistanceCosine:
+		return 1 - scoreThreshold, true, nil
+	case WeaviateDistanceDot:
+		return -scoreThreshold, true, nil
+	case WeaviateDistanceL2Squared, WeaviateDistanceManhattan, WeaviateDistanceHamming:
+		if scoreThreshold <= 0 {
+			return 0, false, nil
+		}
+
+		return 1/scoreThreshold - 1, true, nil
+	default:
+		return 0, false, fmt.Errorf("unsupported distance metric for score threshold: %s", vs.opts.Distance)
+	}
+}
+
+// Delete removes the documents with the given IDs from the Weaviate vector store.
+func (vs *Weaviate) Delete(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if err := vs.client.Data().Deleter().WithClassName(vs.opts.IndexName).WithID(weaviateObjectID(id)).Do(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// weaviateObjectID returns the weaviate object ID for a document ID. Document IDs that are no UUIDs are mapped
+// to name based UUIDs, so that the same document ID always refers to the same object.
+func weaviateObjectID(id string) string {
+	if id == "" {
+		return uuid.New().String()
+	}
+
+	if _, err := uuid.Parse(id); err == nil {
+		return id
+	}
+
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(id)).String()
+}
+
+// weaviateFilterOperators maps the comparison operators to the operators of weaviate.
+var weaviateFilterOperators = map[schema.FilterOperator]filters.WhereOperator{
+	schema.FilterOperatorEq:  filters.Equal,
+	schema.FilterOperatorNe:  filters.NotEqual,
+	schema.FilterOperatorGt:  filters.GreaterThan,
+	schema.FilterOperatorGte: filters.GreaterThanEqual,
+	schema.FilterOperatorLt:  filters.LessThan,
+	schema.FilterOperatorLte: filters.LessThanEqual,
+}
+
+// toWeaviateWhere translates a metadata filter to a weaviate where filter.
+// In filters are translated to an or filter of equal filters.
+func toWeaviateWhere(f *schema.MetadataFilter) (*filters.WhereBuilder, error) {
+	switch f.Operator {
+	case schema.FilterOperatorAnd, schema.FilterOperatorOr:
+		return toWeaviateOperands(f.Operator, f.Filters)
+	case schema.FilterOperatorIn:
+		return toWeaviateOperands(schema.FilterOperatorOr, util.Map(f.Values, func(v any, _ int) *schema.MetadataFilter {
+			return schema.FilterEq(f.Key, v)
+		}))
+	}
+
+	op, ok := weaviateFilterOperators[f.Operator]
+	if !ok {
+		return nil, fmt.Errorf("unsupported filter operator: %s", f.Operator)
+	}
+
+	where := filters.Where().WithPath([]string{f.Key}).WithOperator(op)
+
+	switch v := f.Value.(type) {
+	case string:
+		return where.WithValueText(v), nil
+	case bool:
+		return where.WithValueBoolean(v), nil
+	case float32, float64:
+		n, _ := schema.FilterValueToFloat64(v)
+		return where.WithValueNumber(n), nil
+	default:
+		n, ok := schema.FilterValueToFloat64(v)
+		if !ok {
+			return nil, fmt.Errorf("unsupported filter value type: %T", v)
+		}
+
+		return where.WithValueInt(int64(n)), nil
+	}
 }
 
-// Delete removes a document from the Weaviate vector store based on its UUID.
-func (vs *Weaviate) Delete(ctx context.Context, uuid string) error {
-	return vs.client.Data().Deleter().WithID(uuid).Do(ctx)
+// toWeaviateOperands combines the filters with an and or or operator.
+func toWeaviateOperands(operator schema.FilterOperator, subFilters []*schema.MetadataFilter) (*filters.WhereBuilder, error) {
+	operands := make([]*filters.WhereBuilder, len(subFilters))
+
+	for i, sub := range subFilters {
+		where, err := toWeaviateWhere(sub)
+		if err != nil {
+			return nil, err
+		}
+
+		operands[i] = where
+	}
+
+	op := filters.And
+	if operator == schema.FilterOperatorOr {
+		op = filters.Or
+	}
+
+	return filters.Where().WithOperator(op).WithOperands(operands), nil
 }
@@ -0,0 +1,215 @@
+package vectorstore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/filters"
+
+	"github.com/hupe1980/golc/schema"
+)
+
+func TestWeaviate(t *testing.T) {
+	var objects []map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/batch/objects":
+			var req struct {
+				Objects []map[string]any `json:"objects"`
+			}
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+			objects = req.Objects
+
+			_ = json.NewEncoder(w).Encode(req.Objects)
+		case "/v1/graphql":
+			items := make([]any, len(objects))
+			for i, o := range objects {
+				properties, _ := o["properties"].(map[string]any)
+				properties["_additional"] = map[string]any{"id": o["id"], "distance": 0.25}
+				items[i] = properties
+			}
+
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{"Get": map[string]any{"Docs": items}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := weaviate.New(weaviate.Config{
+		Host:   strings.TrimPrefix(server.URL, "http://"),
+		Scheme: "http",
+	})
+
+	vs := NewWeaviate(client, &mockEmbedder{}, func(o *WeaviateOptions) {
+		o.IndexName = "Docs"
+	})
+
+	ids, err := vs.AddDocuments(context.Background(), []schema.Document{
+		{ID: "doc-1", PageContent: "document1"},
+		{PageContent: "document2"},
+	})
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+	assert.Equal(t, "doc-1", ids[0])
+	assert.Equal(t, weaviateObjectID("doc-1"), objects[0]["id"])
+	assert.Equal(t, ids[1], objects[1]["id"])
+
+	docs, err := vs.SimilaritySearch(context.Background(), "query")
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "doc-1", docs[0].ID)
+	assert.Equal(t, ids[1], docs[1].ID)
+}
+
+func TestWeaviateObjectID(t *testing.T) {
+	t.Run("UUID", func(t *testing.T) {
+		id := uuid.New().String()
+		assert.Equal(t, id, weaviateObjectID(id))
+	})
+
+	t.Run("NameBased", func(t *testing.T) {
+		id := weaviateObjectID("doc-1")
+
+		_, err := uuid.Parse(id)
+		assert.NoError(t, err)
+		assert.Equal(t, id, weaviateObjectID("doc-1"))
+		assert.NotEqual(t, id, weaviateObjectID("doc-2"))
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.NotEqual(t, weaviateObjectID(""), weaviateObjectID(""))
+	})
+}
+
+func TestWeaviateToSearchResult(t *testing.T) {
+	vs := NewWeaviate(nil, &mockEmbedder{}, func(o *WeaviateOptions) {
+		o.AdditionalFields = []string{"source"}
+	})
+
+	result := vs.toSearchResult(map[string]any{
+		"text":   "document1",
+		"source": "wiki",
+		"_additional": map[string]any{
+			"id":       "5f8e9c1e-0c3b-4c1a-9a56-7f1c2d3e4f50",
+			"distance": 0.25,
+			"vector":   []any{1.0, 2.0},
+		},
+	})
+
+	assert.Equal(t, "5f8e9c1e-0c3b-4c1a-9a56-7f1c2d3e4f50", result.ID)
+	assert.Equal(t, "document1", result.PageContent)
+	assert.Equal(t, map[string]any{"source": "wiki"}, result.Metadata)
+	assert.Equal(t, float32(0.75), result.Score)
+	assert.Equal(t, []float32{1, 2}, result.Vector)
+
+	t.Run("DocumentID", func(t *testing.T) {
+		result := vs.toSearchResult(map[string]any{
+			"text":  "document2",
+			"docID": "doc-2",
+			"_additional": map[string]any{
+				"id": weaviateObjectID("doc-2"),
+			},
+		})
+
+		assert.Equal(t, "doc-2", result.ID)
+		assert.Empty(t, result.Metadata)
+	})
+}
+
+func TestWeaviateDistance(t *testing.T) {
+	testCases := []struct {
+		distance       WeaviateDistance
+		expScore       float32
+		expMaxDistance float32
+	}{
+		{distance: WeaviateDistanceCosine, expScore: 0.75, expMaxDistance: 0.5},
+		{distance: WeaviateDistanceDot, expScore: -0.25, expMaxDistance: -0.5},
+		{distance: WeaviateDistanceL2Squared, expScore: 0.8, expMaxDistance: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.distance), func(t *testing.T) {
+			vs := NewWeaviate(nil, &mockEmbedder{}, func(o *WeaviateOptions) {
+				o.Distance = tc.distance
+			})
+
+			assert.Equal(t, tc.expScore, vs.score(0.25))
+
+			maxDistance, ok, err := vs.maxDistance(0.5)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tc.expMaxDistance, maxDistance)
+		})
+	}
+
+	t.Run("NoLimit", func(t *testing.T) {
+		vs := NewWeaviate(nil, &mockEmbedder{}, func(o *WeaviateOptions) {
+			o.Distance = WeaviateDistanceManhattan
+		})
+
+		_, ok, err := vs.maxDistance(-1)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		vs := NewWeaviate(nil, &mockEmbedder{}, func(o *WeaviateOptions) {
+			o.Distance = "unknown"
+		})
+
+		_, _, err := vs.maxDistance(0.5)
+		assert.EqualError(t, err, "unsupported distance metric for score threshold: unknown")
+	})
+}
+
+func TestToWeaviateWhere(t *testing.T) {
+	t.Run("Comparison", func(t *testing.T) {
+		where, err := toWeaviateWhere(schema.FilterGte("year", 2021))
+		assert.NoError(t, err)
+
+		filter := where.Build()
+		assert.Equal(t, []string{"year"}, filter.Path)
+		assert.Equal(t, string(filters.GreaterThanEqual), string(filter.Operator))
+		assert.Equal(t, int64(2021), *filter.ValueInt)
+	})
+
+	t.Run("In", func(t *testing.T) {
+		where, err := toWeaviateWhere(schema.FilterIn("source", "wiki", "blog"))
+		assert.NoError(t, err)
+
+		filter := where.Build()
+		assert.Equal(t, string(filters.Or), string(filter.Operator))
+		assert.Len(t, filter.Operands, 2)
+		assert.Equal(t, "wiki", *filter.Operands[0].ValueText)
+		assert.Equal(t, "blog", *filter.Operands[1].ValueText)
+	})
+
+	t.Run("And", func(t *testing.T) {
+		where, err := toWeaviateWhere(schema.FilterAnd(schema.FilterEq("draft", false), schema.FilterLt("score", 0.5)))
+		assert.NoError(t, err)
+
+		filter := where.Build()
+		assert.Equal(t, string(filters.And), string(filter.Operator))
+		assert.False(t, *filter.Operands[0].ValueBoolean)
+		assert.Equal(t, 0.5, *filter.Operands[1].ValueNumber)
+	})
+
+	t.Run("UnsupportedValue", func(t *testing.T) {
+		_, err := toWeaviateWhere(schema.FilterEq("tags", []string{"a"}))
+		assert.Error(t, err)
+	})
+}